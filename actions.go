@@ -0,0 +1,82 @@
+package gotestdox
+
+// ActionKind identifies the kind of a test event, as given by its Action
+// field, and determined by [Event.Kind].
+type ActionKind int
+
+const (
+	// ActionUnknown is the kind of any event whose action gotestdox doesn't
+	// recognise, perhaps because it was added by a newer release of Go.
+	// Such events are counted in the [Summary], and otherwise ignored.
+	ActionUnknown ActionKind = iota
+	// ActionStart is the kind of the event that begins the output of a
+	// package's test binary.
+	ActionStart
+	// ActionRun is the kind of the event that starts a test.
+	ActionRun
+	// ActionPause is the kind of the event that pauses a parallel test,
+	// until its parent's sequential tests have finished.
+	ActionPause
+	// ActionCont is the kind of the event that resumes a paused test.
+	ActionCont
+	// ActionPass is the kind of the event for a test, or a package, that
+	// passed.
+	ActionPass
+	// ActionBench is the kind of the event for a benchmark that printed
+	// log output but didn't fail.
+	ActionBench
+	// ActionFail is the kind of the event for a test, or a package, that
+	// failed.
+	ActionFail
+	// ActionOutput is the kind of an event giving a line of output.
+	ActionOutput
+	// ActionSkip is the kind of the event for a test that was skipped, or a
+	// package with no tests.
+	ActionSkip
+	// ActionAttr is the kind of an event giving an attribute of a test, as
+	// set by [testing.T.Attr].
+	ActionAttr
+	// ActionBuildOutput is the kind of an event giving a line of output from
+	// building a package's tests.
+	ActionBuildOutput
+	// ActionBuildFail is the kind of the event for a package whose tests
+	// failed to build.
+	ActionBuildFail
+)
+
+// actionKinds maps each action recognised in 'go test -json' output to its
+// kind.
+var actionKinds = map[string]ActionKind{
+	"start":        ActionStart,
+	"run":          ActionRun,
+	"pause":        ActionPause,
+	"cont":         ActionCont,
+	"pass":         ActionPass,
+	"bench":        ActionBench,
+	"fail":         ActionFail,
+	"output":       ActionOutput,
+	"skip":         ActionSkip,
+	"attr":         ActionAttr,
+	"build-output": ActionBuildOutput,
+	"build-fail":   ActionBuildFail,
+}
+
+// String returns the action of the kind, as it appears in 'go test -json'
+// output, such as "pass", or "unknown" for [ActionUnknown].
+func (k ActionKind) String() string {
+	for action, kind := range actionKinds {
+		if kind == k {
+			return action
+		}
+	}
+	if k == ActionUnknown {
+		return "unknown"
+	}
+	return "ActionKind(?)"
+}
+
+// Kind returns the kind of e, according to its Action, or [ActionUnknown]
+// if that isn't an action gotestdox recognises.
+func (e Event) Kind() ActionKind {
+	return actionKinds[e.Action]
+}
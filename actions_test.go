@@ -0,0 +1,163 @@
+package gotestdox_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/bitfield/gotestdox"
+	"github.com/google/go-cmp/cmp"
+)
+
+// The stream in testdata/actions/go1.27.json was captured from a real run
+// of 'go test -json', with the timestamps removed. The older streams are
+// derived from it by leaving out what those releases didn't emit: go1.24.json
+// has no attr events, added in Go 1.25, or OutputType fields, and go1.20.json
+// has no build-output or build-fail events either, since those were added in
+// Go 1.24. future.json adds some actions and fields that no release emits
+// yet.
+
+type outcome struct {
+	Package, Test, Status string
+}
+
+func outcomesFromFixture(t *testing.T, path string, opts ...gotestdox.Option) ([]outcome, gotestdox.Summary) {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	sink := &recordingSink{}
+	td := gotestdox.NewTestDoxer(append([]gotestdox.Option{gotestdox.WithSink(sink)}, opts...)...)
+	td.Stdin = f
+	td.Stderr = new(strings.Builder)
+	td.Filter()
+	outcomes := []outcome{}
+	for _, r := range sink.results {
+		outcomes = append(outcomes, outcome{r.Package, r.Test, r.Status})
+	}
+	return outcomes, sink.summary
+}
+
+var fixtureOutcomes = []outcome{
+	{"example.com/shop", "TestCart_AppliesDiscount", "skip"},
+	{"example.com/shop", "TestCart_AddsItem", "pass"},
+	{"example.com/shop", "TestCart_RejectsNegativeQuantity", "fail"},
+	{"example.com/shop", "", "fail"},
+	{"example.com/shop/broken", "", "fail"},
+}
+
+func TestFilter_ReportsSameOutcomesForStreamsFromEachGoRelease(t *testing.T) {
+	t.Parallel()
+	for _, release := range []string{"go1.20", "go1.24", "go1.27"} {
+		got, sum := outcomesFromFixture(t, "testdata/actions/"+release+".json")
+		if !cmp.Equal(fixtureOutcomes, got) {
+			t.Errorf("%s: %s", release, cmp.Diff(fixtureOutcomes, got))
+		}
+		if sum.Passed != 1 || sum.Failed != 1 || sum.Skipped != 1 {
+			t.Errorf("%s: want 1 passed, 1 failed, 1 skipped, got %d, %d, %d", release, sum.Passed, sum.Failed, sum.Skipped)
+		}
+		if !sum.BuildFailed {
+			t.Errorf("%s: want build failure", release)
+		}
+		if sum.UnknownActions != 0 {
+			t.Errorf("%s: want no unknown actions, got %d", release, sum.UnknownActions)
+		}
+	}
+}
+
+func TestFilter_CountsAndOtherwiseIgnoresEventsWithUnknownActions(t *testing.T) {
+	t.Parallel()
+	got, sum := outcomesFromFixture(t, "testdata/actions/future.json")
+	if !cmp.Equal(fixtureOutcomes, got) {
+		t.Error(cmp.Diff(fixtureOutcomes, got))
+	}
+	if sum.UnknownActions != 3 {
+		t.Errorf("want 3 unknown actions, got %d", sum.UnknownActions)
+	}
+}
+
+func TestFilter_WithDebugLogsEachUnknownActionOnce(t *testing.T) {
+	// Not parallel, since it replaces DebugWriter.
+	buf := new(strings.Builder)
+	saved := gotestdox.DebugWriter
+	gotestdox.DebugWriter = buf
+	defer func() { gotestdox.DebugWriter = saved }()
+	outcomesFromFixture(t, "testdata/actions/future.json", gotestdox.WithDebug())
+	got := []string{}
+	for _, line := range strings.Split(buf.String(), "\n") {
+		if strings.Contains(line, "unknown action") {
+			got = append(got, line)
+		}
+	}
+	want := []string{
+		`gotestdox: ignoring events with unknown action "artifact"`,
+		`gotestdox: ignoring events with unknown action "coverage"`,
+	}
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestTextSink_NotesEventsWithUnknownActions(t *testing.T) {
+	t.Parallel()
+	buf := new(strings.Builder)
+	td := gotestdox.NewTestDoxer()
+	td.Stdin = strings.NewReader(`{"Action":"artifact","Package":"p","Test":"TestA"}
+{"Action":"pass","Package":"p","Test":"TestA"}
+{"Action":"pass","Package":"p","Elapsed":0.1}`)
+	td.Stdout = buf
+	td.Filter()
+	if !td.OK {
+		t.Error("want OK, since unknown actions are ignored")
+	}
+	want := "⚠ 1 events with unknown actions ignored\n"
+	if !strings.HasSuffix(buf.String(), want) {
+		t.Errorf("want output ending %q, got %q", want, buf.String())
+	}
+}
+
+func TestParseJSON_AcceptsUnknownActionsAndExtraFields(t *testing.T) {
+	t.Parallel()
+	got, err := gotestdox.ParseJSON(`{"Action":"artifact","Package":"p","Test":"TestA","Path":"a.png","Tags":{"k":"v"},"Retry":2}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := gotestdox.Event{Action: "artifact", Package: "p", Test: "TestA"}
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+	if got.Kind() != gotestdox.ActionUnknown {
+		t.Errorf("want unknown action, got %v", got.Kind())
+	}
+}
+
+func TestEventKind_RecognisesEachActionEmittedByGoTest(t *testing.T) {
+	t.Parallel()
+	tcs := map[string]gotestdox.ActionKind{
+		"start":        gotestdox.ActionStart,
+		"run":          gotestdox.ActionRun,
+		"pause":        gotestdox.ActionPause,
+		"cont":         gotestdox.ActionCont,
+		"pass":         gotestdox.ActionPass,
+		"bench":        gotestdox.ActionBench,
+		"fail":         gotestdox.ActionFail,
+		"output":       gotestdox.ActionOutput,
+		"skip":         gotestdox.ActionSkip,
+		"attr":         gotestdox.ActionAttr,
+		"build-output": gotestdox.ActionBuildOutput,
+		"build-fail":   gotestdox.ActionBuildFail,
+		"":             gotestdox.ActionUnknown,
+		"Pass":         gotestdox.ActionUnknown,
+	}
+	for action, want := range tcs {
+		got := gotestdox.Event{Action: action}.Kind()
+		if want != got {
+			t.Errorf("%q: want %v, got %v", action, want, got)
+		}
+		if want != gotestdox.ActionUnknown && got.String() != action {
+			t.Errorf("%q: want String to return the action, got %q", action, got.String())
+		}
+	}
+}
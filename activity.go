@@ -0,0 +1,65 @@
+package gotestdox
+
+import "time"
+
+// activity keeps track of the time each test spends actually running, as
+// opposed to paused waiting for other parallel tests, using the timestamps
+// of its run, pause, cont, and completion events (see [WithActiveDurations]).
+type activity struct {
+	running map[string]time.Time
+	active  map[string]time.Duration
+	seen    map[string]bool
+}
+
+func newActivity() *activity {
+	return &activity{
+		running: map[string]time.Time{},
+		active:  map[string]time.Duration{},
+		seen:    map[string]bool{},
+	}
+}
+
+// record notes that the test identified by key had an event with the given
+// action at time t. Events can arrive slightly out of order, so a pause that
+// appears to come before the test started running adds nothing, rather than
+// a negative duration, and a cont for a test that is already running is
+// ignored.
+func (a *activity) record(key string, action ActionKind, t time.Time) {
+	if t.IsZero() {
+		return
+	}
+	switch action {
+	case ActionRun, ActionCont:
+		a.seen[key] = true
+		if _, ok := a.running[key]; !ok {
+			a.running[key] = t
+		}
+	case ActionPause:
+		a.stop(key, t)
+	}
+}
+
+// finish returns the total time the test identified by key spent running,
+// given that it completed at time t, and stops tracking it. If there were no
+// timestamps for the test, it returns false.
+func (a *activity) finish(key string, t time.Time) (time.Duration, bool) {
+	if t.IsZero() || !a.seen[key] {
+		return 0, false
+	}
+	a.stop(key, t)
+	d := a.active[key]
+	delete(a.active, key)
+	delete(a.seen, key)
+	return d, true
+}
+
+func (a *activity) stop(key string, t time.Time) {
+	start, ok := a.running[key]
+	if !ok {
+		return
+	}
+	delete(a.running, key)
+	if t.After(start) {
+		a.active[key] += t.Sub(start)
+	}
+}
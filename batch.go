@@ -0,0 +1,115 @@
+package gotestdox
+
+import (
+	"bufio"
+	"io"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// PrettifyAll is like [Prettify], but prettifies each of names, returning
+// the sentences in the same order. The work is shared between
+// [runtime.GOMAXPROCS] goroutines, so this is much faster than calling
+// Prettify for each name when there are a great many of them.
+func PrettifyAll(names []string, opts ...Option) []string {
+	cfg := newConfig(opts)
+	sentences := make([]string, len(names))
+	workers := runtime.GOMAXPROCS(0)
+	size := (len(names) + workers - 1) / workers
+	var wg sync.WaitGroup
+	for start := 0; start < len(names); start += size {
+		end := start + size
+		if end > len(names) {
+			end = len(names)
+		}
+		wg.Add(1)
+		go func(start, end int) {
+			defer wg.Done()
+			for i := start; i < end; i++ {
+				sentences[i] = prettify(names[i], cfg)
+			}
+		}(start, end)
+	}
+	wg.Wait()
+	return sentences
+}
+
+// prettifyBatchSize is the number of names handed to each worker at a time
+// by [PrettifyReader].
+const prettifyBatchSize = 1024
+
+// prettifyBatch is a batch of names read by [PrettifyReader], whose
+// sentences are ready once done is closed.
+type prettifyBatch struct {
+	names     []string
+	sentences []string
+	done      chan struct{}
+}
+
+// PrettifyReader reads test names from r, one per line, and writes the
+// sentence for each to w, one per line, in the same order, as for
+// [PrettifyAll]. Names are processed as they are read, so the whole input
+// need not fit in memory. It returns the first error encountered reading
+// from r or writing to w.
+func PrettifyReader(r io.Reader, w io.Writer, opts ...Option) error {
+	cfg := newConfig(opts)
+	workers := runtime.GOMAXPROCS(0)
+	jobs := make(chan *prettifyBatch)
+	ordered := make(chan *prettifyBatch, 2*workers)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for b := range jobs {
+				b.sentences = make([]string, len(b.names))
+				for i, name := range b.names {
+					b.sentences[i] = prettify(name, cfg)
+				}
+				close(b.done)
+			}
+		}()
+	}
+	writeErr := make(chan error, 1)
+	go func() {
+		bw := bufio.NewWriter(w)
+		var err error
+		for b := range ordered {
+			<-b.done
+			for _, s := range b.sentences {
+				if err != nil {
+					break
+				}
+				_, err = bw.WriteString(s + "\n")
+			}
+		}
+		if err == nil {
+			err = bw.Flush()
+		}
+		writeErr <- err
+	}()
+	send := func(b *prettifyBatch) {
+		ordered <- b
+		jobs <- b
+	}
+	scanner := bufio.NewScanner(r)
+	b := &prettifyBatch{done: make(chan struct{})}
+	for scanner.Scan() {
+		b.names = append(b.names, strings.TrimSuffix(scanner.Text(), "\r"))
+		if len(b.names) == prettifyBatchSize {
+			send(b)
+			b = &prettifyBatch{done: make(chan struct{})}
+		}
+	}
+	if len(b.names) > 0 {
+		send(b)
+	}
+	close(jobs)
+	close(ordered)
+	wg.Wait()
+	if err := <-writeErr; err != nil {
+		return err
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,101 @@
+package gotestdox_test
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/bitfield/gotestdox"
+	"github.com/google/go-cmp/cmp"
+)
+
+// corpus returns n synthetic test names, based on the inputs of the
+// prettifier test cases, leaving out any that would span more than one line.
+func corpus(n int) []string {
+	inputs := []string{}
+	for _, tc := range Cases {
+		if !strings.ContainsAny(tc.input, "\n\r") {
+			inputs = append(inputs, tc.input)
+		}
+	}
+	names := make([]string, n)
+	for i := range names {
+		names[i] = fmt.Sprintf("%s/case_%d", inputs[i%len(inputs)], i)
+	}
+	return names
+}
+
+func TestPrettifyAll_GivesSameResultsAsPrettifyInOrder(t *testing.T) {
+	t.Parallel()
+	names := corpus(3_000)
+	want := make([]string, len(names))
+	for i, name := range names {
+		want[i] = gotestdox.Prettify(name, gotestdox.WithSubjectSeparator(": "))
+	}
+	got := gotestdox.PrettifyAll(names, gotestdox.WithSubjectSeparator(": "))
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestPrettifyReader_WritesSameOutputAsSerialPrettify(t *testing.T) {
+	t.Parallel()
+	names := corpus(3_000)
+	want := new(strings.Builder)
+	for _, name := range names {
+		fmt.Fprintln(want, gotestdox.Prettify(name))
+	}
+	got := new(strings.Builder)
+	err := gotestdox.PrettifyReader(strings.NewReader(strings.Join(names, "\n")), got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want.String() != got.String() {
+		t.Error(cmp.Diff(want.String(), got.String()))
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("oh no")
+}
+
+func TestPrettifyReader_ReturnsWriteError(t *testing.T) {
+	t.Parallel()
+	input := strings.Join(corpus(3_000), "\n")
+	err := gotestdox.PrettifyReader(strings.NewReader(input), failingWriter{})
+	if err == nil {
+		t.Error("want error")
+	}
+}
+
+func BenchmarkPrettifyAll(b *testing.B) {
+	names := corpus(1_000_000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = gotestdox.PrettifyAll(names)
+	}
+}
+
+func BenchmarkPrettifyReader(b *testing.B) {
+	input := strings.Join(corpus(1_000_000), "\n")
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := gotestdox.PrettifyReader(strings.NewReader(input), io.Discard); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkPrettifySerial(b *testing.B) {
+	names := corpus(1_000_000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, name := range names {
+			_ = gotestdox.Prettify(name)
+		}
+	}
+}
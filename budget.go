@@ -0,0 +1,102 @@
+package gotestdox
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// The default limits on the output kept, in bytes (see [WithOutputBudget]).
+const (
+	defaultTestOutputBudget   = 16 << 10
+	defaultReportOutputBudget = 2 << 20
+)
+
+// outputBudget keeps track of the output kept for each result in a run,
+// limiting it to perTest bytes for each result, and perReport bytes in total
+// for the results of tests and packages that didn't pass. A limit of zero
+// means no limit.
+type outputBudget struct {
+	perTest, perReport int
+	used               int
+}
+
+// keep returns the part of the output of r that's within the budget,
+// counting it in sum.Truncated if it had to be truncated. The output of a
+// result that passed isn't charged against the report's budget, so that
+// the chatter of passing tests can't crowd out the output of failures.
+func (b *outputBudget) keep(r Result, sum *Summary) []string {
+	output, truncated := b.apply(r.Output, r.Status != "pass")
+	if truncated {
+		sum.Truncated++
+	}
+	return output
+}
+
+// apply returns output, truncated if it's over the budget, and reports
+// whether it was truncated. Only if charged is true is the output limited
+// by, and counted against, the report's budget.
+func (b *outputBudget) apply(output []string, charged bool) ([]string, bool) {
+	total := 0
+	for _, out := range output {
+		total += len(out)
+	}
+	limit := -1
+	if b.perTest > 0 {
+		limit = b.perTest
+	}
+	if charged && b.perReport > 0 {
+		left := b.perReport - b.used
+		if left < 0 {
+			left = 0
+		}
+		if limit < 0 || left < limit {
+			limit = left
+		}
+	}
+	if limit < 0 || total <= limit {
+		if charged {
+			b.used += total
+		}
+		return output, false
+	}
+	if charged {
+		b.used += limit
+	}
+	return truncateMiddle(strings.Join(output, ""), limit), true
+}
+
+// truncateMiddle returns the lines of s, cut down to at most limit bytes by
+// removing its middle, so that its beginning and end are kept, and replacing
+// it with a line such as "… 12 KB omitted …". The cuts are never made in the
+// middle of a UTF-8 encoded rune.
+func truncateMiddle(s string, limit int) []string {
+	head := limit / 2
+	for head > 0 && !utf8.RuneStart(s[head]) {
+		head--
+	}
+	tail := len(s) - (limit - limit/2)
+	for tail < len(s) && !utf8.RuneStart(s[tail]) {
+		tail++
+	}
+	marker := fmt.Sprintf("… %d KB omitted …\n", (tail-head+1023)/1024)
+	if head > 0 && s[head-1] != '\n' {
+		marker = "\n" + marker
+	}
+	lines := splitLines(s[:head])
+	lines = append(lines, marker)
+	return append(lines, splitLines(s[tail:])...)
+}
+
+// splitLines splits s after each newline, leaving out the empty string
+// after a final newline.
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
@@ -0,0 +1,157 @@
+package gotestdox_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/bitfield/gotestdox"
+)
+
+// outputEvents returns the 'go test -json' events for a passing test named
+// test in package p which prints the given output, line by line.
+func outputEvents(test string, lines []string) string {
+	var b strings.Builder
+	for _, line := range lines {
+		data, _ := json.Marshal(map[string]string{"Action": "output", "Package": "p", "Test": test, "Output": line})
+		b.Write(data)
+		b.WriteByte('\n')
+	}
+	b.WriteString(`{"Action":"pass","Package":"p","Test":"` + test + `"}` + "\n")
+	return b.String()
+}
+
+func repeatLines(line string, n int) []string {
+	lines := make([]string, n)
+	for i := range lines {
+		lines[i] = line
+	}
+	return lines
+}
+
+func TestFilter_WithOutputBudgetKeepsBeginningAndEndOfLongOutput(t *testing.T) {
+	t.Parallel()
+	lines := append([]string{"first line\n"}, repeatLines(strings.Repeat("x", 99)+"\n", 1000)...)
+	lines = append(lines, "final assertion failed\n")
+	sink := &recordingSink{}
+	td := gotestdox.NewTestDoxer(gotestdox.WithSink(sink), gotestdox.WithOutputBudget(1024, 0))
+	td.Stdin = strings.NewReader(outputEvents("TestA", lines) + `{"Action":"pass","Package":"p"}`)
+	td.Filter()
+	output := sink.results[0].Output
+	got := strings.Join(output, "")
+	if !strings.HasPrefix(got, "first line\n") {
+		t.Errorf("want beginning of output kept, got %q", got)
+	}
+	if !strings.HasSuffix(got, "final assertion failed\n") {
+		t.Errorf("want end of output kept, got %q", got)
+	}
+	if !strings.Contains(got, "\n… 97 KB omitted …\n") {
+		t.Errorf("want omission marker, got %q", got)
+	}
+	if len(got) > 1024+len("\n… 97 KB omitted …\n") {
+		t.Errorf("want at most 1024 bytes of output kept, got %d", len(got))
+	}
+	if sink.summary.Truncated != 1 {
+		t.Errorf("want 1 truncated result, got %d", sink.summary.Truncated)
+	}
+}
+
+func TestFilter_WithOutputBudgetNeverSplitsRunes(t *testing.T) {
+	t.Parallel()
+	for limit := 100; limit < 110; limit++ {
+		sink := &recordingSink{}
+		td := gotestdox.NewTestDoxer(gotestdox.WithSink(sink), gotestdox.WithOutputBudget(limit, 0))
+		td.Stdin = strings.NewReader(outputEvents("TestA", []string{strings.Repeat("é€😀", 100)}))
+		td.Filter()
+		for _, out := range sink.results[0].Output {
+			if !utf8.ValidString(out) {
+				t.Errorf("limit %d: invalid UTF-8 in output %q", limit, out)
+			}
+		}
+	}
+}
+
+// failingEvents is like outputEvents, but for a failing test.
+func failingEvents(test string, lines []string) string {
+	events := outputEvents(test, lines)
+	return strings.TrimSuffix(events, `{"Action":"pass","Package":"p","Test":"`+test+`"}`+"\n") +
+		`{"Action":"fail","Package":"p","Test":"` + test + `"}` + "\n"
+}
+
+func TestFilter_WithOutputBudgetLimitsTotalOutputOfFailuresInRun(t *testing.T) {
+	t.Parallel()
+	line := strings.Repeat("y", 499) + "\n"
+	input := failingEvents("TestA", repeatLines(line, 2)) +
+		failingEvents("TestB", repeatLines(line, 2)) +
+		failingEvents("TestC", repeatLines(line, 2))
+	sink := &recordingSink{}
+	td := gotestdox.NewTestDoxer(gotestdox.WithSink(sink), gotestdox.WithOutputBudget(0, 2500))
+	td.Stdin = strings.NewReader(input)
+	td.Filter()
+	sizes := []int{}
+	for _, r := range sink.results {
+		sizes = append(sizes, len(strings.Join(r.Output, "")))
+	}
+	if sizes[0] != 1000 || sizes[1] != 1000 || sizes[2] >= 1000 {
+		t.Errorf("want third test's output truncated, got sizes %v", sizes)
+	}
+	if sink.summary.Truncated != 1 {
+		t.Errorf("want 1 truncated result, got %d", sink.summary.Truncated)
+	}
+}
+
+func TestFilter_KeepsShortOutputByDefault(t *testing.T) {
+	t.Parallel()
+	lines := repeatLines("short line\n", 10)
+	sink := &recordingSink{}
+	td := gotestdox.NewTestDoxer(gotestdox.WithSink(sink))
+	td.Stdin = strings.NewReader(outputEvents("TestA", lines))
+	td.Filter()
+	got := sink.results[0].Output
+	if len(got) != 10 || sink.summary.Truncated != 0 {
+		t.Errorf("want all output kept, got %q", got)
+	}
+}
+
+func TestFilter_WithOutputBudgetDoesNotChargePassingOutputAgainstReport(t *testing.T) {
+	t.Parallel()
+	line := strings.Repeat("y", 499) + "\n"
+	input := outputEvents("TestA", repeatLines(line, 4)) +
+		failingEvents("TestB", repeatLines(line, 2))
+	sink := &recordingSink{}
+	td := gotestdox.NewTestDoxer(gotestdox.WithSink(sink), gotestdox.WithOutputBudget(0, 1500))
+	td.Stdin = strings.NewReader(input)
+	td.Filter()
+	for _, r := range sink.results {
+		if got := len(strings.Join(r.Output, "")); got < 1000 {
+			t.Errorf("%s: want all output kept, got %d bytes", r.Test, got)
+		}
+	}
+	if sink.summary.Truncated != 0 {
+		t.Errorf("want no truncated results, got %d", sink.summary.Truncated)
+	}
+}
+
+func TestFilter_DetectsCachedPackagesWhoseOutputIsOverBudget(t *testing.T) {
+	t.Parallel()
+	var b strings.Builder
+	chatter := strings.Repeat("z", 15<<10) + "\n"
+	for i := 0; i < 150; i++ {
+		pkg := fmt.Sprintf("p%d", i)
+		for _, out := range []string{chatter, "ok  \t" + pkg + "\t(cached)\n"} {
+			data, _ := json.Marshal(map[string]string{"Action": "output", "Package": pkg, "Output": out})
+			b.Write(data)
+			b.WriteByte('\n')
+		}
+		fmt.Fprintf(&b, `{"Action":"pass","Package":%q}`+"\n", pkg)
+	}
+	sink := &recordingSink{}
+	td := gotestdox.NewTestDoxer(gotestdox.WithSink(sink))
+	td.Stdin = strings.NewReader(b.String())
+	td.Filter()
+	if sink.summary.CachedPackages != 150 {
+		t.Errorf("want 150 cached packages, got %d", sink.summary.CachedPackages)
+	}
+}
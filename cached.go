@@ -0,0 +1,18 @@
+package gotestdox
+
+import "strings"
+
+// isCached reports whether the package result r shows that the results of
+// its tests were replayed from the 'go test' cache, as shown by a line such
+// as "ok   example.com/pkg   (cached)" in its output.
+func isCached(r Result) bool {
+	if r.Status != "pass" {
+		return false
+	}
+	for _, line := range r.Output {
+		if strings.HasPrefix(line, "ok") && strings.Contains(line, "(cached)") {
+			return true
+		}
+	}
+	return false
+}
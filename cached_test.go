@@ -0,0 +1,85 @@
+package gotestdox_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bitfield/gotestdox"
+	"github.com/fatih/color"
+	"github.com/google/go-cmp/cmp"
+)
+
+var cachedInput = `{"Action":"pass","Package":"p","Test":"TestA"}
+{"Action":"pass","Package":"p","Test":"TestB"}
+{"Action":"skip","Package":"p","Test":"TestC"}
+{"Action":"output","Package":"p","Output":"ok  \tp\t(cached)\n"}
+{"Action":"pass","Package":"p","Elapsed":0}
+{"Action":"pass","Package":"q","Test":"TestD"}
+{"Action":"output","Package":"q","Output":"ok  \tq\t0.012s\n"}
+{"Action":"pass","Package":"q","Elapsed":0.012}`
+
+func TestFilter_CountsTestsInCachedPackagesSeparately(t *testing.T) {
+	t.Parallel()
+	sink := &recordingSink{}
+	td := gotestdox.NewTestDoxer(gotestdox.WithSink(sink))
+	td.Stdin = strings.NewReader(cachedInput)
+	td.Filter()
+	if sink.summary.CachedPackages != 1 || sink.summary.CachedPassed != 2 {
+		t.Errorf("want 1 cached package with 2 passed tests, got %d and %d",
+			sink.summary.CachedPackages, sink.summary.CachedPassed)
+	}
+	cached := map[string]bool{}
+	for _, r := range sink.results {
+		if r.Test == "" {
+			cached[r.Package] = r.Cached
+		}
+	}
+	want := map[string]bool{"p": true, "q": false}
+	if !cmp.Equal(want, cached) {
+		t.Error(cmp.Diff(want, cached))
+	}
+}
+
+func TestTextSink_MarksCachedPackages(t *testing.T) {
+	t.Parallel()
+	color.NoColor = true
+	tcs := []struct {
+		name string
+		opts []gotestdox.Option
+		want string
+	}{
+		{
+			name: "default",
+			want: `p (cached):
+ ✔ A (0.00s)
+ ✔ B (0.00s)
+
+q:
+ ✔ D (0.00s)
+
+3 passed (2 cached)
+`,
+		},
+		{
+			name: "WithCachedTestsHidden",
+			opts: []gotestdox.Option{gotestdox.WithCachedTestsHidden()},
+			want: `p (cached):
+
+q:
+ ✔ D (0.00s)
+
+3 passed (2 cached)
+`,
+		},
+	}
+	for _, tc := range tcs {
+		buf := new(strings.Builder)
+		td := gotestdox.NewTestDoxer(tc.opts...)
+		td.Stdin = strings.NewReader(cachedInput)
+		td.Stdout = buf
+		td.Filter()
+		if tc.want != buf.String() {
+			t.Errorf("%s: %s", tc.name, cmp.Diff(tc.want, buf.String()))
+		}
+	}
+}
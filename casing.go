@@ -0,0 +1,19 @@
+//go:build !gotestdox_ascii
+
+package gotestdox
+
+import (
+	"golang.org/x/text/cases"
+	"golang.org/x/text/language"
+)
+
+// titleCase returns s with the first letter of each word in upper case,
+// leaving the other letters unchanged.
+func titleCase(s string) string {
+	return cases.Title(language.Und, cases.NoLower).String(s)
+}
+
+// lowerCase returns s with all letters in lower case.
+func lowerCase(s string) string {
+	return cases.Lower(language.Und).String(s)
+}
@@ -0,0 +1,49 @@
+//go:build gotestdox_ascii
+
+package gotestdox
+
+import "strings"
+
+// This file provides a lightweight implementation of the case conversions
+// used by the prettifier, selected by the gotestdox_ascii build tag, for
+// programs that want to avoid the size of the golang.org/x/text tables. It
+// only changes the case of ASCII letters; any other letters are left as
+// they are. This is enough for most test names, which are Go identifiers,
+// but names containing non-ASCII letters won't be prettified exactly as they
+// would be by default.
+
+// titleCase returns s with the first letter of each word in upper case,
+// leaving the other letters unchanged.
+func titleCase(s string) string {
+	var b strings.Builder
+	upperNext := true
+	for _, r := range s {
+		switch {
+		case 'a' <= r && r <= 'z':
+			if upperNext {
+				r -= 'a' - 'A'
+			}
+			upperNext = false
+		case 'A' <= r && r <= 'Z' || r > 0x7f:
+			upperNext = false
+		case '0' <= r && r <= '9' || strings.ContainsRune("_'.:", r):
+			// part of the current word
+		default:
+			upperNext = true
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// lowerCase returns s with all ASCII letters in lower case.
+func lowerCase(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if 'A' <= r && r <= 'Z' {
+			r += 'a' - 'A'
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
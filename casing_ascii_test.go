@@ -0,0 +1,7 @@
+//go:build gotestdox_ascii
+
+package gotestdox_test
+
+func init() {
+	asciiCasing = true
+}
@@ -0,0 +1,82 @@
+package gotestdox
+
+import "time"
+
+// Clock tells the time, and makes tickers, for the parts of gotestdox that
+// depend on the passage of time: [TestDoxer.Filter], for the stall report
+// (see [WithStallReport]), the progress line (see [WithProgress]), and the
+// time the run took (see [Summary]), and [TestDoxer.ExecGoTest] and its
+// relatives, for package timeouts (see [WithPackageTimeout]) and the wait
+// after passing on a signal. The default is the system clock. A fake clock,
+// such as the one provided by the clocktest package, can be supplied using
+// [WithClock], so that these can be tested without waiting.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+	// NewTicker returns a ticker delivering the time every d.
+	NewTicker(d time.Duration) Ticker
+}
+
+// Ticker delivers the time at intervals on the channel returned by C, as a
+// [time.Ticker] does. Reset stops it and starts it again with the interval
+// d, so that the next tick is d from now, and Stop turns it off.
+type Ticker interface {
+	C() <-chan time.Time
+	Reset(d time.Duration)
+	Stop()
+}
+
+// systemClock is the [Clock] used by default, which uses the time package.
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) NewTicker(d time.Duration) Ticker {
+	return systemTicker{t: time.NewTicker(d)}
+}
+
+type systemTicker struct {
+	t *time.Ticker
+}
+
+func (s systemTicker) C() <-chan time.Time {
+	return s.t.C
+}
+
+func (s systemTicker) Reset(d time.Duration) {
+	s.t.Reset(d)
+}
+
+func (s systemTicker) Stop() {
+	s.t.Stop()
+}
+
+// clockOrSystem returns the clock set by [WithClock], or the system clock,
+// if there is none, as for a TestDoxer not made by [NewTestDoxer].
+func (c config) clockOrSystem() Clock {
+	if c.clock == nil {
+		return systemClock{}
+	}
+	return c.clock
+}
+
+// after returns a channel on which c delivers the time once d has passed,
+// as [time.After] does, and a function to stop it early, which the caller
+// must call when it no longer needs the channel.
+func after(c Clock, d time.Duration) (<-chan time.Time, func()) {
+	t := c.NewTicker(d)
+	return t.C(), t.Stop
+}
+
+// resetTicker resets t with the interval d, first dropping any tick that
+// was delivered but not yet received, so that the next tick received is
+// the one d from now.
+func resetTicker(t Ticker, d time.Duration) {
+	select {
+	case <-t.C():
+	default:
+	}
+	t.Reset(d)
+}
@@ -0,0 +1,157 @@
+// Package clocktest provides a fake [gotestdox.Clock], whose time moves only
+// when it's told to, so that the parts of gotestdox that depend on the
+// passage of time, such as the stall report, can be tested without waiting:
+//
+//	clock := clocktest.New(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+//	td := gotestdox.NewTestDoxer(gotestdox.WithClock(clock), gotestdox.WithStallReport(time.Minute))
+//	...
+//	clock.Advance(time.Minute) // the stall report is printed
+//
+// Since the code under test usually runs in another goroutine,
+// BlockUntilTickers and BlockUntilResets can be used to wait until it has
+// reached a given point, such as having started a ticker, or reacted to an
+// event by resetting one, before moving the time on.
+package clocktest
+
+import (
+	"sync"
+	"time"
+
+	"github.com/bitfield/gotestdox"
+)
+
+// Clock is a fake [gotestdox.Clock]. It's safe for concurrent use.
+type Clock struct {
+	mu      sync.Mutex
+	changed *sync.Cond
+	now     time.Time
+	tickers []*ticker
+	resets  int
+}
+
+// New returns a clock whose time is now, until it's moved on by
+// [Clock.Advance].
+func New(now time.Time) *Clock {
+	c := &Clock{now: now}
+	c.changed = sync.NewCond(&c.mu)
+	return c
+}
+
+// Now implements [gotestdox.Clock], returning the clock's current time.
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// NewTicker implements [gotestdox.Clock], returning a ticker that delivers
+// the time every d, as the clock is moved on by [Clock.Advance].
+func (c *Clock) NewTicker(d time.Duration) gotestdox.Ticker {
+	if d <= 0 {
+		panic("clocktest: non-positive interval for NewTicker")
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t := &ticker{
+		clock:  c,
+		ch:     make(chan time.Time, 1),
+		period: d,
+		next:   c.now.Add(d),
+	}
+	c.tickers = append(c.tickers, t)
+	c.changed.Broadcast()
+	return t
+}
+
+// Advance moves the clock on by d, delivering the ticks due on the way, in
+// order of time, with the clock set to the time of each tick as it's
+// delivered. As with a [time.Ticker], a tick is dropped if the one before
+// hasn't been received yet.
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	end := c.now.Add(d)
+	for {
+		var due *ticker
+		for _, t := range c.tickers {
+			if !t.stopped && !t.next.After(end) && (due == nil || t.next.Before(due.next)) {
+				due = t
+			}
+		}
+		if due == nil {
+			break
+		}
+		c.now = due.next
+		select {
+		case due.ch <- c.now:
+		default:
+		}
+		due.next = due.next.Add(due.period)
+	}
+	c.now = end
+}
+
+// BlockUntilTickers blocks until at least n of the tickers made by the
+// clock are running, that is, started and not stopped.
+func (c *Clock) BlockUntilTickers(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for c.running() < n {
+		c.changed.Wait()
+	}
+}
+
+// BlockUntilResets blocks until the tickers made by the clock have been
+// reset at least n times in all, as gotestdox does with the ticker for the
+// stall report whenever an event arrives.
+func (c *Clock) BlockUntilResets(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for c.resets < n {
+		c.changed.Wait()
+	}
+}
+
+// running returns the number of running tickers. The caller must hold c.mu.
+func (c *Clock) running() int {
+	n := 0
+	for _, t := range c.tickers {
+		if !t.stopped {
+			n++
+		}
+	}
+	return n
+}
+
+// ticker is a [gotestdox.Ticker] driven by a [Clock].
+type ticker struct {
+	clock   *Clock
+	ch      chan time.Time
+	period  time.Duration
+	next    time.Time
+	stopped bool
+}
+
+func (t *ticker) C() <-chan time.Time {
+	return t.ch
+}
+
+func (t *ticker) Reset(d time.Duration) {
+	if d <= 0 {
+		panic("clocktest: non-positive interval for Reset")
+	}
+	c := t.clock
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t.period, t.next, t.stopped = d, c.now.Add(d), false
+	c.resets++
+	c.changed.Broadcast()
+}
+
+func (t *ticker) Stop() {
+	c := t.clock
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t.stopped = true
+	c.changed.Broadcast()
+}
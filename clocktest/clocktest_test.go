@@ -0,0 +1,76 @@
+package clocktest_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bitfield/gotestdox/clocktest"
+)
+
+var start = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+func TestAdvance_DeliversTicksDueInOrderOfTime(t *testing.T) {
+	t.Parallel()
+	c := clocktest.New(start)
+	fast, slow := c.NewTicker(2*time.Second), c.NewTicker(3*time.Second)
+	c.Advance(2 * time.Second)
+	if got := <-fast.C(); !got.Equal(start.Add(2 * time.Second)) {
+		t.Errorf("want tick at 2s, got %v", got.Sub(start))
+	}
+	c.Advance(time.Second)
+	if got := <-slow.C(); !got.Equal(start.Add(3 * time.Second)) {
+		t.Errorf("want tick at 3s, got %v", got.Sub(start))
+	}
+	if got := c.Now(); !got.Equal(start.Add(3 * time.Second)) {
+		t.Errorf("want clock at 3s, got %v", got.Sub(start))
+	}
+}
+
+func TestAdvance_DropsTickIfPreviousOneWasNotReceived(t *testing.T) {
+	t.Parallel()
+	c := clocktest.New(start)
+	tk := c.NewTicker(time.Second)
+	c.Advance(3 * time.Second)
+	if got := <-tk.C(); !got.Equal(start.Add(time.Second)) {
+		t.Errorf("want first tick kept, at 1s, got %v", got.Sub(start))
+	}
+	select {
+	case got := <-tk.C():
+		t.Errorf("want later ticks dropped, got one at %v", got.Sub(start))
+	default:
+	}
+}
+
+func TestReset_StartsIntervalAgainFromNow(t *testing.T) {
+	t.Parallel()
+	c := clocktest.New(start)
+	tk := c.NewTicker(time.Second)
+	c.Advance(900 * time.Millisecond)
+	tk.Reset(time.Second)
+	c.Advance(900 * time.Millisecond)
+	select {
+	case got := <-tk.C():
+		t.Errorf("want no tick before 1s after reset, got one at %v", got.Sub(start))
+	default:
+	}
+	c.Advance(100 * time.Millisecond)
+	if got := <-tk.C(); !got.Equal(start.Add(1900 * time.Millisecond)) {
+		t.Errorf("want tick 1s after reset, got %v", got.Sub(start))
+	}
+	c.BlockUntilResets(1)
+}
+
+func TestStop_StopsTicksAndCountsTickerAsNotRunning(t *testing.T) {
+	t.Parallel()
+	c := clocktest.New(start)
+	tk := c.NewTicker(time.Second)
+	c.BlockUntilTickers(1)
+	tk.Stop()
+	c.Advance(time.Hour)
+	select {
+	case got := <-tk.C():
+		t.Errorf("want no ticks after stop, got one at %v", got.Sub(start))
+	default:
+	}
+	c.BlockUntilTickers(0)
+}
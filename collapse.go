@@ -0,0 +1,245 @@
+package gotestdox
+
+import (
+	"fmt"
+	"strings"
+)
+
+// isNumeric reports whether s is non-empty and consists only of ASCII digits.
+func isNumeric(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// splitParent splits a test name into the name of its parent test and the
+// name of the final subtest. For a top-level test, parent is empty.
+func splitParent(name string) (parent, sub string) {
+	i := strings.LastIndex(name, "/")
+	if i < 0 {
+		return "", name
+	}
+	return name[:i], name[i+1:]
+}
+
+// collapseNumericSubtests replaces any group of two or more sibling subtests
+// whose names are purely numeric (such as the subtests 0, 1, 2... created by a
+// table test that doesn't name its cases) with a single aggregated result
+// summarising their results. The parent test's own result, if present, is
+// replaced too. Groups that mix numeric and named subtests are left alone.
+func collapseNumericSubtests(tests []Result, cfg config) []Result {
+	type group struct {
+		count, failed int
+		mixed         bool
+	}
+	groups := map[string]*group{}
+	for _, e := range tests {
+		parent, sub := splitParent(e.Test)
+		if parent == "" {
+			continue
+		}
+		g := groups[parent]
+		if g == nil {
+			g = &group{}
+			groups[parent] = g
+		}
+		if !isNumeric(sub) {
+			g.mixed = true
+			continue
+		}
+		g.count++
+		if e.Status == "fail" {
+			g.failed++
+		}
+	}
+	collapsed := func(parent string) bool {
+		g := groups[parent]
+		return g != nil && !g.mixed && g.count > 1
+	}
+	result := []Result{}
+	seenParent := map[string]bool{}
+	for _, e := range tests {
+		if parent, _ := splitParent(e.Test); collapsed(parent) {
+			continue
+		}
+		if collapsed(e.Test) {
+			seenParent[e.Test] = true
+			result = append(result, aggregateResult(e, groups[e.Test].count, groups[e.Test].failed, cfg))
+			continue
+		}
+		result = append(result, e)
+	}
+	for _, e := range tests {
+		parent, _ := splitParent(e.Test)
+		if !collapsed(parent) || seenParent[parent] {
+			continue
+		}
+		// the parent's own result is missing, so synthesise one
+		seenParent[parent] = true
+		g := groups[parent]
+		agg := Result{Status: "pass", Package: e.Package, Test: parent}
+		result = append(result, aggregateResult(agg, g.count, g.failed, cfg))
+	}
+	return result
+}
+
+// aggregateResult returns a copy of the parent result e, with a sentence
+// summarising the results of its count numeric subtests.
+func aggregateResult(e Result, count, failed int, cfg config) Result {
+	status := "all passed"
+	if failed > 0 {
+		e.Status = "fail"
+		status = fmt.Sprintf("%d failed", failed)
+	}
+	e.Sentence = fmt.Sprintf("%s (%d cases, %s)", prettify(e.Test, cfg), count, status)
+	return e
+}
+
+// fanOut summarises the results of the direct subtests of a parent test whose
+// subtests are too numerous to list individually.
+type fanOut struct {
+	total, passed, skipped int
+	listed                 []Result
+}
+
+// summariseFanOut replaces the subtests of any parent test with more than
+// threshold direct subtests with a single aggregated result for the parent,
+// giving the number of subtests that passed. It returns the remaining results,
+// with any skipped tests removed, and a map from each aggregated parent test to
+// the subtests that should still be listed individually beneath it: those
+// that failed, and, if listSkips is set, those that were skipped.
+func summariseFanOut(tests []Result, threshold int, listSkips bool, cfg config) ([]Result, map[string][]Result) {
+	groups := map[string]*fanOut{}
+	for _, e := range tests {
+		parent, _ := splitParent(e.Test)
+		if parent == "" {
+			continue
+		}
+		g := groups[parent]
+		if g == nil {
+			g = &fanOut{}
+			groups[parent] = g
+		}
+		g.total++
+		switch {
+		case e.Status == "pass":
+			g.passed++
+		case e.Status == "skip":
+			g.skipped++
+			if listSkips {
+				g.listed = append(g.listed, e)
+			}
+		default:
+			g.listed = append(g.listed, e)
+		}
+	}
+	// collapsedAncestor returns the name of the outermost ancestor of test
+	// whose subtests are being summarised, if any.
+	collapsedAncestor := func(test string) string {
+		parts := strings.Split(test, "/")
+		for i := 1; i < len(parts); i++ {
+			ancestor := strings.Join(parts[:i], "/")
+			if g := groups[ancestor]; g != nil && g.total > threshold {
+				return ancestor
+			}
+		}
+		return ""
+	}
+	result := []Result{}
+	details := map[string][]Result{}
+	seen := map[string]bool{}
+	for _, e := range tests {
+		if collapsedAncestor(e.Test) != "" {
+			continue
+		}
+		if g := groups[e.Test]; g != nil && g.total > threshold {
+			seen[e.Test] = true
+			result = append(result, fanOutResult(e, g, listSkips, cfg))
+			details[e.Test] = g.listed
+			continue
+		}
+		if e.Status == "skip" {
+			continue
+		}
+		result = append(result, e)
+	}
+	for _, e := range tests {
+		parent := collapsedAncestor(e.Test)
+		if parent == "" || seen[parent] {
+			continue
+		}
+		// the parent's own result is missing, so synthesise one
+		seen[parent] = true
+		g := groups[parent]
+		agg := Result{Status: "pass", Package: e.Package, Test: parent}
+		if len(g.listed) > 0 && g.passed+g.skipped < g.total {
+			agg.Status = "fail"
+		}
+		result = append(result, fanOutResult(agg, g, listSkips, cfg))
+		details[parent] = g.listed
+	}
+	return result, details
+}
+
+// fanOutResult returns a copy of the parent result e, with a sentence giving the
+// number of its subtests that passed.
+func fanOutResult(e Result, g *fanOut, listSkips bool, cfg config) Result {
+	e.Sentence = fmt.Sprintf("%s — %s/%s passed", prettify(e.Test, cfg), formatCount(g.passed), formatCount(g.total))
+	if g.skipped > 0 && !listSkips {
+		e.Sentence += fmt.Sprintf(", %s skipped", formatCount(g.skipped))
+	}
+	return e
+}
+
+// formatCount formats n in decimal, with commas separating each group of
+// three digits, such as "5,000".
+func formatCount(n int) string {
+	s := fmt.Sprint(n)
+	if n < 0 {
+		return "-" + formatCount(-n)
+	}
+	for i := len(s) - 3; i > 0; i -= 3 {
+		s = s[:i] + "," + s[i:]
+	}
+	return s
+}
+
+// dropHostParents returns tests without the results of any parent tests that
+// only host the subtests also in tests: that is, whose status is the same
+// as the combined status of their subtests (see [WithParentLines]).
+func dropHostParents(tests []Result) []Result {
+	combined := map[string]string{}
+	for _, r := range tests {
+		for i := strings.LastIndex(r.Test, "/"); i > 0; i = strings.LastIndex(r.Test[:i], "/") {
+			parent := r.Test[:i]
+			combined[parent] = combineStatus(combined[parent], r.Status)
+		}
+	}
+	result := []Result{}
+	for _, r := range tests {
+		if status, ok := combined[r.Test]; ok && status == r.Status {
+			continue
+		}
+		result = append(result, r)
+	}
+	return result
+}
+
+// combineStatus returns the combined status of a group of tests with the
+// status so far, and one more test with the status next. A group fails if
+// any of its tests fails, and otherwise passes if any of them passes.
+func combineStatus(so, next string) string {
+	switch {
+	case so == "fail" || next == "fail":
+		return "fail"
+	case so == "pass" || next == "pass":
+		return "pass"
+	}
+	return next
+}
@@ -0,0 +1,122 @@
+package gotestdox
+
+import (
+	"context"
+	"strings"
+)
+
+// Configuration is a named set of extra arguments and environment variables
+// with which to run 'go test', such as a set of build tags, for use with
+// [TestDoxer.ExecGoTestConfigurations]. Args are passed to 'go test' before
+// any other arguments, and Env entries, of the form "KEY=value", are added
+// to the environment inherited from the current process.
+type Configuration struct {
+	Name string
+	Args []string
+	Env  []string
+}
+
+// ConfigurationSummary gives the results of the tests run under a single
+// [Configuration], in a run started by [TestDoxer.ExecGoTestConfigurations].
+type ConfigurationSummary struct {
+	Name     string `json:"name"`
+	Packages int    `json:"packages"`
+	Passed   int    `json:"passed"`
+	Failed   int    `json:"failed"`
+	Skipped  int    `json:"skipped"`
+}
+
+// ExecGoTestConfigurations is like [TestDoxer.ExecGoTest], but runs 'go test
+// -json' once for each of configs, passing the configuration's own Args
+// followed by userArgs, and with its Env added to the environment. A -C flag
+// at the start of userArgs is kept in front of the configuration's Args. The
+// results are merged into a single report, in which each package heading
+// is followed by the name of the configuration in parentheses, and the
+// [Summary] includes a [ConfigurationSummary] for each configuration. Each
+// [Result] records the name of its configuration, so that, for example, a
+// test that fails under one set of build tags but passes under another is
+// reported separately for each; the durations recorded by
+// [WithDurationHistoryUpdate] are kept separately for each, too.
+//
+// By default the configurations are run one at a time; use
+// [WithConfigurationParallelism] to run several at once. Either way, the
+// results for each configuration are reported in the order given, as soon as
+// it has finished. A failure under any configuration makes td.OK false, but
+// doesn't stop the others being run. If ctx is cancelled, any runs still in
+// progress are stopped.
+func (td *TestDoxer) ExecGoTestConfigurations(ctx context.Context, configs []Configuration, userArgs []string) {
+	first, rest := splitDirFlag(userArgs)
+	runs := make([]*goTestRun, len(configs))
+	for i, c := range configs {
+		args := append(append([]string{"test"}, first...), "-json")
+		args = append(args, c.Args...)
+		runs[i] = &goTestRun{
+			args:          append(args, rest...),
+			env:           c.Env,
+			configuration: c.Name,
+		}
+	}
+	state := td.startRun(userArgs)
+	state.configurations = configs
+	td.execParallel(ctx, runs, td.configParallelism, true, state)
+}
+
+// hasConfiguration reports whether s has a summary for the configuration
+// with the given name.
+func (s *Summary) hasConfiguration(name string) bool {
+	for _, c := range s.Configurations {
+		if c.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// addConfiguration counts the result r in the summary for its
+// configuration, if any.
+func (s *Summary) addConfiguration(r Result) {
+	for i := range s.Configurations {
+		c := &s.Configurations[i]
+		if c.Name != r.Configuration {
+			continue
+		}
+		if r.Test == "" {
+			c.Packages++
+			return
+		}
+		switch r.Status {
+		case "pass":
+			c.Passed++
+		case "fail":
+			c.Failed++
+		case "skip":
+			c.Skipped++
+		}
+		return
+	}
+}
+
+// historyPackage returns the key under which the durations of the tests in
+// the package of r are recorded in the duration history (see
+// [WithDurationHistory]): the name of the package, followed by the name of
+// its configuration, if any, in parentheses.
+func historyPackage(r Result) string {
+	if r.Configuration == "" {
+		return r.Package
+	}
+	return r.Package + " (" + r.Configuration + ")"
+}
+
+// envPrefix returns the environment variables in env as shell assignments to
+// precede a command, or the empty string if there are none.
+func envPrefix(env []string) string {
+	if len(env) == 0 {
+		return ""
+	}
+	assigns := make([]string, len(env))
+	for i, e := range env {
+		k, v, _ := strings.Cut(e, "=")
+		assigns[i] = k + "=" + shellQuote(v)
+	}
+	return strings.Join(assigns, " ") + " "
+}
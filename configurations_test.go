@@ -0,0 +1,67 @@
+package gotestdox_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/bitfield/gotestdox"
+	"github.com/fatih/color"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestExecGoTestConfigurations_LabelsResultsWithEachConfiguration(t *testing.T) {
+	t.Parallel()
+	color.NoColor = true
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"go.mod":              "module example.com/tags\n\ngo 1.18\n",
+		"unit_test.go":        "package tags\n\nimport (\n\t\"os\"\n\t\"testing\"\n)\n\nfunc TestIgnoresStrictMode(t *testing.T) {\n\tif os.Getenv(\"TAGS_MODE\") == \"strict\" {\n\t\tt.Fail()\n\t}\n}\n",
+		"integration_test.go": "//go:build integration\n\npackage tags\n\nimport \"testing\"\n\nfunc TestTalksToDatabase(t *testing.T) {}\n",
+	})
+	configs := []gotestdox.Configuration{
+		{Name: "unit"},
+		{Name: "integration", Args: []string{"-tags=integration"}},
+		{Name: "strict", Env: []string{"TAGS_MODE=strict"}},
+	}
+	statuses := map[string]string{}
+	buf := new(strings.Builder)
+	td := gotestdox.NewTestDoxer(
+		gotestdox.WithConfigurationParallelism(3),
+		gotestdox.WithRerunCommands(),
+		gotestdox.WithOnResult(func(r gotestdox.Result) {
+			statuses[r.Configuration+" "+r.Test] = r.Status
+		}),
+	)
+	td.Stdout = buf
+	stderr := new(strings.Builder)
+	td.Stderr = stderr
+	td.ExecGoTestConfigurations(context.Background(), configs, []string{"-C", dir, "./..."})
+	if td.OK {
+		t.Error("want not ok when a configuration has failing tests")
+	}
+	wantStatuses := map[string]string{
+		"unit TestIgnoresStrictMode":        "pass",
+		"integration TestIgnoresStrictMode": "pass",
+		"integration TestTalksToDatabase":   "pass",
+		"strict TestIgnoresStrictMode":      "fail",
+	}
+	if !cmp.Equal(wantStatuses, statuses) {
+		t.Error(cmp.Diff(wantStatuses, statuses))
+	}
+	got := buf.String()
+	for _, want := range []string{
+		"example.com/tags (unit):\n ✔ Ignores strict mode (",
+		"example.com/tags (integration):\n ✔ Ignores strict mode (",
+		" ✔ Talks to database (",
+		"example.com/tags (strict):\n x Ignores strict mode (",
+		"  TAGS_MODE=strict go test -run '^TestIgnoresStrictMode$' example.com/tags\n",
+		"(unit): 1 packages, 1 passed, 0 failed, 0 skipped\n",
+		"(integration): 1 packages, 2 passed, 0 failed, 0 skipped\n",
+		"(strict): 1 packages, 0 passed, 1 failed, 0 skipped\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("want output to contain %q, got:\n%s\nstderr:\n%s", want, got, stderr)
+		}
+	}
+}
@@ -0,0 +1,7 @@
+//go:build !windows
+
+package gotestdox
+
+// enableColor does nothing on platforms other than Windows, where terminals
+// interpret ANSI escape sequences without any special setup.
+func enableColor() {}
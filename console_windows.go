@@ -0,0 +1,25 @@
+//go:build windows
+
+package gotestdox
+
+import (
+	"os"
+
+	"github.com/fatih/color"
+	"golang.org/x/sys/windows"
+)
+
+// enableColor turns on virtual terminal processing for the Windows console
+// attached to stdout, so that it interprets the ANSI escape sequences used for
+// colour. If that isn't possible, colour is disabled instead.
+func enableColor() {
+	h := windows.Handle(os.Stdout.Fd())
+	var mode uint32
+	if err := windows.GetConsoleMode(h, &mode); err != nil {
+		color.NoColor = true
+		return
+	}
+	if err := windows.SetConsoleMode(h, mode|windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING); err != nil {
+		color.NoColor = true
+	}
+}
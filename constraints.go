@@ -0,0 +1,27 @@
+package gotestdox
+
+import "strings"
+
+// excludedMessage is the reason given by a [TextSink] for a package whose
+// files are all excluded by build constraints.
+const excludedMessage = "build constraints exclude all Go files"
+
+// excludedByConstraints reports whether the build output, or the output, of
+// a package shows that it was left untested because build constraints, such
+// as build tags or file name suffixes, exclude all its Go files.
+func excludedByConstraints(output []string) bool {
+	for _, line := range outputLines(output) {
+		if strings.Contains(line, excludedMessage) {
+			return true
+		}
+	}
+	return false
+}
+
+// onlyExcluded reports whether the only packages in the run that didn't pass
+// were those excluded by build constraints, in which case the failure status
+// of 'go test' is explained by them.
+func (s Summary) onlyExcluded() bool {
+	return s.ExcludedPackages > 0 && !s.BuildFailed && s.VetFailures == 0 &&
+		s.TimedOutPackages == 0 && s.Failed == 0 && s.TeardownFailures == 0
+}
@@ -0,0 +1,110 @@
+package gotestdox_test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bitfield/gotestdox"
+	"github.com/fatih/color"
+	"github.com/google/go-cmp/cmp"
+)
+
+// excludedRun is the output of 'go test -json ./...' followed by 'go test
+// -json ./a', where all the files in package a are excluded by build
+// constraints, and package b has no test files.
+const excludedRun = `{"Action":"start","Package":"example.com/bc/b"}
+{"Action":"output","Package":"example.com/bc/b","Output":"?   \texample.com/bc/b\t[no test files]\n"}
+{"Action":"skip","Package":"example.com/bc/b","Elapsed":0}
+{"Action":"start","Package":"example.com/bc/c"}
+{"Action":"run","Package":"example.com/bc/c","Test":"TestC"}
+{"Action":"pass","Package":"example.com/bc/c","Test":"TestC","Elapsed":0}
+{"Action":"output","Package":"example.com/bc/c","Output":"ok  \texample.com/bc/c\t0.002s\n"}
+{"Action":"pass","Package":"example.com/bc/c","Elapsed":0.002}
+{"ImportPath":"example.com/bc/a","Action":"build-output","Output":"# example.com/bc/a\n"}
+{"ImportPath":"example.com/bc/a","Action":"build-output","Output":"package example.com/bc/a: build constraints exclude all Go files in /tmp/bc/a\n"}
+{"ImportPath":"example.com/bc/a","Action":"build-fail"}
+{"Action":"start","Package":"example.com/bc/a"}
+{"Action":"output","Package":"example.com/bc/a","Output":"FAIL\texample.com/bc/a [setup failed]\n"}
+{"Action":"fail","Package":"example.com/bc/a","Elapsed":0,"FailedBuild":"example.com/bc/a"}
+`
+
+func TestFilter_ShowsPackageExcludedByBuildConstraintsOnOneLine(t *testing.T) {
+	t.Parallel()
+	color.NoColor = true
+	td := gotestdox.NewTestDoxer()
+	td.Stdin = strings.NewReader(excludedRun)
+	buf := new(strings.Builder)
+	td.Stdout = buf
+	td.Filter()
+	want := `example.com/bc/c:
+ ✔ C (0.00s)
+
+example.com/bc/a: skipped (build constraints exclude all Go files)
+
+`
+	if want != buf.String() {
+		t.Error(cmp.Diff(want, buf.String()))
+	}
+	if !td.OK {
+		t.Error("want ok, since an excluded package isn't a failure")
+	}
+	if code := gotestdox.ExitCode(td.Summary, nil); code != gotestdox.ExitOK {
+		t.Errorf("want exit status %d, got %d", gotestdox.ExitOK, code)
+	}
+	sum := td.Summary
+	if sum.Packages != 1 || sum.ExcludedPackages != 1 || sum.NoTestFiles != 1 {
+		t.Errorf("want 1 package tested, 1 excluded, and 1 with no test files, got %d, %d, and %d", sum.Packages, sum.ExcludedPackages, sum.NoTestFiles)
+	}
+}
+
+func TestResumeSummary_CountsExcludedPackagesAndPackagesWithNoTestFiles(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "results.ndjson")
+	sink := &recordingSink{}
+	td := gotestdox.NewTestDoxer(gotestdox.WithSink(sink), gotestdox.WithResultLog(path))
+	td.Stdin = strings.NewReader(excludedRun)
+	td.Filter()
+	got, err := gotestdox.ResumeSummary(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cmp.Equal(sink.summary, got) {
+		t.Error(cmp.Diff(sink.summary, got))
+	}
+}
+
+func TestFilter_WithExcludedPackagesHiddenLeavesOutExcludedPackagesButCountsThem(t *testing.T) {
+	t.Parallel()
+	color.NoColor = true
+	sink := &recordingSink{}
+	td := gotestdox.NewTestDoxer(gotestdox.WithExcludedPackagesHidden(), gotestdox.WithSink(sink))
+	td.Stdin = strings.NewReader(excludedRun)
+	td.Filter()
+	for _, r := range sink.results {
+		if r.Package == "example.com/bc/a" {
+			t.Errorf("want excluded package left out, got %+v", r)
+		}
+	}
+	if sink.summary.ExcludedPackages != 1 {
+		t.Errorf("want 1 excluded package counted, got %d", sink.summary.ExcludedPackages)
+	}
+}
+
+func TestFilter_RecognisesExclusionReportedAsPackageOutput(t *testing.T) {
+	t.Parallel()
+	sink := &recordingSink{}
+	td := gotestdox.NewTestDoxer(gotestdox.WithSink(sink))
+	td.Stdin = strings.NewReader(`{"Action":"output","Package":"example.com/bc/a","Output":"# example.com/bc/a\n"}
+{"Action":"output","Package":"example.com/bc/a","Output":"package example.com/bc/a: build constraints exclude all Go files in /tmp/bc/a\n"}
+{"Action":"output","Package":"example.com/bc/a","Output":"FAIL\texample.com/bc/a [setup failed]\n"}
+{"Action":"fail","Package":"example.com/bc/a","Elapsed":0}
+`)
+	td.Filter()
+	if len(sink.results) != 1 || !sink.results[0].Excluded || sink.results[0].Status != "skip" {
+		t.Fatalf("want a single skipped result for the excluded package, got %+v", sink.results)
+	}
+	if !td.OK || sink.summary.BuildFailed {
+		t.Error("want excluded package not counted as a build failure")
+	}
+}
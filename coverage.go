@@ -0,0 +1,337 @@
+package gotestdox
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// FunctionCoverage gives the statement coverage of a function under test,
+// from a cover profile (see [WithCoverProfile]). Function is the name of the
+// function, or, for a method, the name of its receiver type and the method
+// name, separated by a dot, as in "Client.Do". File is the base name of the
+// source file it's declared in, Percent is the percentage of its statements
+// that ran, and Uncovered gives the ranges of lines containing statements
+// that never ran.
+type FunctionCoverage struct {
+	Package   string      `json:"package"`
+	Function  string      `json:"function"`
+	File      string      `json:"file"`
+	Percent   float64     `json:"percent"`
+	Uncovered []LineRange `json:"uncovered,omitempty"`
+}
+
+// LineRange is a range of lines in a source file, from Start to End
+// inclusive.
+type LineRange struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// String formats r as a single line number, such as "12", or a range, such
+// as "12-14".
+func (r LineRange) String() string {
+	if r.Start == r.End {
+		return strconv.Itoa(r.Start)
+	}
+	return fmt.Sprintf("%d-%d", r.Start, r.End)
+}
+
+// subjectCandidates returns the possible names of the function tested by the
+// test with the given name and sentence, most specific first. For
+// TestClient_Do_RetriesOnTimeout, these are the method "Client.Do" and the
+// function "Client"; otherwise, for a test without a multiword marker, such
+// as TestParseReturnsError, the candidate is the first word of the
+// sentence, "Parse".
+func subjectCandidates(test, sentence string) []string {
+	parent, _ := SplitSubtests(test)
+	name := strings.TrimPrefix(parent, "Test")
+	if parts := strings.Split(name, "_"); len(parts) > 1 && parts[0] != "" {
+		candidates := []string{}
+		if parts[1] != "" {
+			candidates = append(candidates, parts[0]+"."+parts[1])
+		}
+		return append(candidates, parts[0])
+	}
+	first, _, _ := strings.Cut(sentence, " ")
+	if first == "" {
+		return nil
+	}
+	return []string{first}
+}
+
+// coverageIndex maps the blocks of a cover profile to the functions declared
+// in each package, finding and parsing the source of each package at most
+// once.
+type coverageIndex struct {
+	profile  CoverProfile
+	packages *packageResolver
+	funcs    map[string]map[string]FunctionCoverage
+}
+
+func newCoverageIndex(profile CoverProfile, packages *packageResolver) *coverageIndex {
+	return &coverageIndex{
+		profile:  profile,
+		packages: packages,
+		funcs:    map[string]map[string]FunctionCoverage{},
+	}
+}
+
+// functionCoverage returns the coverage of the functions under test by the
+// given results, sorted by package and then by function. Tests whose
+// function can't be found in the package source, or in the profile, are
+// left out.
+func (c *coverageIndex) functionCoverage(results []Result) []FunctionCoverage {
+	seen := map[string]bool{}
+	coverage := []FunctionCoverage{}
+	for _, r := range results {
+		if r.Test == "" || Classify(r.Test) != Test {
+			continue
+		}
+		funcs := c.lookup(r.Package)
+		for _, name := range subjectCandidates(r.Test, r.Sentence) {
+			fc, ok := funcs[name]
+			if !ok {
+				continue
+			}
+			if key := r.Package + " " + name; !seen[key] {
+				seen[key] = true
+				coverage = append(coverage, fc)
+			}
+			break
+		}
+	}
+	sort.Slice(coverage, func(i, j int) bool {
+		if coverage[i].Package != coverage[j].Package {
+			return coverage[i].Package < coverage[j].Package
+		}
+		return coverage[i].Function < coverage[j].Function
+	})
+	return coverage
+}
+
+// lookup returns the coverage of each function in the package pkg, keyed by
+// name, for the source files in the profile that can be found in the
+// package directory, and that match the profile.
+func (c *coverageIndex) lookup(pkg string) map[string]FunctionCoverage {
+	if funcs, ok := c.funcs[pkg]; ok {
+		return funcs
+	}
+	funcs := map[string]FunctionCoverage{}
+	c.funcs[pkg] = funcs
+	dir := c.packages.resolve(pkg, "").dir
+	if dir == "" {
+		return funcs
+	}
+	for file, blocks := range c.profile.Files {
+		var src string
+		switch {
+		case filepath.IsAbs(file) && filepath.Dir(file) == dir:
+			src = file
+		case path.Dir(file) == pkg:
+			src = filepath.Join(dir, path.Base(file))
+		default:
+			continue
+		}
+		for name, fc := range fileCoverage(src, blocks) {
+			fc.Package = pkg
+			funcs[name] = fc
+		}
+	}
+	return funcs
+}
+
+// fileCoverage returns the coverage of each function declared in the Go
+// source file at path, keyed by name, according to blocks. If the file
+// can't be read or parsed, or any block lies outside its text, as when the
+// profile was made from a different version of the file, it returns nil.
+func fileCoverage(path string, blocks []CoverBlock) map[string]FunctionCoverage {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	lines := strings.Split(string(data), "\n")
+	for _, b := range blocks {
+		// columns are byte offsets, as for go/token
+		if b.EndLine > len(lines) || b.StartCol > len(lines[b.StartLine-1])+1 ||
+			b.EndCol > len(lines[b.EndLine-1])+1 {
+			return nil
+		}
+	}
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, path, data, parser.SkipObjectResolution)
+	if err != nil {
+		return nil
+	}
+	funcs := map[string]FunctionCoverage{}
+	for _, decl := range f.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Body == nil {
+			continue
+		}
+		start, end := fset.Position(fn.Pos()), fset.Position(fn.End())
+		total, covered, found := 0, 0, false
+		uncovered := []LineRange{}
+		for _, b := range blocks {
+			if !notBefore(b.StartLine, b.StartCol, start) || !notAfter(b.EndLine, b.EndCol, end) {
+				continue
+			}
+			found = true
+			total += b.Statements
+			if b.Count > 0 {
+				covered += b.Statements
+				continue
+			}
+			if b.Statements > 0 {
+				uncovered = addLineRange(uncovered, LineRange{b.StartLine, b.EndLine})
+			}
+		}
+		if !found {
+			continue
+		}
+		fc := FunctionCoverage{
+			Function: funcName(fn),
+			File:     filepath.Base(path),
+			Percent:  100,
+		}
+		if total > 0 {
+			fc.Percent = 100 * float64(covered) / float64(total)
+		}
+		if len(uncovered) > 0 {
+			fc.Uncovered = uncovered
+		}
+		funcs[fc.Function] = fc
+	}
+	return funcs
+}
+
+// notBefore reports whether the position given by line and col is at or
+// after pos.
+func notBefore(line, col int, pos token.Position) bool {
+	return line > pos.Line || line == pos.Line && col >= pos.Column
+}
+
+// notAfter reports whether the position given by line and col is at or
+// before pos.
+func notAfter(line, col int, pos token.Position) bool {
+	return line < pos.Line || line == pos.Line && col <= pos.Column
+}
+
+// addLineRange adds r to the sorted ranges, merging it with the last one if
+// they overlap or are adjacent.
+func addLineRange(ranges []LineRange, r LineRange) []LineRange {
+	if n := len(ranges); n > 0 && r.Start <= ranges[n-1].End+1 {
+		if r.End > ranges[n-1].End {
+			ranges[n-1].End = r.End
+		}
+		return ranges
+	}
+	return append(ranges, r)
+}
+
+// funcName returns the name of fn, prefixed by the name of its receiver type
+// and a dot if it's a method, as in "Client.Do".
+func funcName(fn *ast.FuncDecl) string {
+	if fn.Recv == nil || len(fn.Recv.List) == 0 {
+		return fn.Name.Name
+	}
+	expr := fn.Recv.List[0].Type
+	for {
+		switch t := expr.(type) {
+		case *ast.StarExpr:
+			expr = t.X
+			continue
+		case *ast.IndexExpr:
+			expr = t.X
+			continue
+		case *ast.IndexListExpr:
+			expr = t.X
+			continue
+		case *ast.Ident:
+			return t.Name + "." + fn.Name.Name
+		}
+		return fn.Name.Name
+	}
+}
+
+// readCoverProfile returns the function coverage for results from the cover
+// profile at path. If there is no profile, it returns nil.
+func readCoverProfile(path string, results []Result, packages *packageResolver) ([]FunctionCoverage, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+	profile, err := ParseCoverProfile(f)
+	if err != nil {
+		return nil, err
+	}
+	return newCoverageIndex(profile, packages).functionCoverage(results), nil
+}
+
+// coverProfilePath returns the path of the cover profile given by
+// [WithCoverProfile], or, failing that, by a -coverprofile flag among the
+// arguments goTestArgs to 'go test', if any. A relative path given to 'go
+// test' is taken to be relative to the directory given by its -C flag, if
+// any.
+func (c config) coverProfilePath(goTestArgs []string) string {
+	if c.coverProfile != "" {
+		return c.coverProfile
+	}
+	dirFlag, rest := splitDirFlag(goTestArgs)
+	flags, _ := splitGoTestArgs(rest)
+	profile := ""
+	for i := 0; i < len(flags); i++ {
+		name, value, hasValue := strings.Cut(strings.TrimLeft(flags[i], "-"), "=")
+		if name != "coverprofile" {
+			continue
+		}
+		if !hasValue && i+1 < len(flags) {
+			i++
+			value = flags[i]
+		}
+		profile = value
+	}
+	if profile == "" || filepath.IsAbs(profile) || len(dirFlag) == 0 {
+		return profile
+	}
+	dir := strings.TrimPrefix(dirFlag[0], "-C=")
+	if dirFlag[0] == "-C" && len(dirFlag) > 1 {
+		dir = dirFlag[1]
+	}
+	return filepath.Join(dir, profile)
+}
+
+// renderCoverage prints the coverage of each function in coverage to w, with
+// a numbered footnote listing the uncovered lines of any function that
+// wasn't fully covered.
+func renderCoverage(w io.Writer, coverage []FunctionCoverage) {
+	fmt.Fprintln(w, "Coverage of functions under test:")
+	notes := []string{}
+	for _, fc := range coverage {
+		if len(fc.Uncovered) == 0 {
+			fmt.Fprintf(w, " %s: %s %.1f%%\n", fc.Package, fc.Function, fc.Percent)
+			continue
+		}
+		ranges := make([]string, len(fc.Uncovered))
+		for i, r := range fc.Uncovered {
+			ranges[i] = r.String()
+		}
+		notes = append(notes, fmt.Sprintf("%s: lines %s not covered", fc.File, strings.Join(ranges, ", ")))
+		fmt.Fprintf(w, " %s: %s %.1f%% [%d]\n", fc.Package, fc.Function, fc.Percent, len(notes))
+	}
+	for i, note := range notes {
+		fmt.Fprintf(w, " [%d] %s\n", i+1, note)
+	}
+}
@@ -0,0 +1,178 @@
+package gotestdox_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bitfield/gotestdox"
+	"github.com/google/go-cmp/cmp"
+)
+
+const calcSource = `package calc
+
+// Add returns the sum of a and b.
+func Add(a, b int) int {
+	return a + b
+}
+
+// Div returns a divided by b, or zero if b is zero.
+func Div(a, b int) int {
+	if b == 0 {
+		return 0
+	}
+	return a / b
+}
+
+type Acc struct{ n int }
+
+func (a *Acc) Inc() {
+	a.n++
+}
+`
+
+const calcProfile = `mode: set
+example.com/calc/calc.go:5.2,6.1 1 1
+example.com/calc/calc.go:10.2,10.12 1 1
+example.com/calc/calc.go:11.3,12.1 1 0
+example.com/calc/calc.go:13.2,13.14 1 1
+example.com/calc/calc.go:19.2,20.1 1 1
+`
+
+const calcInput = `{"Action":"pass","Package":"example.com/calc","Test":"TestAdd_SumsNumbers"}
+{"Action":"pass","Package":"example.com/calc","Test":"TestDivDividesNumbers"}
+{"Action":"pass","Package":"example.com/calc","Test":"TestAcc_Inc_IncrementsCount"}
+{"Action":"pass","Package":"example.com/calc","Test":"TestMissing_DoesNothing"}
+{"Action":"pass","Package":"example.com/calc"}`
+
+// calcPackage writes the calc package source and the given cover profile to
+// a temporary directory, returning the options to find them.
+func calcPackage(t *testing.T, source, profile string) []gotestdox.Option {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "calc.go"), []byte(source), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "cover.out")
+	if err := os.WriteFile(path, []byte(profile), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return []gotestdox.Option{
+		gotestdox.WithPackageDirs(map[string]string{"example.com/calc": dir}),
+		gotestdox.WithCoverProfile(path),
+	}
+}
+
+func TestFilter_WithCoverProfileReportsCoverageOfFunctionsUnderTest(t *testing.T) {
+	t.Parallel()
+	sink := &recordingSink{}
+	opts := append(calcPackage(t, calcSource, calcProfile), gotestdox.WithSink(sink))
+	err := gotestdox.FilterContext(context.Background(), strings.NewReader(calcInput), io.Discard, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []gotestdox.FunctionCoverage{
+		{Package: "example.com/calc", Function: "Acc.Inc", File: "calc.go", Percent: 100},
+		{Package: "example.com/calc", Function: "Add", File: "calc.go", Percent: 100},
+		{
+			Package:   "example.com/calc",
+			Function:  "Div",
+			File:      "calc.go",
+			Percent:   200.0 / 3,
+			Uncovered: []gotestdox.LineRange{{Start: 11, End: 12}},
+		},
+	}
+	if !cmp.Equal(want, sink.summary.Coverage) {
+		t.Error(cmp.Diff(want, sink.summary.Coverage))
+	}
+}
+
+func TestFilter_WithCoverProfilePrintsCoverageWithFootnotes(t *testing.T) {
+	t.Parallel()
+	buf := new(bytes.Buffer)
+	err := gotestdox.FilterContext(context.Background(), strings.NewReader(calcInput), buf, calcPackage(t, calcSource, calcProfile)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `Coverage of functions under test:
+ example.com/calc: Acc.Inc 100.0%
+ example.com/calc: Add 100.0%
+ example.com/calc: Div 66.7% [1]
+ [1] calc.go: lines 11-12 not covered
+`
+	if !strings.HasSuffix(buf.String(), want) {
+		t.Errorf("want output ending with:\n%s\ngot:\n%s", want, buf)
+	}
+}
+
+func TestFilter_WithCoverProfileCountsColumnsInBytes(t *testing.T) {
+	t.Parallel()
+	source := strings.Replace(calcSource, "return a + b\n", "return a + b // ≈ “sum”\n", 1)
+	profile := strings.Replace(calcProfile, "calc.go:5.2,6.1", "calc.go:5.2,5.31", 1)
+	sink := &recordingSink{}
+	opts := append(calcPackage(t, source, profile), gotestdox.WithSink(sink))
+	err := gotestdox.FilterContext(context.Background(), strings.NewReader(calcInput), io.Discard, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sink.summary.Coverage) != 3 {
+		t.Errorf("want coverage of 3 functions, got %#v", sink.summary.Coverage)
+	}
+}
+
+func TestFilter_WithCoverProfileLeavesOutFilesThatDoNotMatchProfile(t *testing.T) {
+	t.Parallel()
+	stale := strings.SplitAfter(calcSource, "}\n")[0] + "\n"
+	sink := &recordingSink{}
+	opts := append(calcPackage(t, stale, calcProfile), gotestdox.WithSink(sink))
+	err := gotestdox.FilterContext(context.Background(), strings.NewReader(calcInput), io.Discard, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sink.summary.Coverage) > 0 {
+		t.Errorf("want no coverage for stale file, got %#v", sink.summary.Coverage)
+	}
+}
+
+func TestFilter_WithCoverProfileReportsNothingIfProfileIsMissing(t *testing.T) {
+	t.Parallel()
+	sink := &recordingSink{}
+	td := gotestdox.NewTestDoxer(
+		gotestdox.WithSink(sink),
+		gotestdox.WithCoverProfile(filepath.Join(t.TempDir(), "bogus.out")),
+	)
+	td.Stdin = strings.NewReader(calcInput)
+	stderr := new(bytes.Buffer)
+	td.Stderr = stderr
+	td.Filter()
+	if !td.OK {
+		t.Error("want OK, got not OK")
+	}
+	if len(sink.summary.Coverage) > 0 || stderr.Len() > 0 {
+		t.Errorf("want no coverage and no errors, got %#v and %q", sink.summary.Coverage, stderr)
+	}
+}
+
+func TestFilter_WithInvalidCoverProfileWarnsAndReportsNoCoverage(t *testing.T) {
+	t.Parallel()
+	sink := &recordingSink{}
+	opts := append(calcPackage(t, calcSource, "bogus\n"), gotestdox.WithSink(sink))
+	td := gotestdox.NewTestDoxer(opts...)
+	td.Stdin = strings.NewReader(calcInput)
+	stderr := new(bytes.Buffer)
+	td.Stderr = stderr
+	td.Filter()
+	if !td.OK {
+		t.Error("want OK, got not OK")
+	}
+	if len(sink.summary.Coverage) > 0 {
+		t.Errorf("want no coverage, got %#v", sink.summary.Coverage)
+	}
+	if !strings.Contains(stderr.String(), "ignoring cover profile") {
+		t.Errorf("want warning, got %q", stderr)
+	}
+}
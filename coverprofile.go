@@ -0,0 +1,156 @@
+package gotestdox
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// CoverProfile is the content of a coverage profile, as written by 'go test
+// -coverprofile'. Mode is the coverage mode, such as "set", and Files maps
+// the name of each source file, usually given as its import path, such as
+// "example.com/pkg/parse.go", to the blocks of statements in it, sorted by
+// position.
+type CoverProfile struct {
+	Mode  string
+	Files map[string][]CoverBlock
+}
+
+// CoverBlock is a block of statements from a [CoverProfile], running from
+// StartLine and StartCol up to EndLine and EndCol. Statements is the number
+// of statements in the block, and Count is the number of times it was run,
+// or, in "set" mode, 1 if it ran at all.
+type CoverBlock struct {
+	StartLine, StartCol int
+	EndLine, EndCol     int
+	Statements          int
+	Count               int
+}
+
+// ParseCoverProfile reads a coverage profile from r. It begins with a line
+// such as "mode: set", followed by one line per block, such as:
+//
+//	example.com/pkg/parse.go:12.34,15.2 3 1
+//
+// giving the file name, the start and end of the block as line.column, the
+// number of statements, and the count. If the same block appears more than
+// once, as when several packages are tested with -coverpkg, the counts are
+// added together. Blank lines are ignored, as are any further mode lines, as
+// written when the profiles of several runs are concatenated.
+func ParseCoverProfile(r io.Reader) (CoverProfile, error) {
+	profile := CoverProfile{Files: map[string][]CoverBlock{}}
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "mode: ") {
+			if profile.Mode == "" {
+				profile.Mode = strings.TrimPrefix(line, "mode: ")
+			}
+			continue
+		}
+		if profile.Mode == "" {
+			return CoverProfile{}, fmt.Errorf("cover profile line %d: missing mode line", n)
+		}
+		file, b, err := parseCoverLine(line)
+		if err != nil {
+			return CoverProfile{}, fmt.Errorf("cover profile line %d: %w", n, err)
+		}
+		profile.Files[file] = append(profile.Files[file], b)
+	}
+	if err := scanner.Err(); err != nil {
+		return CoverProfile{}, err
+	}
+	if profile.Mode == "" {
+		return CoverProfile{}, fmt.Errorf("cover profile: missing mode line")
+	}
+	for file, blocks := range profile.Files {
+		profile.Files[file] = mergeCoverBlocks(blocks)
+	}
+	return profile, nil
+}
+
+// parseCoverLine parses a single block line of a cover profile, returning
+// the file name and the block.
+func parseCoverLine(line string) (string, CoverBlock, error) {
+	// The file name may itself contain colons, as on Windows, so the
+	// position is found from the end.
+	colon := strings.LastIndex(line, ":")
+	if colon < 1 {
+		return "", CoverBlock{}, fmt.Errorf("missing file name in %q", line)
+	}
+	file, rest := line[:colon], line[colon+1:]
+	fields := strings.Fields(rest)
+	if len(fields) != 3 {
+		return "", CoverBlock{}, fmt.Errorf("want position, statements, and count in %q", line)
+	}
+	start, end, ok := strings.Cut(fields[0], ",")
+	if !ok {
+		return "", CoverBlock{}, fmt.Errorf("invalid position %q", fields[0])
+	}
+	var b CoverBlock
+	var err error
+	if b.StartLine, b.StartCol, err = parseLineCol(start); err != nil {
+		return "", CoverBlock{}, err
+	}
+	if b.EndLine, b.EndCol, err = parseLineCol(end); err != nil {
+		return "", CoverBlock{}, err
+	}
+	if b.EndLine < b.StartLine || b.EndLine == b.StartLine && b.EndCol < b.StartCol {
+		return "", CoverBlock{}, fmt.Errorf("block ends before it starts in %q", line)
+	}
+	if b.Statements, err = strconv.Atoi(fields[1]); err != nil || b.Statements < 0 {
+		return "", CoverBlock{}, fmt.Errorf("invalid number of statements %q", fields[1])
+	}
+	if b.Count, err = strconv.Atoi(fields[2]); err != nil || b.Count < 0 {
+		return "", CoverBlock{}, fmt.Errorf("invalid count %q", fields[2])
+	}
+	return file, b, nil
+}
+
+// parseLineCol parses a position such as "12.34" into its line and column.
+func parseLineCol(s string) (int, int, error) {
+	l, c, ok := strings.Cut(s, ".")
+	line, err1 := strconv.Atoi(l)
+	col, err2 := strconv.Atoi(c)
+	if !ok || err1 != nil || err2 != nil || line < 1 || col < 1 {
+		return 0, 0, fmt.Errorf("invalid position %q", s)
+	}
+	return line, col, nil
+}
+
+// mergeCoverBlocks sorts blocks by position, adding together the counts of
+// any that appear more than once.
+func mergeCoverBlocks(blocks []CoverBlock) []CoverBlock {
+	sort.SliceStable(blocks, func(i, j int) bool {
+		a, b := blocks[i], blocks[j]
+		if a.StartLine != b.StartLine {
+			return a.StartLine < b.StartLine
+		}
+		if a.StartCol != b.StartCol {
+			return a.StartCol < b.StartCol
+		}
+		if a.EndLine != b.EndLine {
+			return a.EndLine < b.EndLine
+		}
+		return a.EndCol < b.EndCol
+	})
+	merged := blocks[:0]
+	for _, b := range blocks {
+		if n := len(merged); n > 0 {
+			last := &merged[n-1]
+			if last.StartLine == b.StartLine && last.StartCol == b.StartCol &&
+				last.EndLine == b.EndLine && last.EndCol == b.EndCol {
+				last.Count += b.Count
+				continue
+			}
+		}
+		merged = append(merged, b)
+	}
+	return merged
+}
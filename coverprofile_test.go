@@ -0,0 +1,102 @@
+package gotestdox_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bitfield/gotestdox"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseCoverProfile_ReadsBlocksByFileInPositionOrder(t *testing.T) {
+	t.Parallel()
+	input := `mode: set
+example.com/calc/calc.go:10.2,10.12 1 1
+example.com/calc/calc.go:5.2,6.1 1 1
+
+example.com/calc/calc.go:11.3,12.1 1 0
+example.com/calc/util.go:3.14,5.2 2 0
+`
+	got, err := gotestdox.ParseCoverProfile(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := gotestdox.CoverProfile{
+		Mode: "set",
+		Files: map[string][]gotestdox.CoverBlock{
+			"example.com/calc/calc.go": {
+				{StartLine: 5, StartCol: 2, EndLine: 6, EndCol: 1, Statements: 1, Count: 1},
+				{StartLine: 10, StartCol: 2, EndLine: 10, EndCol: 12, Statements: 1, Count: 1},
+				{StartLine: 11, StartCol: 3, EndLine: 12, EndCol: 1, Statements: 1, Count: 0},
+			},
+			"example.com/calc/util.go": {
+				{StartLine: 3, StartCol: 14, EndLine: 5, EndCol: 2, Statements: 2, Count: 0},
+			},
+		},
+	}
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestParseCoverProfile_AddsCountsOfRepeatedBlocks(t *testing.T) {
+	t.Parallel()
+	input := `mode: count
+example.com/calc/calc.go:5.2,6.1 1 2
+mode: count
+example.com/calc/calc.go:5.2,6.1 1 3
+`
+	got, err := gotestdox.ParseCoverProfile(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []gotestdox.CoverBlock{
+		{StartLine: 5, StartCol: 2, EndLine: 6, EndCol: 1, Statements: 1, Count: 5},
+	}
+	if !cmp.Equal(want, got.Files["example.com/calc/calc.go"]) {
+		t.Error(cmp.Diff(want, got.Files["example.com/calc/calc.go"]))
+	}
+}
+
+func TestParseCoverProfile_AcceptsFileNamesContainingColons(t *testing.T) {
+	t.Parallel()
+	input := "mode: atomic\nC:/src/calc/calc.go:5.2,6.1 1 1\n"
+	got, err := gotestdox.ParseCoverProfile(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := got.Files["C:/src/calc/calc.go"]; !ok {
+		t.Errorf("want blocks for C:/src/calc/calc.go, got %v", got.Files)
+	}
+}
+
+func TestParseCoverProfile_ReturnsErrorForInvalidInput(t *testing.T) {
+	t.Parallel()
+	tcs := map[string]string{
+		"empty":             "",
+		"no mode line":      "example.com/calc/calc.go:5.2,6.1 1 1\n",
+		"no file name":      "mode: set\n5.2,6.1 1 1\n",
+		"missing count":     "mode: set\nexample.com/calc/calc.go:5.2,6.1 1\n",
+		"no end position":   "mode: set\nexample.com/calc/calc.go:5.2 1 1\n",
+		"bad line number":   "mode: set\nexample.com/calc/calc.go:x.2,6.1 1 1\n",
+		"zero column":       "mode: set\nexample.com/calc/calc.go:5.0,6.1 1 1\n",
+		"ends before start": "mode: set\nexample.com/calc/calc.go:6.2,5.1 1 1\n",
+		"negative count":    "mode: set\nexample.com/calc/calc.go:5.2,6.1 1 -1\n",
+		"bad statements":    "mode: set\nexample.com/calc/calc.go:5.2,6.1 x 1\n",
+	}
+	for name, input := range tcs {
+		_, err := gotestdox.ParseCoverProfile(strings.NewReader(input))
+		if err == nil {
+			t.Errorf("%s: want error, got nil", name)
+		}
+	}
+}
+
+func TestParseCoverProfile_ReportsLineNumberOfError(t *testing.T) {
+	t.Parallel()
+	input := "mode: set\nexample.com/calc/calc.go:5.2,6.1 1 1\nbogus\n"
+	_, err := gotestdox.ParseCoverProfile(strings.NewReader(input))
+	if err == nil || !strings.Contains(err.Error(), "line 3") {
+		t.Errorf("want error mentioning line 3, got %v", err)
+	}
+}
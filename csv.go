@@ -0,0 +1,87 @@
+package gotestdox
+
+import (
+	"encoding/csv"
+	"io"
+	"sort"
+	"strconv"
+)
+
+// CSVSink is a [ResultSink] that writes results as CSV, for loading into a
+// spreadsheet. The output begins with a header row, followed by one row per
+// test giving its package, name, sentence, status, elapsed time in seconds,
+// and whether it was flaky. Package results are not included.
+//
+// Rows are written at the end of the run, sorted by package and then by test
+// name, so that the output for the same results is always the same. If
+// [WithSubtestRollup] is supplied, subtests don't get rows of their own, and
+// an extra column gives the number of subtests each test had.
+type CSVSink struct {
+	w       io.Writer
+	results []Result
+	config
+}
+
+// NewCSVSink returns a [*CSVSink] that writes to w, configured by opts.
+func NewCSVSink(w io.Writer, opts ...Option) *CSVSink {
+	return &CSVSink{
+		w:      w,
+		config: newConfig(opts),
+	}
+}
+
+// Result buffers the result of a test, ignoring package results.
+func (s *CSVSink) Result(r Result) error {
+	if r.Test == "" {
+		return nil
+	}
+	s.results = append(s.results, r)
+	return nil
+}
+
+// Summary writes all the buffered results as CSV.
+func (s *CSVSink) Summary(Summary) error {
+	results := s.results
+	header := []string{"package", "test", "sentence", "status", "elapsed", "flaky"}
+	subtests := map[string]int{}
+	if s.rollUpSubtests {
+		header = append(header, "subtests")
+		results = []Result{}
+		for _, r := range s.results {
+			parent, parts := SplitSubtests(r.Test)
+			if len(parts) > 0 {
+				subtests[r.Package+" "+parent]++
+				continue
+			}
+			results = append(results, r)
+		}
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Package != results[j].Package {
+			return results[i].Package < results[j].Package
+		}
+		return results[i].Test < results[j].Test
+	})
+	cw := csv.NewWriter(s.w)
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for _, r := range results {
+		row := []string{
+			r.Package,
+			r.Test,
+			r.Sentence,
+			r.Status,
+			strconv.FormatFloat(r.Elapsed, 'f', 2, 64),
+			strconv.FormatBool(r.Flaky),
+		}
+		if s.rollUpSubtests {
+			row = append(row, strconv.Itoa(subtests[r.Package+" "+r.Test]))
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
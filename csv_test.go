@@ -0,0 +1,52 @@
+package gotestdox_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bitfield/gotestdox"
+	"github.com/google/go-cmp/cmp"
+)
+
+var csvInput = `{"Action":"pass","Package":"q","Test":"TestD","Elapsed":0.25}
+{"Action":"pass","Package":"q","Elapsed":0.3}
+{"Action":"pass","Package":"p","Test":"TestB/handles_\"quotes\",_commas"}
+{"Action":"fail","Package":"p","Test":"TestB/fails_sometimes","Elapsed":0.1}
+{"Action":"fail","Package":"p","Test":"TestB","Elapsed":0.1}
+{"Action":"skip","Package":"p","Test":"TestA"}
+{"Action":"fail","Package":"p","Elapsed":0.2}`
+
+func TestCSVSink_WritesHeaderAndOneRowPerTestInOrder(t *testing.T) {
+	t.Parallel()
+	buf := new(strings.Builder)
+	td := gotestdox.NewTestDoxer(gotestdox.WithSink(gotestdox.NewCSVSink(buf)))
+	td.Stdin = strings.NewReader(csvInput)
+	td.Filter()
+	want := `package,test,sentence,status,elapsed,flaky
+p,TestA,A,skip,0.00,false
+p,TestB,B,fail,0.10,false
+p,TestB/fails_sometimes,B fails sometimes,fail,0.10,false
+p,"TestB/handles_""quotes"",_commas","B handles ""quotes"", commas",pass,0.00,false
+q,TestD,D,pass,0.25,false
+`
+	if want != buf.String() {
+		t.Error(cmp.Diff(want, buf.String()))
+	}
+}
+
+func TestCSVSink_WithSubtestRollupCountsSubtestsOnParentRow(t *testing.T) {
+	t.Parallel()
+	buf := new(strings.Builder)
+	sink := gotestdox.NewCSVSink(buf, gotestdox.WithSubtestRollup())
+	td := gotestdox.NewTestDoxer(gotestdox.WithSink(sink))
+	td.Stdin = strings.NewReader(csvInput)
+	td.Filter()
+	want := `package,test,sentence,status,elapsed,flaky,subtests
+p,TestA,A,skip,0.00,false,0
+p,TestB,B,fail,0.10,false,2
+q,TestD,D,pass,0.25,false,0
+`
+	if want != buf.String() {
+		t.Error(cmp.Diff(want, buf.String()))
+	}
+}
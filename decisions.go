@@ -0,0 +1,55 @@
+package gotestdox
+
+// CaseDecision identifies the rule that [Prettify] applied in deciding the
+// capitalisation of a word, as reported to the callback supplied using
+// [WithDecisionCallback].
+type CaseDecision int
+
+const (
+	// FirstWord is the decision for the first word of the sentence, unless
+	// it's an initialism, which is given an initial capital.
+	FirstWord CaseDecision = iota
+	// ShortWord is the decision for a single-letter word, such as "A",
+	// which is lowercased.
+	ShortWord
+	// Initialism is the decision for a word that looks like an initialism,
+	// such as "JSON" or "IDs", which is left as it is, even if it's the
+	// first word.
+	Initialism
+	// Lowercased is the decision for any other word, which is lowercased.
+	Lowercased
+	// DictionaryMatch is the decision for each part of a word split into
+	// known initialisms from the dictionary given by [WithInitialisms],
+	// which is left as it is.
+	DictionaryMatch
+	// Preserved is the decision for every word when [WithPreservedCase] is
+	// in effect.
+	Preserved
+	// Normalised is the decision for a word matching one of the short words
+	// given by [WithShortWords], ignoring case, when
+	// [WithShortWordNormalisation] is in effect, which is given the
+	// capitalisation of the short word.
+	Normalised
+	// Segmented is the decision for each part of a word split by the
+	// segmenter given by [WithSegmenter], which is left as it is.
+	Segmented
+)
+
+var caseDecisionNames = map[CaseDecision]string{
+	FirstWord:       "FirstWord",
+	ShortWord:       "ShortWord",
+	Initialism:      "Initialism",
+	Lowercased:      "Lowercased",
+	DictionaryMatch: "DictionaryMatch",
+	Preserved:       "Preserved",
+	Normalised:      "Normalised",
+	Segmented:       "Segmented",
+}
+
+// String returns the name of the decision, such as "Initialism".
+func (d CaseDecision) String() string {
+	if s, ok := caseDecisionNames[d]; ok {
+		return s
+	}
+	return "CaseDecision(?)"
+}
@@ -0,0 +1,67 @@
+package gotestdox
+
+import (
+	"go/doc"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// packageDocs finds and caches the first sentence of the doc comment of each
+// package, for [WithPackageDocs]. The source of each package is parsed at
+// most once.
+type packageDocs struct {
+	packages *packageResolver
+	docs     map[string]string
+}
+
+func newPackageDocs(packages *packageResolver) *packageDocs {
+	return &packageDocs{
+		packages: packages,
+		docs:     map[string]string{},
+	}
+}
+
+// synopsis returns the first sentence of the doc comment of the package pkg,
+// tested in the given module directory, or the empty string if it has none,
+// or its source can't be found.
+func (d *packageDocs) synopsis(pkg, module string) string {
+	key := module + " " + pkg
+	if s, ok := d.docs[key]; ok {
+		return s
+	}
+	s := d.read(pkg, module)
+	d.docs[key] = s
+	return s
+}
+
+// read parses the doc comments of the non-test Go files of the package, and
+// returns the first sentence of the first one found, taking the files in
+// alphabetical order, which puts a conventional doc.go ahead of most others.
+func (d *packageDocs) read(pkg, module string) string {
+	dir := d.packages.resolve(pkg, module).dir
+	if dir == "" {
+		return ""
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return ""
+	}
+	sort.Strings(files)
+	fset := token.NewFileSet()
+	for _, path := range files {
+		if strings.HasSuffix(path, "_test.go") {
+			continue
+		}
+		f, err := parser.ParseFile(fset, path, nil, parser.PackageClauseOnly|parser.ParseComments)
+		if err != nil || f.Doc == nil {
+			continue
+		}
+		if s := doc.Synopsis(f.Doc.Text()); s != "" {
+			return s
+		}
+	}
+	return ""
+}
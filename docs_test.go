@@ -0,0 +1,113 @@
+package gotestdox_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bitfield/gotestdox"
+	"github.com/fatih/color"
+	"github.com/google/go-cmp/cmp"
+)
+
+// docsModule writes a module containing a package with a doc comment, and
+// one without, returning the options to find them.
+func docsModule(t *testing.T) []gotestdox.Option {
+	t.Helper()
+	root := t.TempDir()
+	files := map[string]string{
+		"go.mod":              "module example.com/m\n",
+		"store/doc.go":        "// Package store keeps values by key. It is safe for concurrent use.\npackage store\n",
+		"store/store.go":      "// Package store is documented twice.\npackage store\n",
+		"store/x_test.go":     "// Package store_test is not the package doc.\npackage store_test\n",
+		"plain/plain.go":      "package plain\n",
+		"plain/plain_test.go": "// Package plain has only a test doc.\npackage plain\n",
+	}
+	for name, data := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return []gotestdox.Option{
+		gotestdox.WithPackageDocs(),
+		gotestdox.WithPackageDirs(map[string]string{
+			"example.com/m/store": filepath.Join(root, "store"),
+			"example.com/m/plain": filepath.Join(root, "plain"),
+		}),
+	}
+}
+
+const docsInput = `{"Action":"pass","Package":"example.com/m/store","Test":"TestPut"}
+{"Action":"pass","Package":"example.com/m/store"}
+{"Action":"pass","Package":"example.com/m/plain","Test":"TestPlain"}
+{"Action":"pass","Package":"example.com/m/plain"}
+{"Action":"pass","Package":"example.com/m/gone","Test":"TestGone"}
+{"Action":"pass","Package":"example.com/m/gone"}`
+
+func TestFilter_WithPackageDocsSetsFirstSentenceOfPackageDocOnPackageResults(t *testing.T) {
+	t.Parallel()
+	sink := &recordingSink{}
+	td := gotestdox.NewTestDoxer(append(docsModule(t), gotestdox.WithSink(sink))...)
+	td.Stdin = strings.NewReader(docsInput)
+	td.Filter()
+	got := map[string]string{}
+	for _, r := range sink.results {
+		if r.Test != "" && r.Doc != "" {
+			t.Errorf("want no doc for test result %s, got %q", r.Test, r.Doc)
+		}
+		if r.Test == "" {
+			got[r.Package] = r.Doc
+		}
+	}
+	want := map[string]string{
+		"example.com/m/store": "Package store keeps values by key.",
+		"example.com/m/plain": "",
+		"example.com/m/gone":  "",
+	}
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestTextSink_ShowsPackageDocUnderHeading(t *testing.T) {
+	t.Parallel()
+	color.NoColor = true
+	td := gotestdox.NewTestDoxer(docsModule(t)...)
+	td.Stdin = strings.NewReader(docsInput)
+	buf := &bytes.Buffer{}
+	td.Stdout = buf
+	td.Filter()
+	want := `example.com/m/store:
+ Package store keeps values by key.
+ ✔ Put (0.00s)
+
+example.com/m/plain:
+ ✔ Plain (0.00s)
+
+example.com/m/gone:
+ ✔ Gone (0.00s)
+
+`
+	if want != buf.String() {
+		t.Error(cmp.Diff(want, buf.String()))
+	}
+}
+
+func TestTextSink_ShowsNoPackageDocsByDefault(t *testing.T) {
+	t.Parallel()
+	color.NoColor = true
+	td := gotestdox.NewTestDoxer()
+	td.Stdin = strings.NewReader(docsInput)
+	buf := &bytes.Buffer{}
+	td.Stdout = buf
+	td.Filter()
+	if strings.Contains(buf.String(), "keeps values") {
+		t.Errorf("want no package doc without WithPackageDocs, got:\n%s", buf)
+	}
+}
@@ -0,0 +1,91 @@
+package gotestdox
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// durations records the elapsed time, in seconds, of each test in a run,
+// keyed by package (see [historyPackage]) and then by test name. It is the
+// format of the file used by [WithDurationHistory].
+type durations map[string]map[string]float64
+
+// loadDurations reads the durations stored in the file at path. If there is
+// no such file, it returns an empty set of durations, so that the first run
+// with a new history file works as expected.
+func loadDurations(path string) (durations, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return durations{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading duration history: %w", err)
+	}
+	d := durations{}
+	if err := json.Unmarshal(data, &d); err != nil {
+		return nil, fmt.Errorf("reading duration history %s: %w", path, err)
+	}
+	return d, nil
+}
+
+// record stores the elapsed time of each test in results in d, other than
+// skipped tests.
+func (d durations) record(results []Result) {
+	for _, r := range results {
+		if r.Status == "skip" {
+			continue
+		}
+		pkg := historyPackage(r)
+		if d[pkg] == nil {
+			d[pkg] = map[string]float64{}
+		}
+		d[pkg][r.Test] = r.Elapsed
+	}
+}
+
+// save writes d to the file at path, replacing its previous contents. The
+// data is written to a temporary file first, so that an interrupted save
+// doesn't destroy the history.
+func (d durations) save(path string) error {
+	data, err := json.MarshalIndent(d, "", "\t")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".gotestdox-*")
+	if err != nil {
+		return fmt.Errorf("writing duration history: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("writing duration history: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("writing duration history: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("writing duration history: %w", err)
+	}
+	return nil
+}
+
+// regression returns a note describing how much slower the test r was than
+// in the previous run, as recorded in d, or the empty string if it didn't
+// slow down significantly (see [WithDurationRegression]), or wasn't recorded.
+func (d durations) regression(r Result, cfg config) string {
+	previous, ok := d[historyPackage(r)][r.Test]
+	if !ok || r.Elapsed < cfg.regressionMinimum.Seconds() {
+		return ""
+	}
+	increase := r.Elapsed - previous
+	if increase <= cfg.regressionAbsolute.Seconds() || increase <= previous*cfg.regressionRelative {
+		return ""
+	}
+	delta := time.Duration(increase * float64(time.Second)).Round(time.Millisecond)
+	return fmt.Sprintf("+%s vs last run", delta)
+}
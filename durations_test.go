@@ -0,0 +1,157 @@
+package gotestdox_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bitfield/gotestdox"
+	"github.com/fatih/color"
+	"github.com/google/go-cmp/cmp"
+)
+
+var durationInput = `{"Action":"pass","Package":"p","Test":"TestSlow","Elapsed":1.9}
+{"Action":"pass","Package":"p","Test":"TestSteady","Elapsed":1.05}
+{"Action":"pass","Package":"p","Test":"TestTiny","Elapsed":0.09}
+{"Action":"pass","Package":"p","Test":"TestNew","Elapsed":2.5}
+{"Action":"pass","Package":"p","Elapsed":5.6}`
+
+func TestFilter_WithDurationHistoryFlagsSignificantSlowdowns(t *testing.T) {
+	t.Parallel()
+	color.NoColor = true
+	path := filepath.Join(t.TempDir(), "durations.json")
+	writeFiles(t, filepath.Dir(path), map[string]string{
+		"durations.json": `{"p": {"TestSlow": 1.05, "TestSteady": 1.0, "TestTiny": 0.01}}`,
+	})
+	want := `p:
+ ✔ New (2.50s)
+ ✔ Slow (1.90s, +850ms vs last run)
+ ✔ Steady (1.05s)
+ ✔ Tiny (0.09s)
+
+`
+	buf := new(strings.Builder)
+	td := gotestdox.NewTestDoxer(gotestdox.WithDurationHistory(path))
+	td.Stdin = strings.NewReader(durationInput)
+	td.Stdout = buf
+	td.Filter()
+	if want != buf.String() {
+		t.Error(cmp.Diff(want, buf.String()))
+	}
+}
+
+func TestFilter_WithDurationHistoryUpdateRecordsDurationsForNextRun(t *testing.T) {
+	t.Parallel()
+	color.NoColor = true
+	path := filepath.Join(t.TempDir(), "durations.json")
+	td := gotestdox.NewTestDoxer(gotestdox.WithDurationHistoryUpdate(path))
+	td.Stdin = strings.NewReader(durationInput)
+	td.Stdout = new(strings.Builder)
+	td.Stderr = td.Stdout
+	td.Filter()
+	if !td.OK {
+		t.Fatal(td.Stdout)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`"TestSlow": 1.9`, `"TestNew": 2.5`} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("want history to contain %q, got:\n%s", want, data)
+		}
+	}
+}
+
+func TestFilter_WithDurationHistoryUpdateRecordsEachConfigurationSeparately(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "durations.json")
+	td := gotestdox.NewTestDoxer(gotestdox.WithDurationHistoryUpdate(path))
+	td.Stdin = strings.NewReader(`{"Configuration":"unit","Action":"pass","Package":"p","Test":"TestA","Elapsed":0.1}
+{"Configuration":"unit","Action":"pass","Package":"p","Elapsed":0.1}
+{"Configuration":"integration","Action":"pass","Package":"p","Test":"TestA","Elapsed":2.5}
+{"Configuration":"integration","Action":"pass","Package":"p","Elapsed":2.5}`)
+	td.Stdout = new(strings.Builder)
+	td.Filter()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`"p (unit)": {`, `"p (integration)": {`, `"TestA": 2.5`, `"TestA": 0.1`} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("want history to contain %q, got:\n%s", want, data)
+		}
+	}
+}
+
+func TestFilter_WithoutDurationHistoryFileMakesNoComparison(t *testing.T) {
+	t.Parallel()
+	color.NoColor = true
+	path := filepath.Join(t.TempDir(), "missing.json")
+	buf := new(strings.Builder)
+	td := gotestdox.NewTestDoxer(gotestdox.WithDurationHistory(path))
+	td.Stdin = strings.NewReader(durationInput)
+	td.Stdout = buf
+	td.Filter()
+	if strings.Contains(buf.String(), "vs last run") {
+		t.Errorf("want no comparison, got:\n%s", buf)
+	}
+	if _, err := os.Stat(path); err == nil {
+		t.Error("want history file not created without update option")
+	}
+}
+
+func TestFilter_WithNoCasesCheckReportsTestsThatLostTheirSubtests(t *testing.T) {
+	t.Parallel()
+	color.NoColor = true
+	path := filepath.Join(t.TempDir(), "durations.json")
+	writeFiles(t, filepath.Dir(path), map[string]string{
+		"durations.json": `{"p": {"TestValidate": 0.1, "TestValidate/rejects_empty_input": 0.1, "TestParse": 0.1, "TestParse/handles_input": 0.1}}`,
+	})
+	input := `{"Action":"pass","Package":"p","Test":"TestValidate"}
+{"Action":"pass","Package":"p","Test":"TestParse/handles_input"}
+{"Action":"pass","Package":"p","Test":"TestParse"}
+{"Action":"pass","Package":"p","Test":"TestFormat"}
+{"Action":"pass","Package":"p"}`
+	want := `p:
+ ✔ Format (0.00s)
+ ✔ Parse handles input (0.00s)
+ ✔ Validate (0.00s)
+
+⚠ Validate ran no cases (p)
+`
+	buf := new(strings.Builder)
+	td := gotestdox.NewTestDoxer(gotestdox.WithDurationHistory(path), gotestdox.WithNoCasesFailing())
+	td.Stdin = strings.NewReader(input)
+	td.Stdout = buf
+	td.Filter()
+	if want != buf.String() {
+		t.Error(cmp.Diff(want, buf.String()))
+	}
+	if td.OK {
+		t.Error("want not ok when failing on tests with no cases")
+	}
+}
+
+func TestFilter_WithNoCasesHeuristicReportsBareTestsWithoutSubtests(t *testing.T) {
+	t.Parallel()
+	input := `{"Action":"pass","Package":"p","Test":"TestValidate"}
+{"Action":"pass","Package":"p","Test":"TestParse/handles_input"}
+{"Action":"pass","Package":"p","Test":"TestParse"}
+{"Action":"pass","Package":"p","Test":"TestFormatHandlesEmptyInput"}
+{"Action":"pass","Package":"p"}`
+	sink := &recordingSink{}
+	td := gotestdox.NewTestDoxer(gotestdox.WithNoCasesHeuristic(), gotestdox.WithSink(sink))
+	td.Stdin = strings.NewReader(input)
+	td.Filter()
+	want := []gotestdox.Result{
+		{Package: "p", Test: "TestValidate", Sentence: "Validate", Status: "pass"},
+	}
+	if !cmp.Equal(want, sink.summary.NoCases) {
+		t.Error(cmp.Diff(want, sink.summary.NoCases))
+	}
+	if !td.OK {
+		t.Error("want ok unless failing on tests with no cases")
+	}
+}
@@ -0,0 +1,86 @@
+package gotestdox
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FromEnv returns the options configured by the GOTESTDOX_* environment
+// variables, as described for [FromEnvLookup]. To let explicit options take
+// precedence over the environment, supply them after these:
+//
+//	td := gotestdox.NewTestDoxer(append(gotestdox.FromEnv(), opts...)...)
+func FromEnv() []Option {
+	return FromEnvLookup(os.LookupEnv)
+}
+
+// FromEnvLookup is like [FromEnv], but looks up each variable using lookup,
+// which has the same signature as [os.LookupEnv]. This makes it possible to
+// test the effect of the environment without changing it.
+//
+// The following variables are recognised, and translated into the options
+// shown:
+//
+//	GOTESTDOX_DEBUG             any value: [WithDebug]
+//	GOTESTDOX_INITIALISMS       comma-separated words: [WithInitialisms]
+//	GOTESTDOX_SUBJECT_SEPARATOR separator: [WithSubjectSeparator]
+//	GOTESTDOX_SENTENCE_SUFFIX   suffix: [WithSentenceSuffix]
+//	GOTESTDOX_TEST_NAMES        "always": [WithTestNames], "failed": [WithTestNamesOnFailure]
+//	GOTESTDOX_NOISY_SYMBOL      symbol: [WithNoisyTests]
+//	GOTESTDOX_FAIL_FAST         boolean: [WithFailFast]
+//	GOTESTDOX_STALL_AFTER       duration: [WithStallReport]
+//	GOTESTDOX_PROGRESS          boolean: [WithProgress]
+//	GOTESTDOX_DURATION_HISTORY  path: [WithDurationHistory]
+//
+// Booleans and durations are in the forms accepted by [strconv.ParseBool]
+// and [time.ParseDuration]. Empty variables, and those with values that
+// can't be parsed, are ignored.
+func FromEnvLookup(lookup func(string) (string, bool)) []Option {
+	opts := []Option{}
+	get := func(name string) string {
+		v, _ := lookup("GOTESTDOX_" + name)
+		return v
+	}
+	if get("DEBUG") != "" {
+		opts = append(opts, WithDebug())
+	}
+	if v := get("INITIALISMS"); v != "" {
+		words := []string{}
+		for _, w := range strings.Split(v, ",") {
+			if w = strings.TrimSpace(w); w != "" {
+				words = append(words, w)
+			}
+		}
+		opts = append(opts, WithInitialisms(words...))
+	}
+	if v := get("SUBJECT_SEPARATOR"); v != "" {
+		opts = append(opts, WithSubjectSeparator(v))
+	}
+	if v := get("SENTENCE_SUFFIX"); v != "" {
+		opts = append(opts, WithSentenceSuffix(v))
+	}
+	switch get("TEST_NAMES") {
+	case "always":
+		opts = append(opts, WithTestNames())
+	case "failed":
+		opts = append(opts, WithTestNamesOnFailure())
+	}
+	if v := get("NOISY_SYMBOL"); v != "" {
+		opts = append(opts, WithNoisyTests(v))
+	}
+	if ok, err := strconv.ParseBool(get("FAIL_FAST")); err == nil && ok {
+		opts = append(opts, WithFailFast())
+	}
+	if d, err := time.ParseDuration(get("STALL_AFTER")); err == nil {
+		opts = append(opts, WithStallReport(d))
+	}
+	if ok, err := strconv.ParseBool(get("PROGRESS")); err == nil && ok {
+		opts = append(opts, WithProgress())
+	}
+	if v := get("DURATION_HISTORY"); v != "" {
+		opts = append(opts, WithDurationHistory(v))
+	}
+	return opts
+}
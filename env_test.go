@@ -0,0 +1,83 @@
+package gotestdox_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bitfield/gotestdox"
+	"github.com/fatih/color"
+	"github.com/google/go-cmp/cmp"
+)
+
+func lookupIn(env map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		v, ok := env[name]
+		return v, ok
+	}
+}
+
+func TestFromEnvLookup_TranslatesVariablesIntoOptions(t *testing.T) {
+	t.Parallel()
+	opts := gotestdox.FromEnvLookup(lookupIn(map[string]string{
+		"GOTESTDOX_INITIALISMS":       "JSON, XML",
+		"GOTESTDOX_SUBJECT_SEPARATOR": ": ",
+	}))
+	want := "ParseJSONXML: handles input"
+	got := gotestdox.Prettify("TestParseJSONXML_HandlesInput", opts...)
+	if want != got {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestFromEnvLookup_IsOverriddenByExplicitOptions(t *testing.T) {
+	t.Parallel()
+	opts := gotestdox.FromEnvLookup(lookupIn(map[string]string{
+		"GOTESTDOX_SUBJECT_SEPARATOR": ": ",
+	}))
+	opts = append(opts, gotestdox.WithSubjectSeparator(" — "))
+	want := "HandleInput — closes input"
+	got := gotestdox.Prettify("TestHandleInput_ClosesInput", opts...)
+	if want != got {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestFromEnvLookup_ConfiguresFilter(t *testing.T) {
+	t.Parallel()
+	color.NoColor = true
+	opts := gotestdox.FromEnvLookup(lookupIn(map[string]string{
+		"GOTESTDOX_SENTENCE_SUFFIX": ".",
+		"GOTESTDOX_TEST_NAMES":      "failed",
+		"GOTESTDOX_FAIL_FAST":       "true",
+		"GOTESTDOX_STALL_AFTER":     "not a duration",
+	}))
+	input := `{"Action":"pass","Package":"p","Test":"TestA"}
+{"Action":"fail","Package":"p","Test":"TestB"}
+{"Action":"pass","Package":"p","Test":"TestC"}
+{"Action":"fail","Package":"p"}`
+	want := `p:
+ ✔ A. (0.00s)
+ x B.  [TestB] (0.00s)
+
+(run aborted after first failure)
+`
+	buf := new(strings.Builder)
+	td := gotestdox.NewTestDoxer(opts...)
+	td.Stdin = strings.NewReader(input)
+	td.Stdout = buf
+	td.Filter()
+	if want != buf.String() {
+		t.Error(cmp.Diff(want, buf.String()))
+	}
+}
+
+func TestFromEnvLookup_ReturnsNoOptionsForEmptyEnvironment(t *testing.T) {
+	t.Parallel()
+	opts := gotestdox.FromEnvLookup(lookupIn(map[string]string{
+		"GOTESTDOX_FAIL_FAST": "",
+		"HOME":                "/home/gopher",
+	}))
+	if len(opts) != 0 {
+		t.Errorf("want no options, got %d", len(opts))
+	}
+}
@@ -0,0 +1,82 @@
+package gotestdox
+
+import (
+	"context"
+	"encoding/json"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Environment describes the toolchain and source revision that a test run
+// used, as recorded by [WithEnvironment]. Race is true if the tests were run
+// with the -race flag, and Tags gives the value of any -tags flag. Commit is
+// the abbreviated hash of the current git commit, or empty if it couldn't be
+// determined.
+type Environment struct {
+	GoVersion string `json:"goVersion"`
+	GOOS      string `json:"goos"`
+	GOARCH    string `json:"goarch"`
+	Race      bool   `json:"race"`
+	Tags      string `json:"tags,omitempty"`
+	Commit    string `json:"commit,omitempty"`
+}
+
+// gitTimeout is the longest that collectEnvironment waits for git, so that a
+// hung credential helper, for example, can't hold up the report.
+const gitTimeout = 2 * time.Second
+
+// collectEnvironment returns the [Environment] for a run of 'go test' with
+// the given arguments. The Go version and platform are those reported by 'go
+// env', or, if that fails, those of the running program.
+func collectEnvironment(goTestArgs []string) Environment {
+	env := Environment{
+		GoVersion: runtime.Version(),
+		GOOS:      runtime.GOOS,
+		GOARCH:    runtime.GOARCH,
+	}
+	if out, err := exec.Command("go", "env", "-json", "GOVERSION", "GOOS", "GOARCH").Output(); err == nil {
+		var goEnv struct {
+			GOVERSION, GOOS, GOARCH string
+		}
+		if json.Unmarshal(out, &goEnv) == nil && goEnv.GOVERSION != "" {
+			env.GoVersion, env.GOOS, env.GOARCH = goEnv.GOVERSION, goEnv.GOOS, goEnv.GOARCH
+		}
+	}
+	flags, _ := splitGoTestArgs(goTestArgs)
+	for i := 0; i < len(flags); i++ {
+		name, value, hasValue := strings.Cut(strings.TrimLeft(flags[i], "-"), "=")
+		switch name {
+		case "race":
+			env.Race = !hasValue || value == "true"
+		case "tags":
+			if !hasValue && i+1 < len(flags) {
+				i++
+				value = flags[i]
+			}
+			env.Tags = value
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), gitTimeout)
+	defer cancel()
+	if out, err := exec.CommandContext(ctx, "git", "rev-parse", "--short", "HEAD").Output(); err == nil {
+		env.Commit = strings.TrimSpace(string(out))
+	}
+	return env
+}
+
+// String formats env as a single line, suitable for a report banner.
+func (env Environment) String() string {
+	parts := []string{env.GoVersion, env.GOOS + "/" + env.GOARCH}
+	if env.Race {
+		parts = append(parts, "race")
+	}
+	if env.Tags != "" {
+		parts = append(parts, "tags "+env.Tags)
+	}
+	if env.Commit != "" {
+		parts = append(parts, "commit "+env.Commit)
+	}
+	return strings.Join(parts, ", ")
+}
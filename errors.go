@@ -0,0 +1,161 @@
+package gotestdox
+
+import (
+	"errors"
+	"fmt"
+	"unicode/utf8"
+)
+
+// The classes of error reported by gotestdox, which can be distinguished
+// using [errors.Is]. [TestDoxer.Err] returns the class describing the
+// outcome of a run, corresponding to its [ExitCode], and [FilterContext],
+// [Aggregate], and [Validate] wrap them where they apply.
+var (
+	// ErrTestsFailed means that at least one test failed, or a package
+	// failed in TestMain or teardown.
+	ErrTestsFailed = errors.New("tests failed")
+	// ErrBuildFailed means that at least one package failed to build, or
+	// 'go test' failed without any test failing.
+	ErrBuildFailed = errors.New("build failed")
+	// ErrVetFailed means that at least one package failed vet.
+	ErrVetFailed = errors.New("vet failed")
+	// ErrTimedOut means that at least one package was killed for taking
+	// too long (see [WithPackageTimeout]).
+	ErrTimedOut = errors.New("timed out")
+	// ErrThresholdsExceeded means that the run exceeded one of its
+	// thresholds (see [WithThresholds]).
+	ErrThresholdsExceeded = errors.New("thresholds exceeded")
+	// ErrInterrupted means that the run was stopped by a signal, or by
+	// cancelling its context.
+	ErrInterrupted = errors.New("interrupted")
+	// ErrBadStream means that the input wasn't valid 'go test -json'
+	// output. The error is a [*StreamError], or, if the input was refused
+	// by [WithValidation], a [*ValidationError].
+	ErrBadStream = errors.New("invalid 'go test -json' stream")
+)
+
+// streamSnippetMax is the longest part of an invalid line of input given in
+// a [StreamError], in bytes.
+const streamSnippetMax = 200
+
+// StreamError describes a line of input that isn't a valid 'go test -json'
+// event. Line is its line number, counting from 1, Snippet is the line, cut
+// short if it's very long, and Err is the error from parsing it. A
+// StreamError matches [ErrBadStream].
+type StreamError struct {
+	Line    int
+	Snippet string
+	Err     error
+}
+
+func newStreamError(line int, input string, err error) *StreamError {
+	if len(input) > streamSnippetMax {
+		cut := streamSnippetMax
+		for cut > 0 && !utf8.RuneStart(input[cut]) {
+			cut--
+		}
+		input = input[:cut] + "…"
+	}
+	return &StreamError{Line: line, Snippet: input, Err: err}
+}
+
+func (e *StreamError) Error() string {
+	return fmt.Sprintf("line %d: parsing JSON: %v\ninput: %s", e.Line, e.Err, e.Snippet)
+}
+
+// Unwrap returns e.Err.
+func (e *StreamError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is [ErrBadStream].
+func (e *StreamError) Is(target error) bool {
+	return target == ErrBadStream
+}
+
+// ValidationError is the error for input refused by [WithValidation],
+// giving the report of the problems found. A ValidationError matches
+// [ErrBadStream].
+type ValidationError struct {
+	Report ValidationReport
+}
+
+func (e *ValidationError) Error() string {
+	return "input is corrupt:\n" + e.Report.String()
+}
+
+// Is reports whether target is [ErrBadStream].
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrBadStream
+}
+
+// RunError describes the outcome of a run that didn't succeed, as returned
+// by [TestDoxer.Err]. Kind is one of the classes of error, such as
+// [ErrTestsFailed], which the RunError matches, and Err is the cause, if
+// known: for example, the [*exec.ExitError] from 'go test', or the error
+// from a cancelled context, which [errors.As] and [errors.Is] also find.
+type RunError struct {
+	Kind error
+	Err  error
+}
+
+func (e *RunError) Error() string {
+	if e.Err == nil {
+		return e.Kind.Error()
+	}
+	return e.Kind.Error() + ": " + e.Err.Error()
+}
+
+// Unwrap returns e.Err.
+func (e *RunError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is e.Kind.
+func (e *RunError) Is(target error) bool {
+	return target == e.Kind
+}
+
+// Err returns the error, if any, describing the outcome of the last run by
+// td, such as [TestDoxer.Filter] or [TestDoxer.ExecGoTest]: nil if all the
+// tests passed, or otherwise an error matching the class of error
+// corresponding to the exit status given by [ExitCode]. If gotestdox itself
+// failed, as when the input wasn't valid, Err returns that error, such as a
+// [*StreamError].
+func (td *TestDoxer) Err() error {
+	var kind error
+	switch code := td.exitCode(); code {
+	case ExitOK:
+		return nil
+	case ExitInternalError:
+		if td.err != nil {
+			return td.err
+		}
+		return errors.New("internal error")
+	case ExitInterrupted, ExitTerminated:
+		kind = ErrInterrupted
+	case ExitBuildFailed:
+		kind = ErrBuildFailed
+	case ExitVetFailed:
+		kind = ErrVetFailed
+	case ExitTimedOut:
+		kind = ErrTimedOut
+	case ExitThresholdsExceeded:
+		kind = ErrThresholdsExceeded
+	default:
+		kind = ErrTestsFailed
+	}
+	return &RunError{Kind: kind, Err: td.exitErr}
+}
+
+// exitCode returns the exit status given by [ExitCode] for the last run by
+// td, taking into account any error in gotestdox itself, such as failing to
+// start 'go test'.
+func (td *TestDoxer) exitCode() int {
+	code := ExitCode(td.Summary, td.err)
+	if code == ExitOK && !td.OK {
+		// for example, a test that ran no cases, with WithNoCasesFailing
+		code = ExitTestsFailed
+	}
+	return code
+}
@@ -0,0 +1,166 @@
+package gotestdox_test
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"os/exec"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/bitfield/gotestdox"
+)
+
+func TestFilterContext_ReturnsStreamErrorForBadJSONMidStream(t *testing.T) {
+	t.Parallel()
+	input := `{"Action":"pass","Package":"p","Test":"TestA"}
+{"Action":"pass","Package":"p","Test":"TestB"}
+{"Action":"pa`
+	err := gotestdox.FilterContext(context.Background(), strings.NewReader(input), io.Discard)
+	if !errors.Is(err, gotestdox.ErrBadStream) {
+		t.Fatalf("want ErrBadStream, got %v", err)
+	}
+	var serr *gotestdox.StreamError
+	if !errors.As(err, &serr) {
+		t.Fatalf("want *StreamError, got %T", err)
+	}
+	if serr.Line != 3 || serr.Snippet != `{"Action":"pa` {
+		t.Errorf("want line 3 with its input, got line %d, %q", serr.Line, serr.Snippet)
+	}
+}
+
+func TestFilterContext_ReturnsErrorReadingInput(t *testing.T) {
+	t.Parallel()
+	input := `{"Action":"pass","Package":"p","Test":"TestA"}
+` + strings.Repeat("x", bufio.MaxScanTokenSize+1)
+	err := gotestdox.FilterContext(context.Background(), strings.NewReader(input), io.Discard)
+	if !errors.Is(err, bufio.ErrTooLong) {
+		t.Fatalf("want bufio.ErrTooLong, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "after line 1") {
+		t.Errorf("want error giving last line read, got %q", err)
+	}
+}
+
+func TestFilterContext_ReturnsErrorFromFailingReader(t *testing.T) {
+	t.Parallel()
+	errBroken := errors.New("broken pipe")
+	in := io.MultiReader(strings.NewReader(`{"Action":"pass","Package":"p","Test":"TestA"}
+`), iotest.ErrReader(errBroken))
+	err := gotestdox.FilterContext(context.Background(), in, io.Discard)
+	if !errors.Is(err, errBroken) {
+		t.Errorf("want error from reader, got %v", err)
+	}
+}
+
+func TestStreamError_ShortensLongSnippets(t *testing.T) {
+	t.Parallel()
+	input := strings.Repeat("ü", 500)
+	err := gotestdox.FilterContext(context.Background(), strings.NewReader(input), io.Discard)
+	var serr *gotestdox.StreamError
+	if !errors.As(err, &serr) {
+		t.Fatalf("want *StreamError, got %v", err)
+	}
+	if len(serr.Snippet) > 210 || !strings.HasSuffix(serr.Snippet, "ü…") {
+		t.Errorf("want snippet cut short at a rune boundary, got %q", serr.Snippet)
+	}
+}
+
+func TestFilterContext_ReturnsErrInterruptedWhenContextIsCancelled(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	r, w := io.Pipe()
+	defer w.Close()
+	err := gotestdox.FilterContext(ctx, r, io.Discard)
+	if !errors.Is(err, gotestdox.ErrInterrupted) || !errors.Is(err, context.Canceled) {
+		t.Errorf("want ErrInterrupted and context.Canceled, got %v", err)
+	}
+}
+
+func TestAggregate_WrapsStreamErrorForBadJSON(t *testing.T) {
+	t.Parallel()
+	_, err := gotestdox.Aggregate([]io.Reader{strings.NewReader("not JSON")})
+	if !errors.Is(err, gotestdox.ErrBadStream) {
+		t.Errorf("want ErrBadStream, got %v", err)
+	}
+}
+
+func TestAggregate_WithValidationReturnsValidationError(t *testing.T) {
+	t.Parallel()
+	_, err := gotestdox.Aggregate([]io.Reader{strings.NewReader(truncatedRun)}, gotestdox.WithValidation())
+	var verr *gotestdox.ValidationError
+	if !errors.As(err, &verr) || !errors.Is(err, gotestdox.ErrBadStream) {
+		t.Fatalf("want *ValidationError matching ErrBadStream, got %v", err)
+	}
+	if !verr.Report.Corrupt() {
+		t.Error("want report of corrupt input")
+	}
+}
+
+func TestTestDoxerErr_ClassifiesOutcomeOfFilter(t *testing.T) {
+	t.Parallel()
+	tcs := []struct {
+		name, input string
+		want        error
+	}{
+		{
+			name:  "tests passed",
+			input: `{"Action":"pass","Package":"p","Test":"TestA"}`,
+		},
+		{
+			name:  "a test failed",
+			input: `{"Action":"fail","Package":"p","Test":"TestA"}`,
+			want:  gotestdox.ErrTestsFailed,
+		},
+		{
+			name:  "a package failed to build",
+			input: `{"ImportPath":"p","Action":"build-fail"}`,
+			want:  gotestdox.ErrBuildFailed,
+		},
+		{
+			name: "a package failed vet",
+			input: `{"ImportPath":"p [p.test]","Action":"build-output","Output":"# [p]\n"}
+{"ImportPath":"p [p.test]","Action":"build-output","Output":"./p_test.go:9:14: bogus\n"}
+{"ImportPath":"p [p.test]","Action":"build-fail"}
+{"Action":"fail","Package":"p","Elapsed":0,"FailedBuild":"p [p.test]"}`,
+			want: gotestdox.ErrVetFailed,
+		},
+		{
+			name: "bad JSON",
+			input: `{"Action":"pass","Package":"p","Test":"TestA"}
+not JSON`,
+			want: gotestdox.ErrBadStream,
+		},
+	}
+	for _, tc := range tcs {
+		td := gotestdox.NewTestDoxer()
+		td.Stdin = strings.NewReader(tc.input)
+		td.Stdout, td.Stderr = io.Discard, io.Discard
+		td.Filter()
+		err := td.Err()
+		if tc.want == nil && err != nil {
+			t.Errorf("%s: want no error, got %v", tc.name, err)
+		}
+		if tc.want != nil && !errors.Is(err, tc.want) {
+			t.Errorf("%s: want %v, got %v", tc.name, tc.want, err)
+		}
+	}
+}
+
+func TestExecGoTest_ErrIsBuildFailedWrappingExitErrorWhenGoTestExitsWithStatus2(t *testing.T) {
+	useFakeGo(t, "#!/bin/sh\necho 'go: malformed import path' >&2\nexit 2\n")
+	td := gotestdox.NewTestDoxer()
+	td.Stdout, td.Stderr = io.Discard, io.Discard
+	td.ExecGoTest(nil)
+	err := td.Err()
+	if !errors.Is(err, gotestdox.ErrBuildFailed) {
+		t.Fatalf("want ErrBuildFailed, got %v", err)
+	}
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) || exitErr.ExitCode() != 2 {
+		t.Errorf("want exit status 2 from go test, got %v", err)
+	}
+}
@@ -0,0 +1,46 @@
+package gotestdox_test
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/bitfield/gotestdox"
+)
+
+// countRenderer is a trivial custom renderer that prints only the number of
+// tests that passed and failed.
+type countRenderer struct {
+	w io.Writer
+}
+
+func (r countRenderer) Result(gotestdox.Result) error {
+	return nil
+}
+
+func (r countRenderer) Summary(sum gotestdox.Summary) error {
+	_, err := fmt.Fprintf(r.w, "%d passed, %d failed\n", sum.Passed, sum.Failed)
+	return err
+}
+
+func init() {
+	gotestdox.RegisterRenderer("count", func(opts gotestdox.RendererOptions) gotestdox.Renderer {
+		return countRenderer{w: opts.Writer}
+	})
+}
+
+func ExampleRegisterRenderer() {
+	factory, ok := gotestdox.LookupRenderer("count")
+	if !ok {
+		panic("no such renderer")
+	}
+	sink := factory(gotestdox.RendererOptions{Writer: os.Stdout})
+	td := gotestdox.NewTestDoxer(gotestdox.WithSink(sink))
+	td.Stdin = strings.NewReader(`{"Action":"pass","Package":"p","Test":"TestA"}
+{"Action":"fail","Package":"p","Test":"TestB"}
+{"Action":"fail","Package":"p","Elapsed":0.1}`)
+	td.Filter()
+	// Output:
+	// 1 passed, 1 failed
+}
@@ -0,0 +1,87 @@
+package gotestdox
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// exampleSentence returns the sentence for the example function name, as
+// reported when [WithExamples] is supplied: "Example: " followed by what the
+// example is for, such as "Example: ParseJSON" for ExampleParseJSON, or
+// "Example: Event.String" for ExampleEvent_String. A suffix describing the
+// case, as in ExamplePrettify_underscoreHint, becomes the behaviour clause:
+// "Example: Prettify underscore hint". The example for the package as a
+// whole is just "Example".
+func exampleSentence(name string, cfg config) string {
+	name = strings.TrimPrefix(name, "Example")
+	subject, suffix := name, ""
+	if i := strings.LastIndex(name, "_"); i >= 0 {
+		r, _ := utf8.DecodeRuneInString(name[i+1:])
+		if unicode.IsLower(r) {
+			subject, suffix = name[:i], name[i+1:]
+		}
+	}
+	subject = strings.ReplaceAll(strings.TrimPrefix(subject, "_"), "_", ".")
+	words := []string{}
+	if subject != "" {
+		words = append(words, subject)
+	}
+	if suffix != "" {
+		r, size := utf8.DecodeRuneInString(suffix)
+		clause := prettify(string(unicode.ToUpper(r))+suffix[size:], cfg)
+		r, size = utf8.DecodeRuneInString(clause)
+		words = append(words, string(unicode.ToLower(r))+clause[size:])
+	}
+	if len(words) == 0 {
+		return "Example"
+	}
+	return "Example: " + strings.Join(words, " ")
+}
+
+// exampleMismatch returns the lines describing the difference between the
+// output an example produced and the output it should have produced, as
+// given in the output of the failing example, aligned for display under its
+// sentence, or nil if there's no such difference in the output.
+func exampleMismatch(output []string) []string {
+	var got, want []string
+	var section *[]string
+	for _, line := range output {
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case line == "got:":
+			section = &got
+		case line == "want:":
+			section = &want
+		case section == nil:
+		case strings.HasPrefix(line, "--- ") || strings.HasPrefix(line, "=== ") || line == "FAIL":
+			section = nil
+		default:
+			*section = append(*section, line)
+		}
+	}
+	if got == nil && want == nil {
+		return nil
+	}
+	lines := labelled("want: ", want)
+	return append(lines, labelled("got:  ", got)...)
+}
+
+// labelled returns lines, with the first preceded by label, and the rest
+// indented to line up with it. If there are no lines, the label is shown
+// alone.
+func labelled(label string, lines []string) []string {
+	if len(lines) == 0 {
+		return []string{strings.TrimSpace(label)}
+	}
+	result := make([]string, len(lines))
+	indent := strings.Repeat(" ", len(label))
+	for i, line := range lines {
+		if i == 0 {
+			result[i] = label + line
+		} else {
+			result[i] = indent + line
+		}
+	}
+	return result
+}
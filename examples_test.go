@@ -0,0 +1,75 @@
+package gotestdox_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bitfield/gotestdox"
+	"github.com/fatih/color"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFilter_WithExamplesReportsExampleFunctions(t *testing.T) {
+	t.Parallel()
+	color.NoColor = true
+	input := `{"Action":"pass","Package":"p","Test":"TestParse"}
+{"Action":"pass","Package":"p","Test":"Example"}
+{"Action":"pass","Package":"p","Test":"ExampleParseJSON"}
+{"Action":"pass","Package":"p","Test":"ExampleEvent_String"}
+{"Action":"pass","Package":"p","Test":"ExamplePrettify_underscoreHint"}
+{"Action":"pass","Package":"p","Test":"Example_withOptions"}
+{"Action":"pass","Package":"p","Elapsed":0.1}`
+	want := `p:
+ ✔ Example (0.00s)
+ ✔ Example: Event.String (0.00s)
+ ✔ Example: ParseJSON (0.00s)
+ ✔ Example: Prettify underscore hint (0.00s)
+ ✔ Example: with options (0.00s)
+ ✔ Parse (0.00s)
+
+`
+	buf := new(strings.Builder)
+	td := gotestdox.NewTestDoxer(gotestdox.WithExamples())
+	td.Stdin = strings.NewReader(input)
+	td.Stdout = buf
+	td.Filter()
+	if want != buf.String() {
+		t.Error(cmp.Diff(want, buf.String()))
+	}
+}
+
+func TestTextSink_WithExamplesShowsMismatchedOutputOfFailingExample(t *testing.T) {
+	t.Parallel()
+	color.NoColor = true
+	input := `{"Action":"run","Package":"p","Test":"ExampleSum"}
+{"Action":"output","Package":"p","Test":"ExampleSum","Output":"=== RUN   ExampleSum\n"}
+{"Action":"output","Package":"p","Test":"ExampleSum","Output":"--- FAIL: ExampleSum (0.00s)\n"}
+{"Action":"output","Package":"p","Test":"ExampleSum","Output":"got:\n"}
+{"Action":"output","Package":"p","Test":"ExampleSum","Output":"3\n"}
+{"Action":"output","Package":"p","Test":"ExampleSum","Output":"7\n"}
+{"Action":"output","Package":"p","Test":"ExampleSum","Output":"want:\n"}
+{"Action":"output","Package":"p","Test":"ExampleSum","Output":"4\n"}
+{"Action":"output","Package":"p","Test":"ExampleSum","Output":"7\n"}
+{"Action":"fail","Package":"p","Test":"ExampleSum"}
+{"Action":"output","Package":"p","Output":"FAIL\n"}
+{"Action":"fail","Package":"p","Elapsed":0.1}`
+	want := `p:
+ x Example: Sum (0.00s)
+    want: 4
+          7
+    got:  3
+          7
+
+`
+	buf := new(strings.Builder)
+	td := gotestdox.NewTestDoxer(gotestdox.WithExamples())
+	td.Stdin = strings.NewReader(input)
+	td.Stdout = buf
+	td.Filter()
+	if want != buf.String() {
+		t.Error(cmp.Diff(want, buf.String()))
+	}
+	if td.OK {
+		t.Error("want not OK for failing example")
+	}
+}
@@ -0,0 +1,310 @@
+package gotestdox
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// goTestRun is a single 'go' command run by [TestDoxer.execParallel]. If
+// module or configuration is not empty, it is added to each event in the
+// output. Any env entries are added to the command's environment. If
+// timeout is not zero, the run is killed if it takes any longer, and
+// timedOut is set, with timedOutTests listing the tests still running; pkg
+// is then the package it tests.
+type goTestRun struct {
+	dir, module    string
+	configuration  string
+	pkg            string
+	args, env      []string
+	timeout        time.Duration
+	stdout, stderr bytes.Buffer
+	err            error
+	timedOut       bool
+	timedOutTests  []string
+	done           chan struct{}
+}
+
+// execParallel runs each of runs, with at most parallel of them running at
+// once, and filters their combined output. The output of each run is passed
+// to the filter as a single block once the run has finished, either in the
+// order given, if ordered is true, or otherwise in the order the runs finish.
+// If any run fails, td.OK is false at the end.
+//
+// If ctx is cancelled, or the run is aborted by [WithFailFast], any runs still
+// in progress are killed, along with the test binaries they started, and runs
+// not yet started are skipped. Signals are handled as by
+// [TestDoxer.ExecGoTest]: each run is in a process group of its own, and the
+// first SIGINT or SIGTERM is passed on to every run still in progress, and
+// any runs not yet started are skipped. If [WithSignalHandlingDisabled] was
+// supplied, the runs stay in gotestdox's process group, so that signals
+// reach them as usual, but then only the 'go' command itself is killed on
+// cancellation or timeout.
+func (td *TestDoxer) execParallel(ctx context.Context, runs []*goTestRun, parallel int, ordered bool, state *runState) {
+	if parallel < 1 {
+		parallel = 1
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	state.abort = cancel
+	state.timedOut = &timedOutTests{}
+	group := !td.signalsDisabled
+	groups := newProcessGroups()
+	defer td.catchSignals(state, groups)()
+	limit := make(chan struct{}, parallel)
+	finished := make(chan *goTestRun, len(runs))
+	var wg sync.WaitGroup
+	for _, run := range runs {
+		run.done = make(chan struct{})
+		wg.Add(1)
+		go func(run *goTestRun) {
+			defer wg.Done()
+			defer func() {
+				close(run.done)
+				finished <- run
+			}()
+			select {
+			case limit <- struct{}{}:
+			case <-ctx.Done():
+				run.err = ctx.Err()
+				return
+			}
+			defer func() { <-limit }()
+			if groups.interrupted() {
+				return
+			}
+			cmd := exec.Command("go", run.args...)
+			cmd.Dir = run.dir
+			if len(run.env) > 0 {
+				cmd.Env = append(os.Environ(), run.env...)
+			}
+			cmd.Stdout = &run.stdout
+			cmd.Stderr = &run.stderr
+			if group {
+				setProcessGroup(cmd)
+			}
+			if run.err = cmd.Start(); run.err != nil {
+				return
+			}
+			groups.add(cmd)
+			defer groups.remove(cmd)
+			if run.timeout > 0 {
+				run.waitWithTimeout(ctx, cmd, group, td.clockOrSystem())
+				return
+			}
+			stop := killOnCancel(ctx, cmd, group)
+			run.err = cmd.Wait()
+			stop()
+		}(run)
+	}
+	pr, pw := io.Pipe()
+	failed := make(chan error, 1)
+	go func() {
+		var firstErr error
+		for i := range runs {
+			run := runs[i]
+			if ordered {
+				<-run.done
+			} else {
+				run = <-finished
+			}
+			if run.err != nil && ctx.Err() == nil && state.interruption.signal() == "" && !noPackages(run) {
+				if firstErr == nil {
+					firstErr = run.err
+				}
+				td.Stderr.Write(run.stderr.Bytes())
+				if run.timedOut {
+					fmt.Fprintln(td.Stderr, append([]string{"go"}, run.args...), "timed out after", run.timeout)
+				} else {
+					fmt.Fprintln(td.Stderr, append([]string{"go"}, run.args...), run.err)
+				}
+			}
+			if run.timedOut {
+				state.timedOut.add(run)
+			}
+			writeEvents(pw, run)
+		}
+		pw.Close()
+		failed <- firstErr
+	}()
+	td.Stdin = pr
+	if err := td.filter(ctx, state); err != nil {
+		td.OK = false
+		td.err = err
+		fmt.Fprintln(td.Stderr, err)
+	}
+	pr.Close()
+	wg.Wait()
+	if err := <-failed; err != nil && td.Summary.Interrupted == "" && !td.Summary.onlyExcluded() {
+		td.OK = false
+		td.exitErr = err
+		if td.Summary.Failed == 0 && td.Summary.TeardownFailures == 0 && td.Summary.VetFailures == 0 && td.Summary.TimedOutPackages == 0 {
+			td.Summary.BuildFailed = true
+		}
+	}
+}
+
+// killOnCancel kills the started cmd when ctx is cancelled, as by
+// [killCommand]. Calling the returned function, once cmd has finished, stops
+// waiting for ctx.
+func killOnCancel(ctx context.Context, cmd *exec.Cmd, group bool) (stop func()) {
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			killCommand(cmd, group)
+		case <-done:
+		}
+	}()
+	return func() { close(done) }
+}
+
+// killCommand kills the started cmd, along with any processes it started,
+// if group is set, meaning that cmd has a process group of its own (see
+// [setProcessGroup]), so that test binaries don't outlive the 'go' command
+// that started them.
+func killCommand(cmd *exec.Cmd, group bool) {
+	if group {
+		killProcessGroup(cmd)
+	} else {
+		cmd.Process.Kill()
+	}
+}
+
+// waitWithTimeout waits for the started cmd for run, killing it, as by
+// [killCommand], if it's still running after run.timeout, by clock, or when
+// ctx is cancelled. If it times out, the events needed to complete the
+// output for the package are added to run.stdout (see [timeoutEvents]).
+func (run *goTestRun) waitWithTimeout(ctx context.Context, cmd *exec.Cmd, group bool, clock Clock) {
+	done := make(chan struct{})
+	killed := make(chan time.Time, 1)
+	go func() {
+		timeout, stop := after(clock, run.timeout)
+		defer stop()
+		select {
+		case <-timeout:
+			killed <- clock.Now()
+			killCommand(cmd, group)
+		case <-ctx.Done():
+			killCommand(cmd, group)
+		case <-done:
+		}
+		close(killed)
+	}()
+	run.err = cmd.Wait()
+	close(done)
+	if at, ok := <-killed; ok {
+		events, tests := timeoutEvents(run.stdout.Bytes(), run.pkg, run.timeout, at)
+		if events == nil {
+			return
+		}
+		run.timedOut, run.timedOutTests = true, tests
+		run.stdout.Truncate(len(completeLines(run.stdout.Bytes())))
+		run.stdout.Write(events)
+	}
+}
+
+// ExecGoTestPackages is like [TestDoxer.ExecGoTest], but runs a separate 'go
+// test -json' process for each package matched by the package patterns in
+// userArgs, as listed by 'go list', so that a package that is slow to build
+// doesn't hold up the others. The results for each package are reported as
+// soon as it has finished. All the processes share the same build cache, as
+// usual.
+//
+// The number of processes run at once is limited to [runtime.GOMAXPROCS],
+// unless a different limit is set using [WithPackageParallelism]. If only a
+// single package matches, and it has no timeout, it is tested by
+// [TestDoxer.ExecGoTest] instead. Build flags that affect which packages
+// match, such as -tags, are passed to 'go list' too. If ctx is cancelled,
+// any packages still being tested are stopped, including a single package
+// tested by [TestDoxer.ExecGoTest]. A limit on how long each package may
+// take can be set using [WithPackageTimeout] and [WithPackageTimeouts].
+func (td *TestDoxer) ExecGoTestPackages(ctx context.Context, userArgs []string) {
+	flags, _ := splitGoTestArgs(userArgs)
+	first, flags := splitDirFlag(flags)
+	list := exec.CommandContext(ctx, "go", goListArgs(userArgs)...)
+	list.Stderr = td.Stderr
+	out, err := list.Output()
+	if err != nil {
+		td.OK = false
+		fmt.Fprintln(td.Stderr, list.Args, err)
+		return
+	}
+	pkgs := strings.Fields(string(out))
+	if len(pkgs) == 0 || len(pkgs) == 1 && td.packageTimeoutFor(pkgs[0]) == 0 {
+		td.execGoTest(ctx, userArgs)
+		return
+	}
+	runs := make([]*goTestRun, len(pkgs))
+	for i, pkg := range pkgs {
+		args := append([]string{"test"}, first...)
+		args = append(append(args, "-json"), flags...)
+		runs[i] = &goTestRun{
+			pkg:     pkg,
+			args:    append(args, pkg),
+			timeout: td.packageTimeoutFor(pkg),
+		}
+	}
+	parallel := td.packageParallelism
+	if parallel < 1 {
+		parallel = runtime.GOMAXPROCS(0)
+	}
+	state := td.startRun(userArgs)
+	state.packageTotal = len(pkgs)
+	td.execParallel(ctx, runs, parallel, false, state)
+}
+
+// listFlags are the 'go build' flags that affect which packages match a
+// pattern, and so must be given to 'go list' as well as to 'go test'.
+var listFlags = map[string]bool{"mod": true, "modfile": true, "tags": true}
+
+// goListArgs returns the arguments for 'go list' to list the packages that
+// 'go test' would test, given the args userArgs: the -C flag, if any, any
+// build flags among userArgs that affect which packages match, such as
+// -tags, and the package patterns.
+func goListArgs(userArgs []string) []string {
+	flags, patterns := splitGoTestArgs(userArgs)
+	first, flags := splitDirFlag(flags)
+	args := append([]string{"list"}, first...)
+	args = append(args, selectFlags(flags, listFlags)...)
+	return append(args, patterns...)
+}
+
+// selectFlags returns those of the 'go test' flags that are named in names,
+// with their values.
+func selectFlags(flags []string, names map[string]bool) []string {
+	selected := []string{}
+	for i := 0; i < len(flags); i++ {
+		name, _, hasValue := strings.Cut(strings.TrimLeft(flags[i], "-"), "=")
+		n := 1
+		if !hasValue && valueFlags[name] && i+1 < len(flags) {
+			n = 2
+		}
+		if names[name] {
+			selected = append(selected, flags[i:i+n]...)
+		}
+		i += n - 1
+	}
+	return selected
+}
+
+// splitDirFlag splits args into the -C flag and its value, if args starts
+// with one, and the remaining args. Since the go command requires -C to come
+// first, it must be kept in front of any args added by gotestdox.
+func splitDirFlag(args []string) (dir, rest []string) {
+	if len(args) > 0 && (args[0] == "-C" || strings.HasPrefix(args[0], "-C=")) {
+		n := 1
+		if args[0] == "-C" && len(args) > 1 {
+			n = 2
+		}
+		return args[:n], args[n:]
+	}
+	return []string{}, args
+}
@@ -0,0 +1,298 @@
+package gotestdox_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bitfield/gotestdox"
+	"github.com/fatih/color"
+)
+
+func TestExecGoTestPackages_RunsEachPackageSeparatelyAndAggregatesFailures(t *testing.T) {
+	t.Parallel()
+	color.NoColor = true
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"go.mod":      "module example.com/m\n\ngo 1.18\n",
+		"a/a_test.go": "package a\n\nimport \"testing\"\n\nfunc TestAWorks(t *testing.T) {}\n",
+		"b/b_test.go": "package b\n\nimport \"testing\"\n\nfunc TestBFails(t *testing.T) { t.Fail() }\n",
+		"c/c_test.go": "package c\n\nimport \"testing\"\n\nfunc TestCWorks(t *testing.T) {}\n",
+	})
+	buf := new(strings.Builder)
+	stderr := new(strings.Builder)
+	td := gotestdox.NewTestDoxer(gotestdox.WithPackageParallelism(2))
+	td.Stdout = buf
+	td.Stderr = stderr
+	td.ExecGoTestPackages(context.Background(), []string{"-C", dir, "-count=1", "./..."})
+	if td.OK {
+		t.Error("want not ok when a package has failing tests")
+	}
+	got := buf.String()
+	for _, want := range []string{
+		"example.com/m/a:\n ✔ A works (",
+		"example.com/m/b:\n x B fails (",
+		"example.com/m/c:\n ✔ C works (",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("want output to contain %q, got:\n%s\nstderr:\n%s", want, got, stderr)
+		}
+	}
+}
+
+func TestExecGoTestPackages_ListsPackagesBuiltOnlyWithGivenTags(t *testing.T) {
+	t.Parallel()
+	color.NoColor = true
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"go.mod":      "module example.com/m\n\ngo 1.18\n",
+		"a/a_test.go": "package a\n\nimport \"testing\"\n\nfunc TestAWorks(t *testing.T) {}\n",
+		"b/b_test.go": "//go:build integration\n\npackage b\n\nimport \"testing\"\n\nfunc TestBWorks(t *testing.T) {}\n",
+		"c/c_test.go": "package c\n\nimport \"testing\"\n\nfunc TestCWorks(t *testing.T) {}\n",
+	})
+	buf := new(strings.Builder)
+	stderr := new(strings.Builder)
+	td := gotestdox.NewTestDoxer()
+	td.Stdout = buf
+	td.Stderr = stderr
+	td.ExecGoTestPackages(context.Background(), []string{"-C", dir, "-tags", "integration", "-count=1", "./..."})
+	if !td.OK {
+		t.Errorf("want ok, got stderr:\n%s", stderr)
+	}
+	want := "example.com/m/b:\n ✔ B works ("
+	if !strings.Contains(buf.String(), want) {
+		t.Errorf("want output to contain %q, got:\n%s", want, buf)
+	}
+}
+
+func TestExecGoTestPackages_StopsSinglePackageWhenContextIsCancelled(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"go.mod":      "module example.com/m\n\ngo 1.18\n",
+		"a/a_test.go": "package a\n\nimport (\n\t\"testing\"\n\t\"time\"\n)\n\nfunc TestASleeps(t *testing.T) { time.Sleep(time.Minute) }\n",
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	td := gotestdox.NewTestDoxer()
+	td.Stdout = io.Discard
+	td.Stderr = io.Discard
+	start := time.Now()
+	td.ExecGoTestPackages(ctx, []string{"-C", dir, "-count=1", "./..."})
+	if elapsed := time.Since(start); elapsed > 30*time.Second {
+		t.Errorf("want run stopped promptly on cancellation, took %s", elapsed)
+	}
+	if td.OK {
+		t.Error("want not ok when interrupted")
+	}
+	if err := td.Err(); !errors.Is(err, gotestdox.ErrInterrupted) {
+		t.Errorf("want ErrInterrupted, got %v", err)
+	}
+}
+
+func TestExecGoTestPackages_WithFailFastStopsOtherPackagesAtFirstFailure(t *testing.T) {
+	t.Parallel()
+	color.NoColor = true
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"go.mod":      "module example.com/m\n\ngo 1.18\n",
+		"a/a_test.go": "package a\n\nimport (\n\t\"testing\"\n\t\"time\"\n)\n\nfunc TestASleeps(t *testing.T) { time.Sleep(time.Minute) }\n",
+		"b/b_test.go": "package b\n\nimport \"testing\"\n\nfunc TestBFails(t *testing.T) { t.Fail() }\n",
+	})
+	buf := new(strings.Builder)
+	td := gotestdox.NewTestDoxer(gotestdox.WithPackageParallelism(2), gotestdox.WithFailFast())
+	td.Stdout = buf
+	td.Stderr = io.Discard
+	start := time.Now()
+	td.ExecGoTestPackages(context.Background(), []string{"-C", dir, "-count=1", "./..."})
+	if elapsed := time.Since(start); elapsed > 30*time.Second {
+		t.Errorf("want run stopped promptly after first failure, took %s", elapsed)
+	}
+	if td.OK {
+		t.Error("want not ok")
+	}
+	want := "example.com/m/b:\n x B fails ("
+	if !strings.Contains(buf.String(), want) {
+		t.Errorf("want output to contain %q, got:\n%s", want, buf)
+	}
+	if !strings.Contains(buf.String(), "(run aborted after first failure)") {
+		t.Errorf("want abort note, got:\n%s", buf)
+	}
+}
+
+func TestExecGoTestPackages_WithPackageTimeoutReportsPartialResultsAndCarriesOn(t *testing.T) {
+	t.Parallel()
+	color.NoColor = true
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"go.mod":      "module example.com/m\n\ngo 1.18\n",
+		"a/a_test.go": "package a\n\nimport (\n\t\"testing\"\n\t\"time\"\n)\n\nfunc TestAWorks(t *testing.T) {}\n\nfunc TestAHangs(t *testing.T) {\n\tt.Run(\"forever\", func(t *testing.T) { time.Sleep(time.Hour) })\n}\n",
+		"b/b_test.go": "package b\n\nimport \"testing\"\n\nfunc TestBWorks(t *testing.T) {}\n",
+		"c/c_test.go": "package c\n\nimport (\n\t\"testing\"\n\t\"time\"\n)\n\nfunc TestCSleeps(t *testing.T) { time.Sleep(2 * time.Second) }\n",
+	})
+	buf := new(strings.Builder)
+	td := gotestdox.NewTestDoxer(
+		gotestdox.WithPackageParallelism(3),
+		gotestdox.WithPackageTimeout(time.Minute),
+		gotestdox.WithPackageTimeouts(map[string]time.Duration{"example.com/m/a": 15 * time.Second, "example.com/**": 0}),
+	)
+	td.Stdout = buf
+	td.Stderr = io.Discard
+	start := time.Now()
+	td.ExecGoTestPackages(context.Background(), []string{"-C", dir, "-count=1", "./..."})
+	if elapsed := time.Since(start); elapsed > 45*time.Second {
+		t.Errorf("want hanging package killed at its timeout, took %s", elapsed)
+	}
+	if td.OK {
+		t.Error("want not ok when a package times out")
+	}
+	got := buf.String()
+	for _, want := range []string{
+		" ✔ A works (",
+		" x A hangs forever (",
+		"s, timed out)\n",
+		" x timed out after 15s\n",
+		"example.com/m/b:\n ✔ B works (",
+		"example.com/m/c:\n ✔ C sleeps (",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("want output to contain %q, got:\n%s", want, got)
+		}
+	}
+	if td.Summary.TimedOutPackages != 1 || td.Summary.Passed != 3 || td.Summary.Failed != 2 {
+		t.Errorf("want 1 package timed out, 3 tests passed, 2 failed, got %+v", td.Summary)
+	}
+	if code := gotestdox.ExitCode(td.Summary, nil); code != gotestdox.ExitTimedOut {
+		t.Errorf("want exit code %d, got %d", gotestdox.ExitTimedOut, code)
+	}
+}
+
+// childTest is a test that starts a copy of its own test binary, which
+// appends a line to the file named by log every few milliseconds until it's
+// killed, and waits for it.
+const childTest = `package a
+
+import (
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+)
+
+func TestAStartsChild(t *testing.T) {
+	if os.Getenv("GOTESTDOX_CHILD") != "" {
+		for {
+			f, _ := os.OpenFile(%q, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+			f.WriteString("alive\n")
+			f.Close()
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	cmd := exec.Command(os.Args[0], "-test.run=^TestAStartsChild$")
+	cmd.Env = append(os.Environ(), "GOTESTDOX_CHILD=1")
+	cmd.Run()
+}
+`
+
+func TestExecGoTestPackages_WithPackageTimeoutKillsProcessesStartedByTests(t *testing.T) {
+	t.Parallel()
+	if runtime.GOOS == "windows" {
+		t.Skip("processes started by tests are killed only on Unix")
+	}
+	dir := t.TempDir()
+	log := filepath.Join(t.TempDir(), "child.log")
+	writeFiles(t, dir, map[string]string{
+		"go.mod":      "module example.com/m\n\ngo 1.18\n",
+		"a/a_test.go": fmt.Sprintf(childTest, log),
+		"b/b_test.go": "package b\n\nimport \"testing\"\n\nfunc TestBWorks(t *testing.T) {}\n",
+	})
+	// build the tests first, so that the timeout is spent running them
+	build := exec.Command("go", "test", "-count=1", "-run=^$", "./...")
+	build.Dir = dir
+	if out, err := build.CombinedOutput(); err != nil {
+		t.Fatalf("%v: %s", err, out)
+	}
+	td := gotestdox.NewTestDoxer(gotestdox.WithPackageTimeout(3 * time.Second))
+	td.Stdout = io.Discard
+	td.Stderr = io.Discard
+	td.ExecGoTestPackages(context.Background(), []string{"-C", dir, "-count=1", "./..."})
+	if td.Summary.TimedOutPackages != 1 {
+		t.Fatalf("want 1 package timed out, got %+v", td.Summary)
+	}
+	before, err := os.Stat(log)
+	if err != nil {
+		t.Fatalf("want child started, got %v", err)
+	}
+	time.Sleep(200 * time.Millisecond)
+	after, err := os.Stat(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after.Size() != before.Size() {
+		t.Error("want child of timed-out test killed, but it's still running")
+	}
+}
+
+func TestExecGoTestPackages_WhenContextIsCancelledKillsProcessesStartedByTests(t *testing.T) {
+	t.Parallel()
+	if runtime.GOOS == "windows" {
+		t.Skip("processes started by tests are killed only on Unix")
+	}
+	dir := t.TempDir()
+	log := filepath.Join(t.TempDir(), "child.log")
+	writeFiles(t, dir, map[string]string{
+		"go.mod":      "module example.com/m\n\ngo 1.18\n",
+		"a/a_test.go": fmt.Sprintf(childTest, log),
+		"b/b_test.go": "package b\n\nimport \"testing\"\n\nfunc TestBWorks(t *testing.T) {}\n",
+	})
+	// build the tests first, so that the child is running when ctx is
+	// cancelled
+	build := exec.Command("go", "test", "-count=1", "-run=^$", "./...")
+	build.Dir = dir
+	if out, err := build.CombinedOutput(); err != nil {
+		t.Fatalf("%v: %s", err, out)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	td := gotestdox.NewTestDoxer()
+	td.Stdout = io.Discard
+	td.Stderr = io.Discard
+	td.ExecGoTestPackages(ctx, []string{"-C", dir, "-count=1", "./..."})
+	before, err := os.Stat(log)
+	if err != nil {
+		t.Fatalf("want child started, got %v", err)
+	}
+	time.Sleep(200 * time.Millisecond)
+	after, err := os.Stat(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after.Size() != before.Size() {
+		t.Error("want child of cancelled test killed, but it's still running")
+	}
+}
+
+func TestFilter_IgnoresTimedOutFieldInInput(t *testing.T) {
+	t.Parallel()
+	sink := &recordingSink{}
+	td := gotestdox.NewTestDoxer(gotestdox.WithSink(sink))
+	td.Stdin = strings.NewReader(`{"Action":"fail","Package":"p","Test":"TestA","TimedOut":true}
+{"Action":"fail","Package":"p","Elapsed":30,"TimedOut":true}`)
+	td.Filter()
+	for _, r := range sink.results {
+		if r.TimedOut {
+			t.Errorf("%q: want not timed out, since only gotestdox can time out a package", r.Test)
+		}
+	}
+	if sink.summary.TimedOutPackages != 0 {
+		t.Errorf("want no packages timed out, got %d", sink.summary.TimedOutPackages)
+	}
+}
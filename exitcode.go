@@ -0,0 +1,99 @@
+package gotestdox
+
+import (
+	"strings"
+	"syscall"
+)
+
+// The exit codes returned by [ExitCode]. These are a stable contract: they
+// will not change in future versions, so CI scripts can rely on them.
+const (
+	// ExitOK means that all tests passed.
+	ExitOK = 0
+	// ExitTestsFailed means that at least one test failed.
+	ExitTestsFailed = 1
+	// ExitBuildFailed means that at least one package failed to build, so
+	// its tests couldn't be run.
+	ExitBuildFailed = 2
+	// ExitInternalError means that gotestdox itself failed, for example
+	// because the 'go test -json' output couldn't be parsed, or the report
+	// couldn't be written.
+	ExitInternalError = 3
+	// ExitThresholdsExceeded means that all tests passed, but the run
+	// exceeded one of its thresholds (see [WithThresholds]).
+	ExitThresholdsExceeded = 4
+	// ExitVetFailed means that no package failed to build, but at least
+	// one failed the vet checks run by 'go test', so its tests weren't
+	// run.
+	ExitVetFailed = 5
+	// ExitTimedOut means that no package failed to build or vet, but at
+	// least one was killed for taking too long (see [WithPackageTimeout]).
+	ExitTimedOut = 6
+	// ExitInterrupted means that the run was interrupted by SIGINT, as
+	// when ctrl-C is pressed. This is the conventional status for a
+	// program killed by that signal.
+	ExitInterrupted = 130
+	// ExitTerminated means that the run was interrupted by SIGTERM.
+	ExitTerminated = 143
+)
+
+// ExitCode returns the exit status that best describes the outcome of a run
+// with the summary sum, and the error err, if any, returned by the run. The
+// codes are, in order of precedence:
+//
+//   - [ExitInternalError], if err is not nil, or sum.InternalError is true
+//   - [ExitTerminated], if sum.Interrupted is "terminated", or
+//     [ExitInterrupted], if the run was interrupted by any other signal
+//   - [ExitBuildFailed], if sum.BuildFailed is true
+//   - [ExitVetFailed], if sum.VetFailures is not zero
+//   - [ExitTimedOut], if sum.TimedOutPackages is not zero
+//   - [ExitTestsFailed], if any test failed, other than a flaky test that
+//     passed when it was retried, or any package failed in TestMain or
+//     teardown
+//   - [ExitThresholdsExceeded], if sum.ThresholdViolations is not empty
+//   - [ExitOK] otherwise
+//
+// Flaky tests are treated as passing, unless [WithFlakyAsFailure] is
+// supplied, in which case they count as failures, or [WithFlakyExitCode],
+// which gives a distinct exit code to use when the only failures were
+// flaky.
+func ExitCode(sum Summary, err error, opts ...Option) int {
+	cfg := newConfig(opts)
+	switch {
+	case err != nil || sum.InternalError:
+		return ExitInternalError
+	case sum.Interrupted == syscall.SIGTERM.String():
+		return ExitTerminated
+	case sum.Interrupted != "":
+		return ExitInterrupted
+	case sum.BuildFailed:
+		return ExitBuildFailed
+	case sum.VetFailures > 0:
+		return ExitVetFailed
+	case sum.TimedOutPackages > 0:
+		return ExitTimedOut
+	case sum.Failed > sum.FlakyFailures, sum.TeardownFailures > 0:
+		return ExitTestsFailed
+	case sum.Flaky > 0 && cfg.flakyAsFailure:
+		return ExitTestsFailed
+	case len(sum.ThresholdViolations) > 0:
+		return ExitThresholdsExceeded
+	case sum.Flaky > 0 && cfg.flakyExitCode != 0:
+		return cfg.flakyExitCode
+	}
+	return ExitOK
+}
+
+// isBuildFailure reports whether the package result r shows that the
+// package failed to build, or its tests couldn't be set up.
+func isBuildFailure(r Result) bool {
+	if r.Status != "fail" {
+		return false
+	}
+	for _, line := range r.Output {
+		if strings.Contains(line, "[build failed]") || strings.Contains(line, "[setup failed]") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,185 @@
+package gotestdox_test
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/bitfield/gotestdox"
+)
+
+func TestExitCode_ReturnsCodeDescribingOutcomeOfRun(t *testing.T) {
+	t.Parallel()
+	tcs := []struct {
+		name  string
+		input string
+		opts  []gotestdox.Option
+		want  int
+	}{
+		{
+			name: "all tests passed",
+			input: `{"Action":"pass","Package":"p","Test":"TestA"}
+{"Action":"pass","Package":"p","Elapsed":0.1}`,
+			want: gotestdox.ExitOK,
+		},
+		{
+			name: "a test failed",
+			input: `{"Action":"fail","Package":"p","Test":"TestA"}
+{"Action":"fail","Package":"p","Elapsed":0.1}`,
+			want: gotestdox.ExitTestsFailed,
+		},
+		{
+			name: "a package failed to build",
+			input: `{"Action":"output","Package":"p","Output":"FAIL\tp [build failed]\n"}
+{"Action":"fail","Package":"p","Elapsed":0}`,
+			want: gotestdox.ExitBuildFailed,
+		},
+		{
+			name: "a package failed vet",
+			input: `{"ImportPath":"p [p.test]","Action":"build-output","Output":"# [p]\n"}
+{"ImportPath":"p [p.test]","Action":"build-output","Output":"./p_test.go:9:14: fmt.Printf format %d has arg \"s\" of wrong type string\n"}
+{"ImportPath":"p [p.test]","Action":"build-fail"}
+{"Action":"output","Package":"p","Output":"FAIL\tp [build failed]\n"}
+{"Action":"fail","Package":"p","Elapsed":0,"FailedBuild":"p [p.test]"}`,
+			want: gotestdox.ExitVetFailed,
+		},
+		{
+			name: "a package failed vet and another failed to build",
+			input: `{"ImportPath":"p [p.test]","Action":"build-output","Output":"# [p]\n"}
+{"ImportPath":"p [p.test]","Action":"build-output","Output":"./p_test.go:9:14: fmt.Printf format %d has arg \"s\" of wrong type string\n"}
+{"ImportPath":"p [p.test]","Action":"build-fail"}
+{"Action":"fail","Package":"p","Elapsed":0,"FailedBuild":"p [p.test]"}
+{"ImportPath":"q [q.test]","Action":"build-output","Output":"# q [q.test]\n"}
+{"ImportPath":"q [q.test]","Action":"build-output","Output":"q/q_test.go:5:28: undefined: undefined\n"}
+{"ImportPath":"q [q.test]","Action":"build-fail"}
+{"Action":"fail","Package":"q","Elapsed":0,"FailedBuild":"q [q.test]"}`,
+			want: gotestdox.ExitBuildFailed,
+		},
+		{
+			name: "build-fail event",
+			input: `{"ImportPath":"p","Action":"build-fail"}
+{"Action":"pass","Package":"q","Test":"TestA"}
+{"Action":"pass","Package":"q","Elapsed":0.1}`,
+			want: gotestdox.ExitBuildFailed,
+		},
+		{
+			name: "build failure takes precedence over test failure",
+			input: `{"Action":"fail","Package":"p","Test":"TestA"}
+{"Action":"fail","Package":"p","Elapsed":0.1}
+{"Action":"output","Package":"q","Output":"FAIL\tq [setup failed]\n"}
+{"Action":"fail","Package":"q","Elapsed":0}`,
+			want: gotestdox.ExitBuildFailed,
+		},
+		{
+			name: "package failed in teardown",
+			input: `{"Action":"pass","Package":"p","Test":"TestA"}
+{"Action":"output","Package":"p","Output":"goleak: Errors on successful test run: found unexpected goroutines:\n"}
+{"Action":"fail","Package":"p","Elapsed":0.1}`,
+			want: gotestdox.ExitTestsFailed,
+		},
+		{
+			name:  "invalid JSON",
+			input: `not JSON`,
+			want:  gotestdox.ExitInternalError,
+		},
+		{
+			name: "flaky test passed on retry",
+			input: `{"Action":"fail","Package":"p","Test":"TestA"}
+{"Action":"pass","Package":"p","Test":"TestA"}
+{"Action":"pass","Package":"p","Elapsed":0.1}`,
+			want: gotestdox.ExitOK,
+		},
+		{
+			name: "flaky test with WithFlakyAsFailure",
+			input: `{"Action":"fail","Package":"p","Test":"TestA"}
+{"Action":"pass","Package":"p","Test":"TestA"}
+{"Action":"pass","Package":"p","Elapsed":0.1}`,
+			opts: []gotestdox.Option{gotestdox.WithFlakyAsFailure()},
+			want: gotestdox.ExitTestsFailed,
+		},
+		{
+			name: "flaky test with WithFlakyExitCode",
+			input: `{"Action":"fail","Package":"p","Test":"TestA"}
+{"Action":"pass","Package":"p","Test":"TestA"}
+{"Action":"pass","Package":"p","Elapsed":0.1}`,
+			opts: []gotestdox.Option{gotestdox.WithFlakyExitCode(4)},
+			want: 4,
+		},
+		{
+			name: "flaky test alongside genuine failure",
+			input: `{"Action":"fail","Package":"p","Test":"TestA"}
+{"Action":"pass","Package":"p","Test":"TestA"}
+{"Action":"fail","Package":"p","Test":"TestB"}
+{"Action":"fail","Package":"p","Elapsed":0.1}`,
+			opts: []gotestdox.Option{gotestdox.WithFlakyExitCode(4)},
+			want: gotestdox.ExitTestsFailed,
+		},
+	}
+	for _, tc := range tcs {
+		td := gotestdox.NewTestDoxer()
+		td.Stdin = strings.NewReader(tc.input)
+		td.Stdout = io.Discard
+		td.Stderr = io.Discard
+		td.Filter()
+		got := gotestdox.ExitCode(td.Summary, nil, tc.opts...)
+		if tc.want != got {
+			t.Errorf("%s: want exit code %d, got %d", tc.name, tc.want, got)
+		}
+	}
+}
+
+func TestExitCode_ReturnsInternalErrorGivenNonNilError(t *testing.T) {
+	t.Parallel()
+	got := gotestdox.ExitCode(gotestdox.Summary{Packages: 1}, errors.New("oh no"))
+	if got != gotestdox.ExitInternalError {
+		t.Errorf("want exit code %d, got %d", gotestdox.ExitInternalError, got)
+	}
+}
+
+func TestExitCode_ReturnsTimedOutStatusWhenPackageTimedOut(t *testing.T) {
+	t.Parallel()
+	sum := gotestdox.Summary{Packages: 1, Passed: 1, Failed: 1, TimedOutPackages: 1}
+	got := gotestdox.ExitCode(sum, nil)
+	if got != gotestdox.ExitTimedOut {
+		t.Errorf("want exit code %d, got %d", gotestdox.ExitTimedOut, got)
+	}
+}
+
+func TestExitCode_ReturnsSignalStatusForInterruptedRun(t *testing.T) {
+	t.Parallel()
+	tcs := map[string]int{
+		"interrupt":  gotestdox.ExitInterrupted,
+		"terminated": gotestdox.ExitTerminated,
+	}
+	for signal, want := range tcs {
+		sum := gotestdox.Summary{Packages: 1, Failed: 1, Interrupted: signal}
+		got := gotestdox.ExitCode(sum, nil)
+		if got != want {
+			t.Errorf("%s: want exit code %d, got %d", signal, want, got)
+		}
+	}
+}
+
+func TestFilter_MarksTestThatPassesAfterFailingAsFlaky(t *testing.T) {
+	t.Parallel()
+	sink := &recordingSink{}
+	td := gotestdox.NewTestDoxer(gotestdox.WithSink(sink))
+	td.Stdin = strings.NewReader(`{"Action":"fail","Package":"p","Test":"TestA"}
+{"Action":"pass","Package":"p","Test":"TestA"}
+{"Action":"pass","Package":"p","Test":"TestB"}
+{"Action":"pass","Package":"p","Elapsed":0.1}`)
+	td.Filter()
+	var flaky []string
+	for _, r := range sink.results {
+		if r.Flaky {
+			flaky = append(flaky, r.Test)
+		}
+	}
+	if len(flaky) != 1 || flaky[0] != "TestA" {
+		t.Errorf("want only TestA flaky, got %q", flaky)
+	}
+	if sink.summary.Flaky != 1 {
+		t.Errorf("want 1 flaky test in summary, got %d", sink.summary.Flaky)
+	}
+}
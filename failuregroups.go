@@ -0,0 +1,145 @@
+package gotestdox
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// FailureGroup is a set of tests that failed with identical output, as
+// reported by [WithFailureGroups]. Fingerprint identifies the output, once
+// normalised as described there, and Output is the output of the first of
+// the tests, without the lines added by 'go test' itself.
+type FailureGroup struct {
+	Fingerprint string        `json:"fingerprint"`
+	Output      []string      `json:"output"`
+	Tests       []GroupedTest `json:"tests"`
+}
+
+// GroupedTest is one of the tests in a [FailureGroup].
+type GroupedTest struct {
+	Package  string `json:"package"`
+	Test     string `json:"test"`
+	Sentence string `json:"sentence"`
+}
+
+var (
+	// sourcePosition matches a file:line position, with an optional
+	// column, as given at the start of a message logged by a test.
+	sourcePosition = regexp.MustCompile(`[\w./\\-]+\.go:\d+(:\d+)?`)
+	// measuredDuration matches a duration with a fractional part, such as
+	// "0.00s" or "1.5ms", as measured times almost always have. Whole
+	// durations, such as "2s", are more likely to be configured values
+	// that differ for a reason, so they're left alone.
+	measuredDuration = regexp.MustCompile(`\b(\d+h)?(\d+m)?\d+\.\d+(ns|us|µs|ms|s)`)
+)
+
+// failureOutput returns the output of the failed test r that's worth
+// comparing with that of other tests: its lines, leaving out those added by
+// 'go test', such as "=== RUN", and with the common indentation removed, so
+// that the same message from a subtest and a top-level test can match.
+func failureOutput(r Result) []string {
+	lines := []string{}
+	for _, line := range r.Output {
+		line = strings.TrimRight(line, "\r\n")
+		if isGoTestBoilerplate(line) {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	indent := -1
+	for _, line := range lines {
+		n := len(line) - len(strings.TrimLeft(line, " \t"))
+		if indent < 0 || n < indent {
+			indent = n
+		}
+	}
+	for i, line := range lines {
+		lines[i] = line[indent:]
+	}
+	return lines
+}
+
+// failureFingerprint returns the key by which the failed test r is grouped
+// with others whose output is identical, once any file:line positions, and
+// any measured durations, are replaced by placeholders, or "" if r has no
+// output to compare. Nothing else is normalised, so that outputs differing
+// in any other way, even only by a pointer address, are never merged.
+func failureFingerprint(r Result) string {
+	lines := failureOutput(r)
+	if len(lines) == 0 {
+		return ""
+	}
+	normal := make([]string, len(lines))
+	for i, line := range lines {
+		line = sourcePosition.ReplaceAllString(line, "FILE:LINE")
+		normal[i] = measuredDuration.ReplaceAllString(line, "DURATION")
+	}
+	sum := sha256.Sum256([]byte(strings.Join(normal, "\n")))
+	return hex.EncodeToString(sum[:8])
+}
+
+// groupFailures returns the groups of two or more of the failed tests among
+// results with identical output, in the order in which the first test of
+// each group completed.
+func groupFailures(results []Result) []FailureGroup {
+	groups := []FailureGroup{}
+	index := map[string]int{}
+	for _, r := range results {
+		if r.Status != "fail" {
+			continue
+		}
+		fp := failureFingerprint(r)
+		if fp == "" {
+			continue
+		}
+		i, ok := index[fp]
+		if !ok {
+			i = len(groups)
+			index[fp] = i
+			groups = append(groups, FailureGroup{Fingerprint: fp, Output: failureOutput(r)})
+		}
+		groups[i].Tests = append(groups[i].Tests, GroupedTest{Package: r.Package, Test: r.Test, Sentence: r.Sentence})
+	}
+	shared := []FailureGroup{}
+	for _, g := range groups {
+		if len(g.Tests) > 1 {
+			shared = append(shared, g)
+		}
+	}
+	return shared
+}
+
+// failedIdentically returns the tests among results, in order, that failed
+// with the same output as an earlier one, keyed by the name of that earlier
+// test, the first of its group.
+func failedIdentically(results []Result) map[string][]Result {
+	first := map[string]string{}
+	same := map[string][]Result{}
+	for _, r := range results {
+		if r.Status != "fail" {
+			continue
+		}
+		fp := failureFingerprint(r)
+		if fp == "" {
+			continue
+		}
+		if name, ok := first[fp]; ok {
+			same[name] = append(same[name], r)
+			continue
+		}
+		first[fp] = r.Test
+	}
+	return same
+}
+
+// identicalMessage returns the line introducing the n tests that failed in
+// the same way as the one whose output has just been printed.
+func identicalMessage(n int) string {
+	if n == 1 {
+		return "… and 1 other test failed identically:"
+	}
+	return fmt.Sprintf("… and %d other tests failed identically:", n)
+}
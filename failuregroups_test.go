@@ -0,0 +1,132 @@
+package gotestdox_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bitfield/gotestdox"
+	"github.com/fatih/color"
+	"github.com/google/go-cmp/cmp"
+)
+
+// sharedHelperRun is the output of 'go test -json' for a package in which a
+// shared helper fails in the same way for three tests, called from different
+// lines and taking different times, and two other tests fail with messages
+// differing only in a pointer address.
+const sharedHelperRun = `{"Action":"run","Package":"p","Test":"TestParsesEmptyInput"}
+{"Action":"output","Package":"p","Test":"TestParsesEmptyInput","Output":"=== RUN   TestParsesEmptyInput\n"}
+{"Action":"output","Package":"p","Test":"TestParsesEmptyInput","Output":"    parse_test.go:12: connecting: dial tcp: connection refused after 0.25s\n"}
+{"Action":"output","Package":"p","Test":"TestParsesEmptyInput","Output":"--- FAIL: TestParsesEmptyInput (0.25s)\n"}
+{"Action":"fail","Package":"p","Test":"TestParsesEmptyInput","Elapsed":0.25}
+{"Action":"run","Package":"p","Test":"TestParsesValidInput"}
+{"Action":"output","Package":"p","Test":"TestParsesValidInput","Output":"=== RUN   TestParsesValidInput\n"}
+{"Action":"output","Package":"p","Test":"TestParsesValidInput","Output":"    parse_test.go:20: connecting: dial tcp: connection refused after 0.31s\n"}
+{"Action":"output","Package":"p","Test":"TestParsesValidInput","Output":"--- FAIL: TestParsesValidInput (0.31s)\n"}
+{"Action":"fail","Package":"p","Test":"TestParsesValidInput","Elapsed":0.31}
+{"Action":"run","Package":"p","Test":"TestParses/long_input"}
+{"Action":"output","Package":"p","Test":"TestParses/long_input","Output":"=== RUN   TestParses/long_input\n"}
+{"Action":"output","Package":"p","Test":"TestParses/long_input","Output":"        helper_test.go:8: connecting: dial tcp: connection refused after 0.27s\n"}
+{"Action":"output","Package":"p","Test":"TestParses/long_input","Output":"    --- FAIL: TestParses/long_input (0.27s)\n"}
+{"Action":"fail","Package":"p","Test":"TestParses/long_input","Elapsed":0.27}
+{"Action":"fail","Package":"p","Test":"TestParses","Elapsed":0.27}
+{"Action":"run","Package":"p","Test":"TestStoresConfig"}
+{"Action":"output","Package":"p","Test":"TestStoresConfig","Output":"    store_test.go:9: want nil, got &Config{} at 0xc000012340\n"}
+{"Action":"fail","Package":"p","Test":"TestStoresConfig","Elapsed":0}
+{"Action":"run","Package":"p","Test":"TestStoresDefaults"}
+{"Action":"output","Package":"p","Test":"TestStoresDefaults","Output":"    store_test.go:17: want nil, got &Config{} at 0xc000012398\n"}
+{"Action":"fail","Package":"p","Test":"TestStoresDefaults","Elapsed":0}
+{"Action":"run","Package":"p","Test":"TestLoadsConfig"}
+{"Action":"pass","Package":"p","Test":"TestLoadsConfig","Elapsed":0}
+{"Action":"fail","Package":"p","Elapsed":0.9}
+`
+
+func TestFilter_WithFailureGroupsPrintsIdenticalFailureOutputOnce(t *testing.T) {
+	t.Parallel()
+	color.NoColor = true
+	td := gotestdox.NewTestDoxer(gotestdox.WithFailureGroups())
+	td.Stdin = strings.NewReader(sharedHelperRun)
+	buf := new(strings.Builder)
+	td.Stdout = buf
+	td.Filter()
+	want := `p:
+ ✔ Loads config (0.00s)
+ x Parses empty input (0.25s)
+    parse_test.go:12: connecting: dial tcp: connection refused after 0.25s
+    … and 2 other tests failed identically:
+     x Parses long input (0.27s)
+     x Parses valid input (0.31s)
+ x Stores config (0.00s)
+    store_test.go:9: want nil, got &Config{} at 0xc000012340
+ x Stores defaults (0.00s)
+    store_test.go:17: want nil, got &Config{} at 0xc000012398
+
+`
+	if want != buf.String() {
+		t.Error(cmp.Diff(want, buf.String()))
+	}
+}
+
+func TestFilter_WithFailureGroupsRecordsGroupsInSummary(t *testing.T) {
+	t.Parallel()
+	td := gotestdox.NewTestDoxer(gotestdox.WithFailureGroups())
+	td.Stdin = strings.NewReader(sharedHelperRun)
+	td.Stdout = new(strings.Builder)
+	td.Filter()
+	groups := td.Summary.FailureGroups
+	if len(groups) != 1 {
+		t.Fatalf("want 1 group, got %d: %+v", len(groups), groups)
+	}
+	if groups[0].Fingerprint == "" {
+		t.Error("want a fingerprint for the group")
+	}
+	wantOutput := []string{"parse_test.go:12: connecting: dial tcp: connection refused after 0.25s"}
+	if !cmp.Equal(wantOutput, groups[0].Output) {
+		t.Error(cmp.Diff(wantOutput, groups[0].Output))
+	}
+	wantTests := []gotestdox.GroupedTest{
+		{Package: "p", Test: "TestParsesEmptyInput", Sentence: "Parses empty input"},
+		{Package: "p", Test: "TestParsesValidInput", Sentence: "Parses valid input"},
+		{Package: "p", Test: "TestParses/long_input", Sentence: "Parses long input"},
+	}
+	if !cmp.Equal(wantTests, groups[0].Tests) {
+		t.Error(cmp.Diff(wantTests, groups[0].Tests))
+	}
+}
+
+func TestFilter_WithFailureGroupsNeverMergesOutputsDifferingOnlyInAPointerAddress(t *testing.T) {
+	t.Parallel()
+	input := `{"Action":"output","Package":"p","Test":"TestA","Output":"    a_test.go:5: got (*T)(0xc00001a0f0)\n"}
+{"Action":"fail","Package":"p","Test":"TestA","Elapsed":0}
+{"Action":"output","Package":"p","Test":"TestB","Output":"    a_test.go:5: got (*T)(0xc00001a0f8)\n"}
+{"Action":"fail","Package":"p","Test":"TestB","Elapsed":0}
+{"Action":"fail","Package":"p","Elapsed":0}
+`
+	td := gotestdox.NewTestDoxer(gotestdox.WithFailureGroups())
+	td.Stdin = strings.NewReader(input)
+	buf := new(strings.Builder)
+	td.Stdout = buf
+	td.Filter()
+	if len(td.Summary.FailureGroups) != 0 {
+		t.Errorf("want no groups, got %+v", td.Summary.FailureGroups)
+	}
+	if strings.Contains(buf.String(), "identically") {
+		t.Errorf("want failures shown separately, got:\n%s", buf.String())
+	}
+}
+
+func TestFilter_WithFailureGroupsKeepsWholeDurationsDistinct(t *testing.T) {
+	t.Parallel()
+	input := `{"Action":"output","Package":"p","Test":"TestA","Output":"    a_test.go:5: want timeout 2s, got 3s\n"}
+{"Action":"fail","Package":"p","Test":"TestA","Elapsed":0}
+{"Action":"output","Package":"p","Test":"TestB","Output":"    a_test.go:9: want timeout 2s, got 4s\n"}
+{"Action":"fail","Package":"p","Test":"TestB","Elapsed":0}
+{"Action":"fail","Package":"p","Elapsed":0}
+`
+	td := gotestdox.NewTestDoxer(gotestdox.WithFailureGroups())
+	td.Stdin = strings.NewReader(input)
+	td.Stdout = new(strings.Builder)
+	td.Filter()
+	if len(td.Summary.FailureGroups) != 0 {
+		t.Errorf("want no groups, got %+v", td.Summary.FailureGroups)
+	}
+}
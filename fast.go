@@ -0,0 +1,19 @@
+package gotestdox
+
+import "time"
+
+// hideFast returns the tests that should be shown when [WithMinDuration] is
+// in effect: those that took at least the minimum duration, and any that
+// must be shown regardless, because they failed, or were flaky. It counts
+// the tests left out in s.fastHidden.
+func (s *TextSink) hideFast(tests []Result) []Result {
+	shown := tests[:0:0]
+	for _, r := range tests {
+		if r.Status == "fail" || r.Flaky || time.Duration(r.Elapsed*float64(time.Second)) >= s.minDuration {
+			shown = append(shown, r)
+			continue
+		}
+		s.fastHidden++
+	}
+	return shown
+}
@@ -0,0 +1,64 @@
+package gotestdox_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bitfield/gotestdox"
+	"github.com/fatih/color"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestTextSink_WithMinDurationHidesFastTestsUnlessTheyMustBeShown(t *testing.T) {
+	t.Parallel()
+	color.NoColor = true
+	input := `{"Action":"pass","Package":"p","Test":"TestFast","Elapsed":0.01}
+{"Action":"pass","Package":"p","Test":"TestSlow","Elapsed":0.2}
+{"Action":"fail","Package":"p","Test":"TestFastFailure","Elapsed":0}
+{"Action":"fail","Package":"p","Test":"TestFlaky","Elapsed":0}
+{"Action":"pass","Package":"p","Test":"TestFlaky","Elapsed":0}
+{"Action":"fail","Package":"p","Elapsed":0.3}`
+	want := `p:
+ x Fast failure (0.00s)
+ x Flaky (0.00s)
+ ✔ Flaky (0.00s)
+ ✔ Slow (0.20s)
+
+… 1 fast tests hidden (under 100ms)
+`
+	buf := new(strings.Builder)
+	td := gotestdox.NewTestDoxer(gotestdox.WithMinDuration(100 * time.Millisecond))
+	td.Stdin = strings.NewReader(input)
+	td.Stdout = buf
+	td.Filter()
+	if want != buf.String() {
+		t.Error(cmp.Diff(want, buf.String()))
+	}
+	if td.Summary.Passed != 3 {
+		t.Errorf("want hidden tests counted in summary, got %d passed", td.Summary.Passed)
+	}
+}
+
+func TestTextSink_WithMinDurationReportsNumberHiddenWithThousandsSeparators(t *testing.T) {
+	t.Parallel()
+	color.NoColor = true
+	input := new(strings.Builder)
+	for i := 0; i < 2741; i++ {
+		fmt.Fprintf(input, `{"Action":"pass","Package":"p","Test":"TestFast%d"}`+"\n", i)
+	}
+	input.WriteString(`{"Action":"pass","Package":"p","Elapsed":0.3}`)
+	want := `p:
+
+… 2,741 fast tests hidden (under 1s)
+`
+	buf := new(strings.Builder)
+	td := gotestdox.NewTestDoxer(gotestdox.WithMinDuration(time.Second))
+	td.Stdin = strings.NewReader(input.String())
+	td.Stdout = buf
+	td.Filter()
+	if want != buf.String() {
+		t.Error(cmp.Diff(want, buf.String()))
+	}
+}
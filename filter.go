@@ -0,0 +1,628 @@
+package gotestdox
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+)
+
+// runState is the state of a single run by a [TestDoxer], as opposed to its
+// configuration, so that the same TestDoxer can be used for one run after
+// another. Each run, whether by [TestDoxer.Filter] or by any of the ways of
+// running 'go test', has a runState of its own, created by
+// [TestDoxer.startRun].
+type runState struct {
+	// goTestArgs are the user's args to 'go test', if it's being run.
+	goTestArgs []string
+	// packageTotal is the number of packages being tested, if known, for
+	// the progress line (see [WithProgress]).
+	packageTotal int
+	// moduleDirs and configurations are the modules, or configurations,
+	// being tested by [TestDoxer.ExecGoTestModules] or
+	// [TestDoxer.ExecGoTestConfigurations], if any.
+	moduleDirs     []string
+	configurations []Configuration
+	// abort stops any 'go test' processes still running (see
+	// [WithFailFast]).
+	abort func()
+	// childStderr is reading the standard error of 'go test', when it's
+	// run by [TestDoxer.ExecGoTest].
+	childStderr *childStderr
+	// interruption records the signal, if any, that interrupted the run
+	// (see [TestDoxer.catchSignals]).
+	interruption *interruption
+	// timedOut records the tests and packages that timed out, in a run by
+	// [TestDoxer.execParallel].
+	timedOut *timedOutTests
+	// previous is the duration history read at the start of the run, if
+	// any (see [WithDurationHistory]).
+	previous durations
+}
+
+// startRun forgets the outcome of any previous run by td, and returns the
+// state for a new one, in which goTestArgs are the user's args to 'go
+// test', if it's being run.
+func (td *TestDoxer) startRun(goTestArgs []string) *runState {
+	td.OK = false
+	td.Summary = Summary{}
+	td.VagueNames = nil
+	td.err, td.exitErr = nil, nil
+	return &runState{goTestArgs: goTestArgs}
+}
+
+// findConfiguration returns the configuration being tested with the given
+// name, if there is one.
+func (s *runState) findConfiguration(name string) (Configuration, bool) {
+	for _, conf := range s.configurations {
+		if conf.Name == name {
+			return conf, true
+		}
+	}
+	return Configuration{}, false
+}
+
+// eventFilter is the state of [TestDoxer.filter] as it works through the
+// events of a run, collecting the output and the results of each test and
+// package, and delivering them to the sink.
+type eventFilter struct {
+	*TestDoxer
+	state    *runState
+	clock    Clock
+	summary  Summary
+	sink     ResultSink
+	tee      *teeSink
+	packages *packageResolver
+	// closers are called at the end of the run, whether or not it
+	// succeeded, in reverse order, as if deferred.
+	closers   []func() error
+	recording *historyRecording
+	locator   *testLocator
+	docs      *packageDocs
+	progress  *progressLine
+	redraw    <-chan time.Time
+	stall     *stallReport
+	stallTick Ticker
+	stalled   <-chan time.Time
+	notify    func(Result)
+	budget    *outputBudget
+	skipRules []skipRule
+	active    *activity
+	// all is the relevant results, for the checks run at the end, and
+	// completed the results of all the tests
+	all, completed []Result
+	firstEvent     time.Time
+	lastEvent      time.Time
+	outputBytes    outputCounter
+	// output and buildOutput are the lines of output so far of each test
+	// or package, by key, and of each build, by import path
+	output      map[string][]string
+	buildOutput map[string][]string
+	vetFailed   map[string][]string
+	// failures counts the failures of each test so far, by key, to
+	// recognise flaky tests, and passed and failedTests the tests of each
+	// package that passed or failed
+	failures       map[string]int
+	passed         map[string]int
+	failedTests    map[string]int
+	hasSubtests    map[string]bool
+	testsStarted   map[string]bool
+	excluded       map[string]bool
+	unknownActions map[string]bool
+}
+
+// filter does the work of [TestDoxer.Filter], for the run with the given
+// state, returning the first error encountered. Each completed test, and
+// each completed package, is delivered as a [Result] to the configured
+// [ResultSink], followed by a [Summary] at the end of the stream.
+func (td *TestDoxer) filter(ctx context.Context, state *runState) (err error) {
+	td.OK = true
+	f := &eventFilter{
+		TestDoxer:      td,
+		state:          state,
+		clock:          td.clockOrSystem(),
+		active:         newActivity(),
+		output:         map[string][]string{},
+		buildOutput:    map[string][]string{},
+		vetFailed:      map[string][]string{},
+		failures:       map[string]int{},
+		passed:         map[string]int{},
+		failedTests:    map[string]int{},
+		hasSubtests:    map[string]bool{},
+		testsStarted:   map[string]bool{},
+		excluded:       map[string]bool{},
+		unknownActions: map[string]bool{},
+	}
+	defer func() {
+		if err != nil {
+			f.summary.InternalError = true
+		}
+		td.Summary = f.summary
+	}()
+	defer func() {
+		for i := len(f.closers) - 1; i >= 0; i-- {
+			if cerr := f.closers[i](); err == nil && cerr != nil {
+				err = cerr
+			}
+		}
+	}()
+	if err := f.start(ctx); err != nil {
+		return err
+	}
+	lines, readErr, done := td.readLines()
+	defer close(done)
+	lineNum := 0
+	for !f.summary.Aborted {
+		var line string
+		var ok bool
+		select {
+		case <-ctx.Done():
+			if c, isCloser := td.Stdin.(io.Closer); isCloser {
+				c.Close()
+			}
+			return &RunError{Kind: ErrInterrupted, Err: ctx.Err()}
+		case <-f.redraw:
+			f.progress.draw()
+			continue
+		case now := <-f.stalled:
+			// a tick delivered just before the last event is stale
+			if now.Sub(f.stall.last) >= td.stallAfter {
+				f.stall.report(now)
+			}
+			continue
+		case line, ok = <-lines:
+		}
+		if !ok {
+			if err := <-readErr; err != nil {
+				return fmt.Errorf("reading input after line %d: %w", lineNum, err)
+			}
+			break
+		}
+		lineNum++
+		event, hasElapsed, err := decodeEvent([]byte(line))
+		if err != nil {
+			return newStreamError(lineNum, line, err)
+		}
+		if err := f.event(event, hasElapsed); err != nil {
+			return err
+		}
+	}
+	if err := f.finish(); err != nil {
+		return err
+	}
+	if f.summary.Aborted {
+		for range lines {
+		}
+	}
+	if f.tee != nil {
+		return f.tee.err()
+	}
+	return nil
+}
+
+// start prepares for the run: it validates the input, reads the duration
+// history, and sets up the sink, and whatever else the options call for.
+func (f *eventFilter) start(ctx context.Context) error {
+	if f.validate {
+		in, err := validated(f.Stdin)
+		if err != nil {
+			return err
+		}
+		f.Stdin = in
+	}
+	if f.historyPath != "" {
+		previous, err := loadDurations(f.historyPath)
+		if err != nil {
+			return err
+		}
+		f.state.previous = previous
+	}
+	f.packages = newPackageResolver(ctx, f.packageDirs, f.state.goTestArgs)
+	f.sink = f.TestDoxer.sink
+	if f.sink == nil {
+		f.sink = newTextSink(f.Stdout, f.config, f.state, f.packages)
+	}
+	if len(f.extraRenderers) > 0 {
+		tee, err := newTeeSink(f.sink, f.extraRenderers)
+		if err != nil {
+			return err
+		}
+		f.tee, f.sink = tee, tee
+	}
+	if f.historyDSN != "" {
+		recording, err := startHistoryRecording(f.historyDSN, f.clock)
+		if err != nil {
+			return err
+		}
+		f.recording = recording
+		f.closers = append(f.closers, func() error {
+			if err := recording.store.Close(); err != nil {
+				return fmt.Errorf("closing history store: %w", err)
+			}
+			return nil
+		})
+	}
+	if f.resultLog != "" {
+		log, err := openResultLog(f.resultLog)
+		if err != nil {
+			return err
+		}
+		f.closers = append(f.closers, func() error {
+			if err := log.close(); err != nil {
+				return fmt.Errorf("writing result log: %w", err)
+			}
+			return nil
+		})
+		f.sink = resultLogSink{log: log, next: f.sink, summary: &f.summary}
+	}
+	if f.environment {
+		env := collectEnvironment(f.state.goTestArgs)
+		f.summary.Environment = &env
+	}
+	for _, dir := range f.state.moduleDirs {
+		f.summary.Modules = append(f.summary.Modules, ModuleSummary{Dir: dir})
+	}
+	if f.histogram {
+		f.summary.Durations = newDurationHistogram(f.histogramBounds)
+	}
+	for _, c := range f.state.configurations {
+		f.summary.Configurations = append(f.summary.Configurations, ConfigurationSummary{Name: c.Name})
+	}
+	if f.testLocations {
+		f.locator = newTestLocator(f.packages)
+	}
+	if f.packageDocs {
+		f.docs = newPackageDocs(f.packages)
+	}
+	if f.TestDoxer.progress && isTerminal(f.Stderr) {
+		f.progress = newProgressLine(f.Stderr, f.state.packageTotal, f.clock)
+		ticker := f.clock.NewTicker(progressInterval)
+		f.redraw = ticker.C()
+		f.closers = append(f.closers, func() error {
+			ticker.Stop()
+			f.progress.clear()
+			return nil
+		})
+	}
+	f.notify = f.resultHook(f.progress)
+	if f.stallAfter > 0 {
+		f.stall = newStallReport(f.Stderr, f.clock)
+		f.stallTick = f.clock.NewTicker(f.stallAfter)
+		f.stalled = f.stallTick.C()
+		f.closers = append(f.closers, func() error {
+			f.stallTick.Stop()
+			f.stall.clear()
+			return nil
+		})
+	}
+	if f.noisiestTests > 0 || f.largeOutput > 0 {
+		f.outputBytes = outputCounter{}
+	}
+	f.budget = &outputBudget{perTest: f.testOutputMax, perReport: f.reportOutputMax}
+	f.skipRules = append(append([]skipRule{}, f.TestDoxer.skipRules...), defaultSkipRules...)
+	return nil
+}
+
+// isExcluded reports whether the package result e is for a package whose
+// files are all excluded by build constraints.
+func (f *eventFilter) isExcluded(e Event) bool {
+	if e.Test != "" {
+		return false
+	}
+	key := e.key("")
+	return f.excluded[e.FailedBuild] || !f.testsStarted[key] && excludedByConstraints(f.output[key])
+}
+
+// event processes a single event, delivering the result it completes, if
+// any, to the sink. hasElapsed reports whether the event gave an elapsed
+// time at all (see [decodeEvent]).
+func (f *eventFilter) event(event Event, hasElapsed bool) error {
+	at := event.Time
+	if at.IsZero() {
+		at = f.clock.Now()
+	}
+	if f.firstEvent.IsZero() {
+		f.firstEvent = at
+	}
+	f.lastEvent = at
+	f.summary.WallTime = wallTime(f.firstEvent, f.lastEvent)
+	switch event.Kind() {
+	case ActionUnknown:
+		f.summary.UnknownActions++
+		if !f.unknownActions[event.Action] {
+			f.unknownActions[event.Action] = true
+			if f.debugLog {
+				fmt.Fprintf(DebugWriter, "gotestdox: ignoring events with unknown action %q\n", event.Action)
+			}
+		}
+		return nil
+	case ActionFail:
+		if !f.isExcluded(event) {
+			f.OK = false
+		}
+	case ActionBuildFail:
+		if excludedByConstraints(f.buildOutput[event.ImportPath]) {
+			f.excluded[event.ImportPath] = true
+			delete(f.buildOutput, event.ImportPath)
+			return nil
+		}
+		f.OK = false
+		if diagnostics := vetDiagnostics(f.buildOutput[event.ImportPath]); diagnostics != nil {
+			f.vetFailed[event.ImportPath] = diagnostics
+		} else {
+			f.summary.BuildFailed = true
+		}
+		delete(f.buildOutput, event.ImportPath)
+	case ActionBuildOutput:
+		f.buildOutput[event.ImportPath] = append(f.buildOutput[event.ImportPath], trimCR(event.Output))
+		return nil
+	}
+	key := event.key(event.Test)
+	if f.activeDurations {
+		f.active.record(key, event.Kind(), event.Time)
+	}
+	if f.stall != nil {
+		f.stall.record(event, event.Time)
+		resetTicker(f.stallTick, f.stallAfter)
+	}
+	if event.Test != "" {
+		f.testsStarted[event.key("")] = true
+	}
+	if event.Kind() == ActionOutput {
+		if f.outputBytes != nil {
+			f.outputBytes.record(event, key)
+		}
+		f.output[key] = append(f.output[key], trimCR(event.Output))
+		return nil
+	}
+	if event.Test == "" && event.Kind() == ActionSkip && event.Package != "" {
+		f.summary.NoTestFiles++
+	}
+	if event.IsPackageResult() {
+		return f.packageResult(event, key)
+	}
+	return f.testResult(event, key, hasElapsed)
+}
+
+// packageResult delivers the result completed by the package result event,
+// whose key is key, along with the package's output, and anything it tells
+// about the package, such as its vet diagnostics.
+func (f *eventFilter) packageResult(event Event, key string) error {
+	summary := &f.summary
+	result := event.Result()
+	result.TimedOut = f.state.timedOut.has(key)
+	if f.isExcluded(event) {
+		result.Status = "skip"
+		result.Excluded = true
+		summary.ExcludedPackages++
+	} else {
+		summary.Packages++
+		summary.Elapsed += event.Elapsed
+		summary.addModule(result)
+		summary.addConfiguration(result)
+	}
+	result.Output = f.output[key]
+	delete(f.output, key)
+	result.OutputBytes = f.outputBytes.finish(key)
+	summary.addOutputBytes(result)
+	if diagnostics, ok := f.vetFailed[event.FailedBuild]; ok {
+		result.Vet = diagnostics
+	} else if event.FailedBuild == "" && !f.testsStarted[key] && isBuildFailure(result) {
+		result.Vet = untestedDiagnostics(result.Output)
+	}
+	delete(f.testsStarted, key)
+	switch {
+	case result.Excluded:
+	case len(result.Vet) > 0:
+		summary.VetFailures++
+	case isBuildFailure(result):
+		summary.BuildFailed = true
+	}
+	if isCached(result) {
+		result.Cached = true
+		summary.CachedPackages++
+		summary.CachedPassed += f.passed[key]
+	}
+	delete(f.passed, key)
+	if isTeardownFailure(result, f.failedTests[key]) {
+		result.TeardownFailed = true
+		summary.TeardownFailures++
+	}
+	delete(f.failedTests, key)
+	if result.TimedOut {
+		summary.TimedOutPackages++
+	}
+	if f.docs != nil {
+		result.Doc = f.docs.synopsis(result.Package, result.Module)
+	}
+	if seed, ok := shuffleSeed(result.Output); ok {
+		if summary.ShuffleSeeds == nil {
+			summary.ShuffleSeeds = map[string]int64{}
+		}
+		summary.ShuffleSeeds[result.Package] = seed
+	}
+	if f.ignores(result) || result.Excluded && f.excludedHidden {
+		return nil
+	}
+	result.Output = f.budget.keep(result, summary)
+	if f.progress != nil {
+		f.progress.clear()
+	}
+	if err := f.sink.Result(result); err != nil {
+		return err
+	}
+	f.notify(result)
+	return nil
+}
+
+// testResult delivers the result completed by event, whose key is key, if
+// it completes a test or an example, and counts it in the summary, unless
+// it's ignored. If the test failed, with [WithFailFast], the run is
+// aborted.
+func (f *eventFilter) testResult(event Event, key string, hasElapsed bool) error {
+	summary := &f.summary
+	example := f.examples && event.completesExample()
+	if !event.Completed() && !example {
+		return nil
+	}
+	if example {
+		event.Sentence = exampleSentence(event.Test, f.config)
+	} else {
+		event.Sentence = prettify(event.Test, f.config)
+	}
+	result := event.Result()
+	result.TimedOut = f.state.timedOut.has(key)
+	result.Output = f.output[key]
+	delete(f.output, key)
+	result.OutputBytes = f.outputBytes.finish(key)
+	if f.activeDurations {
+		result.Active = result.Elapsed
+		if d, ok := f.active.finish(key, event.Time); ok {
+			result.Active = d.Seconds()
+		}
+	}
+	if f.locator != nil {
+		result.File, result.Line = f.locator.locate(result)
+	}
+	ignored := f.ignores(result)
+	counted := !ignored || !f.ignoreUncounted
+	if ignored {
+		summary.Ignored++
+	}
+	switch {
+	case result.Status == "fail":
+		f.failures[key]++
+	case result.Status == "pass" && f.failures[key] > 0:
+		result.Flaky = true
+		if counted {
+			summary.Flaky++
+			summary.FlakyFailures += f.failures[key]
+		}
+		delete(f.failures, key)
+	}
+	if result.Status == "fail" {
+		f.failedTests[event.key("")]++
+	}
+	if counted {
+		summary.add(result)
+		if result.Status == "pass" {
+			f.passed[event.key("")]++
+		}
+		if f.skipCategories && result.Status == "skip" {
+			if summary.SkipCategories == nil {
+				summary.SkipCategories = map[string]int{}
+			}
+			summary.SkipCategories[skipCategory(result, f.skipRules)]++
+		}
+		if f.histogram && result.Status != "skip" {
+			summary.Durations.add(result.Elapsed, hasElapsed)
+		}
+		summary.addModule(result)
+		summary.addConfiguration(result)
+	}
+	if !ignored {
+		result.Output = f.budget.keep(result, summary)
+	}
+	f.completed = append(f.completed, result)
+	parent, parts := SplitSubtests(event.Test)
+	if len(parts) > 0 {
+		f.hasSubtests[event.key(parent)] = true
+	}
+	if !ignored {
+		if len(parts) == 0 && f.noCasesCheck && !example && !f.hasSubtests[key] && ranNoCases(result, f.state.previous, f.noCasesGuess) {
+			summary.NoCases = append(summary.NoCases, result)
+			if f.noCasesFail {
+				f.OK = false
+			}
+		}
+		if event.Relevant() {
+			f.all = append(f.all, result)
+		}
+		f.notify(result)
+		if err := f.sink.Result(result); err != nil {
+			return err
+		}
+	}
+	if f.failFast && result.Status == "fail" {
+		summary.Aborted = true
+		if f.state.abort != nil {
+			f.state.abort()
+		}
+	}
+	return nil
+}
+
+// finish completes the summary, at the end of the stream, with the checks
+// run over all the results, and delivers it to the sink, before recording
+// the results in the history, if required.
+func (f *eventFilter) finish() error {
+	summary := &f.summary
+	if f.vagueCheck {
+		f.VagueNames = findVagueNames(f.all, f.vagueMinWords)
+		locateVagueNames(f.VagueNames, f.packages)
+		summary.VagueNames = f.VagueNames
+	}
+	if f.scoreCheck {
+		weights := DefaultScoreWeights()
+		if f.scoreWeights != nil {
+			weights = *f.scoreWeights
+		}
+		summary.AverageScore, summary.LowestScores = scoreNames(f.all, weights, f.initialisms, f.scoreLowest)
+		locateScoredNames(summary.LowestScores, f.packages)
+	}
+	if summary.Durations != nil {
+		summary.Durations.finish()
+	}
+	if path := f.coverProfilePath(f.state.goTestArgs); path != "" {
+		coverage, err := readCoverProfile(path, f.all, f.packages)
+		if err != nil {
+			fmt.Fprintf(f.Stderr, "gotestdox: ignoring cover profile: %v\n", err)
+		}
+		summary.Coverage = coverage
+	}
+	for i, m := range summary.Modules {
+		summary.Modules[i].NoTests = m.Packages == 0 && m.Passed+m.Failed+m.Skipped == 0
+	}
+	if f.state.childStderr != nil && f.captureStderr {
+		<-f.state.childStderr.done
+		summary.Stderr = f.state.childStderr.lines
+	}
+	if f.failureGroups || f.noisiestTests > 0 {
+		reported := []Result{}
+		for _, r := range f.completed {
+			if !f.ignores(r) {
+				reported = append(reported, r)
+			}
+		}
+		if f.failureGroups {
+			summary.FailureGroups = groupFailures(reported)
+		}
+		if f.noisiestTests > 0 {
+			summary.NoisiestTests = noisiestTests(reported, f.noisiestTests)
+		}
+	}
+	if f.thresholds != nil {
+		summary.ThresholdViolations = f.thresholds.check(*summary)
+	}
+	if summary.Interrupted = f.state.interruption.signal(); summary.Interrupted != "" {
+		f.OK = false
+	}
+	if f.progress != nil {
+		f.progress.clear()
+	}
+	if err := f.sink.Summary(*summary); err != nil {
+		return err
+	}
+	if f.recording != nil {
+		if err := f.recording.finish(f.completed); err != nil {
+			return err
+		}
+	}
+	if f.historyUpdate {
+		f.state.previous.record(f.completed)
+		if err := f.state.previous.save(f.historyPath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
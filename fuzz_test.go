@@ -18,15 +18,41 @@ func FuzzPrettify(f *testing.F) {
 		if len(input) > 0 && unicode.IsLower([]rune(input)[0]) {
 			t.Skip()
 		}
+		if strings.IndexFunc(input, unicode.IsSpace) >= 0 {
+			// test names never contain spaces
+			t.Skip()
+		}
 		got := gotestdox.Prettify(input)
 		if got == "" {
 			t.Skip()
 		}
-		if strings.ContainsRune(got, '_') {
-			t.Errorf("%q: contains underscore %q", input, got)
+		for _, word := range strings.Fields(got) {
+			if strings.ContainsRune(word, '_') && !isConstantStyle(word) && !strings.ContainsAny(word, "([") {
+				t.Errorf("%q: contains underscore %q", input, got)
+			}
+		}
+		if strings.IndexFunc(got, func(r rune) bool { return r < ' ' }) >= 0 {
+			t.Errorf("%q: contains control characters %q", input, got)
 		}
 		if strings.ContainsRune(got, '/') {
 			t.Errorf("%q: contains slash %q", input, got)
 		}
+		if strings.TrimSpace(got) != got || strings.Contains(got, "  ") {
+			t.Errorf("%q: contains leading, trailing, or doubled spaces %q", input, got)
+		}
 	})
 }
+
+// isConstantStyle reports whether word is an identifier such as MAX_RETRIES,
+// which Prettify leaves as it is.
+func isConstantStyle(word string) bool {
+	if strings.HasPrefix(word, "_") || strings.HasSuffix(word, "_") {
+		return false
+	}
+	for _, r := range word {
+		if !unicode.IsUpper(r) && !unicode.IsDigit(r) && r != '_' {
+			return false
+		}
+	}
+	return true
+}
@@ -7,11 +7,12 @@ require (
 	github.com/google/go-cmp v0.5.9
 	github.com/mattn/go-isatty v0.0.17
 	github.com/rogpeppe/go-internal v1.9.0
+	go.uber.org/goleak v1.2.1
+	golang.org/x/sys v0.4.0
 	golang.org/x/text v0.6.0
 )
 
 require (
 	github.com/mattn/go-colorable v0.1.13 // indirect
 	github.com/pkg/diff v0.0.0-20210226163009-20ebb0f2a09e // indirect
-	golang.org/x/sys v0.4.0 // indirect
 )
@@ -2,16 +2,16 @@ package gotestdox
 
 import (
 	"bufio"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"math"
 	"os"
 	"os/exec"
-	"sort"
 	"strings"
-
-	"github.com/fatih/color"
-	"github.com/mattn/go-isatty"
+	"sync"
+	"time"
 )
 
 // TestDoxer holds the state and config associated with a particular invocation
@@ -20,15 +20,27 @@ type TestDoxer struct {
 	Stdin          io.Reader
 	Stdout, Stderr io.Writer
 	OK             bool
+	// VagueNames lists the tests reported by the vague name check, if it is
+	// enabled (see [WithVagueNameCheck]).
+	VagueNames []VagueName
+	// Summary is the summary of the last run, as delivered to the sink, or
+	// as far as it got, if the run was stopped by an error.
+	Summary Summary
+	// err is the error that stopped the last run, if any, and exitErr the
+	// error from the 'go test' command, if it failed (see
+	// [TestDoxer.Err]).
+	err, exitErr error
+	config
 }
 
 // NewTestDoxer returns a [*TestDoxer] configured with the default I/O streams:
-// [os.Stdin], [os.Stdout], and [os.Stderr].
-func NewTestDoxer() *TestDoxer {
+// [os.Stdin], [os.Stdout], and [os.Stderr], and with any supplied options.
+func NewTestDoxer(opts ...Option) *TestDoxer {
 	return &TestDoxer{
 		Stdin:  os.Stdin,
 		Stdout: os.Stdout,
 		Stderr: os.Stderr,
+		config: newConfig(opts),
 	}
 }
 
@@ -37,24 +49,83 @@ func NewTestDoxer() *TestDoxer {
 // stream, including the full command line that was run. If all tests passed,
 // td.OK will be true. If there was a test failure, or 'go test' returned some
 // error, then td.OK will be false.
+//
+// Anything 'go test' writes to its standard error is forwarded to td.Stderr
+// a line at a time, and never in the middle of a line of the report, even if
+// td.Stdout and td.Stderr are the same terminal, unless [WithCapturedStderr]
+// was supplied. This only applies to the report printed by the default
+// [TextSink], since a sink supplied using [WithSink] may write anywhere.
+//
+// If gotestdox receives SIGINT, as when ctrl-C is pressed, or SIGTERM, the
+// signal is passed on to 'go test', and to the test binaries it started,
+// and the results reported so far are printed, including those for any
+// package that hadn't finished, followed by a note that the run was
+// interrupted. If 'go test' hasn't stopped within a few seconds, it's
+// killed. A second signal kills it immediately; when gotestdox is run as a
+// command (see [Run]), it also makes gotestdox exit, without printing
+// anything further. See [WithSignalHandlingDisabled] to turn this off.
 func (td *TestDoxer) ExecGoTest(userArgs []string) {
-	args := []string{"test", "-json"}
-	args = append(args, userArgs...)
+	td.execGoTest(context.Background(), userArgs)
+}
+
+// execGoTest does the work of [TestDoxer.ExecGoTest], killing 'go test' if
+// parent is cancelled, in which case the run is reported as interrupted.
+func (td *TestDoxer) execGoTest(parent context.Context, userArgs []string) {
+	first, rest := splitDirFlag(userArgs)
+	args := append([]string{"test"}, first...)
+	args = append(append(args, "-json"), rest...)
+	state := td.startRun(userArgs)
+	if td.progress && isTerminal(td.Stderr) {
+		state.packageTotal = countPackages(parent, userArgs)
+	}
+	ctx, cancel := context.WithCancel(parent)
+	defer cancel()
+	state.abort = cancel
 	cmd := exec.Command("go", args...)
 	goTestOutput, err := cmd.StdoutPipe()
 	if err != nil {
+		td.err = err
 		fmt.Fprintln(td.Stderr, cmd.Args, err)
 		return
 	}
-	cmd.Stderr = td.Stderr
+	goTestStderr, err := cmd.StderrPipe()
+	if err != nil {
+		td.err = err
+		fmt.Fprintln(td.Stderr, cmd.Args, err)
+		return
+	}
+	var mu sync.Mutex
+	stdout, stderr := td.Stdout, td.Stderr
+	td.Stdout, td.Stderr = lockedWriter{&mu, stdout}, lockedWriter{&mu, stderr}
+	defer func() {
+		td.Stdout, td.Stderr = stdout, stderr
+	}()
+	if !td.signalsDisabled {
+		setProcessGroup(cmd)
+	}
 	if err := cmd.Start(); err != nil {
+		td.err = err
 		fmt.Fprintln(td.Stderr, cmd.Args, err)
 		return
 	}
+	defer killOnCancel(ctx, cmd, !td.signalsDisabled)()
+	defer td.catchSignals(state, newProcessGroups(cmd))()
+	state.childStderr = td.readStderr(goTestStderr, &mu, stderr)
 	td.Stdin = goTestOutput
-	td.Filter()
-	if err := cmd.Wait(); err != nil {
+	if err := td.filter(parent, state); err != nil {
 		td.OK = false
+		td.err = err
+		fmt.Fprintln(td.Stderr, err)
+	}
+	<-state.childStderr.done
+	if err := cmd.Wait(); err != nil && ctx.Err() == nil && td.Summary.Interrupted == "" && !td.Summary.onlyExcluded() {
+		td.OK = false
+		td.exitErr = err
+		if td.Summary.Failed == 0 && td.Summary.TeardownFailures == 0 && td.Summary.VetFailures == 0 {
+			// 'go test' failed without any test or package failing,
+			// which means the tests couldn't be built or run.
+			td.Summary.BuildFailed = true
+		}
 		fmt.Fprintln(td.Stderr, cmd.Args, err)
 		return
 	}
@@ -65,56 +136,132 @@ func (td *TestDoxer) ExecGoTest(userArgs []string) {
 //
 // For each Go package it sees records about, it will print the full name of
 // the package to td.Stdout, followed by a line giving the pass/fail status and
-// the prettified name of each test, sorted alphabetically.
+// the prettified name of each test, sorted alphabetically. If the package
+// failed even though all its tests passed, as when TestMain or a goroutine
+// leak check fails it, this is shown after the tests, "package failed in
+// TestMain / teardown", followed by the last lines of the package's output.
 //
 // If all tests passed, td.OK will be true at the end. If not, or if there was
-// a parsing error, it will be false. Errors will be reported to td.Stderr.
+// a parsing error, it will be false. Errors will be reported to td.Stderr,
+// and [TestDoxer.Err] describes the outcome.
+//
+// If the vague name check is enabled (see [WithVagueNameCheck]), the tests it
+// finds are listed after all the packages, and stored in td.VagueNames.
+//
+// The printing is done by a [TextSink]. If a different [ResultSink] was
+// supplied using [WithSink], the results are delivered to that instead, and
+// nothing is printed.
 func (td *TestDoxer) Filter() {
-	td.OK = true
-	results := map[string][]Event{}
-	scanner := bufio.NewScanner(td.Stdin)
-	for scanner.Scan() {
-		event, err := ParseJSON(scanner.Text())
-		if err != nil {
-			td.OK = false
-			fmt.Fprintln(td.Stderr, err)
-			return
-		}
-		if event.Action == "fail" {
-			td.OK = false
-		}
-		if event.IsPackageResult() {
-			fmt.Fprintf(td.Stdout, "%s:\n", event.Package)
-			tests := results[event.Package]
-			sort.Slice(tests, func(i, j int) bool {
-				return tests[i].Sentence < tests[j].Sentence
-			})
-			for _, r := range tests {
-				fmt.Fprintln(td.Stdout, r.String())
+	td.filterRun(td.startRun(nil))
+}
+
+// filterRun does the work of [TestDoxer.Filter], for the run with the given
+// state.
+func (td *TestDoxer) filterRun(state *runState) {
+	err := td.filter(context.Background(), state)
+	td.err = err
+	if err != nil {
+		td.OK = false
+		fmt.Fprintln(td.Stderr, err)
+	}
+}
+
+// FilterContext is like [TestDoxer.Filter], but reads from in and writes to
+// out, configured by opts, and returns any error instead of reporting it. It
+// returns nil once the whole stream has been processed, even if some tests
+// failed; use [WithOnResult] to find out about individual results.
+//
+// If the stream contains a line that isn't valid JSON, the error is a
+// [*StreamError], matching [ErrBadStream], and if the stream can't be read,
+// for example because a line is too long, the error from reading it is
+// returned. If ctx is cancelled before the end of the stream, FilterContext
+// returns an error matching both [ErrInterrupted] and ctx.Err() promptly,
+// without waiting for the current read to complete. To make sure no
+// goroutine is left blocked on that read, in is closed on cancellation if it
+// implements [io.Closer].
+func FilterContext(ctx context.Context, in io.Reader, out io.Writer, opts ...Option) error {
+	td := NewTestDoxer(opts...)
+	td.Stdin = in
+	td.Stdout = out
+	td.Stderr = io.Discard
+	return td.filter(ctx, &runState{})
+}
+
+// wallTime returns the time from first to last in seconds, rounded to
+// hundredths of a second, as 'go test' rounds Elapsed.
+func wallTime(first, last time.Time) float64 {
+	return math.Round(last.Sub(first).Seconds()*100) / 100
+}
+
+// trimCR converts a Windows-style CRLF line ending in s, as sometimes
+// introduced by tools that the output of 'go test -json' is piped through,
+// into a plain LF, and removes any other trailing carriage return.
+func trimCR(s string) string {
+	if strings.HasSuffix(s, "\r\n") {
+		return strings.TrimSuffix(s, "\r\n") + "\n"
+	}
+	return strings.TrimSuffix(s, "\r")
+}
+
+// readLines starts a goroutine that reads lines from td.Stdin and sends them
+// on the returned lines channel, which is closed at the end of the input.
+// Closing the returned done channel tells the goroutine to stop sending. If
+// reading fails, as for a line too long to scan, the error is available on
+// the returned errs channel once lines is closed.
+func (td *TestDoxer) readLines() (lines <-chan string, errs <-chan error, done chan<- struct{}) {
+	l := make(chan string)
+	e := make(chan error, 1)
+	d := make(chan struct{})
+	go func() {
+		defer close(l)
+		scanner := bufio.NewScanner(td.Stdin)
+		for scanner.Scan() {
+			select {
+			case l <- scanner.Text():
+			case <-d:
+				return
 			}
-			fmt.Fprintln(td.Stdout)
-		}
-		if event.Relevant() {
-			event.Sentence = Prettify(event.Test)
-			results[event.Package] = append(results[event.Package], event)
 		}
-	}
+		e <- scanner.Err()
+	}()
+	return l, e, d
 }
 
 // Event represents a Go test event as recorded by the 'go test -json' command.
 // It does not attempt to unmarshal all the data, only those fields it needs to
-// know about. It is based on the (unexported) 'event' struct used by Go's
-// [cmd/internal/test2json] package.
+// know about. Time is the time the event was recorded, which is zero if the
+// input didn't say. ImportPath identifies the package being built, for build
+// output, and FailedBuild the build that failed, for the result of a package
+// that couldn't be built. Module and Configuration are not part of the 'go
+// test' output, but are added to events by [TestDoxer.ExecGoTestModules] and
+// [TestDoxer.ExecGoTestConfigurations] respectively. It is based on the
+// (unexported) 'event' struct used by Go's [cmd/internal/test2json] package.
 type Event struct {
-	Action   string
-	Package  string
-	Test     string
-	Sentence string
-	Elapsed  float64
+	Time          time.Time
+	Action        string
+	Package       string
+	Test          string
+	Sentence      string
+	Elapsed       float64
+	Output        string
+	Module        string
+	Configuration string
+	ImportPath    string
+	FailedBuild   string
+}
+
+// key returns a string identifying the given test in the same package, and
+// the same configuration, if any, as e.
+func (e Event) key(test string) string {
+	if e.Configuration != "" {
+		return e.Configuration + " " + e.Package + " " + test
+	}
+	return e.Package + " " + test
 }
 
 // String formats a test Event for display. The prettified test name will be
-// prefixed by a ✔ if the test passed, or an x if it failed.
+// prefixed by a ✔ if the test passed, a ○ if it was skipped, or an x if it
+// failed.
 //
 // The sentence generated by [Prettify] from the name of the test will be
 // shown, followed by the elapsed time in parentheses, to 2 decimal places.
@@ -123,25 +270,51 @@ type Event struct {
 //
 // If the program is attached to an interactive terminal, as determined by
 // [github.com/mattn/go-isatty], and the NO_COLOR environment variable is not
-// set, check marks will be shown in green and x's in red.
+// set, check marks will be shown in green, circles in yellow, and x's in red.
 func (e Event) String() string {
-	status := color.RedString("x")
-	if e.Action == "pass" {
-		status = color.GreenString("✔")
-	}
-	return fmt.Sprintf(" %s %s (%.2fs)", status, e.Sentence, e.Elapsed)
+	return e.Result().String()
 }
 
 // Relevant determines whether or not the test event is one that we are
 // interested in (namely, a pass or fail event on a test). Events on non-tests
 // (for example, examples) are ignored, and all events on tests other than pass
-// or fail events (for example, run or pause events) are also ignored.
+// or fail events (for example, run or pause events) are also ignored. Whether
+// or not the event is on a test is determined by [Classify].
 func (e Event) Relevant() bool {
 	// Events on non-tests are irrelevant
-	if !strings.HasPrefix(e.Test, "Test") {
+	if Classify(e.Test) != Test {
 		return false
 	}
-	if e.Action == "pass" || e.Action == "fail" {
+	switch e.Kind() {
+	case ActionPass, ActionFail:
+		return true
+	}
+	return false
+}
+
+// Completed determines whether or not the test event is one that completes a
+// test: that is, a pass, fail, or skip event on a test.
+func (e Event) Completed() bool {
+	if Classify(e.Test) != Test {
+		return false
+	}
+	return e.completes()
+}
+
+// completesExample reports whether e completes an example function: that is,
+// whether it's a pass, fail, or skip event on an example.
+func (e Event) completesExample() bool {
+	if Classify(e.Test) != Example {
+		return false
+	}
+	return e.completes()
+}
+
+// completes reports whether e is a pass, fail, or skip event, which
+// completes whatever it's about.
+func (e Event) completes() bool {
+	switch e.Kind() {
+	case ActionPass, ActionFail, ActionSkip:
 		return true
 	}
 	return false
@@ -154,12 +327,31 @@ func (e Event) IsPackageResult() bool {
 	if e.Test != "" {
 		return false
 	}
-	if e.Action == "pass" || e.Action == "fail" {
+	switch e.Kind() {
+	case ActionPass, ActionFail:
 		return true
 	}
 	return false
 }
 
+// decodeEvent decodes line, a line of 'go test -json' output, into an
+// [Event], reporting also whether it gave an elapsed time at all, since
+// Elapsed is zero both for a test that took no measurable time and for one
+// whose time wasn't reported.
+func decodeEvent(line []byte) (Event, bool, error) {
+	var e struct {
+		Event
+		Elapsed *float64
+	}
+	if err := json.Unmarshal(line, &e); err != nil {
+		return Event{}, false, err
+	}
+	if e.Elapsed != nil {
+		e.Event.Elapsed = *e.Elapsed
+	}
+	return e.Event, e.Elapsed != nil, nil
+}
+
 // ParseJSON takes a string representing a single JSON test record as emitted
 // by 'go test -json', and attempts to parse it into an [Event], returning any
 // parsing error encountered.
@@ -172,18 +364,12 @@ func ParseJSON(line string) (Event, error) {
 	return event, nil
 }
 
-// Main runs the command-line interface for gotestdox. The exit status for the
-// binary is 0 if the tests passed, or 1 if the tests failed, or there was some
+// Main runs the command-line interface for gotestdox, using the program's
+// arguments and standard streams, as described for [Run]. The exit status for
+// the binary is as described for [ExitCode]: 0 if the tests passed, 1 if the
+// tests failed, 2 if they couldn't be built, or 3 if there was some other
 // error.
 func Main() int {
-	td := NewTestDoxer()
-	if isatty.IsTerminal(os.Stdin.Fd()) {
-		td.ExecGoTest(os.Args[1:])
-	} else {
-		td.Filter()
-	}
-	if !td.OK {
-		return 1
-	}
-	return 0
+	enableColor()
+	return Run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr)
 }
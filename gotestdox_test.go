@@ -1,17 +1,23 @@
 package gotestdox_test
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"os"
+	"os/exec"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/bitfield/gotestdox"
+	"github.com/bitfield/gotestdox/clocktest"
 	"github.com/fatih/color"
 	"github.com/google/go-cmp/cmp"
 	"github.com/rogpeppe/go-internal/testscript"
+	"go.uber.org/goleak"
 )
 
 func TestMain(m *testing.M) {
@@ -22,8 +28,18 @@ func TestMain(m *testing.M) {
 
 func TestGotestdoxProducesCorrectOutputWhen(t *testing.T) {
 	t.Parallel()
+	// Share the build cache with scripts that run 'go test', since
+	// testscript leaves HOME unset.
+	gocache, err := exec.Command("go", "env", "GOCACHE").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
 	testscript.Run(t, testscript.Params{
 		Dir: "testdata/script",
+		Setup: func(env *testscript.Env) error {
+			env.Setenv("GOCACHE", strings.TrimSpace(string(gocache)))
+			return nil
+		},
 	})
 }
 
@@ -31,6 +47,7 @@ func TestParseJSON_ReturnsValidDataForValidJSON(t *testing.T) {
 	t.Parallel()
 	input := `{"Time":"2022-02-28T15:53:43.532326Z","Action":"pass","Package":"github.com/bitfield/script","Test":"TestFindFilesInNonexistentPathReturnsError","Elapsed":0.12}`
 	want := gotestdox.Event{
+		Time:    time.Date(2022, 2, 28, 15, 53, 43, 532326000, time.UTC),
 		Action:  "pass",
 		Package: "github.com/bitfield/script",
 		Test:    "TestFindFilesInNonexistentPathReturnsError",
@@ -113,6 +130,10 @@ func TestRelevantIsFalseForNonTestPassFailEvents(t *testing.T) {
 			Action: "run",
 			Test:   "TestFooDoesX",
 		},
+		{
+			Action: "pass",
+			Test:   "Testable",
+		},
 	}
 	for _, event := range tcs {
 		relevant := event.Relevant()
@@ -241,5 +262,393 @@ func ExampleParseJSON() {
 	}
 	fmt.Printf("%#v\n", event)
 	// Output:
-	// gotestdox.Event{Action:"pass", Package:"demo", Test:"TestItWorks", Sentence:"", Elapsed:0.2}
+	// gotestdox.Event{Time:time.Date(1, time.January, 1, 0, 0, 0, 0, time.UTC), Action:"pass", Package:"demo", Test:"TestItWorks", Sentence:"", Elapsed:0.2, Output:"", Module:"", Configuration:"", ImportPath:"", FailedBuild:""}
+}
+
+func TestFilter_WithVagueNameCheckReportsNamesWithShortBehaviourClauses(t *testing.T) {
+	t.Parallel()
+	input := `{"Action":"pass","Package":"p","Test":"TestParse"}
+{"Action":"pass","Package":"p","Test":"TestParseReturnsErrorOnEmptyInput"}
+{"Action":"pass","Package":"p","Test":"TestLoad/handles_empty_input"}
+{"Action":"pass","Package":"p","Test":"TestLoad/ok"}
+{"Action":"pass","Package":"p","Test":"TestLoad"}
+{"Action":"pass","Package":"p","Test":"TestNewWorks"}
+{"Action":"pass","Package":"p"}`
+	buf := new(strings.Builder)
+	td := gotestdox.NewTestDoxer(gotestdox.WithVagueNameCheck(2))
+	td.Stdin = strings.NewReader(input)
+	td.Stdout = buf
+	td.Filter()
+	want := []gotestdox.VagueName{
+		{Package: "p", Test: "TestLoad/ok"},
+		{Package: "p", Test: "TestNewWorks"},
+		{Package: "p", Test: "TestParse"},
+	}
+	if !cmp.Equal(want, td.VagueNames) {
+		t.Error(cmp.Diff(want, td.VagueNames))
+	}
+	if !strings.Contains(buf.String(), "Vague test names (fewer than 2 behaviour words): 3\n") {
+		t.Errorf("count not reported in output:\n%s", buf)
+	}
+}
+
+func TestFilter_WithVagueNameCheckReportsFileAndLineWhenResolvable(t *testing.T) {
+	t.Parallel()
+	input := `{"Action":"pass","Package":"github.com/bitfield/gotestdox","Test":"TestParseJSON_ErrorsOnInvalidJSON"}
+{"Action":"pass","Package":"github.com/bitfield/gotestdox"}`
+	td := gotestdox.NewTestDoxer(gotestdox.WithVagueNameCheck(10))
+	td.Stdin = strings.NewReader(input)
+	td.Stdout = io.Discard
+	td.Filter()
+	if len(td.VagueNames) != 1 {
+		t.Fatalf("want 1 vague name, got %#v", td.VagueNames)
+	}
+	got := td.VagueNames[0]
+	if got.File != "gotestdox_test.go" || got.Line == 0 {
+		t.Errorf("want location in gotestdox_test.go, got %s:%d", got.File, got.Line)
+	}
+}
+
+func TestFilter_DoesNotReportVagueNamesByDefault(t *testing.T) {
+	t.Parallel()
+	input := `{"Action":"pass","Package":"p","Test":"TestParse"}
+{"Action":"pass","Package":"p"}`
+	buf := new(strings.Builder)
+	td := gotestdox.NewTestDoxer()
+	td.Stdin = strings.NewReader(input)
+	td.Stdout = buf
+	td.Filter()
+	if strings.Contains(buf.String(), "Vague") {
+		t.Errorf("unexpected vague name report:\n%s", buf)
+	}
+}
+
+func TestFilter_WithCollapsedNumericSubtestsAggregatesNumericSiblings(t *testing.T) {
+	t.Parallel()
+	input := `{"Action":"pass","Package":"p","Test":"TestSum/0"}
+{"Action":"pass","Package":"p","Test":"TestSum/1"}
+{"Action":"pass","Package":"p","Test":"TestSum/2"}
+{"Action":"pass","Package":"p","Test":"TestSum","Elapsed":0.01}
+{"Action":"fail","Package":"p","Test":"TestDiv/0"}
+{"Action":"pass","Package":"p","Test":"TestDiv/1"}
+{"Action":"fail","Package":"p","Test":"TestDiv"}
+{"Action":"pass","Package":"p","Test":"TestMul/0"}
+{"Action":"pass","Package":"p","Test":"TestMul/by_zero"}
+{"Action":"pass","Package":"p","Test":"TestMul"}
+{"Action":"fail","Package":"p"}`
+	buf := new(strings.Builder)
+	td := gotestdox.NewTestDoxer(gotestdox.WithCollapsedNumericSubtests())
+	td.Stdin = strings.NewReader(input)
+	td.Stdout = buf
+	color.NoColor = true
+	td.Filter()
+	want := `p:
+ x Div (2 cases, 1 failed) (0.00s)
+ ✔ Mul 0 (0.00s)
+ ✔ Mul by zero (0.00s)
+ ✔ Sum (3 cases, all passed) (0.01s)
+
+`
+	if want != buf.String() {
+		t.Error(cmp.Diff(want, buf.String()))
+	}
+}
+
+func TestFilter_WithFanOutThresholdSummarisesLargeNumbersOfSubtests(t *testing.T) {
+	t.Parallel()
+	input := `{"Action":"pass","Package":"p","Test":"TestParse/a"}
+{"Action":"fail","Package":"p","Test":"TestParse/b"}
+{"Action":"pass","Package":"p","Test":"TestParse/c"}
+{"Action":"skip","Package":"p","Test":"TestParse/d"}
+{"Action":"pass","Package":"p","Test":"TestParse/e"}
+{"Action":"fail","Package":"p","Test":"TestParse","Elapsed":1.2}
+{"Action":"pass","Package":"p","Test":"TestSmall/a"}
+{"Action":"skip","Package":"p","Test":"TestSmall/b"}
+{"Action":"pass","Package":"p","Test":"TestSmall"}
+{"Action":"fail","Package":"p"}`
+	tcs := []struct {
+		name string
+		opts []gotestdox.Option
+		want string
+	}{
+		{
+			name: "counting skips",
+			opts: []gotestdox.Option{gotestdox.WithFanOutThreshold(3)},
+			want: `p:
+ x Parse — 3/5 passed, 1 skipped (1.20s)
+   x Parse b (0.00s)
+ ✔ Small a (0.00s)
+
+`,
+		},
+		{
+			name: "listing skips",
+			opts: []gotestdox.Option{gotestdox.WithFanOutThreshold(3), gotestdox.WithFanOutSkipsListed()},
+			want: `p:
+ x Parse — 3/5 passed (1.20s)
+   x Parse b (0.00s)
+   ○ Parse d (0.00s)
+ ✔ Small a (0.00s)
+
+`,
+		},
+	}
+	color.NoColor = true
+	for _, tc := range tcs {
+		buf := new(strings.Builder)
+		td := gotestdox.NewTestDoxer(tc.opts...)
+		td.Stdin = strings.NewReader(input)
+		td.Stdout = buf
+		td.Filter()
+		if tc.want != buf.String() {
+			t.Errorf("%s: %s", tc.name, cmp.Diff(tc.want, buf.String()))
+		}
+	}
+}
+
+func TestFilter_WithFanOutThresholdFormatsLargeCountsWithThousandsSeparators(t *testing.T) {
+	t.Parallel()
+	input := new(strings.Builder)
+	for i := 0; i < 1200; i++ {
+		fmt.Fprintf(input, `{"Action":"pass","Package":"p","Test":"TestParse/%d"}`+"\n", i)
+	}
+	input.WriteString(`{"Action":"pass","Package":"p","Test":"TestParse"}` + "\n")
+	input.WriteString(`{"Action":"pass","Package":"p"}`)
+	buf := new(strings.Builder)
+	td := gotestdox.NewTestDoxer(gotestdox.WithFanOutThreshold(50))
+	td.Stdin = strings.NewReader(input.String())
+	td.Stdout = buf
+	td.Filter()
+	if !strings.Contains(buf.String(), "Parse — 1,200/1,200 passed") {
+		t.Errorf("want summary line, got:\n%s", buf)
+	}
+}
+
+func TestFilterContext_CallsOnResultForEachCompletedTest(t *testing.T) {
+	t.Parallel()
+	input := `{"Action":"run","Package":"p","Test":"TestA"}
+{"Action":"pass","Package":"p","Test":"TestA","Elapsed":0.1}
+{"Action":"fail","Package":"p","Test":"TestB/fails_cleanly"}
+{"Action":"fail","Package":"p","Test":"TestB"}
+{"Action":"fail","Package":"p"}`
+	var got []gotestdox.Result
+	err := gotestdox.FilterContext(context.Background(), strings.NewReader(input), io.Discard,
+		gotestdox.WithOnResult(func(r gotestdox.Result) {
+			got = append(got, r)
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []gotestdox.Result{
+		{Package: "p", Test: "TestA", Sentence: "A", Status: "pass", Elapsed: 0.1},
+		{Package: "p", Test: "TestB/fails_cleanly", Sentence: "B fails cleanly", Status: "fail"},
+		{Package: "p", Test: "TestB", Sentence: "B", Status: "fail"},
+	}
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestFilterContext_ReturnsErrorForInvalidJSON(t *testing.T) {
+	t.Parallel()
+	err := gotestdox.FilterContext(context.Background(), strings.NewReader("bogus"), io.Discard)
+	if err == nil {
+		t.Error("want error")
+	}
+}
+
+func TestFilterContext_ReturnsPromptlyWithoutLeakingGoroutinesWhenCancelled(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+	r, w := io.Pipe()
+	defer w.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	gotFirst := make(chan struct{})
+	errs := make(chan error)
+	go func() {
+		errs <- gotestdox.FilterContext(ctx, r, io.Discard,
+			gotestdox.WithOnResult(func(gotestdox.Result) {
+				close(gotFirst)
+			}),
+		)
+	}()
+	go fmt.Fprintln(w, `{"Action":"pass","Package":"p","Test":"TestA"}`)
+	<-gotFirst
+	cancel()
+	select {
+	case err := <-errs:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("want context.Canceled, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("FilterContext did not return after cancellation")
+	}
+}
+
+func TestFilterContext_TrimsCarriageReturnsFromCRLFInput(t *testing.T) {
+	t.Parallel()
+	f, err := os.Open("testdata/crlf_failure.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var got []gotestdox.Result
+	err = gotestdox.FilterContext(context.Background(), f, io.Discard,
+		gotestdox.WithOnResult(func(r gotestdox.Result) {
+			got = append(got, r)
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []gotestdox.Result{{
+		Package:  "dummy",
+		Test:     "TestDummy",
+		Sentence: "Dummy",
+		Status:   "fail",
+		Output: []string{
+			"=== RUN   TestDummy\n",
+			"    dummy_test.go:8: oh no\n",
+			"--- FAIL: TestDummy (0.00s)\n",
+		},
+	}}
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestFilter_WithActiveDurationsExcludesTimeSpentPaused(t *testing.T) {
+	t.Parallel()
+	color.NoColor = true
+	input := `{"Time":"2023-01-01T00:00:00Z","Action":"run","Package":"p","Test":"TestA"}
+{"Time":"2023-01-01T00:00:00.1Z","Action":"pause","Package":"p","Test":"TestA"}
+{"Time":"2023-01-01T00:00:00Z","Action":"run","Package":"p","Test":"TestB"}
+{"Time":"2023-01-01T00:00:00.2Z","Action":"pause","Package":"p","Test":"TestB"}
+{"Time":"2023-01-01T00:00:01Z","Action":"cont","Package":"p","Test":"TestA"}
+{"Time":"2023-01-01T00:00:01.2Z","Action":"pass","Package":"p","Test":"TestA","Elapsed":1.2}
+{"Time":"2023-01-01T00:00:01.3Z","Action":"cont","Package":"p","Test":"TestB"}
+{"Time":"2023-01-01T00:00:01.2Z","Action":"pause","Package":"p","Test":"TestB"}
+{"Time":"2023-01-01T00:00:01.8Z","Action":"pass","Package":"p","Test":"TestB","Elapsed":1.8}
+{"Action":"pass","Package":"p","Test":"TestC","Elapsed":0.5}
+{"Time":"2023-01-01T00:00:02Z","Action":"pass","Package":"p","Elapsed":2}`
+	var got []gotestdox.Result
+	buf := new(strings.Builder)
+	err := gotestdox.FilterContext(context.Background(), strings.NewReader(input), buf,
+		gotestdox.WithActiveDurations(),
+		gotestdox.WithOnResult(func(r gotestdox.Result) {
+			got = append(got, r)
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]float64{"TestA": 0.3, "TestB": 0.2, "TestC": 0.5}
+	for _, r := range got {
+		if diff := r.Active - want[r.Test]; diff > 1e-9 || diff < -1e-9 {
+			t.Errorf("%s: want active %v, got %v", r.Test, want[r.Test], r.Active)
+		}
+	}
+	wantText := `p:
+ ✔ A (0.30s)
+ ✔ B (0.20s)
+ ✔ C (0.50s)
+
+`
+	if wantText != buf.String() {
+		t.Error(cmp.Diff(wantText, buf.String()))
+	}
+}
+
+func TestFilter_WithStallReportListsRunningTestsWhenNoEventsArrive(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+	clock := clocktest.New(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	r, w := io.Pipe()
+	reports := make(reportWriter, 10)
+	td := gotestdox.NewTestDoxer(gotestdox.WithStallReport(50*time.Millisecond), gotestdox.WithClock(clock))
+	td.Stdin = r
+	td.Stdout = io.Discard
+	td.Stderr = reports
+	done := make(chan struct{})
+	go func() {
+		td.Filter()
+		close(done)
+	}()
+	clock.BlockUntilTickers(1)
+	started := clock.Now().Add(-time.Minute).Format(time.RFC3339Nano)
+	fmt.Fprintf(w, `{"Time":%q,"Action":"run","Package":"p","Test":"TestHangs"}`+"\n", started)
+	fmt.Fprintln(w, `{"Action":"run","Package":"p","Test":"TestQuick"}`)
+	fmt.Fprintln(w, `{"Action":"pass","Package":"p","Test":"TestQuick"}`)
+	clock.BlockUntilResets(3)
+	clock.Advance(49 * time.Millisecond)
+	clock.Advance(time.Millisecond)
+	want := "no events for 50ms; running: p TestHangs (1m0s)\n"
+	if got := <-reports; want != got {
+		t.Errorf("want stall report %q, got %q", want, got)
+	}
+	clock.Advance(50 * time.Millisecond)
+	want = "no events for 100ms; running: p TestHangs (1m0s)\n"
+	if got := <-reports; want != got {
+		t.Errorf("want repeated stall report %q, got %q", want, got)
+	}
+	fmt.Fprintln(w, `{"Action":"fail","Package":"p","Test":"TestHangs"}`)
+	w.Close()
+	<-done
+	if len(reports) > 0 {
+		t.Errorf("want no more reports, got %q", <-reports)
+	}
+}
+
+// reportWriter is an io.Writer that sends each write on the channel as a
+// string, so that a test can wait for something to be written.
+type reportWriter chan string
+
+func (w reportWriter) Write(p []byte) (int, error) {
+	w <- string(p)
+	return len(p), nil
+}
+
+func TestFilter_RecordsWallTimeOfRunByClock(t *testing.T) {
+	t.Parallel()
+	clock := clocktest.New(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	r, w := io.Pipe()
+	sink := &recordingSink{}
+	delivered := make(chan struct{}, 2)
+	td := gotestdox.NewTestDoxer(gotestdox.WithSink(sink), gotestdox.WithClock(clock), gotestdox.WithOnResult(func(gotestdox.Result) {
+		delivered <- struct{}{}
+	}))
+	td.Stdin = r
+	done := make(chan struct{})
+	go func() {
+		td.Filter()
+		close(done)
+	}()
+	fmt.Fprintln(w, `{"Action":"pass","Package":"p","Test":"TestA","Elapsed":1}`)
+	// once the first result is delivered, its event's time has been taken
+	<-delivered
+	clock.Advance(90 * time.Second)
+	fmt.Fprintln(w, `{"Action":"pass","Package":"p","Elapsed":2}`)
+	w.Close()
+	<-done
+	if sink.summary.WallTime != 90 {
+		t.Errorf("want wall time 90s, got %vs", sink.summary.WallTime)
+	}
+	if sink.summary.Elapsed != 2 {
+		t.Errorf("want elapsed 2s, as reported by the package, got %vs", sink.summary.Elapsed)
+	}
+}
+
+func TestFilter_RecordsWallTimeOfRunByTimesOfEvents(t *testing.T) {
+	t.Parallel()
+	input := `{"Time":"2024-01-01T12:00:00Z","Action":"run","Package":"p","Test":"TestA"}
+{"Time":"2024-01-01T12:01:30.004Z","Action":"pass","Package":"p","Test":"TestA","Elapsed":90}
+{"Time":"2024-01-01T12:02:00.5Z","Action":"pass","Package":"p","Elapsed":120.5}`
+	sink := &recordingSink{}
+	// the clock is ignored for events that give their own time
+	clock := clocktest.New(time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC))
+	td := gotestdox.NewTestDoxer(gotestdox.WithSink(sink), gotestdox.WithClock(clock))
+	td.Stdin = strings.NewReader(input)
+	td.Filter()
+	if sink.summary.WallTime != 120.5 {
+		t.Errorf("want wall time 120.5s, got %vs", sink.summary.WallTime)
+	}
 }
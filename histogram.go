@@ -0,0 +1,181 @@
+package gotestdox
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// defaultHistogramBounds are the bucket boundaries used by
+// [WithDurationHistogram] if no others are given.
+var defaultHistogramBounds = []time.Duration{
+	time.Millisecond,
+	10 * time.Millisecond,
+	100 * time.Millisecond,
+	time.Second,
+	10 * time.Second,
+}
+
+// histogramSampleLimit is the number of durations kept by a
+// [DurationHistogram] in order to compute exact percentiles. Beyond this,
+// the percentiles are estimated from the buckets instead.
+const histogramSampleLimit = 100_000
+
+// DurationHistogram describes the distribution of the durations of the
+// tests in a run, as requested by [WithDurationHistogram]. Each test that
+// passed or failed is counted in the first of the Buckets whose upper bound
+// is at least its duration, or in Unknown, if its duration wasn't reported.
+//
+// P50, P90, and P99 are the 50th, 90th, and 99th percentile durations, in
+// seconds. These are exact for up to 100,000 tests, and estimated from the
+// buckets for larger runs, in which case Estimated is true.
+type DurationHistogram struct {
+	Buckets   []DurationBucket `json:"buckets"`
+	Unknown   int              `json:"unknown"`
+	P50       float64          `json:"p50"`
+	P90       float64          `json:"p90"`
+	P99       float64          `json:"p99"`
+	Estimated bool             `json:"estimated,omitempty"`
+	samples   []float64
+	count     int
+	max       float64
+}
+
+// DurationBucket is a single bucket of a [DurationHistogram]. UpperBound is
+// the longest duration counted in the bucket, in seconds, or nil for the
+// last bucket, which has no upper bound.
+type DurationBucket struct {
+	UpperBound *float64 `json:"upperBound"`
+	Count      int      `json:"count"`
+}
+
+func newDurationHistogram(bounds []time.Duration) *DurationHistogram {
+	bounds = append([]time.Duration{}, bounds...)
+	sort.Slice(bounds, func(i, j int) bool { return bounds[i] < bounds[j] })
+	h := &DurationHistogram{
+		Buckets: make([]DurationBucket, 0, len(bounds)+1),
+		samples: []float64{},
+	}
+	for _, b := range bounds {
+		upper := b.Seconds()
+		h.Buckets = append(h.Buckets, DurationBucket{UpperBound: &upper})
+	}
+	h.Buckets = append(h.Buckets, DurationBucket{})
+	return h
+}
+
+// add counts a test with the given duration in seconds in h, or as unknown
+// if known is false.
+func (h *DurationHistogram) add(elapsed float64, known bool) {
+	if !known {
+		h.Unknown++
+		return
+	}
+	for i, b := range h.Buckets {
+		if b.UpperBound == nil || elapsed <= *b.UpperBound {
+			h.Buckets[i].Count++
+			break
+		}
+	}
+	h.count++
+	h.max = math.Max(h.max, elapsed)
+	if len(h.samples) < histogramSampleLimit {
+		h.samples = append(h.samples, elapsed)
+	}
+}
+
+// finish computes the percentiles once all the tests have been added.
+func (h *DurationHistogram) finish() {
+	if h.count == 0 {
+		return
+	}
+	if h.count > len(h.samples) {
+		h.Estimated = true
+		h.P50, h.P90, h.P99 = h.estimate(0.5), h.estimate(0.9), h.estimate(0.99)
+		return
+	}
+	sort.Float64s(h.samples)
+	h.P50, h.P90, h.P99 = h.percentile(0.5), h.percentile(0.9), h.percentile(0.99)
+}
+
+// percentile returns the p'th percentile of the sorted samples, using the
+// nearest-rank method.
+func (h *DurationHistogram) percentile(p float64) float64 {
+	rank := int(math.Ceil(p * float64(len(h.samples))))
+	if rank < 1 {
+		rank = 1
+	}
+	return h.samples[rank-1]
+}
+
+// estimate returns an estimate of the p'th percentile from the buckets,
+// assuming the durations in each bucket are evenly distributed between its
+// bounds. The last bucket is taken to end at the longest duration seen.
+func (h *DurationHistogram) estimate(p float64) float64 {
+	rank := p * float64(h.count)
+	lower, seen := 0.0, 0
+	for _, b := range h.Buckets {
+		upper := h.max
+		if b.UpperBound != nil {
+			upper = math.Min(*b.UpperBound, h.max)
+		}
+		if b.Count > 0 && float64(seen+b.Count) >= rank {
+			return lower + (upper-lower)*(rank-float64(seen))/float64(b.Count)
+		}
+		seen += b.Count
+		if b.UpperBound != nil {
+			lower = *b.UpperBound
+		}
+	}
+	return h.max
+}
+
+// histogramBarWidth is the length of the bar for the largest bucket in the
+// chart printed by [DurationHistogram.render].
+const histogramBarWidth = 40
+
+// render prints h to w as a bar chart, with one line per bucket, headed by
+// the percentiles.
+func (h *DurationHistogram) render(w io.Writer) {
+	approx := ""
+	if h.Estimated {
+		approx = "~"
+	}
+	fmt.Fprintf(w, "Durations (p50 %s%.2fs, p90 %s%.2fs, p99 %s%.2fs):\n", approx, h.P50, approx, h.P90, approx, h.P99)
+	most := h.Unknown
+	labels := make([]string, len(h.Buckets))
+	previous := ""
+	for i, b := range h.Buckets {
+		if b.UpperBound == nil {
+			labels[i] = "> " + previous
+		} else {
+			previous = time.Duration(*b.UpperBound * float64(time.Second)).String()
+			labels[i] = "≤ " + previous
+		}
+		if b.Count > most {
+			most = b.Count
+		}
+	}
+	for i, b := range h.Buckets {
+		fmt.Fprintf(w, " %-8s %s%d\n", labels[i], bar(b.Count, most), b.Count)
+	}
+	if h.Unknown > 0 {
+		fmt.Fprintf(w, " %-8s %s%d\n", "unknown", bar(h.Unknown, most), h.Unknown)
+	}
+}
+
+// bar returns a bar representing n out of a maximum of most, followed by a
+// space, with any non-zero count shown by at least one block.
+func bar(n, most int) string {
+	if n == 0 || most == 0 {
+		return ""
+	}
+	width := n * histogramBarWidth / most
+	if width < 1 {
+		width = 1
+	}
+	return strings.Repeat("█", width) + " "
+}
@@ -0,0 +1,97 @@
+package gotestdox_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bitfield/gotestdox"
+	"github.com/fatih/color"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFilter_WithDurationHistogramPrintsBarChartOfDurations(t *testing.T) {
+	t.Parallel()
+	color.NoColor = true
+	input := `{"Action":"pass","Package":"p","Test":"TestA","Elapsed":0}
+{"Action":"pass","Package":"p","Test":"TestB","Elapsed":0}
+{"Action":"pass","Package":"p","Test":"TestC","Elapsed":0.05}
+{"Action":"fail","Package":"p","Test":"TestD","Elapsed":12}
+{"Action":"skip","Package":"p","Test":"TestE","Elapsed":0}
+{"Action":"pass","Package":"p","Test":"TestF"}
+{"Action":"fail","Package":"p","Elapsed":12.1}`
+	want := `p:
+ ✔ A (0.00s)
+ ✔ B (0.00s)
+ ✔ C (0.05s)
+ x D (12.00s)
+ ✔ F (0.00s)
+
+Durations (p50 0.00s, p90 12.00s, p99 12.00s):
+ ≤ 1ms    ████████████████████████████████████████ 2
+ ≤ 10ms   0
+ ≤ 100ms  ████████████████████ 1
+ ≤ 1s     0
+ ≤ 10s    0
+ > 10s    ████████████████████ 1
+ unknown  ████████████████████ 1
+`
+	buf := new(strings.Builder)
+	td := gotestdox.NewTestDoxer(gotestdox.WithDurationHistogram())
+	td.Stdin = strings.NewReader(input)
+	td.Stdout = buf
+	td.Filter()
+	if want != buf.String() {
+		t.Error(cmp.Diff(want, buf.String()))
+	}
+}
+
+func TestFilter_WithDurationHistogramRecordsExactPercentilesInSummary(t *testing.T) {
+	t.Parallel()
+	input := new(strings.Builder)
+	for i := 1; i <= 100; i++ {
+		fmt.Fprintf(input, `{"Action":"pass","Package":"p","Test":"Test%d","Elapsed":%g}`+"\n", i, float64(i)/100)
+	}
+	sink := &recordingSink{}
+	td := gotestdox.NewTestDoxer(
+		gotestdox.WithDurationHistogram(500*time.Millisecond, 100*time.Millisecond),
+		gotestdox.WithSink(sink),
+	)
+	td.Stdin = strings.NewReader(input.String())
+	td.Filter()
+	data, err := json.Marshal(sink.summary.Durations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"buckets":[{"upperBound":0.1,"count":10},{"upperBound":0.5,"count":40},{"upperBound":null,"count":50}],"unknown":0,"p50":0.5,"p90":0.9,"p99":0.99}`
+	if want != string(data) {
+		t.Error(cmp.Diff(want, string(data)))
+	}
+}
+
+func TestFilter_WithDurationHistogramEstimatesPercentilesForLargeRuns(t *testing.T) {
+	t.Parallel()
+	input := new(strings.Builder)
+	for i := 0; i < 100_001; i++ {
+		fmt.Fprintf(input, `{"Action":"pass","Package":"p","Test":"TestA/%d","Elapsed":%g}`+"\n", i, float64(i%100)/1000)
+	}
+	sink := &recordingSink{}
+	td := gotestdox.NewTestDoxer(
+		gotestdox.WithDurationHistogram(50*time.Millisecond, 100*time.Millisecond),
+		gotestdox.WithSink(sink),
+	)
+	td.Stdin = strings.NewReader(input.String())
+	td.Filter()
+	h := sink.summary.Durations
+	if !h.Estimated {
+		t.Error("want percentiles estimated")
+	}
+	for name, got := range map[string]float64{"p50": h.P50, "p90": h.P90} {
+		want := map[string]float64{"p50": 0.05, "p90": 0.09}[name]
+		if got < want-0.005 || got > want+0.005 {
+			t.Errorf("want %s about %.3f, got %.3f", name, want, got)
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package gotestdox
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+)
+
+// History is the result of aggregating the events from several runs of 'go
+// test -json', as produced by [Aggregate]. It lists every test seen in any of
+// the runs, ranked by failure rate, with the most frequently failing tests
+// first.
+type History struct {
+	Runs  int           `json:"runs"`
+	Tests []TestHistory `json:"tests"`
+}
+
+// TestHistory records the results of a single test across several runs. Runs
+// is the number of runs in which the test appeared, which may be fewer than
+// the total number of runs if the test was added or removed between them.
+// A test executed more than once in the same run, as with 'go test -count',
+// counts once towards Runs, Passes, and Failures: as a failure if any of its
+// executions failed. FailureRate is the proportion of runs in which the test
+// failed.
+//
+// FirstFailure and LastFailure give the times of the earliest and latest
+// failures seen, if any. Elapsed lists the duration of the test, in seconds,
+// for each run in which it appeared, in the order the runs were supplied,
+// averaged over its executions in that run.
+// MeanElapsed is the average of these, and ElapsedTrend is the difference
+// between the average duration over the later half of the runs and that over
+// the earlier half, so that a positive trend means the test is getting
+// slower.
+type TestHistory struct {
+	Package      string     `json:"package"`
+	Test         string     `json:"test"`
+	Sentence     string     `json:"sentence"`
+	Runs         int        `json:"runs"`
+	Passes       int        `json:"passes"`
+	Failures     int        `json:"failures"`
+	FailureRate  float64    `json:"failureRate"`
+	FirstFailure *time.Time `json:"firstFailure,omitempty"`
+	LastFailure  *time.Time `json:"lastFailure,omitempty"`
+	Elapsed      []float64  `json:"elapsed"`
+	MeanElapsed  float64    `json:"meanElapsed"`
+	ElapsedTrend float64    `json:"elapsedTrend"`
+}
+
+// Aggregate reads the 'go test -json' output of each of the given runs, and
+// returns a [History] summarising the results of each test across all of
+// them. Tests are identified by their package and name, so a test that
+// appears in only some of the runs is reported for those runs alone.
+//
+// The options are applied when prettifying the test names. If any run
+// contains invalid JSON, Aggregate returns an error identifying the run,
+// wrapping a [*StreamError]. If [WithValidation] is supplied, each run is
+// checked first, and a corrupt run is refused in the same way.
+func Aggregate(runs []io.Reader, opts ...Option) (History, error) {
+	cfg := newConfig(opts)
+	tests := map[string]*TestHistory{}
+	for i, r := range runs {
+		if cfg.validate {
+			in, err := validated(r)
+			if err != nil {
+				return History{}, fmt.Errorf("run %d: %w", i+1, err)
+			}
+			r = in
+		}
+		executions := map[string]int{}
+		failed := map[string]bool{}
+		scanner := bufio.NewScanner(r)
+		for line := 1; scanner.Scan(); line++ {
+			var e Event
+			if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+				return History{}, fmt.Errorf("run %d: %w", i+1, newStreamError(line, scanner.Text(), err))
+			}
+			if !e.Relevant() {
+				continue
+			}
+			key := e.Package + " " + e.Test
+			t := tests[key]
+			if t == nil {
+				t = &TestHistory{
+					Package:  e.Package,
+					Test:     e.Test,
+					Sentence: prettify(e.Test, cfg),
+					Elapsed:  []float64{},
+				}
+				tests[key] = t
+			}
+			executions[key]++
+			t.record(e, executions[key], failed[key])
+			if e.Kind() != ActionPass {
+				failed[key] = true
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return History{}, fmt.Errorf("run %d: %w", i+1, err)
+		}
+	}
+	h := History{
+		Runs:  len(runs),
+		Tests: make([]TestHistory, 0, len(tests)),
+	}
+	for _, t := range tests {
+		t.summarise()
+		h.Tests = append(h.Tests, *t)
+	}
+	sort.Slice(h.Tests, func(i, j int) bool {
+		a, b := h.Tests[i], h.Tests[j]
+		if a.FailureRate != b.FailureRate {
+			return a.FailureRate > b.FailureRate
+		}
+		if a.Failures != b.Failures {
+			return a.Failures > b.Failures
+		}
+		if a.Package != b.Package {
+			return a.Package < b.Package
+		}
+		return a.Test < b.Test
+	})
+	return h, nil
+}
+
+// record adds the result e, the nth execution of the test in the current
+// run, to t. If the test has already run in this run, as with 'go test
+// -count', the run is still counted once: as a failure if any execution
+// failed, whether or not an earlier one did (failedBefore), with the mean
+// of their durations.
+func (t *TestHistory) record(e Event, n int, failedBefore bool) {
+	failed := e.Kind() != ActionPass
+	if failed {
+		when := e.Time
+		if t.FirstFailure == nil || when.Before(*t.FirstFailure) {
+			t.FirstFailure = &when
+		}
+		if t.LastFailure == nil || when.After(*t.LastFailure) {
+			t.LastFailure = &when
+		}
+	}
+	if n > 1 {
+		last := len(t.Elapsed) - 1
+		t.Elapsed[last] += (e.Elapsed - t.Elapsed[last]) / float64(n)
+		if failed && !failedBefore {
+			t.Passes--
+			t.Failures++
+		}
+		return
+	}
+	t.Runs++
+	t.Elapsed = append(t.Elapsed, e.Elapsed)
+	if failed {
+		t.Failures++
+		return
+	}
+	t.Passes++
+}
+
+// summarise computes the derived statistics for t once all runs have been
+// recorded.
+func (t *TestHistory) summarise() {
+	if t.Runs == 0 {
+		return
+	}
+	t.FailureRate = float64(t.Failures) / float64(t.Runs)
+	t.MeanElapsed = mean(t.Elapsed)
+	if len(t.Elapsed) > 1 {
+		half := len(t.Elapsed) / 2
+		t.ElapsedTrend = mean(t.Elapsed[len(t.Elapsed)-half:]) - mean(t.Elapsed[:half])
+	}
+}
+
+func mean(xs []float64) float64 {
+	if len(xs) == 0 {
+		return 0
+	}
+	total := 0.0
+	for _, x := range xs {
+		total += x
+	}
+	return total / float64(len(xs))
+}
+
+// Render writes h to w as a table, with one row per test, giving its failure
+// rate, the number of failures and runs, the times of its first and last
+// failures, its average duration and trend, and its prettified sentence.
+func (h History) Render(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "FAIL RATE\tFAILS\tRUNS\tFIRST FAILURE\tLAST FAILURE\tMEAN\tTREND\tTEST")
+	for _, t := range h.Tests {
+		fmt.Fprintf(tw, "%.0f%%\t%d\t%d\t%s\t%s\t%.2fs\t%+.2fs\t%s: %s\n",
+			t.FailureRate*100,
+			t.Failures,
+			t.Runs,
+			formatTime(t.FirstFailure),
+			formatTime(t.LastFailure),
+			t.MeanElapsed,
+			t.ElapsedTrend,
+			t.Package,
+			t.Sentence,
+		)
+	}
+	return tw.Flush()
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return t.Format(time.RFC3339)
+}
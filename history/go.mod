@@ -0,0 +1,29 @@
+module github.com/bitfield/gotestdox/history
+
+go 1.23
+
+require (
+	github.com/bitfield/gotestdox v0.0.0-00010101000000-000000000000
+	github.com/google/go-cmp v0.5.9
+	modernc.org/sqlite v1.34.5
+)
+
+require (
+	github.com/dustin/go-humanize v1.0.1 // indirect
+	github.com/fatih/color v1.14.1 // indirect
+	github.com/google/uuid v1.6.0 // indirect
+	github.com/mattn/go-colorable v0.1.13 // indirect
+	github.com/mattn/go-isatty v0.0.20 // indirect
+	github.com/ncruces/go-strftime v0.1.9 // indirect
+	github.com/remyoudompheng/bigfft v0.0.0-20230129092748-24d4a6f8daec // indirect
+	golang.org/x/sys v0.22.0 // indirect
+	golang.org/x/text v0.6.0 // indirect
+	modernc.org/libc v1.55.3 // indirect
+	modernc.org/mathutil v1.6.0 // indirect
+	modernc.org/memory v1.8.0 // indirect
+)
+
+// No release of gotestdox has the history store API yet, so the module in
+// the parent directory is used instead. Once one does, require it, and drop
+// this.
+replace github.com/bitfield/gotestdox => ../
@@ -0,0 +1,307 @@
+// Package history records the results of gotestdox runs in a SQLite
+// database, for tracking flaky tests and test durations over time.
+//
+// Importing the package registers its [Store] with gotestdox, so that
+// [gotestdox.WithHistoryStore] records every run automatically:
+//
+//	import _ "github.com/bitfield/gotestdox/history"
+//
+//	td := gotestdox.NewTestDoxer(gotestdox.WithHistoryStore("history.db"))
+//
+// The database is created if necessary, and its schema is kept up to date,
+// so a newer version of the package can use a database created by an older
+// one. Several processes, such as parallel CI jobs, can safely record runs
+// in the same database at once.
+package history
+
+import (
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bitfield/gotestdox"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+func init() {
+	gotestdox.RegisterHistoryStore(func(dsn string) (gotestdox.HistoryStore, error) {
+		return Open(dsn)
+	})
+}
+
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+// busyTimeout is how long SQLite waits for another connection to release
+// its lock on the database before giving up.
+const busyTimeout = 5 * time.Second
+
+// maxAttempts is the number of times a transaction is attempted, when the
+// database is still locked after busyTimeout.
+const maxAttempts = 5
+
+// Store is a SQLite database recording the results of test runs. It is safe
+// for concurrent use.
+type Store struct {
+	db *sql.DB
+}
+
+// Open opens the database with the given data source name, usually the path
+// of the database file, creating it if necessary, and applies any schema
+// migrations it hasn't had yet.
+func Open(dsn string) (*Store, error) {
+	db, err := sql.Open("sqlite", withPragmas(dsn))
+	if err != nil {
+		return nil, err
+	}
+	s := &Store{db: db}
+	if err := s.migrate(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("migrating %s: %w", dsn, err)
+	}
+	return s, nil
+}
+
+// withPragmas adds the connection parameters needed for concurrent use to
+// dsn: a busy timeout, write-ahead logging, and transactions that take the
+// write lock at once, so that two writers can't deadlock.
+func withPragmas(dsn string) string {
+	sep := "?"
+	if strings.Contains(dsn, "?") {
+		sep = "&"
+	}
+	return dsn + sep + strings.Join([]string{
+		"_pragma=busy_timeout(" + strconv.Itoa(int(busyTimeout.Milliseconds())) + ")",
+		"_pragma=journal_mode(WAL)",
+		"_pragma=foreign_keys(1)",
+		"_txlock=immediate",
+	}, "&")
+}
+
+// Close closes the database.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// migrate applies each embedded migration newer than the database's schema
+// version, in order.
+func (s *Store) migrate() error {
+	files, err := fs.Glob(migrations, "migrations/*.sql")
+	if err != nil {
+		return err
+	}
+	sort.Strings(files)
+	return s.transaction(func(tx *sql.Tx) error {
+		if _, err := tx.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (version INTEGER PRIMARY KEY)`); err != nil {
+			return err
+		}
+		var current int
+		if err := tx.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&current); err != nil {
+			return err
+		}
+		for _, file := range files {
+			name := strings.TrimPrefix(file, "migrations/")
+			prefix, _, _ := strings.Cut(name, "_")
+			version, err := strconv.Atoi(prefix)
+			if err != nil {
+				return fmt.Errorf("migration %s: bad version number", name)
+			}
+			if version <= current {
+				continue
+			}
+			script, err := migrations.ReadFile(file)
+			if err != nil {
+				return err
+			}
+			if _, err := tx.Exec(string(script)); err != nil {
+				return fmt.Errorf("migration %s: %w", name, err)
+			}
+			if _, err := tx.Exec(`INSERT INTO schema_migrations (version) VALUES (?)`, version); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// transaction runs fn in a transaction, which is committed if fn succeeds,
+// and rolled back otherwise. If the database is locked by another writer
+// for longer than the busy timeout, the transaction is retried a few times
+// before giving up.
+func (s *Store) transaction(fn func(*sql.Tx) error) error {
+	var err error
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		if err = s.try(fn); !isBusy(err) {
+			return err
+		}
+		time.Sleep(time.Duration(attempt) * 100 * time.Millisecond)
+	}
+	return err
+}
+
+func (s *Store) try(fn func(*sql.Tx) error) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// isBusy reports whether err means the database was locked.
+func isBusy(err error) bool {
+	var e *sqlite.Error
+	if !errors.As(err, &e) {
+		return false
+	}
+	code := e.Code() & 0xff
+	return code == sqlite3.SQLITE_BUSY || code == sqlite3.SQLITE_LOCKED
+}
+
+// Record stores the results of the run described by meta.
+func (s *Store) Record(meta gotestdox.RunMeta, results []gotestdox.Result) error {
+	return s.transaction(func(tx *sql.Tx) error {
+		run, err := tx.Exec(`INSERT INTO runs (started, finished, host) VALUES (?, ?, ?)`,
+			meta.Started.UnixNano(), meta.Finished.UnixNano(), meta.Host)
+		if err != nil {
+			return err
+		}
+		id, err := run.LastInsertId()
+		if err != nil {
+			return err
+		}
+		insert, err := tx.Prepare(`INSERT INTO results
+			(run_id, package, test, configuration, sentence, status, elapsed, flaky)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)`)
+		if err != nil {
+			return err
+		}
+		defer insert.Close()
+		for _, r := range results {
+			if r.Test == "" {
+				continue
+			}
+			if _, err := insert.Exec(id, r.Package, r.Test, r.Configuration, r.Sentence, r.Status, r.Elapsed, r.Flaky); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Flakiness describes how often a test's result has changed over a number
+// of runs, as reported by [Store.FlakiestSince]. Runs is the number of runs
+// in which the test appeared, and Failures the number of times it failed.
+// Flips is the number of times it passed after failing, or failed after
+// passing, including when it was retried within the same run.
+type Flakiness struct {
+	Package  string
+	Test     string
+	Sentence string
+	Runs     int
+	Failures int
+	Flips    int
+}
+
+// FlakiestSince returns the n tests whose results changed most often in the
+// runs started at or after since, most changeable first, or all such tests,
+// if n is zero or less. Tests that always passed, or always failed, are not
+// flaky, and so aren't included.
+func (s *Store) FlakiestSince(since time.Time, n int) ([]Flakiness, error) {
+	rows, err := s.db.Query(`SELECT r.package, r.test, r.sentence, r.status, r.run_id
+		FROM results r JOIN runs ON runs.id = r.run_id
+		WHERE runs.started >= ? AND r.status != 'skip'
+		ORDER BY r.package, r.test, runs.started, runs.id, r.rowid`, since.UnixNano())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	flaky := []Flakiness{}
+	var f *Flakiness
+	var lastStatus string
+	var lastRun int64
+	for rows.Next() {
+		var pkg, test, sentence, status string
+		var run int64
+		if err := rows.Scan(&pkg, &test, &sentence, &status, &run); err != nil {
+			return nil, err
+		}
+		if f == nil || f.Package != pkg || f.Test != test {
+			flaky = append(flaky, Flakiness{Package: pkg, Test: test})
+			f = &flaky[len(flaky)-1]
+			lastStatus, lastRun = "", 0
+		}
+		f.Sentence = sentence
+		if run != lastRun {
+			f.Runs++
+		}
+		if status == "fail" {
+			f.Failures++
+		}
+		if lastStatus != "" && status != lastStatus {
+			f.Flips++
+		}
+		lastStatus, lastRun = status, run
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	result := flaky[:0]
+	for _, f := range flaky {
+		if f.Flips > 0 {
+			result = append(result, f)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].Flips != result[j].Flips {
+			return result[i].Flips > result[j].Flips
+		}
+		return result[i].Failures > result[j].Failures
+	})
+	if n > 0 && len(result) > n {
+		result = result[:n]
+	}
+	return result, nil
+}
+
+// DurationPoint is the duration of a test in a single run, as reported by
+// [Store.DurationTrend]. Elapsed is in seconds.
+type DurationPoint struct {
+	Started time.Time
+	Status  string
+	Elapsed float64
+}
+
+// DurationTrend returns the duration of the test in package pkg with the
+// given name in each run in which it passed or failed, oldest first.
+func (s *Store) DurationTrend(pkg, test string) ([]DurationPoint, error) {
+	rows, err := s.db.Query(`SELECT runs.started, r.status, r.elapsed
+		FROM results r JOIN runs ON runs.id = r.run_id
+		WHERE r.package = ? AND r.test = ? AND r.status != 'skip'
+		ORDER BY runs.started, runs.id, r.rowid`, pkg, test)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	points := []DurationPoint{}
+	for rows.Next() {
+		var p DurationPoint
+		var started int64
+		if err := rows.Scan(&started, &p.Status, &p.Elapsed); err != nil {
+			return nil, err
+		}
+		p.Started = time.Unix(0, started)
+		points = append(points, p)
+	}
+	return points, rows.Err()
+}
@@ -0,0 +1,174 @@
+package history_test
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bitfield/gotestdox"
+	"github.com/bitfield/gotestdox/history"
+	"github.com/google/go-cmp/cmp"
+)
+
+func openStore(t *testing.T, path string) *history.Store {
+	t.Helper()
+	s, err := history.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+// run returns the metadata for a run starting at the given number of
+// minutes past a fixed time.
+func run(minutes int) gotestdox.RunMeta {
+	start := time.Date(2026, 1, 1, 0, minutes, 0, 0, time.UTC)
+	return gotestdox.RunMeta{Started: start, Finished: start.Add(time.Second), Host: "ci"}
+}
+
+func result(test, status string, elapsed float64) gotestdox.Result {
+	return gotestdox.Result{Package: "p", Test: test, Sentence: test, Status: status, Elapsed: elapsed}
+}
+
+func TestOpen_AppliesMigrationsOnlyOnce(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "history.db")
+	s := openStore(t, path)
+	if err := s.Record(run(0), []gotestdox.Result{result("TestA", "pass", 1)}); err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+	s = openStore(t, path)
+	points, err := s.DurationTrend("p", "TestA")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(points) != 1 {
+		t.Errorf("want recorded run kept after reopening, got %d points", len(points))
+	}
+}
+
+func TestFlakiestSince_RanksTestsByNumberOfChangesInResult(t *testing.T) {
+	t.Parallel()
+	s := openStore(t, filepath.Join(t.TempDir(), "history.db"))
+	runs := [][]gotestdox.Result{
+		{result("TestStable", "pass", 0), result("TestBroken", "fail", 0), result("TestFlaky", "pass", 0), result("TestRetried", "pass", 0)},
+		{result("TestStable", "pass", 0), result("TestBroken", "fail", 0), result("TestFlaky", "fail", 0), result("TestRetried", "pass", 0)},
+		{result("TestStable", "pass", 0), result("TestBroken", "fail", 0), result("TestFlaky", "pass", 0), result("TestRetried", "fail", 0), result("TestRetried", "pass", 0)},
+		{result("TestOld", "fail", 0)},
+	}
+	for i, results := range runs {
+		if err := s.Record(run(10-i*3), results); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got, err := s.FlakiestSince(run(0).Started.Add(time.Minute), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []history.Flakiness{
+		{Package: "p", Test: "TestFlaky", Sentence: "TestFlaky", Runs: 3, Failures: 1, Flips: 2},
+		{Package: "p", Test: "TestRetried", Sentence: "TestRetried", Runs: 3, Failures: 1, Flips: 1},
+	}
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+	got, err = s.FlakiestSince(time.Time{}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 {
+		t.Errorf("want 1 result, got %d", len(got))
+	}
+}
+
+func TestDurationTrend_ReturnsDurationsOldestFirst(t *testing.T) {
+	t.Parallel()
+	s := openStore(t, filepath.Join(t.TempDir(), "history.db"))
+	for i, elapsed := range []float64{0.3, 0.1, 0.2} {
+		if err := s.Record(run(i), []gotestdox.Result{result("TestA", "pass", elapsed), result("TestB", "pass", 9)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.Record(run(5), []gotestdox.Result{result("TestA", "skip", 0)}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := s.DurationTrend("p", "TestA")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []history.DurationPoint{
+		{Started: run(0).Started, Status: "pass", Elapsed: 0.3},
+		{Started: run(1).Started, Status: "pass", Elapsed: 0.1},
+		{Started: run(2).Started, Status: "pass", Elapsed: 0.2},
+	}
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestRecord_IsSafeForConcurrentWritersToSameDatabase(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "history.db")
+	const writers, runsEach = 8, 10
+	var wg sync.WaitGroup
+	errs := make(chan error, writers)
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			s, err := history.Open(path)
+			if err != nil {
+				errs <- err
+				return
+			}
+			defer s.Close()
+			for i := 0; i < runsEach; i++ {
+				results := []gotestdox.Result{result("TestA", "pass", float64(w))}
+				if err := s.Record(run(w*runsEach+i), results); err != nil {
+					errs <- fmt.Errorf("writer %d: %w", w, err)
+					return
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+	points, err := openStore(t, path).DurationTrend("p", "TestA")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(points) != writers*runsEach {
+		t.Errorf("want %d runs recorded, got %d", writers*runsEach, len(points))
+	}
+}
+
+func TestFilter_WithHistoryStoreRecordsRunInDatabase(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "history.db")
+	input := `{"Action":"pass","Package":"p","Test":"TestA","Elapsed":0.5}
+{"Action":"skip","Package":"p","Test":"TestB"}
+{"Action":"pass","Package":"p","Elapsed":0.6}`
+	td := gotestdox.NewTestDoxer(gotestdox.WithHistoryStore(path))
+	td.Stdin = strings.NewReader(input)
+	td.Stdout = io.Discard
+	td.Filter()
+	if !td.OK {
+		t.Fatal("filter failed")
+	}
+	points, err := openStore(t, path).DurationTrend("p", "TestA")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(points) != 1 || points[0].Elapsed != 0.5 {
+		t.Errorf("want one run with TestA taking 0.5s, got %+v", points)
+	}
+}
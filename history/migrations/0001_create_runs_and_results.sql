@@ -0,0 +1,20 @@
+CREATE TABLE runs (
+	id       INTEGER PRIMARY KEY,
+	started  INTEGER NOT NULL,
+	finished INTEGER NOT NULL,
+	host     TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE results (
+	run_id        INTEGER NOT NULL REFERENCES runs (id) ON DELETE CASCADE,
+	package       TEXT NOT NULL,
+	test          TEXT NOT NULL,
+	configuration TEXT NOT NULL DEFAULT '',
+	sentence      TEXT NOT NULL DEFAULT '',
+	status        TEXT NOT NULL,
+	elapsed       REAL NOT NULL,
+	flaky         INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE INDEX results_by_test ON results (package, test);
+CREATE INDEX runs_by_start ON runs (started);
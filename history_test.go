@@ -0,0 +1,191 @@
+package gotestdox_test
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bitfield/gotestdox"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestAggregate_RanksTestsByFailureRateAcrossRuns(t *testing.T) {
+	t.Parallel()
+	runs := []io.Reader{
+		strings.NewReader(`{"Time":"2022-03-01T10:00:00Z","Action":"fail","Package":"p","Test":"TestFlaky","Elapsed":1}
+{"Time":"2022-03-01T10:00:00Z","Action":"pass","Package":"p","Test":"TestStable","Elapsed":0.1}
+{"Time":"2022-03-01T10:00:00Z","Action":"pass","Package":"p","Test":"TestRemoved","Elapsed":0.1}
+{"Time":"2022-03-01T10:00:01Z","Action":"fail","Package":"p","Elapsed":1.5}`),
+		strings.NewReader(`{"Time":"2022-03-02T10:00:00Z","Action":"pass","Package":"p","Test":"TestFlaky","Elapsed":2}
+{"Time":"2022-03-02T10:00:00Z","Action":"pass","Package":"p","Test":"TestStable","Elapsed":0.1}
+{"Time":"2022-03-02T10:00:00Z","Action":"fail","Package":"p","Test":"TestAdded","Elapsed":0.5}
+{"Time":"2022-03-02T10:00:01Z","Action":"fail","Package":"p","Elapsed":1.5}`),
+		strings.NewReader(`{"Time":"2022-03-03T10:00:00Z","Action":"fail","Package":"p","Test":"TestFlaky","Elapsed":3}
+{"Time":"2022-03-03T10:00:00Z","Action":"pass","Package":"p","Test":"TestStable","Elapsed":0.1}
+{"Time":"2022-03-03T10:00:00Z","Action":"fail","Package":"p","Test":"TestAdded","Elapsed":0.5}
+{"Time":"2022-03-03T10:00:01Z","Action":"fail","Package":"p","Elapsed":1.5}`),
+	}
+	h, err := gotestdox.Aggregate(runs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := time.Date(2022, 3, 1, 10, 0, 0, 0, time.UTC)
+	last := time.Date(2022, 3, 3, 10, 0, 0, 0, time.UTC)
+	added := time.Date(2022, 3, 2, 10, 0, 0, 0, time.UTC)
+	want := gotestdox.History{
+		Runs: 3,
+		Tests: []gotestdox.TestHistory{
+			{
+				Package:      "p",
+				Test:         "TestAdded",
+				Sentence:     "Added",
+				Runs:         2,
+				Failures:     2,
+				FailureRate:  1,
+				FirstFailure: &added,
+				LastFailure:  &last,
+				Elapsed:      []float64{0.5, 0.5},
+				MeanElapsed:  0.5,
+			},
+			{
+				Package:      "p",
+				Test:         "TestFlaky",
+				Sentence:     "Flaky",
+				Runs:         3,
+				Passes:       1,
+				Failures:     2,
+				FailureRate:  2.0 / 3.0,
+				FirstFailure: &first,
+				LastFailure:  &last,
+				Elapsed:      []float64{1, 2, 3},
+				MeanElapsed:  2,
+				ElapsedTrend: 2,
+			},
+			{
+				Package:     "p",
+				Test:        "TestRemoved",
+				Sentence:    "Removed",
+				Runs:        1,
+				Passes:      1,
+				Elapsed:     []float64{0.1},
+				MeanElapsed: 0.1,
+			},
+			{
+				Package:     "p",
+				Test:        "TestStable",
+				Sentence:    "Stable",
+				Runs:        3,
+				Passes:      3,
+				Elapsed:     []float64{0.1, 0.1, 0.1},
+				MeanElapsed: 0.1,
+			},
+		},
+	}
+	if !cmp.Equal(want, h, cmpApprox) {
+		t.Error(cmp.Diff(want, h, cmpApprox))
+	}
+}
+
+func TestAggregate_CountsTestRepeatedWithinRunOncePerRun(t *testing.T) {
+	t.Parallel()
+	runs := []io.Reader{
+		strings.NewReader(`{"Time":"2022-03-01T10:00:00Z","Action":"pass","Package":"p","Test":"TestFlaky","Elapsed":1}
+{"Time":"2022-03-01T10:00:01Z","Action":"fail","Package":"p","Test":"TestFlaky","Elapsed":2}
+{"Time":"2022-03-01T10:00:02Z","Action":"fail","Package":"p","Test":"TestFlaky","Elapsed":3}
+{"Time":"2022-03-01T10:00:03Z","Action":"fail","Package":"p","Elapsed":6}`),
+		strings.NewReader(`{"Time":"2022-03-02T10:00:00Z","Action":"pass","Package":"p","Test":"TestFlaky","Elapsed":4}
+{"Time":"2022-03-02T10:00:01Z","Action":"pass","Package":"p","Test":"TestFlaky","Elapsed":4}
+{"Time":"2022-03-02T10:00:02Z","Action":"pass","Package":"p","Test":"TestFlaky","Elapsed":4}
+{"Time":"2022-03-02T10:00:03Z","Action":"pass","Package":"p","Elapsed":12}`),
+	}
+	h, err := gotestdox.Aggregate(runs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := time.Date(2022, 3, 1, 10, 0, 1, 0, time.UTC)
+	last := time.Date(2022, 3, 1, 10, 0, 2, 0, time.UTC)
+	want := gotestdox.History{
+		Runs: 2,
+		Tests: []gotestdox.TestHistory{
+			{
+				Package:      "p",
+				Test:         "TestFlaky",
+				Sentence:     "Flaky",
+				Runs:         2,
+				Passes:       1,
+				Failures:     1,
+				FailureRate:  0.5,
+				FirstFailure: &first,
+				LastFailure:  &last,
+				Elapsed:      []float64{2, 4},
+				MeanElapsed:  3,
+				ElapsedTrend: 2,
+			},
+		},
+	}
+	if !cmp.Equal(want, h, cmpApprox) {
+		t.Error(cmp.Diff(want, h, cmpApprox))
+	}
+}
+
+var cmpApprox = cmp.Comparer(func(x, y float64) bool {
+	d := x - y
+	return d < 1e-9 && d > -1e-9
+})
+
+func TestAggregate_ReturnsErrorIdentifyingRunWithInvalidJSON(t *testing.T) {
+	t.Parallel()
+	runs := []io.Reader{
+		strings.NewReader(`{"Action":"pass","Package":"p","Test":"TestA"}`),
+		strings.NewReader(`bogus`),
+	}
+	_, err := gotestdox.Aggregate(runs)
+	if err == nil {
+		t.Fatal("want error")
+	}
+	if !strings.HasPrefix(err.Error(), "run 2:") {
+		t.Errorf("want error identifying run 2, got %q", err)
+	}
+}
+
+func TestHistory_MarshalsToJSONWithoutFailureTimesForPassingTests(t *testing.T) {
+	t.Parallel()
+	h, err := gotestdox.Aggregate([]io.Reader{
+		strings.NewReader(`{"Action":"pass","Package":"p","Test":"TestA","Elapsed":0.5}`),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"runs":1,"tests":[{"package":"p","test":"TestA","sentence":"A","runs":1,"passes":1,"failures":0,"failureRate":0,"elapsed":[0.5],"meanElapsed":0.5,"elapsedTrend":0}]}`
+	if want != string(data) {
+		t.Error(cmp.Diff(want, string(data)))
+	}
+}
+
+func TestHistoryRender_ListsEachTestWithItsFailureRate(t *testing.T) {
+	t.Parallel()
+	h, err := gotestdox.Aggregate([]io.Reader{
+		strings.NewReader(`{"Time":"2022-03-01T10:00:00Z","Action":"fail","Package":"p","Test":"TestA","Elapsed":0.5}`),
+		strings.NewReader(`{"Time":"2022-03-02T10:00:00Z","Action":"pass","Package":"p","Test":"TestA","Elapsed":0.7}`),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := new(strings.Builder)
+	err = h.Render(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `FAIL RATE  FAILS  RUNS  FIRST FAILURE         LAST FAILURE          MEAN   TREND   TEST
+50%        1      2     2022-03-01T10:00:00Z  2022-03-01T10:00:00Z  0.60s  +0.20s  p: A
+`
+	if want != buf.String() {
+		t.Error(cmp.Diff(want, buf.String()))
+	}
+}
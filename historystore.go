@@ -0,0 +1,92 @@
+package gotestdox
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// RunMeta describes a test run whose results are recorded in a
+// [HistoryStore]: when it started and finished, and the name of the host it
+// ran on.
+type RunMeta struct {
+	Started  time.Time
+	Finished time.Time
+	Host     string
+}
+
+// HistoryStore is a database recording the results of test runs, for
+// tracking flakiness and durations over time, such as the one provided by
+// the [github.com/bitfield/gotestdox/history] package. Record stores the
+// results of the run described by meta.
+type HistoryStore interface {
+	Record(meta RunMeta, results []Result) error
+	Close() error
+}
+
+var (
+	historyStoreMu   sync.Mutex
+	openHistoryStore func(dsn string) (HistoryStore, error)
+)
+
+// RegisterHistoryStore sets the function used by [WithHistoryStore] to
+// open the [HistoryStore] with a given data source name. It's called from
+// the init function of the package providing the store, so that the
+// database driver is only linked into programs that import that package.
+// RegisterHistoryStore panics if it's called more than once, or open is
+// nil.
+func RegisterHistoryStore(open func(dsn string) (HistoryStore, error)) {
+	if open == nil {
+		panic("gotestdox: RegisterHistoryStore called with nil function")
+	}
+	historyStoreMu.Lock()
+	defer historyStoreMu.Unlock()
+	if openHistoryStore != nil {
+		panic("gotestdox: RegisterHistoryStore called twice")
+	}
+	openHistoryStore = open
+}
+
+// errNoHistoryStore is returned when [WithHistoryStore] is supplied, but no
+// store has been registered.
+var errNoHistoryStore = errors.New("no history store registered: import github.com/bitfield/gotestdox/history")
+
+// historyRecording records the results of a run in a [HistoryStore], as
+// requested by [WithHistoryStore].
+type historyRecording struct {
+	store HistoryStore
+	meta  RunMeta
+	clock Clock
+}
+
+// startHistoryRecording opens the store with the given data source name,
+// noting the time the run started, by clock.
+func startHistoryRecording(dsn string, clock Clock) (*historyRecording, error) {
+	historyStoreMu.Lock()
+	open := openHistoryStore
+	historyStoreMu.Unlock()
+	if open == nil {
+		return nil, errNoHistoryStore
+	}
+	store, err := open(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("opening history store: %w", err)
+	}
+	host, _ := os.Hostname()
+	return &historyRecording{
+		store: store,
+		meta:  RunMeta{Started: clock.Now(), Host: host},
+		clock: clock,
+	}, nil
+}
+
+// finish records the results of the run in the store.
+func (h *historyRecording) finish(results []Result) error {
+	h.meta.Finished = h.clock.Now()
+	if err := h.store.Record(h.meta, results); err != nil {
+		return fmt.Errorf("recording history: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package gotestdox_test
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/bitfield/gotestdox"
+	"github.com/google/go-cmp/cmp"
+)
+
+// fakeHistoryStore records the runs it's given, by data source name.
+type fakeHistoryStore struct {
+	dsn string
+}
+
+var (
+	fakeHistoryMu   sync.Mutex
+	fakeHistoryRuns = map[string][][]gotestdox.Result{}
+)
+
+func (s fakeHistoryStore) Record(meta gotestdox.RunMeta, results []gotestdox.Result) error {
+	if meta.Started.IsZero() || meta.Finished.Before(meta.Started) {
+		return errors.New("invalid run times")
+	}
+	fakeHistoryMu.Lock()
+	defer fakeHistoryMu.Unlock()
+	fakeHistoryRuns[s.dsn] = append(fakeHistoryRuns[s.dsn], results)
+	return nil
+}
+
+func (s fakeHistoryStore) Close() error {
+	if s.dsn == "unclosable" {
+		return errors.New("can't close unclosable")
+	}
+	return nil
+}
+
+func init() {
+	gotestdox.RegisterHistoryStore(func(dsn string) (gotestdox.HistoryStore, error) {
+		if dsn == "bogus" {
+			return nil, errors.New("can't open bogus")
+		}
+		return fakeHistoryStore{dsn: dsn}, nil
+	})
+}
+
+func TestFilter_WithHistoryStoreRecordsCompletedTests(t *testing.T) {
+	t.Parallel()
+	td := gotestdox.NewTestDoxer(gotestdox.WithHistoryStore(t.Name()))
+	td.Stdin = strings.NewReader(sinkInput)
+	td.Stdout = io.Discard
+	td.Filter()
+	fakeHistoryMu.Lock()
+	defer fakeHistoryMu.Unlock()
+	runs := fakeHistoryRuns[t.Name()]
+	if len(runs) != 1 {
+		t.Fatalf("want 1 run recorded, got %d", len(runs))
+	}
+	var got []string
+	for _, r := range runs[0] {
+		got = append(got, r.Test)
+	}
+	want := []string{"TestB", "TestA/works_fine", "TestA", "TestC", "TestD"}
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestFilter_WithHistoryStoreReportsErrorOpeningStore(t *testing.T) {
+	t.Parallel()
+	stderr := new(strings.Builder)
+	td := gotestdox.NewTestDoxer(gotestdox.WithHistoryStore("bogus"))
+	td.Stdin = strings.NewReader(sinkInput)
+	td.Stdout = io.Discard
+	td.Stderr = stderr
+	td.Filter()
+	if td.OK {
+		t.Error("want not OK")
+	}
+	if !strings.Contains(stderr.String(), "can't open bogus") {
+		t.Errorf("want error opening store, got %q", stderr)
+	}
+}
+
+func TestFilter_WithHistoryStoreReportsErrorClosingStore(t *testing.T) {
+	t.Parallel()
+	stderr := new(strings.Builder)
+	td := gotestdox.NewTestDoxer(gotestdox.WithHistoryStore("unclosable"))
+	td.Stdin = strings.NewReader(sinkInput)
+	td.Stdout = io.Discard
+	td.Stderr = stderr
+	td.Filter()
+	if td.OK {
+		t.Error("want not OK")
+	}
+	if !strings.Contains(stderr.String(), "closing history store: can't close unclosable") {
+		t.Errorf("want error closing store, got %q", stderr)
+	}
+}
+
+func TestRegisterHistoryStore_PanicsIfCalledTwice(t *testing.T) {
+	t.Parallel()
+	defer func() {
+		if recover() == nil {
+			t.Error("want panic")
+		}
+	}()
+	gotestdox.RegisterHistoryStore(func(string) (gotestdox.HistoryStore, error) {
+		return nil, nil
+	})
+}
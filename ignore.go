@@ -0,0 +1,92 @@
+package gotestdox
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+)
+
+// IgnoreFile is the name of the file, in the current directory, from which
+// [Run] reads patterns for [WithIgnore], if it exists.
+const IgnoreFile = ".gotestdoxignore"
+
+// ReadIgnoreFile reads the patterns for [WithIgnore] from the file at
+// filePath, which has one pattern per line. Blank lines, and lines starting
+// with '#', are ignored, as is any leading and trailing space. If any
+// pattern is malformed, ReadIgnoreFile returns an error giving its line
+// number.
+func ReadIgnoreFile(filePath string) ([]string, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	patterns := []string{}
+	scanner := bufio.NewScanner(f)
+	line := 0
+	for scanner.Scan() {
+		line++
+		pattern := strings.TrimSpace(scanner.Text())
+		if pattern == "" || strings.HasPrefix(pattern, "#") {
+			continue
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w: %q", filePath, line, err, pattern)
+		}
+		patterns = append(patterns, pattern)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return patterns, nil
+}
+
+// ignores reports whether the result r matches any of the patterns given by
+// [WithIgnore]: that is, whether the pattern matches its package path, or
+// the full name of its test, or the name of its top-level test.
+func (c config) ignores(r Result) bool {
+	for _, pattern := range c.ignorePatterns {
+		if matchGlob(pattern, r.Package) {
+			return true
+		}
+		if r.Test == "" {
+			continue
+		}
+		top, _, _ := strings.Cut(r.Test, "/")
+		if matchGlob(pattern, r.Test) || matchGlob(pattern, top) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchGlob reports whether name matches the shell pattern, as for
+// [path.Match], except that a "**" element of the pattern matches any
+// number of elements of name, including none. A malformed pattern matches
+// nothing.
+func matchGlob(pattern, name string) bool {
+	return matchElems(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchElems(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchElems(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, err := path.Match(pattern[0], name[0]); err != nil || !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
@@ -0,0 +1,124 @@
+package gotestdox_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bitfield/gotestdox"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestWithIgnore_MatchesPackagesAndTestsByPattern(t *testing.T) {
+	t.Parallel()
+	tcs := []struct {
+		pattern, pkg, test string
+		want               bool
+	}{
+		{"**/generated/**", "example.com/app/generated/api", "TestA", true},
+		{"**/generated/**", "example.com/app/generated", "TestA", true},
+		{"**/generated/**", "generated", "TestA", true},
+		{"**/generated/**", "example.com/app/generatedcode", "TestA", false},
+		{"**/generated/**", "example.com/app", "TestGenerated", false},
+		{"example.com/*/gen", "example.com/app/gen", "TestA", true},
+		{"example.com/*/gen", "example.com/app/sub/gen", "TestA", false},
+		{"example.com/**/gen", "example.com/app/sub/gen", "TestA", true},
+		{"example.com/**", "example.com", "TestA", true},
+		{"**", "example.com/app", "TestA", true},
+		{"TestGolden*", "example.com/app", "TestGoldenFiles", true},
+		{"TestGolden*", "example.com/app", "TestGoldenFiles/receipt_v2", true},
+		{"TestGolden*", "example.com/app", "TestParseGolden", false},
+		{"TestParse/empty_*", "example.com/app", "TestParse/empty_input", true},
+		{"TestParse/empty_*", "example.com/app", "TestParse/nonempty_input", false},
+		{"Test?", "example.com/app", "TestA", true},
+		{"Test[AB]", "example.com/app", "TestC", false},
+		{"[unclosed", "[unclosed", "TestA", false},
+	}
+	for _, tc := range tcs {
+		sink := &recordingSink{}
+		td := gotestdox.NewTestDoxer(gotestdox.WithSink(sink), gotestdox.WithIgnore(tc.pattern))
+		td.Stdin = strings.NewReader(`{"Action":"pass","Package":"` + tc.pkg + `","Test":"` + tc.test + `"}`)
+		td.Filter()
+		got := len(sink.results) == 0
+		if tc.want != got {
+			t.Errorf("pattern %q, package %q, test %q: want ignored %t, got %t", tc.pattern, tc.pkg, tc.test, tc.want, got)
+		}
+	}
+}
+
+var ignoreInput = `{"Action":"pass","Package":"p","Test":"TestA"}
+{"Action":"fail","Package":"p","Test":"TestGoldenB"}
+{"Action":"skip","Package":"p","Test":"TestGoldenC"}
+{"Action":"fail","Package":"p","Elapsed":0.1}
+{"Action":"pass","Package":"p/generated","Test":"TestD"}
+{"Action":"pass","Package":"p/generated","Elapsed":0.1}`
+
+func TestFilter_WithIgnoreCountsIgnoredTestsButDoesNotDeliverThem(t *testing.T) {
+	t.Parallel()
+	sink := &recordingSink{}
+	td := gotestdox.NewTestDoxer(gotestdox.WithSink(sink), gotestdox.WithIgnore("TestGolden*", "**/generated"))
+	td.Stdin = strings.NewReader(ignoreInput)
+	td.Filter()
+	delivered := []string{}
+	for _, r := range sink.results {
+		delivered = append(delivered, r.Package+" "+r.Test)
+	}
+	want := []string{"p TestA", "p "}
+	if !cmp.Equal(want, delivered) {
+		t.Error(cmp.Diff(want, delivered))
+	}
+	sum := sink.summary
+	if sum.Passed != 2 || sum.Failed != 1 || sum.Skipped != 1 || sum.Ignored != 3 {
+		t.Errorf("want 2 passed, 1 failed, 1 skipped, 3 ignored, got %d, %d, %d, %d", sum.Passed, sum.Failed, sum.Skipped, sum.Ignored)
+	}
+	if sum.TeardownFailures != 0 {
+		t.Errorf("want package with ignored failure not taken for teardown failure, got %d", sum.TeardownFailures)
+	}
+}
+
+func TestFilter_WithIgnoredUncountedCountsIgnoredTestsSeparately(t *testing.T) {
+	t.Parallel()
+	sink := &recordingSink{}
+	td := gotestdox.NewTestDoxer(gotestdox.WithSink(sink),
+		gotestdox.WithIgnore("TestGolden*", "**/generated"), gotestdox.WithIgnoredUncounted())
+	td.Stdin = strings.NewReader(ignoreInput)
+	td.Filter()
+	sum := sink.summary
+	if sum.Passed != 1 || sum.Failed != 0 || sum.Skipped != 0 || sum.Ignored != 3 {
+		t.Errorf("want 1 passed, 0 failed, 0 skipped, 3 ignored, got %d, %d, %d, %d", sum.Passed, sum.Failed, sum.Skipped, sum.Ignored)
+	}
+	if td.OK {
+		t.Error("want not OK, since the package failed")
+	}
+}
+
+func TestReadIgnoreFile_ReadsPatternsSkippingCommentsAndBlankLines(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), gotestdox.IgnoreFile)
+	err := os.WriteFile(path, []byte("# generated code\n**/generated/**\n\n  TestGolden*  \n"), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := gotestdox.ReadIgnoreFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"**/generated/**", "TestGolden*"}
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestReadIgnoreFile_ErrorsOnMalformedPattern(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), gotestdox.IgnoreFile)
+	err := os.WriteFile(path, []byte("TestA\n[unclosed\n"), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = gotestdox.ReadIgnoreFile(path)
+	if err == nil || !strings.Contains(err.Error(), ":2:") {
+		t.Errorf("want error giving line 2, got %v", err)
+	}
+}
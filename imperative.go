@@ -0,0 +1,61 @@
+package gotestdox
+
+import "strings"
+
+// imperativeVerbs are the verbs recognised by [WithImperativeMood] by
+// default, in the third-person forms that [Prettify] usually produces.
+var imperativeVerbs = []string{
+	"accepts", "adds", "allows", "applies", "builds", "calls", "checks",
+	"closes", "converts", "counts", "creates", "deletes", "emits", "excludes",
+	"fails", "finds", "fixes", "formats", "gets", "handles", "ignores",
+	"includes", "keeps", "loads", "matches", "opens", "parses", "passes",
+	"prevents", "prints", "produces", "reads", "receives", "records",
+	"rejects", "removes", "reports", "returns", "runs", "saves", "sends",
+	"sets", "skips", "sorts", "splits", "starts", "stops", "supports",
+	"tries", "updates", "uses", "validates", "writes",
+}
+
+// imperative returns sentence with its first behaviour word, the one
+// following the function name, changed from the third person to the
+// imperative mood, if it's one of the given verbs: for example, "Parse
+// returns error on empty input" becomes "Parse return error on empty
+// input". If sep is not empty and separates the function name from the
+// behaviour (see [WithSubjectSeparator]), the word after it is used.
+// Otherwise, sentence is returned unchanged.
+func imperative(sentence, sep string, verbs map[string]bool) string {
+	subject, behaviour, ok := "", "", false
+	if sep != "" {
+		subject, behaviour, ok = strings.Cut(sentence, sep)
+		subject += sep
+	}
+	if !ok {
+		subject, behaviour, ok = strings.Cut(sentence, " ")
+		subject += " "
+	}
+	if !ok {
+		return sentence
+	}
+	word, rest, _ := strings.Cut(behaviour, " ")
+	if !verbs[strings.ToLower(word)] {
+		return sentence
+	}
+	if rest != "" {
+		rest = " " + rest
+	}
+	return subject + baseForm(word) + rest
+}
+
+// baseForm returns the base form of the third-person verb word, such as
+// "return" for "returns", "match" for "matches", or "try" for "tries".
+func baseForm(word string) string {
+	lower := strings.ToLower(word)
+	switch {
+	case strings.HasSuffix(lower, "ies"):
+		return word[:len(word)-3] + "y"
+	case strings.HasSuffix(lower, "sses"), strings.HasSuffix(lower, "shes"),
+		strings.HasSuffix(lower, "ches"), strings.HasSuffix(lower, "xes"),
+		strings.HasSuffix(lower, "zzes"):
+		return word[:len(word)-2]
+	}
+	return strings.TrimSuffix(strings.TrimSuffix(word, "s"), "S")
+}
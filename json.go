@@ -0,0 +1,51 @@
+package gotestdox
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// JSONSink is a [ResultSink] that writes the results of a run, and its
+// [Summary], as a single JSON object, for other programs to read:
+//
+//	{"results":[...],"summary":{...}}
+//
+// The results, including package results, are in the order they completed,
+// each in the same form as a line of the input to [UnmarshalResults]. The
+// object is written, followed by a newline, at the end of the run.
+type JSONSink struct {
+	w       io.Writer
+	results []Result
+	config
+}
+
+// NewJSONSink returns a [*JSONSink] that writes to w, configured by opts.
+func NewJSONSink(w io.Writer, opts ...Option) *JSONSink {
+	return &JSONSink{
+		w:       w,
+		results: []Result{},
+		config:  newConfig(opts),
+	}
+}
+
+// Result buffers r.
+func (s *JSONSink) Result(r Result) error {
+	s.results = append(s.results, r)
+	return nil
+}
+
+// Summary writes the buffered results, and sum, as JSON.
+func (s *JSONSink) Summary(sum Summary) error {
+	data, err := json.Marshal(struct {
+		Results []Result `json:"results"`
+		Summary Summary  `json:"summary"`
+	}{
+		Results: s.results,
+		Summary: sum,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = s.w.Write(append(data, '\n'))
+	return err
+}
@@ -0,0 +1,47 @@
+package gotestdox_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/bitfield/gotestdox"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestJSONSink_WritesResultsAndSummaryAsSingleObject(t *testing.T) {
+	t.Parallel()
+	buf := new(strings.Builder)
+	td := gotestdox.NewTestDoxer(gotestdox.WithSink(gotestdox.NewJSONSink(buf)))
+	td.Stdin = strings.NewReader(sinkInput)
+	td.Filter()
+	if !strings.HasSuffix(buf.String(), "}\n") || strings.Count(buf.String(), "\n") != 1 {
+		t.Errorf("want one line of JSON, got:\n%s", buf)
+	}
+	var got struct {
+		Results []gotestdox.Result
+		Summary struct {
+			SchemaVersion int
+			Passed        int
+			Failed        int
+		}
+	}
+	if err := json.Unmarshal([]byte(buf.String()), &got); err != nil {
+		t.Fatal(err)
+	}
+	want := []gotestdox.Result{
+		{Package: "p", Test: "TestB", Sentence: "B", Status: "fail", Elapsed: 0.5},
+		{Package: "p", Test: "TestA/works_fine", Sentence: "A works fine", Status: "pass"},
+		{Package: "p", Test: "TestA", Sentence: "A", Status: "pass"},
+		{Package: "p", Test: "TestC", Sentence: "C", Status: "skip"},
+		{Package: "p", Status: "fail", Elapsed: 1.2},
+		{Package: "q", Test: "TestD", Sentence: "D", Status: "pass"},
+		{Package: "q", Status: "pass", Elapsed: 0.1},
+	}
+	if !cmp.Equal(want, got.Results) {
+		t.Error(cmp.Diff(want, got.Results))
+	}
+	if got.Summary.SchemaVersion != gotestdox.SchemaVersion || got.Summary.Passed != 3 || got.Summary.Failed != 1 {
+		t.Errorf("want summary of schema version %d with 3 passed and 1 failed, got %+v", gotestdox.SchemaVersion, got.Summary)
+	}
+}
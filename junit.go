@@ -0,0 +1,149 @@
+package gotestdox
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// JUnitSink is a [ResultSink] that writes results as JUnit XML, the format
+// read by most CI systems to display test reports. Each package is a
+// <testsuite>, and each test a <testcase> within it, named by its sentence,
+// with a <failure> element containing its output if it failed, or a
+// <skipped> element if it was skipped. Package results are used only for the
+// time each suite took.
+//
+// The report is written at the end of the run, with suites sorted by package
+// and test cases by test name, so that the output for the same results is
+// always the same.
+type JUnitSink struct {
+	w        io.Writer
+	results  []Result
+	packages map[string]Result
+	config
+}
+
+// NewJUnitSink returns a [*JUnitSink] that writes to w, configured by opts.
+func NewJUnitSink(w io.Writer, opts ...Option) *JUnitSink {
+	return &JUnitSink{
+		w:        w,
+		packages: map[string]Result{},
+		config:   newConfig(opts),
+	}
+}
+
+// Result buffers the result of a test, or of a package.
+func (s *JUnitSink) Result(r Result) error {
+	if r.Test == "" {
+		s.packages[junitSuiteName(r)] = r
+		return nil
+	}
+	s.results = append(s.results, r)
+	return nil
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Skipped  int             `xml:"skipped,attr"`
+	Time     string          `xml:"time,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	ClassName string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Skipped   *junitSkipped `xml:"skipped,omitempty"`
+}
+
+type junitFailure struct {
+	Message  string `xml:"message,attr"`
+	Contents string `xml:",chardata"`
+}
+
+type junitSkipped struct {
+	Message string `xml:"message,attr"`
+}
+
+// Summary writes all the buffered results as JUnit XML.
+func (s *JUnitSink) Summary(sum Summary) error {
+	results := append([]Result(nil), s.results...)
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Test < results[j].Test
+	})
+	suites := map[string]*junitTestSuite{}
+	for _, r := range results {
+		name := junitSuiteName(r)
+		suite, ok := suites[name]
+		if !ok {
+			suite = &junitTestSuite{Name: name}
+			suites[name] = suite
+		}
+		tc := junitTestCase{
+			ClassName: r.Package,
+			Name:      r.Sentence,
+			Time:      junitTime(r.Elapsed),
+		}
+		switch r.Status {
+		case "fail":
+			tc.Failure = &junitFailure{
+				Message:  "Failed",
+				Contents: strings.Join(r.Output, ""),
+			}
+			suite.Failures++
+		case "skip":
+			tc.Skipped = &junitSkipped{Message: "Skipped"}
+			suite.Skipped++
+		}
+		suite.Tests++
+		suite.Cases = append(suite.Cases, tc)
+	}
+	names := make([]string, 0, len(suites))
+	for name := range suites {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	report := junitTestSuites{Time: junitTime(sum.Elapsed)}
+	for _, name := range names {
+		suite := suites[name]
+		suite.Time = junitTime(s.packages[name].Elapsed)
+		report.Tests += suite.Tests
+		report.Failures += suite.Failures
+		report.Skipped += suite.Skipped
+		report.Suites = append(report.Suites, *suite)
+	}
+	data, err := xml.MarshalIndent(report, "", "\t")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(s.w, "%s%s\n", xml.Header, data)
+	return err
+}
+
+// junitSuiteName returns the name of the test suite for the package of r,
+// including its build configuration, if any.
+func junitSuiteName(r Result) string {
+	if r.Configuration != "" {
+		return r.Package + " (" + r.Configuration + ")"
+	}
+	return r.Package
+}
+
+// junitTime formats a time in seconds as JUnit expects.
+func junitTime(seconds float64) string {
+	return fmt.Sprintf("%.3f", seconds)
+}
@@ -0,0 +1,43 @@
+package gotestdox_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bitfield/gotestdox"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestJUnitSink_WritesTestSuitePerPackage(t *testing.T) {
+	t.Parallel()
+	buf := new(strings.Builder)
+	td := gotestdox.NewTestDoxer(gotestdox.WithSink(gotestdox.NewJUnitSink(buf)))
+	td.Stdin = strings.NewReader(`{"Action":"run","Package":"p","Test":"TestB"}
+{"Action":"output","Package":"p","Test":"TestB","Output":"    b_test.go:5: want <1>, got 2\n"}
+{"Action":"fail","Package":"p","Test":"TestB","Elapsed":0.5}
+{"Action":"pass","Package":"p","Test":"TestA","Elapsed":0.25}
+{"Action":"skip","Package":"p","Test":"TestC"}
+{"Action":"fail","Package":"p","Elapsed":1.2}
+{"Action":"pass","Package":"q","Test":"TestD"}
+{"Action":"pass","Package":"q","Elapsed":0.1}`)
+	td.Filter()
+	want := `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites tests="4" failures="1" skipped="1" time="1.300">
+	<testsuite name="p" tests="3" failures="1" skipped="1" time="1.200">
+		<testcase classname="p" name="A" time="0.250"></testcase>
+		<testcase classname="p" name="B" time="0.500">
+			<failure message="Failed">    b_test.go:5: want &lt;1&gt;, got 2&#xA;</failure>
+		</testcase>
+		<testcase classname="p" name="C" time="0.000">
+			<skipped message="Skipped"></skipped>
+		</testcase>
+	</testsuite>
+	<testsuite name="q" tests="1" failures="0" skipped="0" time="0.100">
+		<testcase classname="q" name="D" time="0.000"></testcase>
+	</testsuite>
+</testsuites>
+`
+	if want != buf.String() {
+		t.Error(cmp.Diff(want, buf.String()))
+	}
+}
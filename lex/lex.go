@@ -0,0 +1,125 @@
+// Package lex provides the state machine that gotestdox uses to split test
+// names into words, so that other ways of tokenising them can be tried out
+// without forking gotestdox.
+//
+// The lexer is built from state functions, in the style of Rob Pike's talk
+// on 'Lexical Scanning in Go' (https://www.youtube.com/watch?v=HxaD_trXwRE).
+// Each state function reads the input of a lexer, usually by way of the
+// [Runes] it embeds, collects the words it finds, and returns the next
+// state, or nil when it's done. [Run] runs the states in turn. gotestdox's
+// own lexer is written this way, with its heuristics on top, and a
+// [Segmenter] can be plugged into it, using gotestdox.WithSegmenter, to
+// decide where the words fall in spans it can't split by itself, such as a
+// run of capital letters.
+package lex
+
+// EOF is the rune returned by [Runes.Next] and [Runes.Peek] at the end of
+// the input.
+const EOF rune = 0
+
+// StateFunc is a state of a lexer of type L. It reads from the input,
+// collecting any words it finds, and returns the next state, or nil if the
+// lexer has finished.
+type StateFunc[L any] func(L) StateFunc[L]
+
+// Run runs l from the state start until it finishes.
+func Run[L any](l L, start StateFunc[L]) {
+	for state := start; state != nil; {
+		state = state(l)
+	}
+}
+
+// Runes reads the runes of Input, keeping track of the current word, which
+// runs from Start up to Pos. A lexer can embed it, adding its own ways of
+// collecting the words, and its state functions can move Start and Pos
+// directly when they recognise a whole word at once.
+type Runes struct {
+	Input      []rune
+	Start, Pos int
+}
+
+// Next returns the rune at the current position and moves past it, or
+// returns EOF at the end of the input.
+func (c *Runes) Next() rune {
+	next := c.Peek()
+	c.Pos++
+	return next
+}
+
+// Peek returns the rune at the current position, or EOF at the end of the
+// input, without moving.
+func (c *Runes) Peek() rune {
+	if c.Pos >= len(c.Input) {
+		return EOF
+	}
+	return c.Input[c.Pos]
+}
+
+// Prev returns the rune just before the current position.
+func (c *Runes) Prev() rune {
+	return c.Input[c.Pos-1]
+}
+
+// Backup moves back one rune.
+func (c *Runes) Backup() {
+	c.Pos--
+}
+
+// Skip discards the current word, so that the next one starts at the
+// current position.
+func (c *Runes) Skip() {
+	c.Start = c.Pos
+}
+
+// Word returns the current word.
+func (c *Runes) Word() string {
+	return string(c.Input[c.Start:c.Pos])
+}
+
+// Segmenter decides where the word boundaries fall in a span of a test name
+// that the lexer can't split by itself, such as "JSONXML", since there's no
+// change of case to go by. Segment returns the words making up span, or
+// nil, or a single word, to leave it as it is. The words are used exactly as
+// returned, so they should normally keep the case they had in span.
+type Segmenter interface {
+	Segment(span string) []string
+}
+
+// SegmenterFunc is a function that can be used as a [Segmenter].
+type SegmenterFunc func(span string) []string
+
+// Segment returns f(span).
+func (f SegmenterFunc) Segment(span string) []string {
+	return f(span)
+}
+
+// Dictionary is a [Segmenter] that splits a span into a sequence of the
+// words in the dictionary, preferring longer ones, as gotestdox does with
+// the initialisms given by gotestdox.WithInitialisms. A span that can't be
+// made up entirely of words from the dictionary is left as it is.
+type Dictionary map[string]bool
+
+// Segment implements [Segmenter]. Since a span can often be split in many
+// ways, each suffix of it is considered only once, working back from the
+// end, so that the time taken grows only with the square of its length.
+func (d Dictionary) Segment(span string) []string {
+	// next[i] is the end of the first word in the preferred split of
+	// span[i:], or zero if it can't be split
+	next := make([]int, len(span)+1)
+	for start := len(span) - 1; start >= 0; start-- {
+		for end := len(span); end > start; end-- {
+			if d[span[start:end]] && (end == len(span) || next[end] != 0) {
+				next[start] = end
+				break
+			}
+		}
+	}
+	if span != "" && next[0] == 0 {
+		return nil
+	}
+	words := []string{}
+	for start := 0; start < len(span); start = next[start] {
+		words = append(words, span[start:next[start]])
+	}
+	return words
+}
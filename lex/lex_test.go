@@ -0,0 +1,113 @@
+package lex_test
+
+import (
+	"strings"
+	"testing"
+	"unicode"
+
+	"github.com/bitfield/gotestdox/lex"
+	"github.com/google/go-cmp/cmp"
+)
+
+// camelLexer is a minimal lexer that splits its input at underscores and
+// before each capital letter, lowercasing the words.
+type camelLexer struct {
+	lex.Runes
+	words []string
+}
+
+func (l *camelLexer) Emit() bool {
+	if l.Pos <= l.Start {
+		l.Skip()
+		return false
+	}
+	l.EmitAs(strings.ToLower(l.Word()))
+	return true
+}
+
+func (l *camelLexer) EmitAs(word string) {
+	l.words = append(l.words, word)
+	l.Skip()
+}
+
+func between(l *camelLexer) lex.StateFunc[*camelLexer] {
+	for {
+		switch l.Next() {
+		case lex.EOF:
+			return nil
+		case '_':
+			l.Skip()
+		default:
+			return word
+		}
+	}
+}
+
+func word(l *camelLexer) lex.StateFunc[*camelLexer] {
+	for {
+		switch r := l.Peek(); {
+		case r == lex.EOF:
+			l.Emit()
+			return nil
+		case r == '_', unicode.IsUpper(r):
+			l.Emit()
+			return between
+		}
+		l.Next()
+	}
+}
+
+func TestRun_RunsStatesUntilLexerFinishes(t *testing.T) {
+	t.Parallel()
+	l := &camelLexer{Runes: lex.Runes{Input: []rune("ParsesInput_quickly")}}
+	lex.Run(l, between)
+	want := []string{"parses", "input", "quickly"}
+	if !cmp.Equal(want, l.words) {
+		t.Error(cmp.Diff(want, l.words))
+	}
+}
+
+func TestRunes_PeekAndNextReturnEOFAtEndOfInput(t *testing.T) {
+	t.Parallel()
+	c := &lex.Runes{Input: []rune("ab")}
+	c.Next()
+	c.Next()
+	if c.Peek() != lex.EOF || c.Next() != lex.EOF {
+		t.Error("want EOF at end of input")
+	}
+	c.Backup()
+	if c.Prev() != 'b' || c.Word() != "ab" {
+		t.Errorf("want word %q after backing up from EOF, got %q", "ab", c.Word())
+	}
+}
+
+func TestDictionary_SplitsSpanIntoKnownWordsPreferringLongerOnes(t *testing.T) {
+	t.Parallel()
+	d := lex.Dictionary{"JSON": true, "JS": true, "ON": true, "XML": true}
+	tcs := map[string][]string{
+		"JSONXML": {"JSON", "XML"},
+		"JSXML":   {"JS", "XML"},
+		"XMLJSON": {"XML", "JSON"},
+		"JSONX":   nil,
+	}
+	for span, want := range tcs {
+		got := d.Segment(span)
+		if !cmp.Equal(want, got) {
+			t.Errorf("%q: %s", span, cmp.Diff(want, got))
+		}
+	}
+}
+
+func TestDictionary_SplitsLongSpanWithManyPossibleSplitsQuickly(t *testing.T) {
+	t.Parallel()
+	d := lex.Dictionary{"A": true, "AA": true, "AAA": true}
+	span := strings.Repeat("A", 200)
+	want := append(strings.Fields(strings.Repeat("AAA ", 66)), "AA")
+	got := d.Segment(span)
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+	if got := d.Segment(span + "B"); got != nil {
+		t.Errorf("want nil for span that can't be split, got %q", got)
+	}
+}
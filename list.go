@@ -0,0 +1,126 @@
+package gotestdox
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// ExecGoTestList runs 'go test -list .', with any extra args supplied by the
+// user, and prints the sentence for each test it lists, as described for
+// [TestDoxer.FilterList], without running any tests. This gives a preview of
+// what the report will look like. Any errors are reported to td's Stderr
+// stream, including the full command line that was run, and make td.OK
+// false.
+func (td *TestDoxer) ExecGoTestList(userArgs []string) {
+	state := td.startRun(userArgs)
+	first, rest := splitDirFlag(userArgs)
+	args := append(append([]string{"test"}, first...), "-list", ".")
+	cmd := exec.Command("go", append(args, rest...)...)
+	goTestOutput, err := cmd.StdoutPipe()
+	if err != nil {
+		fmt.Fprintln(td.Stderr, cmd.Args, err)
+		return
+	}
+	cmd.Stderr = td.Stderr
+	if err := cmd.Start(); err != nil {
+		fmt.Fprintln(td.Stderr, cmd.Args, err)
+		return
+	}
+	td.Stdin = goTestOutput
+	td.filterList(state)
+	if err := cmd.Wait(); err != nil {
+		td.OK = false
+		fmt.Fprintln(td.Stderr, cmd.Args, err)
+	}
+}
+
+// FilterList reads from td's Stdin stream the plain-text output of 'go test
+// -list', which gives the names of the tests in each package, one per line,
+// followed by a line such as "ok  example.com/pkg  0.001s". For each
+// package with tests, it prints the name of the package to td.Stdout,
+// followed by the prettified name of each test, sorted alphabetically,
+// without any pass/fail status or elapsed time.
+//
+// Benchmarks and fuzz tests are left out, unless [WithListedBenchmarks] was
+// supplied, and so are examples. As for [TestDoxer.Filter], a different
+// [ResultSink] can be supplied using [WithSink]: each test is delivered as a
+// [Result] with an empty Status, followed by a result for its package.
+//
+// td.OK will be true at the end, unless the listing reported a failure, such
+// as a package that failed to build.
+func (td *TestDoxer) FilterList() {
+	td.filterList(td.startRun(nil))
+}
+
+// filterList does the work of [TestDoxer.FilterList], for the run with the
+// given state.
+func (td *TestDoxer) filterList(state *runState) {
+	td.OK = true
+	sink := td.sink
+	if sink == nil {
+		sink = newTextSink(td.Stdout, td.config, state, newPackageResolver(context.Background(), td.packageDirs, state.goTestArgs))
+	}
+	summary := Summary{}
+	pending := []Result{}
+	scanner := bufio.NewScanner(td.Stdin)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) == 1 && td.listed(fields[0]) {
+			pending = append(pending, Result{
+				Test:     fields[0],
+				Sentence: prettify(fields[0], td.config),
+			})
+			continue
+		}
+		if fields[0] == "FAIL" {
+			td.OK = false
+		}
+		if len(fields) < 2 || fields[0] != "ok" && fields[0] != "FAIL" {
+			continue
+		}
+		pkg := fields[1]
+		summary.Packages++
+		for _, r := range pending {
+			r.Package = pkg
+			if err := sink.Result(r); err != nil {
+				td.reportListError(err)
+				return
+			}
+		}
+		pending = pending[:0]
+		if err := sink.Result(Result{Package: pkg, Status: "pass"}); err != nil {
+			td.reportListError(err)
+			return
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		td.reportListError(err)
+		return
+	}
+	if err := sink.Summary(summary); err != nil {
+		td.reportListError(err)
+	}
+}
+
+// listed reports whether name, from the output of 'go test -list', should be
+// included by [TestDoxer.FilterList].
+func (td *TestDoxer) listed(name string) bool {
+	switch Classify(name) {
+	case Test:
+		return true
+	case Benchmark, Fuzz:
+		return td.listBenchmarks
+	}
+	return false
+}
+
+func (td *TestDoxer) reportListError(err error) {
+	td.OK = false
+	fmt.Fprintln(td.Stderr, err)
+}
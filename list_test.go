@@ -0,0 +1,101 @@
+package gotestdox_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bitfield/gotestdox"
+	"github.com/fatih/color"
+	"github.com/google/go-cmp/cmp"
+)
+
+var listInput = `TestParsesInput
+TestHandleInput_ClosesInput
+BenchmarkParse
+FuzzParse
+ExampleParse
+ok  	example.com/parser	0.002s
+TestWorks
+ok  	example.com/other	0.001s
+?   	example.com/empty	[no test files]
+`
+
+func TestFilterList_PrintsSentencesForListedTestsByPackage(t *testing.T) {
+	t.Parallel()
+	color.NoColor = true
+	tcs := []struct {
+		opts []gotestdox.Option
+		want string
+	}{
+		{
+			want: `example.com/parser:
+ HandleInput closes input
+ Parses input
+
+example.com/other:
+ Works
+
+`,
+		},
+		{
+			opts: []gotestdox.Option{gotestdox.WithListedBenchmarks()},
+			want: `example.com/parser:
+ Benchmark parse
+ Fuzz parse
+ HandleInput closes input
+ Parses input
+
+example.com/other:
+ Works
+
+`,
+		},
+	}
+	for _, tc := range tcs {
+		buf := new(strings.Builder)
+		td := gotestdox.NewTestDoxer(tc.opts...)
+		td.Stdin = strings.NewReader(listInput)
+		td.Stdout = buf
+		td.FilterList()
+		if !td.OK {
+			t.Error("want ok")
+		}
+		if tc.want != buf.String() {
+			t.Error(cmp.Diff(tc.want, buf.String()))
+		}
+	}
+}
+
+func TestFilterList_IsNotOKWhenListingReportsFailure(t *testing.T) {
+	t.Parallel()
+	td := gotestdox.NewTestDoxer()
+	td.Stdin = strings.NewReader("TestA\nFAIL\texample.com/broken [build failed]\n")
+	td.Stdout = new(strings.Builder)
+	td.FilterList()
+	if td.OK {
+		t.Error("want not ok")
+	}
+}
+
+func TestExecGoTestList_PreviewsSentencesWithoutRunningTests(t *testing.T) {
+	t.Parallel()
+	color.NoColor = true
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"go.mod":    "module example.com/list\n\ngo 1.18\n",
+		"a_test.go": "package list\n\nimport \"testing\"\n\nfunc TestNeverRuns(t *testing.T) { t.Fatal(\"ran\") }\n",
+	})
+	buf := new(strings.Builder)
+	stderr := new(strings.Builder)
+	td := gotestdox.NewTestDoxer()
+	td.Stdout = buf
+	td.Stderr = stderr
+	td.ExecGoTestList([]string{"-C", dir, "./..."})
+	if !td.OK {
+		t.Fatalf("want ok, stderr:\n%s", stderr)
+	}
+	want := "example.com/list:\n Never runs\n\n"
+	if want != buf.String() {
+		t.Error(cmp.Diff(want, buf.String()))
+	}
+}
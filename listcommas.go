@@ -0,0 +1,112 @@
+package gotestdox
+
+import (
+	"strings"
+	"unicode"
+)
+
+// notListItems are the words that can't be items of a list punctuated by
+// [WithListCommas], since they join or qualify other words rather than
+// naming things or actions.
+var notListItems = map[string]bool{
+	"a": true, "an": true, "the": true, "and": true, "or": true, "nor": true,
+	"not": true, "no": true, "is": true, "are": true, "was": true, "has": true,
+	"does": true, "as": true, "at": true, "by": true, "for": true, "from": true,
+	"in": true, "into": true, "of": true, "on": true, "to": true, "with": true,
+	"its": true, "this": true, "these": true, "those": true, "us": true,
+	"unless": true, "when": true, "if": true, "then": true, "than": true,
+}
+
+// listItemKind describes a word that may be an item of a list, for
+// [WithListCommas]: the items of a list must all be of the same kind.
+type listItemKind int
+
+const (
+	notItem listItemKind = iota
+	initialismItem
+	wordItem
+)
+
+// listItem returns the kind of list item that word could be. An initialism
+// is two or more capital letters or digits, including at least one letter,
+// such as "JSON" or "HTTP2". An ordinary word is made of letters, all lower
+// case except perhaps the first, and ends in 's', as does a plural noun,
+// such as "records", or a verb such as "creates", so that a phrase like
+// "handles empty input and output" isn't mistaken for a list.
+func listItem(word string) listItemKind {
+	runes := []rune(word)
+	if len(runes) < 2 {
+		return notItem
+	}
+	upper, lower, digits := 0, 0, 0
+	for _, r := range runes {
+		switch {
+		case unicode.IsUpper(r):
+			upper++
+		case unicode.IsLower(r):
+			lower++
+		case unicode.IsDigit(r):
+			digits++
+		default:
+			return notItem
+		}
+	}
+	switch {
+	case lower == 0 && upper > 0:
+		return initialismItem
+	case digits > 0, upper > 1, upper == 1 && !unicode.IsUpper(runes[0]):
+		return notItem
+	case strings.HasSuffix(word, "s") && !notListItems[lowerCase(word)]:
+		return wordItem
+	}
+	return notItem
+}
+
+// punctuateList punctuates any list in words, which are one level of a
+// test's sentence, as described for [WithListCommas]. The first word isn't
+// considered as a list item if it's the subject of the sentence.
+//
+// The first word of the clause is usually its verb, which ends in 's' as
+// often as the nouns after it do. If a run of such words reaches it, and
+// the clause goes on after the last item, as in "creates reads updates and
+// deletes records", the run is taken to be a list of verbs, including the
+// first word. Otherwise, as in "parses headers bodies and trailers", it's
+// taken to be a verb followed by a list of nouns, which starts after it.
+func punctuateList(words []string, subject bool) {
+	first := 0
+	if subject {
+		first = 1
+	}
+	for i := first + 2; i < len(words)-1; i++ {
+		if lowerCase(words[i]) != "and" {
+			continue
+		}
+		kind := listItem(words[i+1])
+		if kind == notItem {
+			continue
+		}
+		start := i
+		for start > first && listItem(words[start-1]) == kind {
+			start--
+		}
+		if kind == wordItem && start == first && i+2 == len(words) {
+			start++
+		}
+		if i-start < 2 {
+			continue
+		}
+		for j := start; j < i; j++ {
+			words[j] += ","
+		}
+	}
+}
+
+// addListCommas punctuates any lists in the words of each level of the
+// sentence, as described for [WithListCommas].
+func (p *prettifier) addListCommas() {
+	start := 0
+	for _, end := range append(p.segments, len(p.words)) {
+		punctuateList(p.words[start:end], start == 0 && p.subject)
+		start = end
+	}
+}
@@ -0,0 +1,134 @@
+package gotestdox
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// testLocation is the location of a test function, or of a t.Run call
+// starting a subtest, given as a path and line number.
+type testLocation struct {
+	file string
+	line int
+}
+
+// testLocator finds the locations of tests in the source of their packages,
+// for [Result].File and [Result].Line. The test files of each package are
+// parsed at most once.
+type testLocator struct {
+	packages *packageResolver
+	tests    map[string]map[string]testLocation
+}
+
+func newTestLocator(packages *packageResolver) *testLocator {
+	return &testLocator{
+		packages: packages,
+		tests:    map[string]map[string]testLocation{},
+	}
+}
+
+// locate returns the location of the test r, as a path relative to the root
+// of its module, with forward slashes, and a line number. A subtest is
+// located at the t.Run call that started it, if the call gives its name as
+// a string literal, or at the location of its nearest such ancestor, or of
+// its top-level test function, otherwise. If the test can't be found, locate
+// returns the empty string and zero.
+func (l *testLocator) locate(r Result) (string, int) {
+	key := r.Module + " " + r.Package
+	tests, ok := l.tests[key]
+	if !ok {
+		tests = l.index(r.Package, r.Module)
+		l.tests[key] = tests
+	}
+	for name := r.Test; name != ""; {
+		if loc, ok := tests[name]; ok {
+			return loc.file, loc.line
+		}
+		i := strings.LastIndex(name, "/")
+		if i < 0 {
+			break
+		}
+		name = name[:i]
+	}
+	return "", 0
+}
+
+// index returns the locations of the test functions in the test files of
+// the package pkg, tested in the given module directory, and of any subtests
+// started with a literal name, keyed by test name.
+func (l *testLocator) index(pkg, module string) map[string]testLocation {
+	tests := map[string]testLocation{}
+	dirs := l.packages.resolve(pkg, module)
+	if dirs.dir == "" {
+		return tests
+	}
+	files, err := filepath.Glob(filepath.Join(dirs.dir, "*_test.go"))
+	if err != nil {
+		return tests
+	}
+	fset := token.NewFileSet()
+	for _, path := range files {
+		f, err := parser.ParseFile(fset, path, nil, parser.SkipObjectResolution)
+		if err != nil {
+			continue
+		}
+		file := displayPath(path, dirs.module)
+		for _, decl := range f.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Recv != nil || fn.Body == nil {
+				continue
+			}
+			name := fn.Name.Name
+			tests[name] = testLocation{file, fset.Position(fn.Pos()).Line}
+			indexSubtests(fn.Body, name, file, fset, tests)
+		}
+	}
+	return tests
+}
+
+// indexSubtests records in tests the location of each call in node of the
+// form t.Run("name", ...), with a literal name, as a subtest of parent,
+// including any nested subtests started within the same call.
+func indexSubtests(node ast.Node, parent, file string, fset *token.FileSet, tests map[string]testLocation) {
+	ast.Inspect(node, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok || len(call.Args) != 2 {
+			return true
+		}
+		sel, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok || sel.Sel.Name != "Run" {
+			return true
+		}
+		lit, ok := call.Args[0].(*ast.BasicLit)
+		if !ok || lit.Kind != token.STRING {
+			return true
+		}
+		sub, err := strconv.Unquote(lit.Value)
+		if err != nil {
+			return true
+		}
+		name := parent + "/" + strings.ReplaceAll(sub, " ", "_")
+		if _, dup := tests[name]; !dup {
+			tests[name] = testLocation{file, fset.Position(call.Pos()).Line}
+		}
+		indexSubtests(call.Args[1], name, file, fset, tests)
+		return false
+	})
+}
+
+// displayPath returns path relative to the module directory, with forward
+// slashes, or path unchanged if it's not inside the module.
+func displayPath(path, module string) string {
+	if module == "" {
+		return path
+	}
+	rel, err := filepath.Rel(module, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return path
+	}
+	return filepath.ToSlash(rel)
+}
@@ -0,0 +1,159 @@
+package gotestdox_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bitfield/gotestdox"
+	"github.com/fatih/color"
+	"github.com/google/go-cmp/cmp"
+)
+
+const locationSource = `package pkg
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	t.Run("handles empty input", func(t *testing.T) {
+		t.Run("without panicking", func(t *testing.T) {})
+	})
+	for _, tc := range []string{"a"} {
+		t.Run(tc, func(t *testing.T) {})
+	}
+}
+
+func TestLoad(t *testing.T) {}
+`
+
+// locationModule writes a module containing a package with locationSource
+// as its test file, returning the options to find it.
+func locationModule(t *testing.T) (string, []gotestdox.Option) {
+	t.Helper()
+	root := t.TempDir()
+	dir := filepath.Join(root, "internal", "pkg")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "go.mod"), []byte("module example.com/m\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "pkg_test.go"), []byte(locationSource), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return dir, []gotestdox.Option{
+		gotestdox.WithPackageDirs(map[string]string{"example.com/m/internal/pkg": dir}),
+	}
+}
+
+const locationInput = `{"Action":"pass","Package":"example.com/m/internal/pkg","Test":"TestParse/handles_empty_input/without_panicking"}
+{"Action":"pass","Package":"example.com/m/internal/pkg","Test":"TestParse/handles_empty_input"}
+{"Action":"pass","Package":"example.com/m/internal/pkg","Test":"TestParse/a"}
+{"Action":"pass","Package":"example.com/m/internal/pkg","Test":"TestParse"}
+{"Action":"pass","Package":"example.com/m/internal/pkg","Test":"TestLoad"}
+{"Action":"pass","Package":"example.com/m/internal/pkg","Test":"TestGone"}
+{"Action":"pass","Package":"example.com/m/internal/pkg"}`
+
+func TestFilter_WithTestLocationsSetsFileAndLineOfEachTest(t *testing.T) {
+	t.Parallel()
+	_, opts := locationModule(t)
+	sink := &recordingSink{}
+	td := gotestdox.NewTestDoxer(append(opts, gotestdox.WithSink(sink), gotestdox.WithTestLocations())...)
+	td.Stdin = strings.NewReader(locationInput)
+	td.Filter()
+	type location struct {
+		Test string
+		File string
+		Line int
+	}
+	got := []location{}
+	for _, r := range sink.results {
+		got = append(got, location{r.Test, r.File, r.Line})
+	}
+	file := "internal/pkg/pkg_test.go"
+	want := []location{
+		{"TestParse/handles_empty_input/without_panicking", file, 7},
+		{"TestParse/handles_empty_input", file, 6},
+		{"TestParse/a", file, 5},
+		{"TestParse", file, 5},
+		{"TestLoad", file, 14},
+		{"TestGone", "", 0},
+		{"", "", 0},
+	}
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestFilter_WithPackageDirsAloneDoesNotLocateTests(t *testing.T) {
+	t.Parallel()
+	_, opts := locationModule(t)
+	sink := &recordingSink{}
+	td := gotestdox.NewTestDoxer(append(opts, gotestdox.WithSink(sink))...)
+	td.Stdin = strings.NewReader(locationInput)
+	td.Filter()
+	for _, r := range sink.results {
+		if r.File != "" || r.Line != 0 {
+			t.Errorf("%q: want no location, got %s:%d", r.Test, r.File, r.Line)
+		}
+	}
+}
+
+func TestFilter_ParsesTestFilesOfEachPackageOnlyOnce(t *testing.T) {
+	t.Parallel()
+	dir, opts := locationModule(t)
+	deleted := false
+	sink := &recordingSink{}
+	opts = append(opts, gotestdox.WithSink(sink), gotestdox.WithTestLocations(), gotestdox.WithOnResult(func(gotestdox.Result) {
+		if !deleted {
+			deleted = true
+			if err := os.Remove(filepath.Join(dir, "pkg_test.go")); err != nil {
+				t.Error(err)
+			}
+		}
+	}))
+	td := gotestdox.NewTestDoxer(opts...)
+	td.Stdin = strings.NewReader(locationInput)
+	td.Filter()
+	for _, r := range sink.results {
+		if r.Test == "TestLoad" && r.Line != 14 {
+			t.Errorf("want TestLoad located from cached source, got %s:%d", r.File, r.Line)
+		}
+	}
+}
+
+func TestTextSink_WithTestLocationsShowsLocationAfterSentence(t *testing.T) {
+	t.Parallel()
+	color.NoColor = true
+	_, opts := locationModule(t)
+	buf := new(strings.Builder)
+	td := gotestdox.NewTestDoxer(append(opts, gotestdox.WithTestLocations())...)
+	td.Stdin = strings.NewReader(locationInput)
+	td.Stdout = buf
+	td.Filter()
+	want := `example.com/m/internal/pkg:
+ ✔ Gone (0.00s)
+ ✔ Load  internal/pkg/pkg_test.go:14 (0.00s)
+ ✔ Parse a  internal/pkg/pkg_test.go:5 (0.00s)
+ ✔ Parse handles empty input without panicking  internal/pkg/pkg_test.go:7 (0.00s)
+
+`
+	if want != buf.String() {
+		t.Error(cmp.Diff(want, buf.String()))
+	}
+}
+
+func TestTextSink_DoesNotShowLocationsByDefault(t *testing.T) {
+	t.Parallel()
+	color.NoColor = true
+	_, opts := locationModule(t)
+	buf := new(strings.Builder)
+	td := gotestdox.NewTestDoxer(opts...)
+	td.Stdin = strings.NewReader(locationInput)
+	td.Stdout = buf
+	td.Filter()
+	if strings.Contains(buf.String(), "pkg_test.go") {
+		t.Errorf("unexpected location in output:\n%s", buf)
+	}
+}
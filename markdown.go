@@ -0,0 +1,124 @@
+package gotestdox
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// MarkdownSink is a [ResultSink] that writes results as Markdown, for a
+// README or a pull request. Each package gets a level-two heading, followed
+// by its doc comment, if known, and a bulleted list of its tests, marked by
+// the same symbols as in the output of a [TreeSink]. A test with subtests
+// gets a level-three heading of its own, in title case (see
+// [TitleCaseSentence] and [WithTitleStopWords]), followed by a list of them,
+// with only the words that each adds to its parent's sentence:
+//
+//	## p
+//
+//	- ✔ Formats dates
+//
+//	### Parse of the Config File
+//
+//	- ✔ handles empty input
+//	- x rejects invalid input
+//
+// The tests of each package are written, sorted by name, once its result
+// arrives, or at the end of the run, for any package that didn't finish.
+type MarkdownSink struct {
+	w     io.Writer
+	tests map[string][]Result
+	order []string
+	config
+}
+
+// NewMarkdownSink returns a [*MarkdownSink] that writes to w, configured by
+// opts.
+func NewMarkdownSink(w io.Writer, opts ...Option) *MarkdownSink {
+	return &MarkdownSink{
+		w:      w,
+		tests:  map[string][]Result{},
+		config: newConfig(opts),
+	}
+}
+
+// Result buffers the result of a test, or writes the tests for a package,
+// given its result.
+func (s *MarkdownSink) Result(r Result) error {
+	key := Event{Package: r.Package, Configuration: r.Configuration}.key("")
+	if r.Test == "" {
+		return s.writePackage(key, r)
+	}
+	if _, ok := s.tests[key]; !ok {
+		s.order = append(s.order, key)
+	}
+	s.tests[key] = append(s.tests[key], r)
+	return nil
+}
+
+// Summary writes the tests of any packages whose results never arrived.
+func (s *MarkdownSink) Summary(Summary) error {
+	for _, key := range s.order {
+		tests, ok := s.tests[key]
+		if !ok {
+			continue
+		}
+		if err := s.writePackage(key, tests[0]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writePackage writes the heading for the package whose result, or any of
+// whose test results, is pkg, followed by the tests buffered under key, and
+// forgets them.
+func (s *MarkdownSink) writePackage(key string, pkg Result) error {
+	tests := s.tests[key]
+	delete(s.tests, key)
+	if len(tests) == 0 {
+		return nil
+	}
+	heading := pkg.Package
+	if pkg.Configuration != "" {
+		heading += " (" + pkg.Configuration + ")"
+	}
+	b := new(strings.Builder)
+	fmt.Fprintf(b, "## %s\n\n", heading)
+	if pkg.Doc != "" {
+		fmt.Fprintf(b, "%s\n\n", pkg.Doc)
+	}
+	t := newTestTree(tests)
+	var parents []string
+	list := false
+	for _, name := range t.children[""] {
+		if len(t.children[name]) > 0 {
+			parents = append(parents, name)
+			continue
+		}
+		fmt.Fprintf(b, "- %s %s\n", statusSymbol(t.results[name].Status), t.results[name].Sentence)
+		list = true
+	}
+	if list {
+		b.WriteString("\n")
+	}
+	for _, name := range parents {
+		r := t.results[name]
+		fmt.Fprintf(b, "### %s\n\n", s.titleCase(r.Sentence))
+		t.writeList(b, "", t.children[name], r.Sentence)
+		b.WriteString("\n")
+	}
+	_, err := io.WriteString(s.w, b.String())
+	return err
+}
+
+// writeList writes the tests names, whose parent's sentence is
+// parentSentence, to b as Markdown list items, each indented by indent, and
+// followed by a nested list of its own subtests.
+func (t testTree) writeList(b *strings.Builder, indent string, names []string, parentSentence string) {
+	for _, name := range names {
+		r := t.results[name]
+		fmt.Fprintf(b, "%s- %s %s\n", indent, statusSymbol(r.Status), subtestWords(r.Sentence, parentSentence))
+		t.writeList(b, indent+"  ", t.children[name], r.Sentence)
+	}
+}
@@ -0,0 +1,86 @@
+package gotestdox_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bitfield/gotestdox"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestMarkdownSink_WritesHeadingAndListOfTestsPerPackage(t *testing.T) {
+	t.Parallel()
+	buf := new(strings.Builder)
+	td := gotestdox.NewTestDoxer(gotestdox.WithSink(gotestdox.NewMarkdownSink(buf)))
+	td.Stdin = strings.NewReader(sinkInput)
+	td.Filter()
+	want := `## p
+
+- x B
+- ○ C
+
+### A
+
+- ✔ works fine
+
+## q
+
+- ✔ D
+
+`
+	if want != buf.String() {
+		t.Error(cmp.Diff(want, buf.String()))
+	}
+}
+
+func TestMarkdownSink_NestsSubtestsOfSubtests(t *testing.T) {
+	t.Parallel()
+	buf := new(strings.Builder)
+	td := gotestdox.NewTestDoxer(gotestdox.WithSink(gotestdox.NewMarkdownSink(buf)))
+	td.Stdin = strings.NewReader(`{"Action":"pass","Package":"p","Test":"TestParse/rejects_invalid_input/with_nested_brackets"}
+{"Action":"pass","Package":"p","Test":"TestParse/rejects_invalid_input"}
+{"Action":"pass","Package":"p","Test":"TestParse/handles_empty_input"}
+{"Action":"pass","Package":"p","Test":"TestParse"}
+{"Action":"pass","Package":"p"}`)
+	td.Filter()
+	want := `## p
+
+### Parse
+
+- ✔ handles empty input
+- ✔ rejects invalid input
+  - ✔ with nested brackets
+
+`
+	if want != buf.String() {
+		t.Error(cmp.Diff(want, buf.String()))
+	}
+}
+
+func TestMarkdownSink_TitleCasesHeadingsForTestsWithSubtests(t *testing.T) {
+	t.Parallel()
+	input := `{"Action":"pass","Package":"p","Test":"TestParsingOfTheConfigFile/handles_empty_input"}
+{"Action":"pass","Package":"p","Test":"TestParsingOfTheConfigFile"}
+{"Action":"pass","Package":"p"}`
+	tcs := []struct {
+		opts []gotestdox.Option
+		want string
+	}{
+		{
+			want: "### Parsing of the Config File\n",
+		},
+		{
+			opts: []gotestdox.Option{gotestdox.WithTitleStopWords("config")},
+			want: "### Parsing Of The config File\n",
+		},
+	}
+	for _, tc := range tcs {
+		buf := new(strings.Builder)
+		td := gotestdox.NewTestDoxer(gotestdox.WithSink(gotestdox.NewMarkdownSink(buf, tc.opts...)))
+		td.Stdin = strings.NewReader(input)
+		td.Filter()
+		if !strings.Contains(buf.String(), tc.want) {
+			t.Errorf("want heading %q, got:\n%s", tc.want, buf)
+		}
+	}
+}
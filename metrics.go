@@ -0,0 +1,155 @@
+package gotestdox
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// defaultSlowestTests is the number of tests for which a [MetricsSink]
+// reports individual durations, unless set by [WithSlowestTests].
+const defaultSlowestTests = 10
+
+// MetricsSink is a [ResultSink] that writes a summary of the run as metrics
+// in the Prometheus text exposition format, suitable for the node exporter's
+// textfile collector. The metrics are written at the end of the run:
+//
+//   - gotestdox_tests_total, the number of tests with each status
+//   - gotestdox_flaky_tests_total, the number of flaky tests
+//   - gotestdox_package_duration_seconds, the elapsed time of each package
+//   - gotestdox_test_duration_seconds, the elapsed time of each of the
+//     slowest tests, labelled with its sentence
+//
+// To keep the number of series under control, only the ten slowest tests are
+// included, unless a different number is set using [WithSlowestTests]. A
+// test or package run more than once, as with 'go test -count', is reported
+// once, with the duration of its longest run, and the series of a run under
+// a [Configuration] have a configuration label naming it.
+type MetricsSink struct {
+	w        io.Writer
+	tests    []Result
+	packages []Result
+	config
+}
+
+// NewMetricsSink returns a [*MetricsSink] that writes to w, configured by
+// opts.
+func NewMetricsSink(w io.Writer, opts ...Option) *MetricsSink {
+	return &MetricsSink{
+		w:      w,
+		config: newConfig(opts),
+	}
+}
+
+// Result records the result of a test or package.
+func (s *MetricsSink) Result(r Result) error {
+	if r.Test == "" {
+		s.packages = append(s.packages, r)
+		return nil
+	}
+	s.tests = append(s.tests, r)
+	return nil
+}
+
+// Summary writes the metrics for the run.
+func (s *MetricsSink) Summary(sum Summary) error {
+	w := bufio.NewWriter(s.w)
+	flaky := 0
+	for _, r := range s.tests {
+		if r.Flaky {
+			flaky++
+		}
+	}
+	writeMetricHeader(w, "gotestdox_tests_total", "counter", "Number of tests completed, by status.")
+	fmt.Fprintf(w, "gotestdox_tests_total{status=\"pass\"} %d\n", sum.Passed)
+	fmt.Fprintf(w, "gotestdox_tests_total{status=\"fail\"} %d\n", sum.Failed)
+	fmt.Fprintf(w, "gotestdox_tests_total{status=\"skip\"} %d\n", sum.Skipped)
+	writeMetricHeader(w, "gotestdox_flaky_tests_total", "counter", "Number of tests that failed before passing.")
+	fmt.Fprintf(w, "gotestdox_flaky_tests_total %d\n", flaky)
+	packages := longestRuns(s.packages)
+	sort.SliceStable(packages, func(i, j int) bool {
+		if packages[i].Package != packages[j].Package {
+			return packages[i].Package < packages[j].Package
+		}
+		return packages[i].Configuration < packages[j].Configuration
+	})
+	writeMetricHeader(w, "gotestdox_package_duration_seconds", "gauge", "Elapsed time of each package.")
+	for _, r := range packages {
+		fmt.Fprintf(w, "gotestdox_package_duration_seconds{package=%s%s} %g\n", quoteLabel(r.Package), configurationLabel(r), r.Elapsed)
+	}
+	slowest := longestRuns(s.tests)
+	sort.SliceStable(slowest, func(i, j int) bool {
+		if slowest[i].Elapsed != slowest[j].Elapsed {
+			return slowest[i].Elapsed > slowest[j].Elapsed
+		}
+		if slowest[i].Package != slowest[j].Package {
+			return slowest[i].Package < slowest[j].Package
+		}
+		if slowest[i].Test != slowest[j].Test {
+			return slowest[i].Test < slowest[j].Test
+		}
+		return slowest[i].Configuration < slowest[j].Configuration
+	})
+	limit := s.slowestTests
+	switch {
+	case limit == 0:
+		limit = defaultSlowestTests
+	case limit < 0:
+		limit = 0
+	}
+	if len(slowest) > limit {
+		slowest = slowest[:limit]
+	}
+	writeMetricHeader(w, "gotestdox_test_duration_seconds", "gauge", "Elapsed time of the slowest tests.")
+	for _, r := range slowest {
+		fmt.Fprintf(w, "gotestdox_test_duration_seconds{package=%s,test=%s,sentence=%s%s} %g\n",
+			quoteLabel(r.Package), quoteLabel(r.Test), quoteLabel(r.Sentence), configurationLabel(r), r.Elapsed)
+	}
+	return w.Flush()
+}
+
+// longestRuns returns results, keeping only the longest run of each test or
+// package that was run more than once, as with 'go test -count', so that
+// each series is reported only once. The results of different
+// configurations are kept apart.
+func longestRuns(results []Result) []Result {
+	runs := []Result{}
+	index := map[string]int{}
+	for _, r := range results {
+		key := r.Configuration + "\x00" + r.Package + "\x00" + r.Test
+		i, ok := index[key]
+		switch {
+		case !ok:
+			index[key] = len(runs)
+			runs = append(runs, r)
+		case r.Elapsed > runs[i].Elapsed:
+			runs[i] = r
+		}
+	}
+	return runs
+}
+
+// configurationLabel returns the configuration label for the series of r,
+// with a leading comma, or "" if r wasn't run under a configuration.
+func configurationLabel(r Result) string {
+	if r.Configuration == "" {
+		return ""
+	}
+	return ",configuration=" + quoteLabel(r.Configuration)
+}
+
+func writeMetricHeader(w io.Writer, name, kind, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(w, "# TYPE %s %s\n", name, kind)
+}
+
+// labelEscaper escapes the characters that are special in label values in
+// the Prometheus text format.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// quoteLabel returns v as a quoted label value.
+func quoteLabel(v string) string {
+	return `"` + labelEscaper.Replace(v) + `"`
+}
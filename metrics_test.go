@@ -0,0 +1,126 @@
+package gotestdox_test
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/bitfield/gotestdox"
+	"github.com/google/go-cmp/cmp"
+)
+
+var metricsInput = `{"Action":"pass","Package":"p","Test":"TestA","Elapsed":0.5}
+{"Action":"fail","Package":"p","Test":"TestB/handles_\"quotes\"_and_\\backslashes","Elapsed":1.5}
+{"Action":"fail","Package":"p","Test":"TestB","Elapsed":1.5}
+{"Action":"skip","Package":"p","Test":"TestC"}
+{"Action":"fail","Package":"p","Elapsed":2.25}
+{"Action":"pass","Package":"a","Test":"TestD","Elapsed":0.1}
+{"Action":"pass","Package":"a","Elapsed":0.2}`
+
+func TestMetricsSink_WritesSummaryInPrometheusTextFormat(t *testing.T) {
+	t.Parallel()
+	buf := new(strings.Builder)
+	sink := gotestdox.NewMetricsSink(buf, gotestdox.WithSlowestTests(2))
+	td := gotestdox.NewTestDoxer(gotestdox.WithSink(sink))
+	td.Stdin = strings.NewReader(metricsInput)
+	td.Filter()
+	want := `# HELP gotestdox_tests_total Number of tests completed, by status.
+# TYPE gotestdox_tests_total counter
+gotestdox_tests_total{status="pass"} 2
+gotestdox_tests_total{status="fail"} 2
+gotestdox_tests_total{status="skip"} 1
+# HELP gotestdox_flaky_tests_total Number of tests that failed before passing.
+# TYPE gotestdox_flaky_tests_total counter
+gotestdox_flaky_tests_total 0
+# HELP gotestdox_package_duration_seconds Elapsed time of each package.
+# TYPE gotestdox_package_duration_seconds gauge
+gotestdox_package_duration_seconds{package="a"} 0.2
+gotestdox_package_duration_seconds{package="p"} 2.25
+# HELP gotestdox_test_duration_seconds Elapsed time of the slowest tests.
+# TYPE gotestdox_test_duration_seconds gauge
+gotestdox_test_duration_seconds{package="p",test="TestB",sentence="B"} 1.5
+gotestdox_test_duration_seconds{package="p",test="TestB/handles_\"quotes\"_and_\\backslashes",sentence="B handles \"quotes\" and \\backslashes"} 1.5
+`
+	if want != buf.String() {
+		t.Error(cmp.Diff(want, buf.String()))
+	}
+	validateExposition(t, buf.String())
+}
+
+func TestMetricsSink_ReportsEachSeriesOnceForRepeatedRunsAndConfigurations(t *testing.T) {
+	t.Parallel()
+	input := `{"Action":"pass","Package":"p","Test":"TestA","Elapsed":0.5,"Configuration":"race"}
+{"Action":"pass","Package":"p","Test":"TestA","Elapsed":0.7,"Configuration":"race"}
+{"Action":"pass","Package":"p","Elapsed":1.2,"Configuration":"race"}
+{"Action":"pass","Package":"p","Test":"TestA","Elapsed":0.1}
+{"Action":"pass","Package":"p","Test":"TestA","Elapsed":0.2}
+{"Action":"pass","Package":"p","Elapsed":0.3}`
+	buf := new(strings.Builder)
+	sink := gotestdox.NewMetricsSink(buf)
+	td := gotestdox.NewTestDoxer(gotestdox.WithSink(sink))
+	td.Stdin = strings.NewReader(input)
+	td.Filter()
+	want := `gotestdox_package_duration_seconds{package="p"} 0.3
+gotestdox_package_duration_seconds{package="p",configuration="race"} 1.2
+# HELP gotestdox_test_duration_seconds Elapsed time of the slowest tests.
+# TYPE gotestdox_test_duration_seconds gauge
+gotestdox_test_duration_seconds{package="p",test="TestA",sentence="A",configuration="race"} 0.7
+gotestdox_test_duration_seconds{package="p",test="TestA",sentence="A"} 0.2
+`
+	if !strings.HasSuffix(buf.String(), want) {
+		t.Errorf("want output ending:\n%s\ngot:\n%s", want, buf)
+	}
+	validateExposition(t, buf.String())
+}
+
+func TestMetricsSink_WithNegativeSlowestTestsReportsNoTestDurations(t *testing.T) {
+	t.Parallel()
+	buf := new(strings.Builder)
+	sink := gotestdox.NewMetricsSink(buf, gotestdox.WithSlowestTests(-1))
+	td := gotestdox.NewTestDoxer(gotestdox.WithSink(sink))
+	td.Stdin = strings.NewReader(metricsInput)
+	td.Filter()
+	if strings.Contains(buf.String(), "gotestdox_test_duration_seconds{") {
+		t.Errorf("want no test durations, got:\n%s", buf)
+	}
+	validateExposition(t, buf.String())
+}
+
+var (
+	metricLine  = regexp.MustCompile(`^([a-zA-Z_:][a-zA-Z0-9_:]*)(\{(.*)\})? (\S+)$`)
+	labelPair   = regexp.MustCompile(`^([a-zA-Z_][a-zA-Z0-9_]*)="((?:[^"\\\n]|\\[\\"n])*)"(,|$)`)
+	commentLine = regexp.MustCompile(`^# (HELP|TYPE) [a-zA-Z_:][a-zA-Z0-9_:]* \S.*$`)
+)
+
+// validateExposition checks that text is valid according to the Prometheus
+// text exposition format, as far as gotestdox uses it.
+func validateExposition(t *testing.T, text string) {
+	t.Helper()
+	seen := map[string]bool{}
+	for i, line := range strings.Split(strings.TrimSuffix(text, "\n"), "\n") {
+		if strings.HasPrefix(line, "#") {
+			if !commentLine.MatchString(line) {
+				t.Errorf("line %d: invalid comment %q", i+1, line)
+			}
+			continue
+		}
+		m := metricLine.FindStringSubmatch(line)
+		if m == nil {
+			t.Errorf("line %d: invalid sample %q", i+1, line)
+			continue
+		}
+		for labels := m[3]; labels != ""; {
+			pair := labelPair.FindString(labels)
+			if pair == "" {
+				t.Errorf("line %d: invalid labels %q", i+1, m[3])
+				break
+			}
+			labels = labels[len(pair):]
+		}
+		series := m[1] + m[2]
+		if seen[series] {
+			t.Errorf("line %d: duplicate series %s", i+1, series)
+		}
+		seen[series] = true
+	}
+}
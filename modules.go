@@ -0,0 +1,155 @@
+package gotestdox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// ModuleSummary gives the results of the tests in a single module, in a run
+// started by [TestDoxer.ExecGoTestModules]. Dir is the module directory, as
+// supplied. NoTests is true if the module contained no packages to test.
+type ModuleSummary struct {
+	Dir      string `json:"dir"`
+	Packages int    `json:"packages"`
+	Passed   int    `json:"passed"`
+	Failed   int    `json:"failed"`
+	Skipped  int    `json:"skipped"`
+	NoTests  bool   `json:"noTests,omitempty"`
+}
+
+// WorkspaceModules returns the directories of the modules used by the
+// workspace defined in the given go.work file, in the order in which they
+// are listed there. Relative directories are interpreted relative to the
+// directory containing the go.work file.
+func WorkspaceModules(gowork string) ([]string, error) {
+	out, err := exec.Command("go", "work", "edit", "-json", gowork).Output()
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", gowork, err)
+	}
+	var work struct {
+		Use []struct {
+			DiskPath string
+		}
+	}
+	if err := json.Unmarshal(out, &work); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", gowork, err)
+	}
+	dirs := make([]string, 0, len(work.Use))
+	for _, u := range work.Use {
+		dir := u.DiskPath
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(filepath.Dir(gowork), dir)
+		}
+		dirs = append(dirs, dir)
+	}
+	return dirs, nil
+}
+
+// ExecGoTestModules is like [TestDoxer.ExecGoTest], but runs 'go test -json
+// ./...' separately in each of the module directories dirs, such as those
+// returned by [WorkspaceModules], passing userArgs to each. The results are
+// merged into a single report, in which each package is labelled with the
+// directory of its module, and the [Summary] includes a [ModuleSummary] for
+// each module.
+//
+// By default the modules are tested one at a time; use [WithModuleParallelism]
+// to test several at once. Either way, the results for each module are
+// reported in the order given, as soon as that module has finished. A
+// failure in any module makes td.OK false, but doesn't stop the other
+// modules being tested, and a module with no packages to test is not
+// considered a failure.
+func (td *TestDoxer) ExecGoTestModules(dirs []string, userArgs []string) {
+	runs := make([]*goTestRun, len(dirs))
+	for i, dir := range dirs {
+		args := append([]string{"test", "-json"}, userArgs...)
+		runs[i] = &goTestRun{
+			dir:    dir,
+			args:   append(args, "./..."),
+			module: dir,
+		}
+	}
+	state := td.startRun(userArgs)
+	state.moduleDirs = dirs
+	td.execParallel(context.Background(), runs, td.moduleParallelism, true, state)
+}
+
+// noPackages reports whether the run failed only because there were no
+// packages to test.
+func noPackages(run *goTestRun) bool {
+	if run.stdout.Len() > 0 {
+		return false
+	}
+	stderr := run.stderr.String()
+	return strings.Contains(stderr, "no packages to test") ||
+		strings.Contains(stderr, "matched no packages")
+}
+
+// writeEvents writes each line of the output of run, from 'go test -json', to
+// w, adding its Module and Configuration fields to each event, unless they
+// are empty. Lines that aren't JSON objects are written unchanged.
+func writeEvents(w io.Writer, run *goTestRun) {
+	out := run.stdout.Bytes()
+	fields := ""
+	if run.module != "" {
+		quoted, _ := json.Marshal(run.module)
+		fields += fmt.Sprintf("\"Module\":%s,", quoted)
+	}
+	if run.configuration != "" {
+		quoted, _ := json.Marshal(run.configuration)
+		fields += fmt.Sprintf("\"Configuration\":%s,", quoted)
+	}
+	if fields == "" {
+		w.Write(out)
+		return
+	}
+	for _, line := range bytes.Split(out, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		if bytes.HasPrefix(line, []byte("{\"")) {
+			fmt.Fprintf(w, "{%s%s\n", fields, line[1:])
+			continue
+		}
+		fmt.Fprintf(w, "%s\n", line)
+	}
+}
+
+// hasModule reports whether s has a summary for the module in dir.
+func (s *Summary) hasModule(dir string) bool {
+	for _, m := range s.Modules {
+		if m.Dir == dir {
+			return true
+		}
+	}
+	return false
+}
+
+// addModule counts the result r in the summary for its module, if any.
+func (s *Summary) addModule(r Result) {
+	for i := range s.Modules {
+		m := &s.Modules[i]
+		if m.Dir != r.Module {
+			continue
+		}
+		if r.Test == "" {
+			m.Packages++
+			return
+		}
+		switch r.Status {
+		case "pass":
+			m.Passed++
+		case "fail":
+			m.Failed++
+		case "skip":
+			m.Skipped++
+		}
+		return
+	}
+}
@@ -0,0 +1,88 @@
+package gotestdox_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bitfield/gotestdox"
+	"github.com/fatih/color"
+	"github.com/google/go-cmp/cmp"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestExecGoTestModules_MergesResultsFromEachWorkspaceModule(t *testing.T) {
+	t.Parallel()
+	color.NoColor = true
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"go.work":          "go 1.18\n\nuse (\n\t./a\n\t./b\n\t./empty\n)\n",
+		"a/go.mod":         "module example.com/a\n\ngo 1.18\n",
+		"a/a_test.go":      "package a\n\nimport \"testing\"\n\nfunc TestAWorks(t *testing.T) {}\n",
+		"b/go.mod":         "module example.com/b\n\ngo 1.18\n",
+		"b/b_test.go":      "package b\n\nimport \"testing\"\n\nfunc TestBFails(t *testing.T) { t.Fail() }\n",
+		"empty/go.mod":     "module example.com/empty\n\ngo 1.18\n",
+		"empty/README.txt": "nothing to see here\n",
+	})
+	dirs, err := gotestdox.WorkspaceModules(filepath.Join(dir, "go.work"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantDirs := []string{
+		filepath.Join(dir, "a"),
+		filepath.Join(dir, "b"),
+		filepath.Join(dir, "empty"),
+	}
+	if !cmp.Equal(wantDirs, dirs) {
+		t.Fatal(cmp.Diff(wantDirs, dirs))
+	}
+	buf := new(strings.Builder)
+	td := gotestdox.NewTestDoxer(gotestdox.WithModuleParallelism(2))
+	td.Stdout = buf
+	stderr := new(strings.Builder)
+	td.Stderr = stderr
+	td.ExecGoTestModules(dirs, nil)
+	if td.OK {
+		t.Error("want not ok when a module has failing tests")
+	}
+	got := buf.String()
+	for _, want := range []string{
+		"[" + dirs[0] + "] example.com/a:\n ✔ A works (",
+		"[" + dirs[1] + "] example.com/b:\n x B fails (",
+		dirs[0] + ": 1 packages, 1 passed, 0 failed, 0 skipped\n",
+		dirs[1] + ": 1 packages, 0 passed, 1 failed, 0 skipped\n",
+		dirs[2] + ": no tests\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("want output to contain %q, got:\n%s\nstderr:\n%s", want, got, stderr)
+		}
+	}
+}
+
+func TestExecGoTestModules_LeavesNothingBehindForNextRunBySameTestDoxer(t *testing.T) {
+	useFakeGoTest(t)
+	td := gotestdox.NewTestDoxer()
+	td.Stdout, td.Stderr = new(strings.Builder), new(strings.Builder)
+	td.ExecGoTestModules([]string{t.TempDir()}, nil)
+	if len(td.Summary.Modules) != 1 {
+		t.Fatalf("want summary for 1 module, got %+v", td.Summary.Modules)
+	}
+	td.Stdin = strings.NewReader(sinkInput)
+	td.Filter()
+	if len(td.Summary.Modules) != 0 {
+		t.Errorf("want no modules in summary of next run, got %+v", td.Summary.Modules)
+	}
+}
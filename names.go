@@ -0,0 +1,91 @@
+package gotestdox
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// NameKind identifies the kind of function a Go test name refers to, as
+// determined by [Classify].
+type NameKind int
+
+const (
+	// NotATest is the kind of any name that the testing package would not
+	// run.
+	NotATest NameKind = iota
+	// Test is the kind of names like TestFoo.
+	Test
+	// Benchmark is the kind of names like BenchmarkFoo.
+	Benchmark
+	// Fuzz is the kind of names like FuzzFoo.
+	Fuzz
+	// Example is the kind of names like ExampleFoo.
+	Example
+)
+
+var kindNames = map[NameKind]string{
+	NotATest:  "NotATest",
+	Test:      "Test",
+	Benchmark: "Benchmark",
+	Fuzz:      "Fuzz",
+	Example:   "Example",
+}
+
+// String returns the name of the kind, such as "Test".
+func (k NameKind) String() string {
+	if s, ok := kindNames[k]; ok {
+		return s
+	}
+	return "NameKind(?)"
+}
+
+var prefixes = []struct {
+	prefix string
+	kind   NameKind
+}{
+	{"Test", Test},
+	{"Benchmark", Benchmark},
+	{"Fuzz", Fuzz},
+	{"Example", Example},
+}
+
+// Classify determines what kind of function the test name refers to, applying
+// the same rules as the testing package: the name must begin with one of the
+// prefixes Test, Benchmark, Fuzz, or Example, and the prefix must be followed
+// either by nothing, or by a rune that is not a lowercase letter. So TestFoo
+// and Test_foo are tests, but Testable is not.
+//
+// If the name is that of a subtest, only the parent test name (the part before
+// the first slash) is considered.
+func Classify(name string) NameKind {
+	parent, _ := SplitSubtests(name)
+	for _, p := range prefixes {
+		if !strings.HasPrefix(parent, p.prefix) {
+			continue
+		}
+		rest := parent[len(p.prefix):]
+		if rest == "" {
+			return p.kind
+		}
+		r, _ := utf8.DecodeRuneInString(rest)
+		if unicode.IsLower(r) {
+			return NotATest
+		}
+		return p.kind
+	}
+	return NotATest
+}
+
+// SplitSubtests splits a test name into the name of the top-level test
+// function, and the names of any subtests, in order of nesting. For example,
+// "TestFoo/bar/baz" is split into "TestFoo" and ["bar", "baz"]. Since a test
+// function's name can't contain a slash, the first slash always marks the
+// start of the subtests. If there are no subtests, parts is nil.
+func SplitSubtests(name string) (parent string, parts []string) {
+	parent, rest, found := strings.Cut(name, "/")
+	if !found {
+		return parent, nil
+	}
+	return parent, strings.Split(rest, "/")
+}
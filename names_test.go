@@ -0,0 +1,74 @@
+package gotestdox_test
+
+import (
+	"testing"
+
+	"github.com/bitfield/gotestdox"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestClassify_AppliesTheTestingPackageNamingRules(t *testing.T) {
+	t.Parallel()
+	tcs := []struct {
+		name string
+		want gotestdox.NameKind
+	}{
+		{"Test", gotestdox.Test},
+		{"TestFoo", gotestdox.Test},
+		{"Test_foo", gotestdox.Test},
+		{"Test2FA", gotestdox.Test},
+		{"TestFoo/bar_baz", gotestdox.Test},
+		{"Testable", gotestdox.NotATest},
+		{"Testable/Foo", gotestdox.NotATest},
+		{"BenchmarkFoo", gotestdox.Benchmark},
+		{"Benchmarks", gotestdox.NotATest},
+		{"FuzzParse", gotestdox.Fuzz},
+		{"Fuzzy", gotestdox.NotATest},
+		{"Example", gotestdox.Example},
+		{"ExampleFoo_suffix", gotestdox.Example},
+		{"Examples", gotestdox.NotATest},
+		{"", gotestdox.NotATest},
+		{"MyTestFoo", gotestdox.NotATest},
+		{"TestÉcole", gotestdox.Test},
+		{"Testé", gotestdox.NotATest},
+	}
+	for _, tc := range tcs {
+		got := gotestdox.Classify(tc.name)
+		if tc.want != got {
+			t.Errorf("%q: want %v, got %v", tc.name, tc.want, got)
+		}
+	}
+}
+
+func TestSplitSubtests_SplitsTopLevelTestFromSubtests(t *testing.T) {
+	t.Parallel()
+	tcs := []struct {
+		name   string
+		parent string
+		parts  []string
+	}{
+		{"TestFoo", "TestFoo", nil},
+		{"TestFoo/bar", "TestFoo", []string{"bar"}},
+		{"TestFoo/bar/baz_qux", "TestFoo", []string{"bar", "baz_qux"}},
+		{"TestFoo/", "TestFoo", []string{""}},
+		{"Test//x", "Test", []string{"", "x"}},
+	}
+	for _, tc := range tcs {
+		parent, parts := gotestdox.SplitSubtests(tc.name)
+		if tc.parent != parent {
+			t.Errorf("%q: want parent %q, got %q", tc.name, tc.parent, parent)
+		}
+		if !cmp.Equal(tc.parts, parts) {
+			t.Errorf("%q: %s", tc.name, cmp.Diff(tc.parts, parts))
+		}
+	}
+}
+
+func TestNameKindString_GivesNameOfKind(t *testing.T) {
+	t.Parallel()
+	want := "Benchmark"
+	got := gotestdox.Benchmark.String()
+	if want != got {
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
@@ -0,0 +1,17 @@
+package gotestdox
+
+import "strings"
+
+// ranNoCases reports whether the completed top-level test r, which had no
+// subtests in this run, looks as though it should have had some: either
+// because it had subtests in the previous run, as recorded by
+// [WithDurationHistory], or, if heuristic is true, because it passed and
+// its sentence consists of the function name alone, as for a table test.
+func ranNoCases(r Result, previous durations, heuristic bool) bool {
+	for test := range previous[historyPackage(r)] {
+		if strings.HasPrefix(test, r.Test+"/") {
+			return true
+		}
+	}
+	return heuristic && r.Status == "pass" && behaviourWords(r.Sentence) == 0
+}
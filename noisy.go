@@ -0,0 +1,57 @@
+package gotestdox
+
+import (
+	"regexp"
+	"strings"
+)
+
+// logLine matches a line of output written by t.Log or a similar method,
+// which is indented, and prefixed by the file name and line number of the
+// call.
+var logLine = regexp.MustCompile(`^\s+[^\s:]+\.go:\d+: `)
+
+// boilerplate lists the prefixes of the lines that 'go test' itself writes
+// to the output of a test.
+var boilerplate = []string{
+	"=== RUN", "=== PAUSE", "=== CONT", "=== NAME",
+	"--- PASS", "--- FAIL", "--- SKIP",
+}
+
+// noisyLines returns the lines in output, the output of a test, that were
+// not written by 'go test' itself, without their trailing newlines. Unless
+// includeLogs is true, lines written by t.Log and similar methods are not
+// included either, as far as they can be identified.
+func noisyLines(output []string, includeLogs bool) []string {
+	noisy := []string{}
+	inLog := false
+	for _, line := range output {
+		line = strings.TrimRight(line, "\n")
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || isBoilerplate(trimmed) {
+			inLog = false
+			continue
+		}
+		if !includeLogs {
+			if logLine.MatchString(line) {
+				inLog = true
+				continue
+			}
+			// continuation of a multiline log message
+			if inLog && strings.HasPrefix(line, "        ") {
+				continue
+			}
+		}
+		inLog = false
+		noisy = append(noisy, line)
+	}
+	return noisy
+}
+
+func isBoilerplate(line string) bool {
+	for _, prefix := range boilerplate {
+		if strings.HasPrefix(line, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,1297 @@
+package gotestdox
+
+import (
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/bitfield/gotestdox/lex"
+)
+
+// Option configures the behaviour of a [TestDoxer], or of a single call to
+// [Prettify]. Options are applied in the order given, so later options
+// override earlier ones.
+type Option func(*config)
+
+// config holds the settings shared by the filter and the prettifier. The
+// defaults are set by newConfig.
+type config struct {
+	vagueCheck          bool
+	vagueMinWords       int
+	scoreCheck          bool
+	scoreLowest         int
+	scoreWeights        *ScoreWeights
+	noCasesCheck        bool
+	noCasesGuess        bool
+	noCasesFail         bool
+	initialisms         map[string]bool
+	segmenter           lex.Segmenter
+	numberJoiner        string
+	shortWords          map[string]string
+	fillerWords         map[string]bool
+	prefixMatching      PrefixMatching
+	normaliseShortWords bool
+	numericLabel        string
+	subjectSep          string
+	subtestSep          string
+	maxFuncWords        int
+	maxSentenceLen      int
+	maxWordLen          int
+	preserveCase        bool
+	conjunctions        bool
+	listCommas          bool
+	plusWord            string
+	debugLog            bool
+	onDecision          func(string, CaseDecision)
+	stopWords           map[string]bool
+	// filter options
+	collapseNumeric   bool
+	fanOutThreshold   int
+	fanOutListSkips   bool
+	onResult          func(Result)
+	listBenchmarks    bool
+	examples          bool
+	sink              ResultSink
+	extraRenderers    []additionalRenderer
+	sentenceSuffix    string
+	imperativeVerbs   map[string]bool
+	rollUpSubtests    bool
+	slowestTests      int
+	histogram         bool
+	histogramBounds   []time.Duration
+	showNames         bool
+	showNamesFailed   bool
+	activeDurations   bool
+	parentLines       bool
+	hideCachedTests   bool
+	excludedHidden    bool
+	failureGroups     bool
+	noisiestTests     int
+	clock             Clock
+	largeOutput       int
+	largeOutputSymbol string
+	minDuration       time.Duration
+	noisySymbol       string
+	noisyLogs         bool
+	sourceSnippets    bool
+	coverProfile      string
+	testLocations     bool
+	packageDocs       bool
+	testOutputMax     int
+	ignorePatterns    []string
+	ignoreUncounted   bool
+	reportOutputMax   int
+	tabWidth          int
+	thresholds        *Thresholds
+	validate          bool
+	resultLog         string
+	packageDirs       map[string]string
+	skipCategories    bool
+	skipRules         []skipRule
+	stallAfter        time.Duration
+	progress          bool
+	rerunCommands     bool
+	rerunFlags        bool
+	// exec options
+	moduleParallelism  int
+	packageParallelism int
+	packageTimeout     time.Duration
+	packageTimeouts    map[string]time.Duration
+	configParallelism  int
+	failFast           bool
+	flakyAsFailure     bool
+	flakyExitCode      int
+	shutdownTimeout    time.Duration
+	environment        bool
+	prefixStderr       bool
+	captureStderr      bool
+	signalsDisabled    bool
+	// duration history options
+	historyPath        string
+	historyUpdate      bool
+	historyDSN         string
+	regressionAbsolute time.Duration
+	regressionRelative float64
+	regressionMinimum  time.Duration
+	// exitOnSecondSignal is set only by [Run] (see [TestDoxer.catchSignals])
+	exitOnSecondSignal bool
+}
+
+func newConfig(opts []Option) config {
+	c := config{
+		maxFuncWords:       5,
+		regressionAbsolute: 100 * time.Millisecond,
+		regressionRelative: 0.5,
+		regressionMinimum:  100 * time.Millisecond,
+		shutdownTimeout:    5 * time.Second,
+		testOutputMax:      defaultTestOutputBudget,
+		reportOutputMax:    defaultReportOutputBudget,
+		tabWidth:           4,
+	}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return c
+}
+
+// WithVagueNameCheck enables a check, run at the end of [TestDoxer.Filter],
+// that reports tests whose behaviour clause (the part of the sentence after
+// the function name) has fewer than minWords words. Names like TestParse,
+// TestNew, or TestOK tell the reader nothing about the expected behaviour.
+//
+// A test with subtests is never reported itself, since its subtests carry the
+// behaviour; instead, each subtest is checked in its own right.
+func WithVagueNameCheck(minWords int) Option {
+	return func(c *config) {
+		c.vagueCheck = true
+		c.vagueMinWords = minWords
+	}
+}
+
+// WithSentenceScores enables a check, run at the end of [TestDoxer.Filter],
+// that rates the sentence for each test using [Score], and records the
+// average score in the [Summary], so that it can be tracked over time. The
+// given number of lowest-scoring tests are listed too, or all of them, if
+// the number is negative, with the location of each test function, if it
+// can be found, as candidates for renaming.
+//
+// As for [WithVagueNameCheck], a test with subtests is never scored itself.
+// To change how sentences are scored, use [WithScoreWeights].
+func WithSentenceScores(lowest int) Option {
+	return func(c *config) {
+		c.scoreCheck = true
+		c.scoreLowest = lowest
+	}
+}
+
+// WithScoreWeights sets the weights used by [WithSentenceScores] to rate
+// each sentence, instead of the [DefaultScoreWeights]. Any initialisms
+// supplied using [WithInitialisms] are never counted as camel case.
+func WithScoreWeights(w ScoreWeights) Option {
+	return func(c *config) {
+		c.scoreWeights = &w
+	}
+}
+
+// WithPackageDocs causes [TestDoxer.Filter] to set the Doc field of the
+// [Result] for each package to the first sentence of the package's doc
+// comment, so that a report, such as a generated specification, can
+// introduce each package before listing its behaviours. The package's
+// source is found in the same way as for [WithTestLocations]. Packages with
+// no doc comment, or whose source can't be found, have an empty Doc. A
+// [TextSink] prints the sentence, in italics, under the package's heading.
+func WithPackageDocs() Option {
+	return func(c *config) {
+		c.packageDocs = true
+	}
+}
+
+// WithTestLocations causes a [TextSink] to show the location of the source
+// of each test after its sentence, dimmed, relative to the root of its
+// module. For example:
+//
+//	✔ Parser rejects BOM  parser/parse_test.go:42 (0.00s)
+//
+// The location is also given by the File and Line fields of each [Result],
+// as described there. Tests whose source can't be found show no location.
+func WithTestLocations() Option {
+	return func(c *config) {
+		c.testLocations = true
+	}
+}
+
+// WithCoverProfile causes [TestDoxer.Filter] to read the coverage profile at
+// the given path, as written by 'go test -coverprofile', at the end of the
+// run, and to report the coverage of each function under test: that is,
+// the function named before the multiword marker in a test name, as in
+// TestHandleInput_ClosesInput, or, failing that, by the first word of the
+// sentence. A method can be named with its receiver type, as in
+// TestClient_Do_RetriesOnTimeout. The results are listed in the [Summary],
+// with the lines of any statements that weren't run.
+//
+// Without this option, the profile named by any -coverprofile flag passed
+// to 'go test' is used. If the profile doesn't exist, or a function can't be
+// found in the package source, or the source no longer matches the profile,
+// that function's coverage is simply not reported.
+func WithCoverProfile(path string) Option {
+	return func(c *config) {
+		c.coverProfile = path
+	}
+}
+
+// WithNoCasesCheck enables a check, run by [TestDoxer.Filter], for
+// top-level tests that ran no subtests, although they had subtests in the
+// previous run, as recorded by [WithDurationHistory]. This can happen when a
+// table test's slice of cases is accidentally emptied, so that the test
+// passes without checking anything. Such tests are listed in the [Summary],
+// and a [TextSink] prints a warning for each, such as:
+//
+//	⚠ Validate ran no cases
+//
+// Without a duration history, the check does nothing, unless
+// [WithNoCasesHeuristic] is also supplied.
+func WithNoCasesCheck() Option {
+	return func(c *config) {
+		c.noCasesCheck = true
+	}
+}
+
+// WithNoCasesHeuristic is like [WithNoCasesCheck], but also reports any
+// passing top-level test that ran no subtests, and whose sentence consists
+// only of the function name, such as TestValidate. This is a weaker check,
+// since such a test may have no subtests by design.
+func WithNoCasesHeuristic() Option {
+	return func(c *config) {
+		c.noCasesCheck = true
+		c.noCasesGuess = true
+	}
+}
+
+// WithNoCasesFailing is like [WithNoCasesCheck], but treats any test it
+// reports as a failure of the run, so that td.OK is false.
+func WithNoCasesFailing() Option {
+	return func(c *config) {
+		c.noCasesCheck = true
+		c.noCasesFail = true
+	}
+}
+
+// WithDebug causes [Prettify] to write debug information about how it
+// interprets each name to [DebugWriter]. This is the same as setting the
+// GOTESTDOX_DEBUG environment variable (see [FromEnv]).
+func WithDebug() Option {
+	return func(c *config) {
+		c.debugLog = true
+	}
+}
+
+// WithInitialisms adds the given words to a dictionary of known initialisms,
+// such as "JSON" or "XML". When [Prettify] encounters a run of capital letters
+// with no other word break, such as "JSONXML", it uses the dictionary to split
+// it into separate words, preferring longer matches. A run that can't be
+// split entirely into known initialisms is left as it is.
+//
+// Without a dictionary, all-caps runs are never split, unless a different
+// way of splitting them is given by [WithSegmenter].
+func WithInitialisms(words ...string) Option {
+	return func(c *config) {
+		if c.initialisms == nil {
+			c.initialisms = map[string]bool{}
+		}
+		for _, w := range words {
+			c.initialisms[w] = true
+		}
+	}
+}
+
+// WithSegmenter makes [Prettify] use s, instead of the dictionary given by
+// [WithInitialisms], to split any run of capital letters with no other word
+// break, such as "JSONXML", into separate words. The words returned by s are
+// used as they are, and reported to any callback given by
+// [WithDecisionCallback] as [Segmented]. This is the place to try out a
+// different way of finding the words, such as a word list for names written
+// entirely in capitals; see the [lex] package for the building blocks of
+// the rest of the lexer.
+func WithSegmenter(s lex.Segmenter) Option {
+	return func(c *config) {
+		c.segmenter = s
+	}
+}
+
+// WithShortWords adds the given words to the list of short initialisms,
+// such as "ID" or "OK", whose capitalisation is restored by
+// [WithShortWordNormalisation]. By default, the list contains CI, DB, ID,
+// IO, IP, OK, OS, and UI. Each word should be given in the capitalisation
+// it's to have.
+func WithShortWords(words ...string) Option {
+	return func(c *config) {
+		if c.shortWords == nil {
+			c.shortWords = make(map[string]string, len(defaultShortWords)+len(words))
+			for k, v := range defaultShortWords {
+				c.shortWords[k] = v
+			}
+		}
+		for _, w := range words {
+			c.shortWords[strings.ToLower(w)] = w
+		}
+	}
+}
+
+// WithShortWordsOnly is like [WithShortWords], but replaces the default
+// list, rather than adding to it.
+func WithShortWordsOnly(words ...string) Option {
+	return func(c *config) {
+		c.shortWords = make(map[string]string, len(words))
+		for _, w := range words {
+			c.shortWords[strings.ToLower(w)] = w
+		}
+	}
+}
+
+// WithPrefixMatching sets how [Prettify] recognises the prefix of a test
+// name to leave out of the sentence. The default, [ExactPrefix], removes
+// "Test" exactly, as the testing package requires, so that Go names are
+// prettified as usual. [CaseInsensitive] is for names from elsewhere, such
+// as "TEST_user_login", which would otherwise begin with the prefix.
+func WithPrefixMatching(m PrefixMatching) Option {
+	return func(c *config) {
+		c.prefixMatching = m
+	}
+}
+
+// WithLeadingFillerWords causes [Prettify] to drop any of the given words,
+// ignoring case, from the start of a sentence, as long as at least two more
+// words of the test's own name follow, so that, for example, given "that",
+// "it", and "the", TestThatTheServerStartsQuickly becomes "Server starts
+// quickly", rather than "That the server starts quickly", and
+// TestItRejectsNulls becomes "Rejects nulls". The word that is left first
+// is capitalised as usual. Words are never dropped from a marked function
+// name, such as TestIt_Works, or from subtest names, so the sentence is
+// never left empty. By default, no words are dropped.
+func WithLeadingFillerWords(words ...string) Option {
+	return func(c *config) {
+		if c.fillerWords == nil {
+			c.fillerWords = make(map[string]bool, len(words))
+		}
+		for _, w := range words {
+			c.fillerWords[strings.ToLower(w)] = true
+		}
+	}
+}
+
+// WithShortWordNormalisation causes [Prettify] to give any word matching
+// one of the short words (see [WithShortWords]), ignoring case, the
+// capitalisation in the list, even if it's typed differently in the test
+// name. For example, TestValidatesIdFormat becomes "Validates ID format",
+// and TestParse/reads_ids becomes "Parse reads IDs". This takes precedence
+// over [WithPreservedCase].
+//
+// Without this option, a short word is kept in capitals only if it's
+// already in capitals in the test name, like any other initialism.
+func WithShortWordNormalisation() Option {
+	return func(c *config) {
+		c.normaliseShortWords = true
+	}
+}
+
+// WithInitialismNumberJoiner sets a string to be inserted between an
+// initialism and the number that immediately follows it, such as in the names
+// of standards like RFC3339, ISO8601, or SHA256. By default, these are kept
+// together as a single word ("RFC3339"). With a joiner of " ", for example,
+// they would be rendered as "RFC 3339". A non-breaking space ("\u00a0") can be
+// used to keep the two parts visually associated.
+func WithInitialismNumberJoiner(joiner string) Option {
+	return func(c *config) {
+		c.numberJoiner = joiner
+	}
+}
+
+// WithNumericSubtestLabel sets a label to be shown before the name of any
+// subtest whose name is purely numeric. Table tests that range over a slice of
+// unnamed cases produce subtests such as TestSum/0, TestSum/1, and so on, which
+// would otherwise be rendered as "Sum 0", "Sum 1". With the label "case",
+// these become "Sum case 0", "Sum case 1".
+func WithNumericSubtestLabel(label string) Option {
+	return func(c *config) {
+		c.numericLabel = label
+	}
+}
+
+// WithMaxFunctionNameWords sets the largest number of words that [Prettify]
+// will join together into a multiword function name, when the end of the
+// name is marked with an underscore. If there are more words than this
+// before the first underscore, as in
+// TestParsesTheConfigurationFileAndValidatesIt_Properly, they are unlikely
+// to be the name of a function, so the underscore is treated as an ordinary
+// word separator instead. The default is 5; a value of zero or less removes
+// the limit.
+func WithMaxFunctionNameWords(n int) Option {
+	return func(c *config) {
+		c.maxFuncWords = n
+	}
+}
+
+// WithPreservedCase causes [Prettify] to keep the capitalisation of every
+// word exactly as it appears in the test name, only inserting spaces at the
+// word boundaries it finds. This is useful when names deliberately
+// capitalise domain terms, since by default, every word but the first is
+// lowercased unless it's an initialism. For example, the name
+// TestChargesVATOnEUOrders becomes "Charges VAT On EU Orders".
+func WithPreservedCase() Option {
+	return func(c *config) {
+		c.preserveCase = true
+	}
+}
+
+// WithDecisionCallback causes [Prettify] to call fn for each word whose
+// capitalisation it decides, with the word as it appears in the test name,
+// and the [CaseDecision] that was applied to it. Words such as numeric
+// literals and ordinals, which are always emitted as they are, aren't
+// reported. This makes it possible to collect statistics over a large
+// corpus of test names, such as which words are most often treated as
+// initialisms, without parsing the debug output.
+func WithDecisionCallback(fn func(word string, decision CaseDecision)) Option {
+	return func(c *config) {
+		c.onDecision = fn
+	}
+}
+
+// WithTitleStopWords sets the words that [TitleCaseSentence], and the
+// headings of a [MarkdownSink], keep in lower case, except at the start of a
+// heading, replacing the default set of common English stop words ("a",
+// "an", "the", "of", "to", "in", "on", "for"). Calling it with no words
+// means every word is capitalised.
+func WithTitleStopWords(words ...string) Option {
+	return func(c *config) {
+		c.stopWords = map[string]bool{}
+		for _, w := range words {
+			c.stopWords[strings.ToLower(w)] = true
+		}
+	}
+}
+
+// WithConjunctions causes [Prettify] to render an '&' between two letters in
+// a subtest name as the word "and", and a '+' as the word plus, unless plus
+// is empty, in which case '+' is kept as it is. For example, with a plus of
+// "plus", TestMerge/a+b_yields_ab becomes "Merge a plus b yields ab", and
+// TestQuery/filters_name&age becomes "Query filters name and age".
+//
+// A symbol next to a digit or another symbol, as in "utf+8" or "c++", is
+// always kept as it is, since it's probably part of a technical term.
+// Without this option, '+' and '&' are never changed.
+func WithConjunctions(plus string) Option {
+	return func(c *config) {
+		c.conjunctions = true
+		c.plusWord = plus
+	}
+}
+
+// WithListCommas causes [Prettify] to punctuate a list of three or more
+// items ending in "and", with a comma after each item but the last,
+// including the one before "and". For example, with the initialisms JSON,
+// YAML, and TOML (see [WithInitialisms]), TestSupportsJSONYAMLAndTOML
+// becomes "Supports JSON, YAML, and TOML", and
+// TestParsesHeadersBodiesAndTrailers becomes "Parses headers, bodies, and
+// trailers".
+//
+// Since a test name doesn't say where a list begins, this is a heuristic:
+// the items must be consecutive words of the same kind as the one after
+// "and", either all initialisms, or all ordinary words ending in 's', such as
+// plural nouns, and not words such as "is" or "this". The first word of a
+// sentence, or of a level of a subtest name, is usually a verb, so it starts
+// a list of words only if the sentence goes on after the list, as in
+// TestCreatesReadsUpdatesAndDeletesRecords, which becomes "Creates, reads,
+// updates, and deletes records". A phrase with only two items, such as
+// "parses name and value", is never changed. Lists don't extend across the
+// levels of a subtest name.
+func WithListCommas() Option {
+	return func(c *config) {
+		c.listCommas = true
+	}
+}
+
+// WithSubjectSeparator causes [Prettify] to separate the function name from
+// the rest of the sentence with sep, instead of a single space, when the
+// test name marks the end of a multiword function name with an underscore.
+// For example, with a separator of " — ", the name
+// TestHandleInput_ClosesInputAfterReading becomes:
+//
+//	HandleInput — closes input after reading
+//
+// The separator is used exactly as given, so it should include any spaces
+// wanted around it. Names without such a marker are not affected, since
+// there's no way to tell where the function name ends.
+func WithSubjectSeparator(sep string) Option {
+	return func(c *config) {
+		c.subjectSep = sep
+	}
+}
+
+// WithMaxSentenceLength limits the sentences produced by [Prettify] to n
+// runes, for the sake of renderers that can't cope with very long lines, as
+// for tests generated from data. A longer sentence is cut short at a word
+// boundary, and ends with an ellipsis, which counts towards the limit. This
+// is done once the words have been cased, so the result is the same as the
+// beginning of the full sentence. The default, 0, means no limit.
+func WithMaxSentenceLength(n int) Option {
+	return func(c *config) {
+		c.maxSentenceLen = n
+	}
+}
+
+// WithMaxWordLength limits each word of the sentences produced by
+// [Prettify] to n runes. A longer word, such as an embedded base64 blob, is
+// shortened by replacing its middle with an ellipsis, so that its beginning
+// and end can still be told apart:
+//
+//	TestAccepts/token=eyJhbGciOiJIUzI1NiJ9 → Accepts toke…iJ9 (with n = 8)
+//
+// Words are shortened before any limit set by [WithMaxSentenceLength] is
+// applied. The default, 0, means no limit.
+func WithMaxWordLength(n int) Option {
+	return func(c *config) {
+		c.maxWordLen = n
+	}
+}
+
+// WithSubtestSeparator causes [Prettify] to join the levels of a test's
+// subtests with sep, rather than a space, so that the hierarchy of nested
+// subtests is still visible in the sentence. For example, with a separator
+// of " › ", TestAPI/users/create/validates_email becomes:
+//
+//	API › users › create › validates email
+//
+// The words within each level are still joined by spaces. As with
+// [WithSubjectSeparator], the separator is used exactly as given.
+func WithSubtestSeparator(sep string) Option {
+	return func(c *config) {
+		c.subtestSep = sep
+	}
+}
+
+// WithCollapsedNumericSubtests causes [TestDoxer.Filter] to collapse any group
+// of two or more purely numeric sibling subtests (see
+// [WithNumericSubtestLabel]) into a single line, such as:
+//
+//	✔ Sum (12 cases, all passed) (0.01s)
+//
+// If any of the subtests failed, the line is shown as a failure, with the
+// number of failed cases. A group containing any named subtests alongside the
+// numeric ones is not collapsed.
+func WithCollapsedNumericSubtests() Option {
+	return func(c *config) {
+		c.collapseNumeric = true
+	}
+}
+
+// WithFanOutThreshold causes [TestDoxer.Filter] to summarise the results of
+// any test with more than threshold direct subtests on a single line, rather
+// than listing every subtest, which can make the report unreadable for large
+// table or property-based tests. For example:
+//
+//	x Parse handles generated inputs — 4,998/5,000 passed (1.20s)
+//	   x Parse handles generated inputs case 17 (0.00s)
+//	   x Parse handles generated inputs case 804 (0.00s)
+//
+// Only the failing subtests are listed individually beneath the summary line.
+// Skipped subtests are counted, but not listed, unless
+// [WithFanOutSkipsListed] is also given.
+func WithFanOutThreshold(threshold int) Option {
+	return func(c *config) {
+		c.fanOutThreshold = threshold
+	}
+}
+
+// WithFanOutSkipsListed causes skipped subtests to be treated like failures
+// for the purpose of [WithFanOutThreshold]: that is, they are listed
+// individually beneath the summary line, marked by a circle rather than the
+// cross of a failure.
+func WithFanOutSkipsListed() Option {
+	return func(c *config) {
+		c.fanOutListSkips = true
+	}
+}
+
+// WithOnResult sets a function to be called with the [Result] of each test as
+// soon as it completes, so that a program embedding gotestdox can follow the
+// progress of a run without parsing its text output.
+func WithOnResult(fn func(Result)) Option {
+	return func(c *config) {
+		c.onResult = fn
+	}
+}
+
+// WithListedBenchmarks causes [TestDoxer.FilterList] to include the
+// benchmarks and fuzz tests in a listing, as well as the tests.
+func WithListedBenchmarks() Option {
+	return func(c *config) {
+		c.listBenchmarks = true
+	}
+}
+
+// WithExamples causes [TestDoxer.Filter] to report the results of example
+// functions, which are ignored by default. An example's sentence says what
+// it's an example of, such as "Example: ParseJSON" for ExampleParseJSON, with
+// any suffix describing the case as the behaviour clause, so that
+// ExamplePrettify_underscoreHint becomes "Example: Prettify underscore
+// hint". When an example fails because its output didn't match, a
+// [TextSink] shows the wanted and actual output under its sentence.
+func WithExamples() Option {
+	return func(c *config) {
+		c.examples = true
+	}
+}
+
+// WithSink causes results to be delivered to sink, instead of being printed
+// as text. This allows a program embedding gotestdox to use its parsing and
+// prettifying without any text output at all.
+func WithSink(sink ResultSink) Option {
+	return func(c *config) {
+		c.sink = sink
+	}
+}
+
+// WithAdditionalRenderer causes [TestDoxer.Filter] to deliver the results
+// to the renderer registered under the given name (see [RegisterRenderer]),
+// writing to w, as well as to the usual sink, in the same pass over the
+// input. For example, a run can print the usual report to the terminal,
+// while writing CSV to a file. It can be supplied more than once.
+//
+// The renderer is configured only by opts, not by the options for the run,
+// and its output is never coloured. If the renderer returns an error, no
+// more results are delivered to it, but the run carries on, and the error
+// is reported at the end. At the end of the run, the usual sink gets the
+// [Summary] first, followed by the additional renderers, in the order they
+// were supplied. If no such renderer is registered, Filter reports an error
+// before reading any input.
+func WithAdditionalRenderer(name string, w io.Writer, opts ...Option) Option {
+	return func(c *config) {
+		c.extraRenderers = append(c.extraRenderers, additionalRenderer{name, w, opts})
+	}
+}
+
+// WithSentenceSuffix causes each sentence printed by a [TextSink] to end with
+// suffix, typically ".", so that the output reads as a specification
+// document. The sentence itself, as produced by [Prettify] and stored in each
+// [Result], is not changed.
+//
+// No suffix is added to a sentence that already ends with punctuation, or
+// that ends with a code-like token kept verbatim from the test name (such as
+// "add(2,3)=5" or "MAX_SIZE"), where it would read as part of the code.
+// Questions, that is, sentences about whether something happens or not, are
+// left alone too.
+func WithSentenceSuffix(suffix string) Option {
+	return func(c *config) {
+		c.sentenceSuffix = suffix
+	}
+}
+
+// WithImperativeMood causes a [TextSink] to show sentences in the imperative
+// mood, rather than the third person, for teams who write their specs that
+// way: "Parse return error on empty input", instead of "Parse returns error
+// on empty input". Only the first word after the function name is changed,
+// and only if it's one of a built-in list of common verbs, such as
+// "returns", "handles", or "closes", or one of the given extra verbs, also
+// in the third person. Other words ending in "s", such as "status", are
+// left alone.
+//
+// This changes only how sentences are shown, not the sentences produced by
+// [Prettify], or reported in each [Result].
+func WithImperativeMood(verbs ...string) Option {
+	return func(c *config) {
+		if c.imperativeVerbs == nil {
+			c.imperativeVerbs = map[string]bool{}
+			for _, v := range imperativeVerbs {
+				c.imperativeVerbs[v] = true
+			}
+		}
+		for _, v := range verbs {
+			c.imperativeVerbs[strings.ToLower(v)] = true
+		}
+	}
+}
+
+// WithSubtestRollup causes a [CSVSink] to roll the results of subtests up
+// into their top-level test, rather than writing a row for each subtest. Each
+// row then gives the number of subtests the test had, in an extra column.
+func WithSubtestRollup() Option {
+	return func(c *config) {
+		c.rollUpSubtests = true
+	}
+}
+
+// WithTestNames causes a [TextSink] to show the original name of each test,
+// dimmed and in brackets, after its sentence, so that it can be pasted
+// straight into 'go test -run'. For example:
+//
+//	x Parser rejects BOM  [TestParser_RejectsBOM] (0.00s)
+func WithTestNames() Option {
+	return func(c *config) {
+		c.showNames = true
+	}
+}
+
+// WithTestNamesOnFailure is like [WithTestNames], but shows the original
+// names of failing tests only.
+func WithTestNamesOnFailure() Option {
+	return func(c *config) {
+		c.showNamesFailed = true
+	}
+}
+
+// WithRerunCommands causes a [TextSink] to print, after the results for any
+// package with failing tests, a command line that reruns each failing test
+// on its own, such as:
+//
+//	go test -run '^TestParser$/^rejects_BOM$' ./internal/parser
+//
+// Where a test failed because of a failing subtest, only the subtest is
+// listed.
+func WithRerunCommands() Option {
+	return func(c *config) {
+		c.rerunCommands = true
+	}
+}
+
+// WithRerunFlags is like [WithRerunCommands], but also includes in each
+// command any flags that 'go test' was originally run with, other than -run
+// and -skip. This only has an effect when the tests are run by
+// [TestDoxer.ExecGoTest], since otherwise the flags aren't known. Flags that
+// take a value should be given in the form -name=value, unless they're
+// standard 'go test' flags.
+func WithRerunFlags() Option {
+	return func(c *config) {
+		c.rerunCommands = true
+		c.rerunFlags = true
+	}
+}
+
+// WithModuleParallelism sets the number of modules that
+// [TestDoxer.ExecGoTestModules] tests at once. The default is 1, meaning
+// that the modules are tested one after another.
+func WithModuleParallelism(n int) Option {
+	return func(c *config) {
+		c.moduleParallelism = n
+	}
+}
+
+// WithConfigurationParallelism sets the maximum number of configurations to
+// run at once in [TestDoxer.ExecGoTestConfigurations]. The default is 1.
+func WithConfigurationParallelism(n int) Option {
+	return func(c *config) {
+		c.configParallelism = n
+	}
+}
+
+// WithPackageParallelism sets the number of packages that
+// [TestDoxer.ExecGoTestPackages] tests at once.
+func WithPackageParallelism(n int) Option {
+	return func(c *config) {
+		c.packageParallelism = n
+	}
+}
+
+// WithPackageTimeout sets the longest that [TestDoxer.ExecGoTestPackages]
+// lets the 'go test' process for any one package run. If the limit is
+// reached, the process is killed, along with any processes it started, such
+// as the test binary and any helpers it left running. The tests that were
+// still running are reported as failed, and marked as timed out, as is the
+// package; the results of the tests that had already finished are reported
+// as usual, and the other packages carry on being tested. Zero, the default,
+// means no limit. See [WithPackageTimeouts] to set a different limit for
+// particular packages.
+//
+// Unlike the -timeout flag to 'go test', which stops the whole run with a
+// panic, this includes the time the package takes to build.
+func WithPackageTimeout(d time.Duration) Option {
+	return func(c *config) {
+		c.packageTimeout = d
+	}
+}
+
+// WithPackageTimeouts sets the limit described for [WithPackageTimeout] for
+// each package whose import path matches one of the patterns in timeouts,
+// overriding the limit for all the others. Patterns are as for [WithIgnore],
+// so that "example.com/slow/**" matches every package under
+// example.com/slow. If more than one pattern matches, the longest wins. A
+// limit of zero means that matching packages have no limit.
+func WithPackageTimeouts(timeouts map[string]time.Duration) Option {
+	return func(c *config) {
+		if c.packageTimeouts == nil {
+			c.packageTimeouts = map[string]time.Duration{}
+		}
+		for pattern, d := range timeouts {
+			c.packageTimeouts[pattern] = d
+		}
+	}
+}
+
+// WithFailFast causes [TestDoxer.Filter] to stop at the first test failure,
+// printing the results seen so far, including those for any package that
+// hadn't finished, followed by a note that the run was aborted. The rest of
+// the input is read and discarded, so that the program writing it doesn't
+// see a broken pipe.
+//
+// When the tests are run by [TestDoxer.ExecGoTest], or any of its variants,
+// the 'go test' processes still running are stopped as well, along with the
+// test binaries they started.
+func WithFailFast() Option {
+	return func(c *config) {
+		c.failFast = true
+	}
+}
+
+// WithSignalHandlingDisabled stops [TestDoxer.ExecGoTest], and any of its
+// variants, from catching SIGINT and SIGTERM, so that they have their usual
+// effect, as a program embedding gotestdox may want to handle them itself.
+// By default, the first such signal is passed on to each 'go test' process,
+// and the results so far are reported, followed by a note that the run was
+// interrupted (see [Summary]). A second signal stops 'go test' at once, but
+// doesn't exit the program, except when gotestdox is run as a command (see
+// [Run]).
+//
+// Since the 'go test' processes then share the program's process group, to
+// receive its signals, a process killed by [WithFailFast] or
+// [WithPackageTimeout] may leave the test binaries it started running.
+func WithSignalHandlingDisabled() Option {
+	return func(c *config) {
+		c.signalsDisabled = true
+	}
+}
+
+// WithFlakyAsFailure causes [ExitCode] to treat flaky tests, which failed
+// but then passed when retried, as failures.
+func WithFlakyAsFailure() Option {
+	return func(c *config) {
+		c.flakyAsFailure = true
+	}
+}
+
+// WithFlakyExitCode sets the exit code returned by [ExitCode] when the only
+// tests that failed were flaky ones, which passed when retried. By default,
+// such a run counts as passing.
+func WithFlakyExitCode(code int) Option {
+	return func(c *config) {
+		c.flakyExitCode = code
+	}
+}
+
+// WithEnvironment causes [TestDoxer.Filter] to record the Go version,
+// platform, race detector and build tags settings, and git commit for the
+// run, in the [Summary], for the benefit of anyone reading an archived
+// report. A [TextSink] prints them on a single line at the end of the
+// report. The race and tags settings are only known when the tests are run
+// by [TestDoxer.ExecGoTest], or any of its variants.
+func WithEnvironment() Option {
+	return func(c *config) {
+		c.environment = true
+	}
+}
+
+// WithDurationHistory causes [TestDoxer.Filter] to compare the duration of
+// each test with that recorded for it in the file at path, and a [TextSink]
+// to show any significant slowdown alongside the duration, such as:
+//
+//	✔ Parser handles large input (1.90s, +850ms vs last run)
+//
+// If the file doesn't exist, no comparison is made. The file isn't changed
+// unless [WithDurationHistoryUpdate] is also supplied.
+func WithDurationHistory(path string) Option {
+	return func(c *config) {
+		c.historyPath = path
+	}
+}
+
+// WithDurationHistoryUpdate is like [WithDurationHistory], but also updates
+// the file at path with the durations of the tests in this run, creating it
+// if necessary, ready for comparison with the next.
+func WithDurationHistoryUpdate(path string) Option {
+	return func(c *config) {
+		c.historyPath = path
+		c.historyUpdate = true
+	}
+}
+
+// WithHistoryStore causes [TestDoxer.Filter] to record the results of every
+// test in the [HistoryStore] with the given data source name, at the end of
+// the run. The store must have been registered by importing the package
+// that provides it, such as [github.com/bitfield/gotestdox/history];
+// otherwise, Filter reports an error.
+func WithHistoryStore(dsn string) Option {
+	return func(c *config) {
+		c.historyDSN = dsn
+	}
+}
+
+// WithPrefixedStderr causes [TestDoxer.ExecGoTest] to mark each line it
+// forwards from the standard error of 'go test', such as vet errors or
+// module download messages, with the prefix "stderr│ ", to distinguish it
+// from the report.
+func WithPrefixedStderr() Option {
+	return func(c *config) {
+		c.prefixStderr = true
+	}
+}
+
+// WithCapturedStderr causes [TestDoxer.ExecGoTest] to capture the standard
+// error of 'go test', instead of forwarding it, and include it in the
+// [Summary], so that a [TextSink] prints it at the end of the report.
+func WithCapturedStderr() Option {
+	return func(c *config) {
+		c.captureStderr = true
+	}
+}
+
+// WithDurationRegression sets the thresholds used by [WithDurationHistory] to
+// decide whether a test has slowed down significantly. A test is only
+// reported if its duration has increased by more than absolute, and by more
+// than relative times its previous duration (so 0.5 means 50%). Tests that
+// took less than minimum are never reported, since their durations are
+// mostly noise. The defaults are 100ms, 0.5, and 100ms respectively.
+func WithDurationRegression(absolute time.Duration, relative float64, minimum time.Duration) Option {
+	return func(c *config) {
+		c.regressionAbsolute = absolute
+		c.regressionRelative = relative
+		c.regressionMinimum = minimum
+	}
+}
+
+// WithShutdownTimeout sets how long [Serve] waits, once its context is
+// cancelled, for the connections in progress to finish before closing them.
+func WithShutdownTimeout(d time.Duration) Option {
+	return func(c *config) {
+		c.shutdownTimeout = d
+	}
+}
+
+// WithThresholds causes [TestDoxer.Filter] to check the outcome of the run
+// against t, recording a message for each threshold that was exceeded in
+// [Summary].ThresholdViolations, so that a CI job can fail when, for
+// example, the tests take too long, or too few of them ran. A [TextSink]
+// lists the messages at the end of the report, and [ExitCode] returns
+// [ExitThresholdsExceeded]. Thresholds can be read from a file using
+// [ReadThresholdsFile].
+func WithThresholds(t Thresholds) Option {
+	return func(c *config) {
+		c.thresholds = &t
+	}
+}
+
+// WithValidation causes [TestDoxer.Filter] and [Aggregate] to check their
+// input using [Validate] before reporting on it, and to refuse input that
+// the check finds to be corrupt, returning a [*ValidationError] that
+// describes the problems found. This means reading all the input before
+// printing anything, so it's intended for archived artifacts rather than for
+// live runs.
+func WithValidation() Option {
+	return func(c *config) {
+		c.validate = true
+	}
+}
+
+// WithTabWidth sets the width of the tab stops to which a [TextSink]
+// expands any tabs in the test output it prints, such as the output of a
+// package that failed in TestMain or teardown, or a source snippet (see
+// [WithSourceSnippets]), so that indented lines, as in a diff, still line up
+// under the sentence. The default is 4. A width of
+// 0 leaves tabs as they are. Lines of output are never wrapped, however
+// long.
+func WithTabWidth(n int) Option {
+	return func(c *config) {
+		c.tabWidth = n
+	}
+}
+
+// WithSlowestTests sets the number of tests for which a [MetricsSink]
+// reports individual durations. Only the n slowest tests are included, or
+// none, if n is negative. Zero means the default, ten.
+func WithSlowestTests(n int) Option {
+	return func(c *config) {
+		c.slowestTests = n
+	}
+}
+
+// WithDurationHistogram causes [TestDoxer.Filter] to include a
+// [DurationHistogram] of the durations of the tests in the [Summary], and a
+// [TextSink] to print it as a bar chart at the end of the report. The
+// histogram buckets end at the given bounds, plus a final bucket for
+// anything longer. If no bounds are given, the defaults are 1ms, 10ms,
+// 100ms, 1s, and 10s.
+func WithDurationHistogram(bounds ...time.Duration) Option {
+	return func(c *config) {
+		c.histogram = true
+		c.histogramBounds = bounds
+		if len(bounds) == 0 {
+			c.histogramBounds = defaultHistogramBounds
+		}
+	}
+}
+
+// WithNoisyTests causes a [TextSink] to mark any passing test that wrote to
+// standard output or standard error with symbol, such as "⚠", after its
+// sentence, and to list what it wrote in a section at the end of the report.
+// Stray output like this often comes from debugging code that was left in by
+// mistake.
+//
+// Output written by t.Log and similar methods isn't counted, as far as it can
+// be recognised by the file name and line number that precedes it, unless
+// [WithNoisyTestLogs] is also supplied.
+func WithNoisyTests(symbol string) Option {
+	return func(c *config) {
+		c.noisySymbol = symbol
+	}
+}
+
+// WithNoisiestTests causes [TestDoxer.Filter] to count the bytes of output
+// written by each test, and each package, recording them in the OutputBytes
+// field of each [Result], and the totals in the [Summary], along with the n
+// tests that wrote the most, which a [TextSink] lists in a "Noisiest tests"
+// section at the end of the report. Tests that log megabytes, even when
+// they pass, slow down CI and bloat its artifacts. Only the counts are kept,
+// so this doesn't keep any more of the output than usual.
+func WithNoisiestTests(n int) Option {
+	return func(c *config) {
+		c.noisiestTests = n
+	}
+}
+
+// WithLargeOutputMarker causes a [TextSink] to mark any test that wrote more
+// than limit bytes of output with symbol, such as "📢", after its sentence.
+// The output is counted as described for [WithNoisiestTests].
+func WithLargeOutputMarker(limit int, symbol string) Option {
+	return func(c *config) {
+		c.largeOutput = limit
+		c.largeOutputSymbol = symbol
+	}
+}
+
+// WithNoisyTestLogs causes [WithNoisyTests] to count output written by t.Log
+// and similar methods too.
+func WithNoisyTestLogs() Option {
+	return func(c *config) {
+		c.noisyLogs = true
+	}
+}
+
+// WithActiveDurations causes [TestDoxer.Filter] to work out how long each
+// test spent actually running, leaving out any time it spent paused waiting
+// for other parallel tests, using the timestamps of its events. This is
+// recorded as the Active field of its [Result], and shown by a [TextSink]
+// in place of the elapsed time reported by 'go test', which includes the
+// time spent paused. If there are no timestamps, as when the input wasn't
+// produced by 'go test -json', the elapsed time is used instead.
+func WithActiveDurations() Option {
+	return func(c *config) {
+		c.activeDurations = true
+	}
+}
+
+// WithProgress causes [TestDoxer.Filter] to show a single line of progress
+// on td.Stderr, if it's a terminal, while the results of each package are
+// collected, such as:
+//
+//	pkg 7/23 · 312 tests · 2 failed · 41s
+//
+// The line is rewritten in place as results arrive, at once, in red, when a
+// test fails, so that a long run can be stopped early, and removed before
+// anything else is printed, such as the results of each package as it
+// completes, and the final report. The total number of packages is known
+// only when they're listed before the run, as by [TestDoxer.ExecGoTest];
+// when filtering standard input, only the number completed is shown. If
+// td.Stderr isn't a terminal, nothing is shown.
+func WithProgress() Option {
+	return func(c *config) {
+		c.progress = true
+	}
+}
+
+// WithClock causes [TestDoxer.Filter], and the methods that run 'go test',
+// to tell the time by c, instead of the system clock, as described for
+// [Clock]. This is for testing: with a fake clock, the stall report, for
+// example, can be made to appear at a given point in the input, without
+// having to wait for it.
+func WithClock(c Clock) Option {
+	return func(cfg *config) {
+		cfg.clock = c
+	}
+}
+
+// WithStallReport causes [TestDoxer.Filter] to print a status line to
+// td.Stderr whenever no events have arrived for the given duration, listing
+// the tests that have started but not yet finished, and how long each has
+// been running according to the timestamp of its run event. This makes it
+// easier to see which tests are hung during a long run. If Stderr is a
+// terminal, the status line is cleared when the next event arrives;
+// otherwise, each report is printed on a line of its own.
+func WithStallReport(after time.Duration) Option {
+	return func(c *config) {
+		c.stallAfter = after
+	}
+}
+
+// WithSkipCategories causes [TestDoxer.Filter] to sort skipped tests into
+// categories, according to why they were skipped, and a [TextSink] to show
+// how many were in each category at the end of the report, such as:
+//
+//	14 skipped: 9 short-mode, 3 network, 2 other
+//
+// This makes it obvious when a run that looks healthy actually left out a
+// large part of the suite. The reason for each skip is found by matching
+// the test's output against a set of built-in patterns for tests skipped
+// in short mode ("short-mode"), for lack of a network ("network"), or for
+// want of a build tag ("build-tag"). Tests matching none of these are
+// counted as "other". More categories can be added using
+// [WithSkipCategory].
+func WithSkipCategories() Option {
+	return func(c *config) {
+		c.skipCategories = true
+	}
+}
+
+// WithSkipCategory is like [WithSkipCategories], but also adds a category
+// with the given name for skipped tests whose output matches pattern. The
+// categories are tried in the order they were added, before the built-in
+// ones, and the first that matches is used.
+func WithSkipCategory(name string, pattern *regexp.Regexp) Option {
+	return func(c *config) {
+		c.skipCategories = true
+		c.skipRules = append(c.skipRules, skipRule{name: name, pattern: pattern})
+	}
+}
+
+// WithSourceSnippets causes a [TextSink] to show, after each failing test, a
+// few lines of the test's source code around the line where it failed, as
+// given by the first file name and line number in its output, such as
+// "parse_test.go:42: ". The failing line is marked with '>'.
+//
+// Only files inside the module containing the test's package are read, and
+// each is read at most once. If the file can't be found, doesn't have
+// enough lines, or is generated code, no snippet is shown.
+func WithSourceSnippets() Option {
+	return func(c *config) {
+		c.sourceSnippets = true
+	}
+}
+
+// WithIgnore causes [TestDoxer.Filter] to leave out of the report any tests
+// whose package path, or test name, matches one of the given patterns, and
+// any packages whose path matches, as for generated packages whose tests
+// are just noise. The patterns are as for [path.Match], except that a "**"
+// element matches any number of path elements, including none, so that
+// "**/generated/**" matches any package with a "generated" element in its
+// path. A pattern without a slash, such as "TestGolden*", can match the name
+// of a top-level test, in which case its subtests are ignored too. A
+// malformed pattern matches nothing. Patterns can be read from a file using
+// [ReadIgnoreFile].
+//
+// Ignored tests are still counted in the [Summary], as usual, unless
+// [WithIgnoredUncounted] is supplied, and the number of them is recorded in
+// Summary.Ignored.
+func WithIgnore(patterns ...string) Option {
+	return func(c *config) {
+		c.ignorePatterns = append(c.ignorePatterns, patterns...)
+	}
+}
+
+// WithIgnoredUncounted causes tests left out by [WithIgnore] to be counted
+// only in Summary.Ignored, and not in the numbers of tests passed, failed,
+// or skipped. A failing ignored test still causes its package, and so the
+// run, to fail.
+func WithIgnoredUncounted() Option {
+	return func(c *config) {
+		c.ignoreUncounted = true
+	}
+}
+
+// WithOutputBudget limits the output kept in each [Result] delivered by
+// [TestDoxer.Filter] to perTest bytes, and the output kept in all the
+// results of the run that didn't pass to perReport bytes, so that a single
+// test printing a huge diff can't bloat every report or artifact produced
+// from the results. A zero limit means no limit. The defaults are 16 KB per
+// test and 2 MB per report. The limits apply only to the results delivered:
+// the whole output is still used to tell, for example, whether a package's
+// result was cached.
+//
+// Output over the limit is cut from the middle, so that both the beginning
+// and the end, which usually give the context and the final assertion, are
+// kept, and replaced by a line such as "… 120 KB omitted …". The number of
+// results whose output was cut is recorded in [Summary].Truncated.
+func WithOutputBudget(perTest, perReport int) Option {
+	return func(c *config) {
+		c.testOutputMax = perTest
+		c.reportOutputMax = perReport
+	}
+}
+
+// WithResultLog causes [TestDoxer.Filter] to append each [Result], as soon
+// as it's complete, to the file at path, as a line of JSON, creating the
+// file if necessary. Since the results are written as the tests run, rather
+// than at the end, the file survives even if the run is interrupted, and
+// [ResumeSummary] can recover the counts of tests from it.
+//
+// To keep the run fast, lines are buffered and written out every second,
+// and at the end of the run, so some of the most recent results may be
+// lost if gotestdox itself is killed.
+func WithResultLog(path string) Option {
+	return func(c *config) {
+		c.resultLog = path
+	}
+}
+
+// WithPackageDirs supplies the source directory of each package, keyed by
+// import path, for features that need to find the source of a test, such as
+// [WithSourceSnippets], [WithVagueNameCheck], and [WithTestLocations].
+// Otherwise, the directories are found using a single 'go list -json' for
+// the module being tested, the first time they're needed, and shared by all
+// those features. This is useful when the directories
+// are already known, or 'go list' would be slow or give the wrong answer, as
+// with some vendored or symlinked layouts. Packages not in dirs are not
+// resolved.
+func WithPackageDirs(dirs map[string]string) Option {
+	return func(c *config) {
+		c.packageDirs = dirs
+	}
+}
+
+// WithMinDuration causes a [TextSink] to leave out the lines for tests that
+// took less than d, so that only the tests that took a meaningful amount of
+// time are listed. Tests that failed, or were flaky, are always shown,
+// however fast they were. The hidden tests still count in the [Summary],
+// and the number hidden is printed at the end of the report, so that it's
+// clear the list is incomplete.
+func WithMinDuration(d time.Duration) Option {
+	return func(c *config) {
+		c.minDuration = d
+	}
+}
+
+// WithExcludedPackagesHidden leaves out of the report, entirely, any package
+// whose Go files are all excluded by build constraints, such as build tags
+// or file name suffixes for other platforms. Normally, each is shown on a
+// single line, giving the reason. They are still counted in
+// Summary.ExcludedPackages. This is useful in a repository with many
+// platform-specific packages, which would otherwise clutter every report.
+func WithExcludedPackagesHidden() Option {
+	return func(c *config) {
+		c.excludedHidden = true
+	}
+}
+
+// WithFailureGroups causes a [TextSink] to show the output of each failed
+// test under its sentence, printing it only once for a set of tests that
+// failed identically, as when a shared helper breaks, followed by "… and N
+// other tests failed identically:" and their sentences. Outputs are
+// compared exactly, once the lines added by 'go test' are left out, and any
+// file:line positions, and any measured durations with a fractional part,
+// such as "0.25s", are disregarded, so that genuinely different failures
+// are never merged: outputs differing only by a pointer address, say, are
+// shown separately. Tests are grouped within each package in the report,
+// and across the whole run in Summary.FailureGroups.
+func WithFailureGroups() Option {
+	return func(c *config) {
+		c.failureGroups = true
+	}
+}
+
+// WithCachedTestsHidden causes a [TextSink] to show only the heading for
+// each package whose results were replayed from the 'go test' cache, marked
+// "(cached)", leaving out the lines for its tests, which can't have changed
+// since they last ran.
+func WithCachedTestsHidden() Option {
+	return func(c *config) {
+		c.hideCachedTests = true
+	}
+}
+
+// WithParentLines causes a [TextSink] to show a line for every test with
+// subtests, as well as for each of its subtests. By default, a parent test's
+// own line is left out if any of its subtests are shown, since it adds
+// nothing to them, unless the parent's status differs from what its
+// subtests' results would suggest: for example, if the parent failed for
+// its own reasons, although all its subtests passed.
+func WithParentLines() Option {
+	return func(c *config) {
+		c.parentLines = true
+	}
+}
@@ -0,0 +1,87 @@
+package gotestdox
+
+import (
+	"fmt"
+	"sort"
+)
+
+// OutputSize is the amount of output written by a test, as reported by
+// [WithNoisiestTests].
+type OutputSize struct {
+	Package  string `json:"package"`
+	Test     string `json:"test"`
+	Sentence string `json:"sentence"`
+	Bytes    int    `json:"bytes"`
+}
+
+// String formats an OutputSize for display in the summary of a run.
+func (o OutputSize) String() string {
+	return fmt.Sprintf(" %s: %s (%s)", o.Package, o.Sentence, formatBytes(o.Bytes))
+}
+
+// outputCounter counts the bytes of output written by each test, and by each
+// package, including those of its tests, keeping only the counts, so that
+// the output itself can be dropped as usual.
+type outputCounter map[string]int
+
+// record counts the output of the output event e, whose key is key.
+func (c outputCounter) record(e Event, key string) {
+	n := len(e.Output)
+	c[key] += n
+	if e.Test != "" {
+		c[e.key("")] += n
+	}
+}
+
+// finish returns the number of bytes counted for key, and forgets them, so
+// that a test run again starts from zero.
+func (c outputCounter) finish(key string) int {
+	n := c[key]
+	delete(c, key)
+	return n
+}
+
+// addOutputBytes counts the output written by the package whose result is r
+// in s.
+func (s *Summary) addOutputBytes(r Result) {
+	if r.OutputBytes == 0 {
+		return
+	}
+	if s.PackageOutputBytes == nil {
+		s.PackageOutputBytes = map[string]int{}
+	}
+	s.PackageOutputBytes[r.Package] += r.OutputBytes
+	s.OutputBytes += r.OutputBytes
+}
+
+// noisiestTests returns the n tests among results that wrote the most
+// output, most first, leaving out any that wrote none. Tests that wrote the
+// same amount are in the order they completed.
+func noisiestTests(results []Result, n int) []OutputSize {
+	sizes := []OutputSize{}
+	for _, r := range results {
+		if r.Test == "" || r.OutputBytes == 0 {
+			continue
+		}
+		sizes = append(sizes, OutputSize{Package: r.Package, Test: r.Test, Sentence: r.Sentence, Bytes: r.OutputBytes})
+	}
+	sort.SliceStable(sizes, func(i, j int) bool {
+		return sizes[i].Bytes > sizes[j].Bytes
+	})
+	if len(sizes) > n {
+		sizes = sizes[:n]
+	}
+	return sizes
+}
+
+// formatBytes formats n bytes for display, in bytes, KB, or MB, as
+// appropriate, counting 1024 bytes to the KB.
+func formatBytes(n int) string {
+	switch {
+	case n < 1024:
+		return fmt.Sprintf("%d B", n)
+	case n < 1024*1024:
+		return fmt.Sprintf("%.1f KB", float64(n)/1024)
+	}
+	return fmt.Sprintf("%.1f MB", float64(n)/(1024*1024))
+}
@@ -0,0 +1,120 @@
+package gotestdox_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bitfield/gotestdox"
+	"github.com/fatih/color"
+	"github.com/google/go-cmp/cmp"
+)
+
+// loggingRun is the output of 'go test -json' for a package in which one
+// test logs 2,000 bytes, another logs a little, with characters escaped in
+// the JSON, and a third logs nothing.
+var loggingRun = `{"Action":"run","Package":"p","Test":"TestLogsEverything"}
+{"Action":"output","Package":"p","Test":"TestLogsEverything","Output":"` + strings.Repeat("x", 1999) + `\n"}
+{"Action":"pass","Package":"p","Test":"TestLogsEverything","Elapsed":0}
+{"Action":"run","Package":"p","Test":"TestLogsALittle"}
+{"Action":"output","Package":"p","Test":"TestLogsALittle","Output":"café\t\"ok\"\n"}
+{"Action":"pass","Package":"p","Test":"TestLogsALittle","Elapsed":0}
+{"Action":"run","Package":"p","Test":"TestIsQuiet"}
+{"Action":"pass","Package":"p","Test":"TestIsQuiet","Elapsed":0}
+{"Action":"output","Package":"p","Output":"PASS\n"}
+{"Action":"pass","Package":"p","Elapsed":0.1}
+`
+
+func TestFilter_WithNoisiestTestsCountsDecodedOutputBytesPerTestAndPackage(t *testing.T) {
+	t.Parallel()
+	sink := &recordingSink{}
+	td := gotestdox.NewTestDoxer(gotestdox.WithSink(sink), gotestdox.WithNoisiestTests(1))
+	td.Stdin = strings.NewReader(loggingRun)
+	td.Filter()
+	got := map[string]int{}
+	for _, r := range sink.results {
+		got[r.Test] = r.OutputBytes
+	}
+	// "café\t\"ok\"\n" is 11 bytes once decoded, though 20 in the JSON
+	want := map[string]int{
+		"TestLogsEverything": 2000,
+		"TestLogsALittle":    11,
+		"TestIsQuiet":        0,
+		"":                   2016,
+	}
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+	sum := sink.summary
+	if sum.OutputBytes != 2016 {
+		t.Errorf("want 2016 bytes in total, got %d", sum.OutputBytes)
+	}
+	if !cmp.Equal(map[string]int{"p": 2016}, sum.PackageOutputBytes) {
+		t.Error(cmp.Diff(map[string]int{"p": 2016}, sum.PackageOutputBytes))
+	}
+	wantNoisiest := []gotestdox.OutputSize{
+		{Package: "p", Test: "TestLogsEverything", Sentence: "Logs everything", Bytes: 2000},
+	}
+	if !cmp.Equal(wantNoisiest, sum.NoisiestTests) {
+		t.Error(cmp.Diff(wantNoisiest, sum.NoisiestTests))
+	}
+}
+
+func TestFilter_WithNoisiestTestsListsNoisiestTestsAtEndOfReport(t *testing.T) {
+	t.Parallel()
+	color.NoColor = true
+	td := gotestdox.NewTestDoxer(gotestdox.WithNoisiestTests(5))
+	td.Stdin = strings.NewReader(loggingRun)
+	buf := new(strings.Builder)
+	td.Stdout = buf
+	td.Filter()
+	want := `p:
+ ✔ Is quiet (0.00s)
+ ✔ Logs a little (0.00s)
+ ✔ Logs everything (0.00s)
+
+Noisiest tests (2.0 KB of output in total):
+ p: Logs everything (2.0 KB)
+ p: Logs a little (11 B)
+`
+	if want != buf.String() {
+		t.Error(cmp.Diff(want, buf.String()))
+	}
+}
+
+func TestFilter_WithLargeOutputMarkerMarksTestsWritingMoreThanLimit(t *testing.T) {
+	t.Parallel()
+	color.NoColor = true
+	td := gotestdox.NewTestDoxer(gotestdox.WithLargeOutputMarker(1024, "📢"))
+	td.Stdin = strings.NewReader(loggingRun)
+	buf := new(strings.Builder)
+	td.Stdout = buf
+	td.Filter()
+	want := `p:
+ ✔ Is quiet (0.00s)
+ ✔ Logs a little (0.00s)
+ ✔ Logs everything 📢 (0.00s)
+
+`
+	if want != buf.String() {
+		t.Error(cmp.Diff(want, buf.String()))
+	}
+	if len(td.Summary.NoisiestTests) != 0 {
+		t.Errorf("want no list of noisiest tests without WithNoisiestTests, got %v", td.Summary.NoisiestTests)
+	}
+}
+
+func TestFilter_CountsNoOutputBytesByDefault(t *testing.T) {
+	t.Parallel()
+	sink := &recordingSink{}
+	td := gotestdox.NewTestDoxer(gotestdox.WithSink(sink))
+	td.Stdin = strings.NewReader(loggingRun)
+	td.Filter()
+	for _, r := range sink.results {
+		if r.OutputBytes != 0 {
+			t.Errorf("%q: want no output bytes counted, got %d", r.Test, r.OutputBytes)
+		}
+	}
+	if sink.summary.OutputBytes != 0 || sink.summary.PackageOutputBytes != nil {
+		t.Errorf("want no totals, got %d and %v", sink.summary.OutputBytes, sink.summary.PackageOutputBytes)
+	}
+}
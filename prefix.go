@@ -0,0 +1,61 @@
+package gotestdox
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// PrefixMatching determines how [Prettify] recognises the prefix of a test
+// name, such as "Test", which is left out of the sentence (see
+// [WithPrefixMatching]).
+type PrefixMatching int
+
+const (
+	// ExactPrefix is the default matching, which removes the prefix "Test"
+	// exactly as Go spells it, and nothing else.
+	ExactPrefix PrefixMatching = iota
+	// CaseInsensitive matching removes any of the prefixes test,
+	// benchmark, bench, fuzz, or example, ignoring case, as long as it's
+	// followed by a separator, such as an underscore, or a change of case,
+	// so that names like "TEST_user_login" and "test_user_login", from
+	// generated test registries or other languages, become "User login".
+	CaseInsensitive
+)
+
+var prefixMatchingNames = map[PrefixMatching]string{
+	ExactPrefix:     "ExactPrefix",
+	CaseInsensitive: "CaseInsensitive",
+}
+
+// String returns the name of the matching, such as "ExactPrefix".
+func (m PrefixMatching) String() string {
+	if s, ok := prefixMatchingNames[m]; ok {
+		return s
+	}
+	return "PrefixMatching(?)"
+}
+
+// insensitivePrefixes are the prefixes removed by [CaseInsensitive]
+// matching, longest first where one is a prefix of another.
+var insensitivePrefixes = []string{"test", "benchmark", "bench", "fuzz", "example"}
+
+// trimTestPrefix returns input without its test prefix, as matched by m.
+func trimTestPrefix(input string, m PrefixMatching) string {
+	if m != CaseInsensitive {
+		return strings.TrimPrefix(input, "Test")
+	}
+	for _, prefix := range insensitivePrefixes {
+		if len(input) <= len(prefix) || !strings.EqualFold(input[:len(prefix)], prefix) {
+			continue
+		}
+		last, _ := utf8.DecodeLastRuneInString(input[:len(prefix)])
+		next, _ := utf8.DecodeRuneInString(input[len(prefix):])
+		if unicode.IsLetter(next) && unicode.IsUpper(next) == unicode.IsUpper(last) {
+			// a longer word, such as "Testable" or "FUZZY"
+			continue
+		}
+		return input[len(prefix):]
+	}
+	return input
+}
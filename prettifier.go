@@ -5,10 +5,10 @@ import (
 	"io"
 	"os"
 	"strings"
+	"sync"
 	"unicode"
 
-	"golang.org/x/text/cases"
-	"golang.org/x/text/language"
+	"github.com/bitfield/gotestdox/lex"
 )
 
 // Prettify takes a string input representing the name of a Go test, and
@@ -52,66 +52,255 @@ import (
 //
 // # Debugging
 //
-// If the GOTESTDOX_DEBUG environment variable is set, Prettify will output
-// (copious) debug information to the [DebugWriter] stream, elaborating on its
-// decisions.
-func Prettify(input string) string {
-	p := &prettifier{
-		input: []rune(strings.TrimPrefix(input, "Test")),
-		words: []string{},
-		debug: io.Discard,
-	}
+// If the [WithDebug] option is supplied, or the GOTESTDOX_DEBUG environment
+// variable is set, Prettify will output (copious) debug information to the
+// [DebugWriter] stream, elaborating on its decisions.
+//
+// # Options
+//
+// The transformation can be customised by supplying options, such as
+// [WithInitialisms].
+func Prettify(input string, opts ...Option) string {
+	// For compatibility, the environment is still consulted here, though not
+	// by the prettifier itself.
 	if os.Getenv("GOTESTDOX_DEBUG") != "" {
-		p.debug = DebugWriter
+		opts = append([]Option{WithDebug()}, opts...)
 	}
-	p.log("input:", input)
-	for state := betweenWords; state != nil; {
-		state = state(p)
+	return prettify(input, newConfig(opts))
+}
+
+// PrettifyFunc returns a function that prettifies a test name as [Prettify]
+// does, with the given options. The options are applied once, rather than on
+// every call, and the GOTESTDOX_DEBUG environment variable isn't consulted,
+// so this is the better choice where names are prettified one at a time, as
+// they arrive, such as in a WebAssembly module, which has no environment.
+// The function is safe to call from multiple goroutines.
+func PrettifyFunc(opts ...Option) func(input string) string {
+	cfg := newConfig(opts)
+	return func(input string) string {
+		return prettify(input, cfg)
+	}
+}
+
+// prettifiers holds idle prettifiers for reuse, to save allocating new ones
+// when many names are prettified, as by [PrettifyAll].
+var prettifiers = sync.Pool{
+	New: func() any {
+		return &prettifier{}
+	},
+}
+
+func prettify(input string, cfg config) string {
+	p := tokenise(input, cfg)
+	defer prettifiers.Put(p)
+	if p.listCommas {
+		p.addListCommas()
+	}
+	if p.maxWordLen > 0 {
+		for i, word := range p.words {
+			p.words[i] = shortenWord(word, p.maxWordLen)
+		}
+	}
+	result := p.join()
+	if p.maxSentenceLen > 0 {
+		result = p.truncate(result)
 	}
-	result := strings.Join(p.words, " ")
 	p.log(fmt.Sprintf("result: %q", result))
 	return result
 }
 
-// Heavily inspired by Rob Pike's talk on 'Lexical Scanning in Go':
-// https://www.youtube.com/watch?v=HxaD_trXwRE
-type prettifier struct {
-	debug          io.Writer
-	input          []rune
-	start, pos     int
-	words          []string
-	inSubTest      bool
-	seenUnderscore bool
+// parse returns the words of the sentence for the test name input. A
+// function name marked as described for [Prettify] is a single word.
+func parse(input string, cfg config) []string {
+	p := tokenise(input, cfg)
+	defer prettifiers.Put(p)
+	return append([]string{}, p.words...)
+}
+
+// tokenise returns a prettifier from the pool which has split input into
+// words.
+// The caller should return it to the pool when finished with it.
+func tokenise(input string, cfg config) *prettifier {
+	p := prettifiers.Get().(*prettifier)
+	*p = prettifier{
+		Runes:    lex.Runes{Input: appendRunes(p.Input[:0], trimTestPrefix(input, cfg.prefixMatching))},
+		words:    p.words[:0],
+		segments: p.segments[:0],
+		debug:    io.Discard,
+		config:   cfg,
+	}
+	if p.debugLog {
+		p.debug = DebugWriter
+	}
+	p.log("input:", input)
+	lex.Run(p, betweenWords)
+	if len(p.fillerWords) > 0 {
+		p.dropFillers()
+	}
+	return p
+}
+
+// dropFillers removes any filler words (see [WithLeadingFillerWords]) from
+// the start of the sentence, as long as at least two words of the top-level
+// test name are left, and capitalises the new first word, unless case is
+// being preserved.
+func (p *prettifier) dropFillers() {
+	if p.subject {
+		return
+	}
+	name := len(p.words)
+	if len(p.segments) > 0 {
+		name = p.segments[0]
+	}
+	n := 0
+	for name-n > 2 && p.fillerWords[lowerCase(p.words[n])] {
+		n++
+	}
+	if n == 0 {
+		return
+	}
+	p.log("drop fillers", p.words[:n])
+	p.words = append(p.words[:0], p.words[n:]...)
+	for i := range p.segments {
+		p.segments[i] -= n
+	}
+	if first := p.words[0]; !p.preserveCase && lowerCase(first) == first {
+		p.words[0] = titleCase(first)
+	}
+}
+
+// join returns the sentence made by joining the words emitted, with the
+// separators configured by [WithSubjectSeparator] and [WithSubtestSeparator],
+// if any.
+func (p *prettifier) join() string {
+	if p.subtestSep == "" {
+		result := strings.Join(p.words, " ")
+		if p.subject && p.subjectSep != "" && len(p.words) > 1 {
+			result = p.words[0] + p.subjectSep + strings.Join(p.words[1:], " ")
+		}
+		return result
+	}
+	levels := []string{}
+	start := 0
+	for _, end := range append(p.segments, len(p.words)) {
+		if end == start {
+			continue
+		}
+		level := p.words[start:end]
+		text := strings.Join(level, " ")
+		if start == 0 && p.subject && p.subjectSep != "" && len(level) > 1 {
+			text = level[0] + p.subjectSep + strings.Join(level[1:], " ")
+		}
+		levels = append(levels, text)
+		start = end
+	}
+	return strings.Join(levels, p.subtestSep)
 }
 
-func (p *prettifier) backup() {
-	p.pos--
+// shortenWord returns word unchanged if it has at most max runes. Otherwise,
+// it returns the beginning and end of word, with an ellipsis in between, in
+// max runes altogether.
+func shortenWord(word string, max int) string {
+	runes := []rune(word)
+	if len(runes) <= max {
+		return word
+	}
+	tail := (max - 1) / 2
+	head := max - 1 - tail
+	return string(runes[:head]) + "…" + string(runes[len(runes)-tail:])
 }
 
-func (p *prettifier) skip() {
-	p.start = p.pos
+// truncate returns sentence unchanged if it has at most p.maxSentenceLen
+// runes. Otherwise, it cuts sentence short at the last space that leaves
+// room for an ellipsis, dropping any separator left dangling at the end,
+// and appends the ellipsis. A sentence whose first word is too long is cut
+// in the middle of the word.
+func (p *prettifier) truncate(sentence string) string {
+	runes := []rune(sentence)
+	if len(runes) <= p.maxSentenceLen {
+		return sentence
+	}
+	cut := p.maxSentenceLen - 1
+	for i := cut; i > 0; i-- {
+		if runes[i] == ' ' {
+			cut = i
+			break
+		}
+	}
+	result := strings.TrimRight(string(runes[:cut]), " ")
+	for _, sep := range []string{p.subtestSep, p.subjectSep} {
+		if sep := strings.TrimSpace(sep); sep != "" && strings.HasSuffix(result, sep) {
+			result = strings.TrimRight(strings.TrimSuffix(result, sep), " ")
+		}
+	}
+	return result + "…"
 }
 
-func (p *prettifier) prev() rune {
-	return p.input[p.pos-1]
+// startSubtest records that the words emitted from now on belong to a new
+// level of subtest.
+func (p *prettifier) startSubtest() {
+	p.inSubTest = true
+	// the multiword function marker can only come before the first slash,
+	// so underscores in subtest names are always plain separators
+	p.markerDone = true
+	p.segments = append(p.segments, len(p.words))
 }
 
-func (p *prettifier) next() rune {
-	next := p.peek()
-	p.pos++
-	return next
+// appendRunes appends the runes of s to buf, returning the extended buffer.
+// Whitespace control characters, such as newlines and tabs, are replaced
+// with underscores, so that they separate words like spaces, and any other
+// C0 control characters are dropped. Go's [testing] package never leaves
+// these in subtest names, but other producers of test events may.
+func appendRunes(buf []rune, s string) []rune {
+	for _, r := range s {
+		switch {
+		case r == '\n', r == '\t', r == '\r', r == '\v', r == '\f':
+			buf = append(buf, '_')
+		case r < ' ':
+			continue
+		default:
+			buf = append(buf, r)
+		}
+	}
+	return buf
 }
 
-func (p *prettifier) peek() rune {
-	if p.pos >= len(p.input) {
-		return eof
+// printable returns s with its whitespace control characters, such as
+// newlines and tabs, replaced by spaces, and any other C0 control characters
+// dropped, so that it can be printed as part of a single line.
+func printable(s string) string {
+	if strings.IndexFunc(s, func(r rune) bool { return r < ' ' }) < 0 {
+		return s
 	}
-	next := p.input[p.pos]
-	return next
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == '\n', r == '\t', r == '\r', r == '\v', r == '\f':
+			return ' '
+		case r < ' ':
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// prettifier is the lexer that splits test names into words, using the
+// state functions betweenWords and inWord.
+//
+// Heavily inspired by Rob Pike's talk on 'Lexical Scanning in Go':
+// https://www.youtube.com/watch?v=HxaD_trXwRE
+type prettifier struct {
+	lex.Runes
+	debug      io.Writer
+	words      []string
+	segments   []int
+	inSubTest  bool
+	markerDone bool
+	subject    bool
+	config
 }
 
 func (p *prettifier) inInitialism() bool {
-	for _, r := range p.input[p.start:p.pos] {
+	for _, r := range p.Input[p.Start:p.Pos] {
 		if unicode.IsLower(r) && r != 's' {
 			return false
 		}
@@ -119,33 +308,663 @@ func (p *prettifier) inInitialism() bool {
 	return true
 }
 
-func (p *prettifier) emit() {
-	word := string(p.input[p.start:p.pos])
-	switch {
+// Emit emits the current word, transforming its case as necessary, and
+// reports whether there was a word to emit. An empty word (for example,
+// between two consecutive separators) is skipped. An all-caps word may be
+// split into several by the segmenter (see [prettifier.splitInitialisms]).
+func (p *prettifier) Emit() bool {
+	if p.Pos <= p.Start {
+		p.Skip()
+		return false
+	}
+	word := p.Word()
+	if p.numericLabel != "" && p.isWholeSubtest() && isNumeric(word) {
+		p.log("numeric subtest", word)
+		p.words = append(p.words, p.numericLabel)
+	}
+	if parts := p.splitInitialisms(word); len(parts) > 1 {
+		p.log(fmt.Sprintf("split %q into %q", word, parts))
+		if p.onDecision != nil {
+			decision := DictionaryMatch
+			if p.segmenter != nil {
+				decision = Segmented
+			}
+			for _, part := range parts {
+				p.onDecision(part, decision)
+			}
+		}
+		p.words = append(p.words, parts...)
+		p.Skip()
+		return true
+	}
+	original, decision := word, Lowercased
+	switch short, ok := p.shortWord(word); {
+	case ok:
+		word = short
+		decision = Normalised
+	case p.preserveCase:
+		// leave capitalisation as is
+		decision = Preserved
+	case len(word) > 1 && p.inInitialism() && !isCapitalisedS(word):
+		// leave capitalisation as is, even for the first word
+		decision = Initialism
 	case len(p.words) == 0:
 		// This is the first word
-		word = cases.Title(language.Und, cases.NoLower).String(word)
+		word = titleCase(word)
+		decision = FirstWord
 	case len(word) == 1:
 		// Single letter word such as A
-		word = cases.Lower(language.Und).String(word)
-	case p.inInitialism():
-		// leave capitalisation as is
+		word = lowerCase(word)
+		decision = ShortWord
 	default:
-		word = cases.Lower(language.Und).String(word)
+		word = lowerCase(word)
+	}
+	if p.onDecision != nil {
+		p.onDecision(original, decision)
+	}
+	if p.numberJoiner != "" {
+		word = joinInitialismNumber(word, p.numberJoiner)
+	}
+	if p.conjunctions && p.inSubTest {
+		word = conjoin(word, p.plusWord)
 	}
 	p.log(fmt.Sprintf("emit %q", word))
 	p.words = append(p.words, word)
-	p.skip()
+	p.Skip()
+	return true
+}
+
+// defaultShortWords are the short words recognised by [prettifier.shortWord]
+// unless replaced using [WithShortWordsOnly], keyed by their lowercase
+// forms.
+var defaultShortWords = map[string]string{
+	"ci": "CI", "db": "DB", "id": "ID", "io": "IO",
+	"ip": "IP", "ok": "OK", "os": "OS", "ui": "UI",
+}
+
+// shortWord returns the configured capitalisation of word, if short word
+// normalisation is enabled (see [WithShortWordNormalisation]), and word is
+// one of the short words, or its plural, ignoring case, as in "Ids".
+// Otherwise, it returns false.
+func (p *prettifier) shortWord(word string) (string, bool) {
+	if !p.normaliseShortWords {
+		return "", false
+	}
+	words := p.shortWords
+	if words == nil {
+		words = defaultShortWords
+	}
+	lower := strings.ToLower(word)
+	if short, ok := words[lower]; ok {
+		return short, true
+	}
+	if stem := strings.TrimSuffix(lower, "s"); stem != lower && len(stem) > 1 && word[len(word)-1] == 's' {
+		if short, ok := words[stem]; ok {
+			return short + "s", true
+		}
+	}
+	return "", false
+}
+
+// isCapitalisedS reports whether word is a capital letter followed by an
+// 's', such as "Is" or "As", which is a word rather than an initialism.
+func isCapitalisedS(word string) bool {
+	runes := []rune(word)
+	return len(runes) == 2 && unicode.IsUpper(runes[0]) && runes[1] == 's'
+}
+
+// pluralInitialisms are common initialisms ending in 'I' which are often
+// pluralised, so that, for example, "APIs" is kept together, rather than
+// split like "IDIs" into "ID" and "Is" (see [prettifier.startsIs]).
+var pluralInitialisms = []string{"ABI", "API", "ASCII", "CLI", "GUI", "PII", "URI"}
+
+// startsIs reports whether the next rune is an 's' that, together with the
+// 'I' at the end of the current all-caps word, makes the word "Is", as in
+// "IDIsUnique", rather than pluralising the word, as in "APIs". A word
+// ending in a known initialism, from [pluralInitialisms] or the dictionary
+// given by [WithInitialisms], is taken to be pluralised.
+func (p *prettifier) startsIs() bool {
+	if p.Peek() != 's' || p.Pos-p.Start < 3 || p.Prev() != 'I' {
+		return false
+	}
+	word := p.Word()
+	if strings.ToUpper(word) != word {
+		return false
+	}
+	for _, known := range pluralInitialisms {
+		if strings.HasSuffix(word, known) {
+			return false
+		}
+	}
+	for known := range p.initialisms {
+		if strings.HasSuffix(word, known) {
+			return false
+		}
+	}
+	return true
+}
+
+// isWholeSubtest reports whether the current word makes up the whole of a
+// subtest name.
+func (p *prettifier) isWholeSubtest() bool {
+	if p.Start == 0 || p.Input[p.Start-1] != '/' {
+		return false
+	}
+	return p.Pos == len(p.Input) || p.Input[p.Pos] == '/'
+}
+
+// splitInitialisms attempts to segment an all-caps word into several, using
+// the [lex.Segmenter] given by [WithSegmenter], if any. Otherwise, it splits
+// the word into a sequence of initialisms from the configured dictionary,
+// such as "JSONXML" into "JSON" and "XML", as described for
+// [lex.Dictionary]. If there is no dictionary, or the word can't be
+// segmented entirely into known initialisms, it returns nil.
+func (p *prettifier) splitInitialisms(word string) []string {
+	if strings.ToUpper(word) != word {
+		return nil
+	}
+	if p.segmenter != nil {
+		return p.segmenter.Segment(word)
+	}
+	if len(p.initialisms) == 0 {
+		return nil
+	}
+	return lex.Dictionary(p.initialisms).Segment(word)
+}
+
+// joinInitialismNumber inserts joiner between the letters and digits of a word
+// consisting of an initialism followed by a number, such as "RFC3339". Any
+// other word is returned unchanged.
+func joinInitialismNumber(word, joiner string) string {
+	i := strings.IndexFunc(word, unicode.IsDigit)
+	if i < 2 {
+		return word
+	}
+	for _, r := range word[:i] {
+		if !unicode.IsUpper(r) {
+			return word
+		}
+	}
+	for _, r := range word[i:] {
+		if !unicode.IsDigit(r) {
+			return word
+		}
+	}
+	return word[:i] + joiner + word[i:]
+}
+
+// conjoin replaces each '&' in word that comes between two letters with
+// " and ", and likewise each '+' with plus surrounded by spaces, unless plus
+// is empty. Symbols next to anything other than a letter, as in "utf+8" or
+// "c++", are left alone, since they're probably part of a technical term.
+func conjoin(word, plus string) string {
+	runes := []rune(word)
+	var b strings.Builder
+	for i, r := range runes {
+		between := i > 0 && i < len(runes)-1 && unicode.IsLetter(runes[i-1]) && unicode.IsLetter(runes[i+1])
+		switch {
+		case between && r == '&':
+			b.WriteString(" and ")
+		case between && r == '+' && plus != "":
+			b.WriteString(" " + plus + " ")
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// EmitAs emits word as it is, without changing its case.
+func (p *prettifier) EmitAs(word string) {
+	p.log(fmt.Sprintf("emit %q", word))
+	p.words = append(p.words, word)
+	p.Skip()
+}
+
+// atOrdinalSuffix reports whether the current word is a number, and the next
+// two runes are an ordinal suffix such as "st" or "nd" (in any case) that ends
+// the word.
+func (p *prettifier) atOrdinalSuffix() bool {
+	if p.Pos == p.Start || p.Pos+2 > len(p.Input) {
+		return false
+	}
+	for _, r := range p.Input[p.Start:p.Pos] {
+		if !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	switch strings.ToLower(string(p.Input[p.Pos : p.Pos+2])) {
+	case "st", "nd", "rd", "th":
+	default:
+		return false
+	}
+	return p.Pos+2 == len(p.Input) || !unicode.IsLower(p.Input[p.Pos+2])
+}
+
+// numericLiteral returns the length of the Go numeric literal with a base
+// prefix, such as "0xFF" or "0b1010", starting at the beginning of the current
+// word, or zero if there is no such literal. The literal must start at a word
+// boundary: the start of the input, a separator, or the end of a camel-case
+// word, so that a "0x" in the middle of some lowercase identifier is not
+// treated as a literal.
+func (p *prettifier) numericLiteral() int {
+	if p.Pos-p.Start > 1 || p.Start+2 >= len(p.Input) || p.Input[p.Start] != '0' {
+		return 0
+	}
+	if !p.atLiteralBoundary() {
+		return 0
+	}
+	var isDigit func(rune) bool
+	switch p.Input[p.Start+1] {
+	case 'x', 'X':
+		isDigit = func(r rune) bool {
+			return unicode.Is(unicode.ASCII_Hex_Digit, r)
+		}
+	case 'b', 'B':
+		isDigit = func(r rune) bool {
+			return r == '0' || r == '1'
+		}
+	case 'o', 'O':
+		isDigit = func(r rune) bool {
+			return r >= '0' && r <= '7'
+		}
+	default:
+		return 0
+	}
+	n := 2
+	for p.Start+n < len(p.Input) && isDigit(p.Input[p.Start+n]) {
+		n++
+	}
+	if n == 2 {
+		// prefix with no digits
+		return 0
+	}
+	return n
+}
+
+// dimension returns the length of a token giving dimensions, such as "2x3"
+// or "1920x1080", consisting of two or more numbers separated by 'x' or 'X',
+// starting at the beginning of the current word, or zero if there is no such
+// token. The numbers may include any numeric characters, as in "2½x3". The
+// token must not follow another digit, and must end at a separator, the end
+// of the input, or the start of a new camel-case word, so that words
+// containing an 'x', such as "box" or "extra", are unaffected. A token
+// starting with "0x" is left for [prettifier.numericLiteral].
+func (p *prettifier) dimension() int {
+	if p.Pos-p.Start != 1 || !unicode.IsNumber(p.Input[p.Start]) {
+		return 0
+	}
+	if p.Start > 0 && unicode.IsNumber(p.Input[p.Start-1]) {
+		return 0
+	}
+	end, numbers := p.Start, 0
+	for {
+		i := end
+		for i < len(p.Input) && unicode.IsNumber(p.Input[i]) {
+			i++
+		}
+		if i == end {
+			break
+		}
+		numbers++
+		end = i
+		if i+1 >= len(p.Input) || p.Input[i] != 'x' && p.Input[i] != 'X' || !unicode.IsNumber(p.Input[i+1]) {
+			break
+		}
+		end++
+	}
+	if numbers < 2 || string(p.Input[p.Start:p.Start+2]) == "0x" {
+		return 0
+	}
+	switch {
+	case end == len(p.Input), p.Input[end] == '_', p.Input[end] == '/':
+	case unicode.IsUpper(p.Input[end]):
+	default:
+		return 0
+	}
+	return end - p.Start
+}
+
+func (p *prettifier) atLiteralBoundary() bool {
+	i := p.Start - 1
+	if i < 0 || p.Input[i] == '_' || p.Input[i] == '/' {
+		return true
+	}
+	if !unicode.IsLower(p.Input[i]) {
+		return false
+	}
+	for i > 0 && unicode.IsLetter(p.Input[i-1]) {
+		i--
+	}
+	return unicode.IsUpper(p.Input[i])
+}
+
+// number returns the length of a token starting with a number, at the
+// beginning of the current word, such as "42", "10ms", or "2FA", or zero if
+// there is no such token, and reports whether the number has a suffix. The
+// digits may be followed by lowercase letters, as in "10ms" or "2x", or by
+// capitals, as in "2FA", "3D", or "5G", possibly made plural by an 's'. A
+// capital followed by a lowercase letter starts a new camel-case word, and
+// so is not part of the token. The token must end at a separator, the end
+// of the input, or the start of a new camel-case word; otherwise, as in
+// "2+2" or "1.5", it's left to the usual rules. The number may be written
+// with digits from any script, as in "٣٤٥", and include other numeric
+// characters, such as the fraction in "2½" or the superscript in "10²".
+func (p *prettifier) number() (n int, suffixed bool) {
+	if p.Pos-p.Start != 1 || !unicode.IsNumber(p.Input[p.Start]) {
+		return 0, false
+	}
+	end := p.Start
+	for end < len(p.Input) && unicode.IsNumber(p.Input[end]) {
+		end++
+	}
+	digits := end
+	isLowerAt := func(i int) bool {
+		return i < len(p.Input) && unicode.IsLower(p.Input[i])
+	}
+	switch {
+	case isLowerAt(end):
+		for isLowerAt(end) {
+			end++
+		}
+	case end < len(p.Input) && unicode.IsUpper(p.Input[end]):
+		for end < len(p.Input) && unicode.IsUpper(p.Input[end]) {
+			end++
+		}
+		switch {
+		case p.Input[end-1] == 'I' && isLowerAt(end) && p.Input[end] == 's' && !isLowerAt(end+1):
+			// the last capital starts the word 'Is'
+			end--
+		case isLowerAt(end) && p.Input[end] == 's' && !isLowerAt(end+1):
+			// plural, as in '3Ds'
+			end++
+		case isLowerAt(end):
+			// the last capital starts a new camel-case word
+			end--
+		}
+	}
+	switch {
+	case end == len(p.Input), p.Input[end] == '_', p.Input[end] == '/':
+	case unicode.IsUpper(p.Input[end]):
+	default:
+		return 0, false
+	}
+	return end - p.Start, end > digits
+}
+
+// isIndex reports whether r is a superscript or subscript digit, such as '²'
+// or '₂', which belongs to whatever comes before it, as in "m²" or "H₂O",
+// rather than starting a number of its own.
+func isIndex(r rune) bool {
+	switch {
+	case r == '¹', r == '²', r == '³':
+		return true
+	case r >= '⁰' && r <= '⁹', r >= '₀' && r <= '₉':
+		return unicode.IsNumber(r)
+	}
+	return false
+}
+
+// letterNumber returns the length of a token consisting of a single letter
+// followed by digits, such as "P99" or "x86", starting at the beginning of the
+// current word, or zero if there is no such token. The token must start at a
+// word boundary, and end at a separator, the end of the input, or the start of
+// a new camel-case word, so that initialisms such as "S390X" are unaffected.
+func (p *prettifier) letterNumber() int {
+	if p.Pos-p.Start != 1 || !unicode.IsLetter(p.Input[p.Start]) || !unicode.IsDigit(p.Peek()) {
+		return 0
+	}
+	if p.Start > 0 && !unicode.IsUpper(p.Input[p.Start]) {
+		if prev := p.Input[p.Start-1]; prev != '_' && prev != '/' {
+			return 0
+		}
+	}
+	end := p.Pos
+	for end < len(p.Input) && unicode.IsDigit(p.Input[end]) {
+		end++
+	}
+	switch {
+	case end == len(p.Input), p.Input[end] == '_', p.Input[end] == '/':
+	case unicode.IsUpper(p.Input[end]) && end+1 < len(p.Input) && unicode.IsLower(p.Input[end+1]):
+	default:
+		return 0
+	}
+	return end - p.Start
+}
+
+// capsSnake returns the length of a constant-style identifier such as
+// "MAX_RETRIES", consisting of two or more all-caps segments (and possibly
+// some numeric segments) joined by underscores, starting at the beginning of
+// the current word, or zero if there is no such identifier. A capital letter
+// followed by a lowercase one is taken as the start of a new camel-case word,
+// and so ends the identifier, provided that at least two capitals are left
+// in the segment before it: otherwise, the segment is not part of the
+// identifier.
+func (p *prettifier) capsSnake() int {
+	if p.Pos-p.Start != 1 || !unicode.IsUpper(p.Input[p.Start]) {
+		return 0
+	}
+	isCaps := func(r rune) bool {
+		return unicode.IsUpper(r) || unicode.IsDigit(r)
+	}
+	end, segments := p.Start, 0
+	for {
+		i := end
+		for i < len(p.Input) && isCaps(p.Input[i]) {
+			i++
+		}
+		if i < len(p.Input) && unicode.IsLower(p.Input[i]) {
+			if i-2 <= end || !unicode.IsUpper(p.Input[i-1]) || !p.camelWordAt(i-1) {
+				// segment runs into a lowercase letter, or would be left
+				// with a single capital once the camel-case word is split
+				// off, as in "A_AAaa"
+				break
+			}
+			// the last capital starts a new camel-case word
+			i--
+		}
+		if i == end {
+			break
+		}
+		if strings.IndexFunc(string(p.Input[end:i]), unicode.IsLetter) >= 0 {
+			segments++
+		}
+		end = i
+		if end+1 >= len(p.Input) || p.Input[end] != '_' || !isCaps(p.Input[end+1]) {
+			break
+		}
+		end++
+	}
+	if segments < 2 {
+		return 0
+	}
+	if end > p.Start && p.Input[end-1] == '_' {
+		end--
+	}
+	return end - p.Start
+}
+
+// groupedWord returns the length of a word, starting at the beginning of the
+// current word and running up to the next separator, that contains a
+// balanced parenthesised or bracketed group, such as "add(2,3)=5" or
+// "[a-z]+", or zero if there is no such word. Underscores inside a group don't
+// end the word, but slashes always do, since they separate subtests. If the
+// brackets in the word are unbalanced, it returns zero.
+func (p *prettifier) groupedWord() int {
+	if p.Pos-p.Start != 1 {
+		return 0
+	}
+	closers := map[rune]rune{')': '(', ']': '['}
+	stack := []rune{}
+	grouped := false
+	i := p.Start
+	for ; i < len(p.Input); i++ {
+		r := p.Input[i]
+		if r == '/' || r == '_' && len(stack) == 0 {
+			break
+		}
+		switch r {
+		case '(', '[':
+			stack = append(stack, r)
+			grouped = true
+		case ')', ']':
+			if len(stack) == 0 || stack[len(stack)-1] != closers[r] {
+				return 0
+			}
+			stack = stack[:len(stack)-1]
+		}
+	}
+	if !grouped || len(stack) > 0 {
+		return 0
+	}
+	return i - p.Start
+}
+
+// typeExpr returns the length of the bracketed group starting at i, such as
+// "[string]" or "[]", together with any type it applies to, as in "[]byte",
+// "[]*Node", or "map[string]int", or zero if there's no balanced group
+// before the next slash. Underscores inside the brackets don't end the
+// expression, and neither does a type following them, as long as it starts
+// with a lowercase letter, or a pointer, or follows "[]", so that the next
+// word of a camel-case name, as in "Set[T]AddsItems", is left alone.
+func (p *prettifier) typeExpr(i int) int {
+	start := i
+	for i < len(p.Input) && p.Input[i] == '[' {
+		open := i
+		depth := 0
+		for ; i < len(p.Input); i++ {
+			if r := p.Input[i]; r == '[' {
+				depth++
+			} else if r == ']' {
+				depth--
+			} else if r == '/' {
+				return 0
+			}
+			if depth == 0 {
+				break
+			}
+		}
+		if depth > 0 {
+			return 0
+		}
+		i++
+		slice := i-open == 2
+		pointer := false
+		for i < len(p.Input) && p.Input[i] == '*' {
+			pointer = true
+			i++
+		}
+		if i == len(p.Input) || !unicode.IsLetter(p.Input[i]) {
+			break
+		}
+		if !unicode.IsLower(p.Input[i]) && !pointer && !slice {
+			break
+		}
+		for i < len(p.Input) {
+			r := p.Input[i]
+			if r == '.' && i+1 < len(p.Input) && unicode.IsLetter(p.Input[i+1]) {
+				i++
+				continue
+			}
+			if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+				break
+			}
+			i++
+		}
+	}
+	return i - start
+}
+
+// qualifiedName returns the length of a qualified Go identifier, such as
+// "http.HandlerFunc" or "strings.Builder", starting at the beginning of the
+// current word: identifiers joined by dots, the last of which starts with a
+// capital letter, unless they're all lowercase, as in "bytes.buffer". A dot
+// that isn't followed by an identifier, such as a full stop at the end of a
+// subtest name, doesn't make a name qualified, though it's kept with the
+// name, rather than becoming a word of its own. If there's no such name, it
+// returns zero.
+func (p *prettifier) qualifiedName() int {
+	if p.Pos-p.Start != 1 {
+		return 0
+	}
+	isIdent := func(r rune) bool {
+		return unicode.IsLetter(r) || unicode.IsDigit(r)
+	}
+	end, parts, lower, last := p.Start, 0, true, p.Start
+	for {
+		i := end
+		if i >= len(p.Input) || !unicode.IsLetter(p.Input[i]) {
+			break
+		}
+		for i < len(p.Input) && isIdent(p.Input[i]) {
+			if unicode.IsUpper(p.Input[i]) {
+				lower = false
+			}
+			i++
+		}
+		parts++
+		last, end = end, i
+		if end+1 >= len(p.Input) || p.Input[end] != '.' {
+			break
+		}
+		end++
+	}
+	if end > p.Start && p.Input[end-1] == '.' {
+		end--
+	}
+	if parts < 2 || !lower && !unicode.IsUpper(p.Input[last]) {
+		return 0
+	}
+	if end < len(p.Input) && p.Input[end] == '.' && (end+1 == len(p.Input) || p.Input[end+1] == '_' || p.Input[end+1] == '/') {
+		// keep a full stop with the name, rather than as a word
+		end++
+	}
+	return end - p.Start
+}
+
+// keyValue returns the length of an expression such as "mode=strict",
+// starting at the beginning of the current word, which ends just before an
+// '='. The value runs from the '=' up to the next separator, unless it's
+// quoted, in which case it runs through the closing quote, so that it may
+// contain underscores. If the current word isn't a key followed by '=', it
+// returns zero.
+func (p *prettifier) keyValue() int {
+	if p.Peek() != '=' {
+		return 0
+	}
+	if r := p.Input[p.Start]; !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+		return 0
+	}
+	i := p.Pos + 1
+	if i < len(p.Input) && (p.Input[i] == '"' || p.Input[i] == '\'') {
+		for j := i + 1; j < len(p.Input) && p.Input[j] != '/'; j++ {
+			if p.Input[j] == p.Input[i] {
+				i = j + 1
+				break
+			}
+		}
+	}
+	for i < len(p.Input) && p.Input[i] != '_' && p.Input[i] != '/' {
+		i++
+	}
+	return i - p.Start
+}
+
+// camelWordAt reports whether there is a camel-case word starting at i: a
+// capital letter followed by at least two lowercase letters.
+func (p *prettifier) camelWordAt(i int) bool {
+	return i+2 < len(p.Input) && unicode.IsLower(p.Input[i+1]) && unicode.IsLower(p.Input[i+2])
 }
 
 func (p *prettifier) multiWordFunction() {
 	var fname string
 	for _, w := range p.words {
-		fname += cases.Title(language.Und, cases.NoLower).String(w)
+		fname += titleCase(w)
 	}
 	p.log("multiword function", fname)
 	p.words = []string{fname}
-	p.seenUnderscore = true
+	p.markerDone = true
+	p.subject = true
 }
 
 func (p *prettifier) log(args ...interface{}) {
@@ -154,115 +973,227 @@ func (p *prettifier) log(args ...interface{}) {
 
 func (p *prettifier) logState(stateName string) {
 	next := "EOF"
-	if p.pos < len(p.input) {
-		next = string(p.input[p.pos])
+	if p.Pos < len(p.Input) {
+		next = string(p.Input[p.Pos])
 	}
 	p.log(fmt.Sprintf("%s: [%s] -> %s",
 		stateName,
-		string(p.input[p.start:p.pos]),
+		p.Word(),
 		next,
 	))
 }
 
-type stateFunc func(p *prettifier) stateFunc
-
-func betweenWords(p *prettifier) stateFunc {
+func betweenWords(p *prettifier) lex.StateFunc[*prettifier] {
 	for {
 		p.logState("betweenWords")
-		switch p.next() {
-		case eof:
+		switch p.Next() {
+		case lex.EOF:
 			return nil
-		case '_', '/':
-			p.skip()
+		case '/':
+			p.startSubtest()
+			p.Skip()
+		case '_':
+			p.Skip()
 		default:
 			return inWord
 		}
 	}
 }
 
-func inWord(p *prettifier) stateFunc {
+func inWord(p *prettifier) lex.StateFunc[*prettifier] {
 	for {
 		p.logState("inWord")
-		switch r := p.peek(); {
-		case r == eof:
-			p.emit()
+		if n := p.groupedWord(); p.inSubTest && n > 0 {
+			// code-like expression such as 'add(2,3)=5'
+			p.Pos = p.Start + n
+			p.EmitAs(p.Word())
+			return betweenWords
+		}
+		if n := p.qualifiedName(); p.inSubTest && n > 0 {
+			// qualified identifier such as 'http.HandlerFunc'
+			p.Pos = p.Start + n
+			p.EmitAs(p.Word())
+			return betweenWords
+		}
+		if p.Pos-p.Start == 1 && p.Input[p.Start] == '[' {
+			if n := p.typeExpr(p.Start); n > 0 {
+				// type expression such as '[]byte', kept as it is
+				p.Pos = p.Start + n
+				p.EmitAs(p.Word())
+				return betweenWords
+			}
+		}
+		if n := p.keyValue(); n > 0 {
+			// key=value expression such as 'mode=strict', kept as it is,
+			// except that any underscores in a quoted value become spaces
+			p.Pos = p.Start + n
+			word := strings.ReplaceAll(p.Word(), "_", " ")
+			if len(p.words) == 0 && !p.preserveCase {
+				key, value, _ := strings.Cut(word, "=")
+				word = titleCase(key) + "=" + value
+			}
+			p.EmitAs(word)
+			return betweenWords
+		}
+		if p.atOrdinalSuffix() {
+			// ordinal number such as '1st'
+			p.Pos += 2
+			word := p.Word()
+			if !p.preserveCase {
+				word = strings.ToLower(word)
+			}
+			p.EmitAs(word)
+			return betweenWords
+		}
+		if n := p.dimension(); n > 0 {
+			// dimensions such as '2x3'
+			p.Pos = p.Start + n
+			word := p.Word()
+			if !p.preserveCase {
+				word = strings.ReplaceAll(word, "X", "x")
+			}
+			p.EmitAs(word)
+			return betweenWords
+		}
+		if n := p.numericLiteral(); n > 0 {
+			// literal such as '0xFF'
+			p.Pos = p.Start + n
+			p.EmitAs(p.Word())
+			return betweenWords
+		}
+		if n, suffixed := p.number(); n > 0 {
+			// number such as '42', or with a suffix, such as '10ms' or
+			// '2FA', whose case is kept as it is
+			p.Pos = p.Start + n
+			if suffixed {
+				p.EmitAs(p.Word())
+			} else {
+				p.Emit()
+			}
+			return betweenWords
+		}
+		if n := p.capsSnake(); n > 0 {
+			// constant-style identifier such as 'MAX_RETRIES'
+			p.Pos = p.Start + n
+			p.EmitAs(p.Word())
+			// its underscores are not function name markers
+			p.markerDone = true
+			return betweenWords
+		}
+		if n := p.letterNumber(); n > 0 {
+			// shorthand such as 'p99' or 'x86'
+			p.Pos = p.Start + n
+			word := p.Word()
+			if !p.initialisms[word] && !p.preserveCase {
+				word = strings.ToLower(word)
+			}
+			p.EmitAs(word)
+			return betweenWords
+		}
+		switch r := p.Peek(); {
+		case r == lex.EOF:
+			p.Emit()
 			return nil
+		case r == '[' && p.typeExpr(p.Pos) > 0:
+			// type arguments, or the rest of a type expression, as in
+			// 'Set[T]' or 'map[string]int', kept as they are, though the
+			// word before them is cased as usual
+			n := p.typeExpr(p.Pos)
+			emitted := p.Emit()
+			p.Pos += n
+			expr := p.Word()
+			if !emitted {
+				p.EmitAs(expr)
+				return betweenWords
+			}
+			p.log(fmt.Sprintf("append %q", expr))
+			p.words[len(p.words)-1] += expr
+			p.Skip()
+			return betweenWords
 		case r == '_':
-			p.emit()
-			if !p.seenUnderscore && !p.inSubTest {
-				// special 'end of function name' marker
-				p.multiWordFunction()
+			emitted := p.Emit()
+			if emitted && !p.markerDone {
+				// only the first underscore can be the marker
+				p.markerDone = true
+				if p.maxFuncWords <= 0 || len(p.words) <= p.maxFuncWords {
+					// special 'end of function name' marker
+					p.multiWordFunction()
+				}
 			}
 			return betweenWords
 		case r == '/':
-			p.emit()
-			p.inSubTest = true
+			p.Emit()
 			return betweenWords
 		case unicode.IsUpper(r):
-			if p.prev() == '-' {
+			if p.Prev() == '-' {
 				// inside hyphenated word
-				p.next()
+				p.Next()
 				continue
 			}
 			if p.inInitialism() {
 				// keep going
-				p.next()
+				p.Next()
 				continue
 			}
-			p.emit()
+			p.Emit()
 			return betweenWords
-		case unicode.IsDigit(r):
-			if unicode.IsDigit(p.prev()) {
-				// in a multi-digit number
-				p.next()
+		case unicode.IsNumber(r) && !isIndex(r):
+			if unicode.IsNumber(p.Prev()) {
+				// in a multi-digit number, or one with a fraction, as
+				// in '2½'
+				p.Next()
 				continue
 			}
-			if p.prev() == '-' {
+			if p.Prev() == '-' {
 				// in a negative number
-				p.next()
+				p.Next()
 				continue
 			}
-			if p.prev() == '=' {
+			if p.Prev() == '=' {
 				// in some phrase like 'n=3'
-				p.next()
+				p.Next()
 				continue
 			}
 			if p.inInitialism() {
 				// keep going
-				p.next()
+				p.Next()
 				continue
 			}
-			p.emit()
+			p.Emit()
 		default:
-			if p.pos-p.start <= 1 {
+			if p.Pos-p.Start <= 1 {
 				// word too short
-				p.next()
+				p.Next()
 				continue
 			}
-			if p.input[p.start] == '\'' {
+			if p.Input[p.Start] == '\'' {
 				// inside a quoted word
-				p.next()
+				p.Next()
 				continue
 			}
 			if !p.inInitialism() {
 				// keep going
-				p.next()
+				p.Next()
+				continue
+			}
+			if p.startsIs() {
+				// the last capital starts the word 'Is'
+				p.Backup()
+				p.Emit()
 				continue
 			}
 			if p.inInitialism() && r == 's' {
-				p.next()
-				p.emit()
+				p.Next()
+				p.Emit()
 				return betweenWords
 			}
 			// start a new word
-			p.backup()
-			p.emit()
+			p.Backup()
+			p.Emit()
 		}
 	}
 }
 
-const eof rune = 0
-
 // DebugWriter identifies the stream to which debug information should be
 // printed, if desired. By default it is [os.Stderr].
 var DebugWriter io.Writer = os.Stderr
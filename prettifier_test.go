@@ -1,16 +1,28 @@
 package gotestdox_test
 
 import (
+	"encoding/json"
 	"fmt"
+	"os"
+	"strings"
 	"testing"
+	"unicode/utf8"
 
 	"github.com/bitfield/gotestdox"
+	"github.com/bitfield/gotestdox/lex"
 	"github.com/google/go-cmp/cmp"
 )
 
+// asciiCasing is true when the tests are built with the gotestdox_ascii tag,
+// in which case cases with non-ASCII input are skipped.
+var asciiCasing = false
+
 func TestPrettify(t *testing.T) {
 	t.Parallel()
 	for _, tc := range Cases {
+		if asciiCasing && !isASCII(tc.input) {
+			continue
+		}
 		t.Run(tc.name, func(t *testing.T) {
 			got := gotestdox.Prettify(tc.input)
 			if tc.want != got {
@@ -20,6 +32,725 @@ func TestPrettify(t *testing.T) {
 	}
 }
 
+func TestPrettifyFunc_GivesSameResultsAsPrettify(t *testing.T) {
+	t.Parallel()
+	prettify := gotestdox.PrettifyFunc(gotestdox.WithSubjectSeparator(": "))
+	for _, tc := range Cases {
+		want := gotestdox.Prettify(tc.input, gotestdox.WithSubjectSeparator(": "))
+		if got := prettify(tc.input); want != got {
+			t.Errorf("%s: %s", tc.name, cmp.Diff(want, got))
+		}
+	}
+}
+
+func isASCII(s string) bool {
+	for _, r := range s {
+		if r > 0x7f {
+			return false
+		}
+	}
+	return true
+}
+
+func TestPrettify_NeverProducesLeadingTrailingOrDoubledSpaces(t *testing.T) {
+	t.Parallel()
+	inputs := []string{
+		"TestFoo_", "TestFoo__Bar", "TestFoo/_/Bar", "Test_", "Test__", "Test/_",
+		"TestFoo_/_Bar", "Test__Foo_Bar", "TestFoo//Bar", "Test/", "TestFoo/_",
+		"TestFoo_/", "Test_/_FooBar_Baz", "TestFoo/__/__",
+	}
+	for _, input := range inputs {
+		got := gotestdox.Prettify(input)
+		if strings.TrimSpace(got) != got || strings.Contains(got, "  ") {
+			t.Errorf("%q: contains leading, trailing, or doubled spaces %q", input, got)
+		}
+	}
+}
+
+func TestPrettify_WithInitialismsSplitsAdjacentKnownInitialisms(t *testing.T) {
+	t.Parallel()
+	dict := gotestdox.WithInitialisms("JSON", "XML", "HTTP", "HTTPS", "URL")
+	tcs := []struct {
+		input, want string
+	}{
+		{
+			input: "TestJSONXMLRoundTrip",
+			want:  "JSON XML round trip",
+		},
+		{
+			input: "TestParsesHTTPSURL",
+			want:  "Parses HTTPS URL",
+		},
+		{
+			input: "TestConvertsJSONToXML",
+			want:  "Converts JSON to XML",
+		},
+		{
+			input: "TestHandlesABCDEFInput",
+			want:  "Handles ABCDEF input",
+		},
+		{
+			input: "TestHandlesJSONABCInput",
+			want:  "Handles JSONABC input",
+		},
+	}
+	for _, tc := range tcs {
+		got := gotestdox.Prettify(tc.input, dict)
+		if tc.want != got {
+			t.Errorf("%q: %s", tc.input, cmp.Diff(tc.want, got))
+		}
+	}
+}
+
+func TestPrettify_WithInitialismsPreservesLetterNumberShorthandInDictionary(t *testing.T) {
+	t.Parallel()
+	want := "Builds for X86 and p50"
+	got := gotestdox.Prettify("TestBuildsForX86/and_P50", gotestdox.WithInitialisms("X86"))
+	if want != got {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestPrettify_WithInitialismNumberJoinerSeparatesInitialismsFromNumbers(t *testing.T) {
+	t.Parallel()
+	joiner := gotestdox.WithInitialismNumberJoiner(" ")
+	tcs := []struct {
+		input, want string
+	}{
+		{
+			input: "TestFormatsPerRFC3339",
+			want:  "Formats per RFC 3339",
+		},
+		{
+			input: "TestParsesISO8601Dates",
+			want:  "Parses ISO 8601 dates",
+		},
+		{
+			input: "TestSHA256EncodesCorrectly",
+			want:  "SHA 256 encodes correctly",
+		},
+		{
+			input: "TestS390XOperandParser",
+			want:  "S390X operand parser",
+		},
+		{
+			input: "TestBC35A",
+			want:  "BC35A",
+		},
+	}
+	for _, tc := range tcs {
+		got := gotestdox.Prettify(tc.input, joiner)
+		if tc.want != got {
+			t.Errorf("%q: %s", tc.input, cmp.Diff(tc.want, got))
+		}
+	}
+}
+
+func TestPrettify_WithNumericSubtestLabelLabelsPurelyNumericSubtests(t *testing.T) {
+	t.Parallel()
+	label := gotestdox.WithNumericSubtestLabel("case")
+	tcs := []struct {
+		input, want string
+	}{
+		{
+			input: "TestSum/0",
+			want:  "Sum case 0",
+		},
+		{
+			input: "TestSum/12",
+			want:  "Sum case 12",
+		},
+		{
+			input: "TestSum/12/3",
+			want:  "Sum case 12 case 3",
+		},
+		{
+			input: "TestSum/adds_2_numbers",
+			want:  "Sum adds 2 numbers",
+		},
+		{
+			input: "TestSum2",
+			want:  "Sum 2",
+		},
+	}
+	for _, tc := range tcs {
+		got := gotestdox.Prettify(tc.input, label)
+		if tc.want != got {
+			t.Errorf("%q: %s", tc.input, cmp.Diff(tc.want, got))
+		}
+	}
+}
+
+func TestPrettify_WithSubjectSeparatorSeparatesMarkedFunctionName(t *testing.T) {
+	t.Parallel()
+	sep := gotestdox.WithSubjectSeparator(" — ")
+	tcs := []struct {
+		input, want string
+	}{
+		{
+			input: "TestHandleInput_ClosesInputAfterReading",
+			want:  "HandleInput — closes input after reading",
+		},
+		{
+			input: "TestHandleInput_ClosesInput/after_reading",
+			want:  "HandleInput — closes input after reading",
+		},
+		{
+			input: "TestHandleInputClosesInputAfterReading",
+			want:  "Handle input closes input after reading",
+		},
+		{
+			input: "TestHandleInput_",
+			want:  "HandleInput",
+		},
+		{
+			input: "TestParse/handles_MAX_SIZE",
+			want:  "Parse handles MAX_SIZE",
+		},
+	}
+	for _, tc := range tcs {
+		got := gotestdox.Prettify(tc.input, sep)
+		if tc.want != got {
+			t.Errorf("%q: %s", tc.input, cmp.Diff(tc.want, got))
+		}
+	}
+}
+
+func TestPrettify_WithSubtestSeparatorJoinsSubtestLevelsWithSeparator(t *testing.T) {
+	t.Parallel()
+	sep := gotestdox.WithSubtestSeparator(" › ")
+	tcs := []struct {
+		input, want string
+	}{
+		{
+			input: "TestAPI/users/create/validates_email",
+			want:  "API › users › create › validates email",
+		},
+		{
+			input: "TestParseInput/rejects_trailing_commas",
+			want:  "Parse input › rejects trailing commas",
+		},
+		{
+			input: "TestFoo//bar",
+			want:  "Foo › bar",
+		},
+		{
+			input: "TestParseInputWithoutSubtests",
+			want:  "Parse input without subtests",
+		},
+	}
+	for _, tc := range tcs {
+		got := gotestdox.Prettify(tc.input, sep)
+		if tc.want != got {
+			t.Errorf("%q: %s", tc.input, cmp.Diff(tc.want, got))
+		}
+	}
+}
+
+func TestPrettify_WithSubtestSeparatorKeepsSubjectSeparatorAfterFunctionName(t *testing.T) {
+	t.Parallel()
+	got := gotestdox.Prettify("TestHandleInput_Closes/after_reading",
+		gotestdox.WithSubtestSeparator(" / "), gotestdox.WithSubjectSeparator(" — "))
+	want := "HandleInput — closes / after reading"
+	if want != got {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestPrettify_WithMaxSentenceLengthTruncatesAtWordBoundary(t *testing.T) {
+	t.Parallel()
+	tcs := []struct {
+		input, want string
+		max         int
+		opts        []gotestdox.Option
+	}{
+		{input: "TestParseReadsWholeFile", max: 23, want: "Parse reads whole file"},
+		{input: "TestParseReadsWholeFile", max: 21, want: "Parse reads whole…"},
+		{input: "TestParseReadsWholeFile", max: 18, want: "Parse reads whole…"},
+		{input: "TestParseReadsWholeFile", max: 17, want: "Parse reads…"},
+		{input: "TestFormat/café_menu", max: 12, want: "Format café…"},
+		{input: "TestFormat/café_menu", max: 11, want: "Format…"},
+		{input: "TestGreets/🌍_world", max: 10, want: "Greets 🌍…"},
+		{input: "TestGreets/🌍_world", max: 8, want: "Greets…"},
+		{input: "TestInternationalisation", max: 6, want: "Inter…"},
+		{input: "TestAPI/users/create", max: 15, want: "API › users…", opts: []gotestdox.Option{gotestdox.WithSubtestSeparator(" › ")}},
+		{input: "TestAPI/users/create", max: 11, want: "API…", opts: []gotestdox.Option{gotestdox.WithSubtestSeparator(" › ")}},
+	}
+	for _, tc := range tcs {
+		opts := append([]gotestdox.Option{gotestdox.WithMaxSentenceLength(tc.max)}, tc.opts...)
+		got := gotestdox.Prettify(tc.input, opts...)
+		if tc.want != got {
+			t.Errorf("%q (max %d): %s", tc.input, tc.max, cmp.Diff(tc.want, got))
+		}
+		if n := utf8.RuneCountInString(got); n > tc.max {
+			t.Errorf("%q (max %d): %d runes in %q", tc.input, tc.max, n, got)
+		}
+	}
+}
+
+func TestPrettify_WithMaxWordLengthShortensLongWordsInTheMiddle(t *testing.T) {
+	t.Parallel()
+	tcs := []struct {
+		input, want string
+		max         int
+	}{
+		{input: "TestAccepts/token=eyJhbGciOiJIUzI1NiJ9", max: 8, want: "Accepts toke…iJ9"},
+		{input: "TestAccepts/token=eyJhbGciOiJIUzI1NiJ9", max: 9, want: "Accepts toke…NiJ9"},
+		{input: "TestAccepts/token=eyJhbGciOiJIUzI1NiJ9", max: 32, want: "Accepts token=eyJhbGciOiJIUzI1NiJ9"},
+		{input: "TestGreets/naïveté_ünïcödé", max: 6, want: "Greets naï…té ünï…dé"},
+		{input: "TestGreets/k=🌍🌎🌏🌍🌎🌏", max: 6, want: "Greets k=🌍…🌎🌏"},
+	}
+	for _, tc := range tcs {
+		got := gotestdox.Prettify(tc.input, gotestdox.WithMaxWordLength(tc.max))
+		if tc.want != got {
+			t.Errorf("%q (max %d): %s", tc.input, tc.max, cmp.Diff(tc.want, got))
+		}
+	}
+}
+
+func TestPrettify_WithMaxWordLengthShortensWordsBeforeTruncatingSentence(t *testing.T) {
+	t.Parallel()
+	got := gotestdox.Prettify("TestAccepts/token=eyJhbGciOiJIUzI1NiJ9_from_the_header",
+		gotestdox.WithMaxWordLength(8), gotestdox.WithMaxSentenceLength(26))
+	want := "Accepts toke…iJ9 from the…"
+	if want != got {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestPrettify_WithPreservedCaseKeepsCapitalisationOfEveryWord(t *testing.T) {
+	t.Parallel()
+	tcs := []struct {
+		input, want string
+	}{
+		{
+			input: "TestChargesVATOnEUOrders",
+			want:  "Charges VAT On EU Orders",
+		},
+		{
+			input: "TestRespectsDoNotTrack",
+			want:  "Respects Do Not Track",
+		},
+		{
+			input: "TestHandleInput_ClosesInput/after_Reading",
+			want:  "HandleInput Closes Input after Reading",
+		},
+		{
+			input: "TestRanks/1ST_and_P99",
+			want:  "Ranks 1ST and P99",
+		},
+		{
+			input: "TestDrives4X4",
+			want:  "Drives 4X4",
+		},
+	}
+	for _, tc := range tcs {
+		got := gotestdox.Prettify(tc.input, gotestdox.WithPreservedCase())
+		if tc.want != got {
+			t.Errorf("%q: %s", tc.input, cmp.Diff(tc.want, got))
+		}
+	}
+}
+
+func TestPrettify_KeepsShortInitialismsInCapitalsOnlyIfTypedSoByDefault(t *testing.T) {
+	t.Parallel()
+	tcs := []struct {
+		input, want string
+	}{
+		{input: "TestValidatesIDFormat", want: "Validates ID format"},
+		{input: "TestConnectsToDBOverIP", want: "Connects to DB over IP"},
+		{input: "TestRunsOnCI/with_UI_disabled", want: "Runs on CI with UI disabled"},
+		{input: "TestValidatesIdFormat", want: "Validates id format"},
+		{input: "TestValidate/the_id_format", want: "Validate the id format"},
+	}
+	for _, tc := range tcs {
+		got := gotestdox.Prettify(tc.input)
+		if tc.want != got {
+			t.Errorf("%q: %s", tc.input, cmp.Diff(tc.want, got))
+		}
+	}
+}
+
+func TestPrettify_WithShortWordNormalisationCapitalisesKnownShortWords(t *testing.T) {
+	t.Parallel()
+	tcs := []struct {
+		input, want string
+		opts        []gotestdox.Option
+	}{
+		{input: "TestValidatesIdFormat", want: "Validates ID format"},
+		{input: "TestValidate/the_id_format", want: "Validate the ID format"},
+		{input: "TestParse/reads_ids_from_the_db", want: "Parse reads IDs from the DB"},
+		{input: "TestIdIsUnique", want: "ID is unique"},
+		{input: "TestReturns/ok_on_os_io", want: "Returns OK on OS IO"},
+		{input: "TestReadsDb", want: "Reads DB", opts: []gotestdox.Option{gotestdox.WithPreservedCase()}},
+		{input: "TestRejects/an_ox", want: "Rejects an ox"},
+		{input: "TestRejects/an_ox", want: "Rejects an OX", opts: []gotestdox.Option{gotestdox.WithShortWords("OX")}},
+		{input: "TestSends/id_to_ox", want: "Sends id to OX", opts: []gotestdox.Option{gotestdox.WithShortWordsOnly("OX")}},
+		{input: "TestReads/cfg_via_io", want: "Reads cfg via IO", opts: []gotestdox.Option{gotestdox.WithShortWords()}},
+	}
+	for _, tc := range tcs {
+		opts := append([]gotestdox.Option{gotestdox.WithShortWordNormalisation()}, tc.opts...)
+		got := gotestdox.Prettify(tc.input, opts...)
+		if tc.want != got {
+			t.Errorf("%q: %s", tc.input, cmp.Diff(tc.want, got))
+		}
+	}
+}
+
+func TestPrettify_WithLeadingFillerWordsDropsFillersFollowedByAtLeastTwoWords(t *testing.T) {
+	t.Parallel()
+	tcs := []struct {
+		input, want string
+		opts        []gotestdox.Option
+	}{
+		{input: "TestThatTheServerStartsQuickly", want: "Server starts quickly"},
+		{input: "TestItRejectsNulls", want: "Rejects nulls"},
+		{input: "TestItWorks", want: "It works"},
+		{input: "TestTheIt", want: "The it"},
+		{input: "TestItThe", want: "It the"},
+		{input: "TestIt/rejects_nulls", want: "It rejects nulls"},
+		{input: "TestItParses/the_input", want: "It parses the input"},
+		{input: "TestItParsesInput/the_header", want: "Parses input the header"},
+		{input: "TestIt_RejectsNulls", want: "It rejects nulls"},
+		{input: "TestItParsesJSONInput", want: "Parses JSON input"},
+		{input: "TestItJSONParses", want: "JSON parses"},
+		{input: "TestItRejectsNulls", want: "Rejects Nulls", opts: []gotestdox.Option{gotestdox.WithPreservedCase()}},
+	}
+	for _, tc := range tcs {
+		opts := append([]gotestdox.Option{gotestdox.WithLeadingFillerWords("that", "It", "THE")}, tc.opts...)
+		got := gotestdox.Prettify(tc.input, opts...)
+		if tc.want != got {
+			t.Errorf("%q: %s", tc.input, cmp.Diff(tc.want, got))
+		}
+	}
+}
+
+func TestPrettify_WithPrefixMatchingCaseInsensitiveStripsPrefixesInAnyCase(t *testing.T) {
+	t.Parallel()
+	tcs := []struct {
+		input, want string
+	}{
+		{input: "TEST_Foo", want: "Foo"},
+		{input: "test_foo", want: "Foo"},
+		{input: "TestFoo", want: "Foo"},
+		{input: "testFoo", want: "Foo"},
+		{input: "TEST_user_login", want: "User login"},
+		{input: "BENCH_parse_input", want: "Parse input"},
+		{input: "fuzz_decoder/empty_input", want: "Decoder empty input"},
+		{input: "Testable", want: "Testable"},
+		{input: "TESTABLE", want: "TESTABLE"},
+		{input: "test", want: "Test"},
+	}
+	for _, tc := range tcs {
+		got := gotestdox.Prettify(tc.input, gotestdox.WithPrefixMatching(gotestdox.CaseInsensitive))
+		if tc.want != got {
+			t.Errorf("%q: %s", tc.input, cmp.Diff(tc.want, got))
+		}
+	}
+}
+
+func TestPrettify_MatchesTestPrefixExactlyByDefault(t *testing.T) {
+	t.Parallel()
+	tcs := []struct {
+		input, want string
+	}{
+		{input: "TEST_Foo", want: "TEST foo"},
+		{input: "test_foo", want: "Test foo"},
+		{input: "TestFoo", want: "Foo"},
+		{input: "Testable", want: "Able"},
+	}
+	for _, tc := range tcs {
+		got := gotestdox.Prettify(tc.input, gotestdox.WithPrefixMatching(gotestdox.ExactPrefix))
+		if tc.want != got {
+			t.Errorf("%q: %s", tc.input, cmp.Diff(tc.want, got))
+		}
+		if got := gotestdox.Prettify(tc.input); tc.want != got {
+			t.Errorf("%q by default: %s", tc.input, cmp.Diff(tc.want, got))
+		}
+	}
+}
+
+func TestPrettify_DropsNoFillerWordsByDefault(t *testing.T) {
+	t.Parallel()
+	want := "That the server starts quickly"
+	got := gotestdox.Prettify("TestThatTheServerStartsQuickly")
+	if want != got {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestPrettify_WithConjunctionsRendersSymbolsBetweenLettersAsWords(t *testing.T) {
+	t.Parallel()
+	tcs := []struct {
+		input, plus, want string
+	}{
+		{
+			input: "TestMerge/a+b_yields_ab",
+			plus:  "plus",
+			want:  "Merge a plus b yields ab",
+		},
+		{
+			input: "TestMerge/a+b_yields_ab",
+			plus:  "",
+			want:  "Merge a+b yields ab",
+		},
+		{
+			input: "TestQuery/filters_name&age",
+			plus:  "plus",
+			want:  "Query filters name and age",
+		},
+		{
+			input: "TestA/c++_and_1+2",
+			plus:  "plus",
+			want:  "A c++ and 1+2",
+		},
+	}
+	for _, tc := range tcs {
+		got := gotestdox.Prettify(tc.input, gotestdox.WithConjunctions(tc.plus))
+		if tc.want != got {
+			t.Errorf("%q: %s", tc.input, cmp.Diff(tc.want, got))
+		}
+	}
+	want := "Query filters name&age"
+	got := gotestdox.Prettify("TestQuery/filters_name&age")
+	if want != got {
+		t.Errorf("want symbols kept by default: %s", cmp.Diff(want, got))
+	}
+}
+
+func TestPrettify_WithListCommasPunctuatesListsOfThreeOrMoreItems(t *testing.T) {
+	t.Parallel()
+	opts := []gotestdox.Option{
+		gotestdox.WithListCommas(),
+		gotestdox.WithInitialisms("CSV", "JSON", "XML", "YAML", "TOML"),
+	}
+	tcs := map[string]string{
+		"TestSupportsJSONYAMLAndTOML":              "Supports JSON, YAML, and TOML",
+		"TestExportsCSVJSONXMLAndHTMLReports":      "Exports CSV, JSON, XML, and HTML reports",
+		"TestParsesHeadersBodiesAndTrailers":       "Parses headers, bodies, and trailers",
+		"TestStore/drops_keys_values_and_indexes":  "Store drops keys, values, and indexes",
+		"TestCreatesReadsUpdatesAndDeletesRecords": "Creates, reads, updates, and deletes records",
+		"TestStore/creates_reads_and_deletes_keys": "Store creates, reads, and deletes keys",
+	}
+	for input, want := range tcs {
+		got := gotestdox.Prettify(input, opts...)
+		if want != got {
+			t.Errorf("%q: %s", input, cmp.Diff(want, got))
+		}
+	}
+}
+
+func TestPrettify_WithListCommasNeverPunctuatesFewerThanThreeItemsOrVerbBeforeNouns(t *testing.T) {
+	t.Parallel()
+	opts := []gotestdox.Option{
+		gotestdox.WithListCommas(),
+		gotestdox.WithInitialisms("JSON", "YAML", "TOML"),
+	}
+	tcs := map[string]string{
+		"TestSupportsJSONAndTOML":                 "Supports JSON and TOML",
+		"TestSupportsJSONYAMLAndTOML/Empty":       "Supports JSON, YAML, and TOML empty",
+		"TestParsesNameAndValue":                  "Parses name and value",
+		"TestHandlesEmptyInputAndOutput":          "Handles empty input and output",
+		"TestReturnsErrorIfKeysAreMissingAndLogs": "Returns error if keys are missing and logs",
+		"TestCreates/reads_and_deletes":           "Creates reads and deletes",
+		"TestParsesJSONYAMLAnd":                   "Parses JSON YAML and",
+		"TestParsesHeadersBodiesAndTrailers":      "Parses headers, bodies, and trailers",
+		"TestReturnsErrorsWarningsAndNotes":       "Returns errors, warnings, and notes",
+		"TestHandleInput_ReadsWritesAndCloses":    "HandleInput reads writes and closes",
+	}
+	for input, want := range tcs {
+		got := gotestdox.Prettify(input, opts...)
+		if want != got {
+			t.Errorf("%q: %s", input, cmp.Diff(want, got))
+		}
+	}
+	want := "Supports JSON YAML and TOML"
+	got := gotestdox.Prettify("TestSupportsJSONYAMLAndTOML", gotestdox.WithInitialisms("JSON", "YAML", "TOML"))
+	if want != got {
+		t.Errorf("want no commas by default: %s", cmp.Diff(want, got))
+	}
+}
+
+func TestPrettify_WithMaxFunctionNameWordsLimitsMultiwordFunctionNames(t *testing.T) {
+	t.Parallel()
+	input := "TestParsesTheConfigurationFileAndValidatesIt_Properly"
+	tcs := []struct {
+		max  int
+		want string
+	}{
+		{
+			max:  2,
+			want: "Parses the configuration file and validates it properly",
+		},
+		{
+			max:  0,
+			want: "ParsesTheConfigurationFileAndValidatesIt properly",
+		},
+	}
+	for _, tc := range tcs {
+		got := gotestdox.Prettify(input, gotestdox.WithMaxFunctionNameWords(tc.max))
+		if tc.want != got {
+			t.Errorf("max %d: %s", tc.max, cmp.Diff(tc.want, got))
+		}
+	}
+}
+
+func TestPrettify_DoesNotSplitAdjacentInitialismsWithoutDictionary(t *testing.T) {
+	t.Parallel()
+	want := "JSONXML round trip"
+	got := gotestdox.Prettify("TestJSONXMLRoundTrip")
+	if want != got {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+// corpusOptions are the sets of options under which the names in
+// testdata/prettify/corpus.jsonl were prettified, before the lexer was
+// rebuilt on the lex package, giving the sentences recorded there.
+var corpusOptions = map[string][]gotestdox.Option{
+	"default":       nil,
+	"preservedCase": {gotestdox.WithPreservedCase()},
+	"initialisms":   {gotestdox.WithInitialisms(corpusInitialisms...)},
+	"shortWords":    {gotestdox.WithShortWordNormalisation()},
+	"conjunctions":  {gotestdox.WithConjunctions("plus"), gotestdox.WithInitialismNumberJoiner("-"), gotestdox.WithNumericSubtestLabel("case")},
+	"subject":       {gotestdox.WithSubjectSeparator(":"), gotestdox.WithSubtestSeparator(" > "), gotestdox.WithMaxFunctionNameWords(2)},
+}
+
+var corpusInitialisms = []string{"API", "HTTP", "ID", "JSON", "URL", "XML"}
+
+// corpusSegmenters give, for some of the sets in corpusOptions, the
+// segmenter that should behave exactly as the default does.
+var corpusSegmenters = map[string]lex.Segmenter{
+	"default": lex.SegmenterFunc(func(string) []string { return nil }),
+	"initialisms": lex.Dictionary(map[string]bool{
+		"API": true, "HTTP": true, "ID": true, "JSON": true, "URL": true, "XML": true,
+	}),
+}
+
+func TestPrettify_GivesSameSentencesForCorpusAsBeforeLexerRefactor(t *testing.T) {
+	t.Parallel()
+	data, err := os.ReadFile("testdata/prettify/corpus.jsonl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var entry struct {
+			Input string
+			Want  map[string]string
+		}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatal(err)
+		}
+		if asciiCasing && !isASCII(entry.Input) {
+			continue
+		}
+		for name, opts := range corpusOptions {
+			want, ok := entry.Want[name]
+			if !ok {
+				t.Fatalf("%q: no %s sentence in corpus", entry.Input, name)
+			}
+			got := gotestdox.Prettify(entry.Input, opts...)
+			if want != got {
+				t.Errorf("%q (%s): %s", entry.Input, name, cmp.Diff(want, got))
+			}
+			if s, ok := corpusSegmenters[name]; ok {
+				got := gotestdox.Prettify(entry.Input, append(opts, gotestdox.WithSegmenter(s))...)
+				if want != got {
+					t.Errorf("%q (%s, explicit segmenter): %s", entry.Input, name, cmp.Diff(want, got))
+				}
+			}
+		}
+	}
+}
+
+func TestPrettify_WithSegmenterSplitsAllCapsRunsInstead(t *testing.T) {
+	t.Parallel()
+	words := lex.Dictionary{"HANDLER": true, "WORKS": true, "JSON": true}
+	var decisions []gotestdox.CaseDecision
+	got := gotestdox.Prettify("TestParse/HANDLERWORKS",
+		gotestdox.WithInitialisms("HAND", "LERWORKS"),
+		gotestdox.WithSegmenter(words),
+		gotestdox.WithDecisionCallback(func(_ string, d gotestdox.CaseDecision) {
+			decisions = append(decisions, d)
+		}),
+	)
+	want := "Parse HANDLER WORKS"
+	if want != got {
+		t.Error(cmp.Diff(want, got))
+	}
+	wantDecisions := []gotestdox.CaseDecision{gotestdox.FirstWord, gotestdox.Segmented, gotestdox.Segmented}
+	if !cmp.Equal(wantDecisions, decisions) {
+		t.Error(cmp.Diff(wantDecisions, decisions))
+	}
+}
+
+func TestPrettify_WithSegmenterLeavesRunAloneIfSegmenterReturnsOneWord(t *testing.T) {
+	t.Parallel()
+	keep := lex.SegmenterFunc(func(span string) []string { return []string{span} })
+	want := "JSONXML round trip"
+	got := gotestdox.Prettify("TestJSONXMLRoundTrip", gotestdox.WithInitialisms("JSON", "XML"), gotestdox.WithSegmenter(keep))
+	if want != got {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestPrettify_WithDecisionCallbackReportsCaseDecisionForEachWord(t *testing.T) {
+	t.Parallel()
+	type decision struct {
+		Word     string
+		Decision gotestdox.CaseDecision
+	}
+	var got []decision
+	record := func(word string, d gotestdox.CaseDecision) {
+		got = append(got, decision{word, d})
+	}
+	gotestdox.Prettify("TestParsesJSON/a_file_from_HTTPURL",
+		gotestdox.WithInitialisms("HTTP", "URL"),
+		gotestdox.WithDecisionCallback(record),
+	)
+	want := []decision{
+		{"Parses", gotestdox.FirstWord},
+		{"JSON", gotestdox.Initialism},
+		{"a", gotestdox.ShortWord},
+		{"file", gotestdox.Lowercased},
+		{"from", gotestdox.Lowercased},
+		{"HTTP", gotestdox.DictionaryMatch},
+		{"URL", gotestdox.DictionaryMatch},
+	}
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+	got = nil
+	gotestdox.Prettify("TestChargesVAT", gotestdox.WithPreservedCase(), gotestdox.WithDecisionCallback(record))
+	want = []decision{
+		{"Charges", gotestdox.Preserved},
+		{"VAT", gotestdox.Preserved},
+	}
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+	got = nil
+	gotestdox.Prettify("TestIDIsUnique", gotestdox.WithDecisionCallback(record))
+	want = []decision{
+		{"ID", gotestdox.Initialism},
+		{"Is", gotestdox.Lowercased},
+		{"Unique", gotestdox.Lowercased},
+	}
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+	got = nil
+	gotestdox.Prettify("TestIdIsOk", gotestdox.WithShortWordNormalisation(), gotestdox.WithDecisionCallback(record))
+	want = []decision{
+		{"Id", gotestdox.Normalised},
+		{"Is", gotestdox.Lowercased},
+		{"Ok", gotestdox.Normalised},
+	}
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
 func BenchmarkPrettify(b *testing.B) {
 	input := "TestParseJSON_CorrectlyParsesASingleGoTestJSONOutputLine"
 	for i := 0; i < b.N; i++ {
@@ -154,6 +885,21 @@ var Cases = []struct {
 		input: "TestCallingTheFunction/Does_Stuff",
 		want:  "Calling the function does stuff",
 	},
+	{
+		name:  "treats underscores in a subtest name as plain separators after a multiword function name",
+		input: "TestStore_Put/empty_key_rejected",
+		want:  "Store put empty key rejected",
+	},
+	{
+		name:  "does not treat an underscore first seen in a subtest name as marking the end of a function name",
+		input: "TestStore/Put_RejectsEmptyKey",
+		want:  "Store put rejects empty key",
+	},
+	{
+		name:  "uses only the first underscore before the slash as the function name marker",
+		input: "TestOpenFile_ReturnsError/missing_file_path",
+		want:  "OpenFile returns error missing file path",
+	},
 	{
 		name:  "eliminates any words containing underscores after splitting",
 		input: "TestSentence/does_x,_correctly",
@@ -209,6 +955,31 @@ var Cases = []struct {
 		input: "TestUniformFactorial/n=3",
 		want:  "Uniform factorial n=3",
 	},
+	{
+		name:  "keeps a key=value expression as it is",
+		input: "TestConfig/sets_Mode=Strict_now",
+		want:  "Config sets Mode=Strict now",
+	},
+	{
+		name:  "keeps a key=value expression whose value contains dots",
+		input: "TestConfig/level=debug.v2",
+		want:  "Config level=debug.v2",
+	},
+	{
+		name:  "keeps a key=value expression with an empty value",
+		input: "TestConfig/flag=_is_empty",
+		want:  "Config flag= is empty",
+	},
+	{
+		name:  "keeps spaces in a quoted value of a key=value expression",
+		input: `TestConfig/name="Bob_Smith"_is_valid`,
+		want:  `Config name="Bob Smith" is valid`,
+	},
+	{
+		name:  "does not treat an '=' at a word boundary as a key=value expression",
+		input: "TestConfig/x_=_Y_Holds",
+		want:  "Config x = y holds",
+	},
 	{
 		name:  "preserves initialisms containing digits",
 		input: "TestS390XOperandParser",
@@ -224,4 +995,484 @@ var Cases = []struct {
 		input: "TestFooReturnsIDsAValue",
 		want:  "Foo returns IDs a value",
 	},
+	{
+		name:  "preserves common plural initialisms ending in I",
+		input: "TestListsAPIsAndURIs",
+		want:  "Lists APIs and URIs",
+	},
+	{
+		name:  "splits 'Is' from an initialism that is the first word",
+		input: "TestIDIsUnique",
+		want:  "ID is unique",
+	},
+	{
+		name:  "splits 'Is' from an initialism later in the sentence",
+		input: "TestChecksThatJSONIsValid",
+		want:  "Checks that JSON is valid",
+	},
+	{
+		name:  "lowercases 'Is' following a camel-case word",
+		input: "TestMatrixIsTransposed",
+		want:  "Matrix is transposed",
+	},
+	{
+		name:  "preserves an initialism that is the first word",
+		input: "TestAPIReturnsJSON",
+		want:  "API returns JSON",
+	},
+	{
+		name:  "title cases an ordinary first word",
+		input: "TestFooBar",
+		want:  "Foo bar",
+	},
+	{
+		name:  "keeps ordinal numbers together as a single word",
+		input: "TestReturns1stMatchOnly",
+		want:  "Returns 1st match only",
+	},
+	{
+		name:  "keeps multi-digit ordinal numbers together",
+		input: "TestSkipsTo22ndLine",
+		want:  "Skips to 22nd line",
+	},
+	{
+		name:  "renders ordinal suffixes in lowercase",
+		input: "TestPicks3RDItem",
+		want:  "Picks 3rd item",
+	},
+	{
+		name:  "lowercases an ordinal number that is the first word",
+		input: "Test2ndGen",
+		want:  "2nd gen",
+	},
+	{
+		name:  "does not treat a number followed by a word as an ordinal",
+		input: "TestReturns1Item",
+		want:  "Returns 1 item",
+	},
+	{
+		name:  "does not treat a number followed by a longer lowercase word as an ordinal",
+		input: "TestFoo/takes_4things",
+		want:  "Foo takes 4things",
+	},
+	{
+		name:  "keeps hex literals together in their original form",
+		input: "TestMasksWith0xFFValue",
+		want:  "Masks with 0xFF value",
+	},
+	{
+		name:  "keeps binary literals together",
+		input: "TestParses0b1010Input",
+		want:  "Parses 0b1010 input",
+	},
+	{
+		name:  "keeps octal literals with an uppercase prefix together",
+		input: "TestFoo/sets_mode_0O755",
+		want:  "Foo sets mode 0O755",
+	},
+	{
+		name:  "keeps a literal that is the first word in its original form",
+		input: "Test0xFFMasksHighBits",
+		want:  "0xFF masks high bits",
+	},
+	{
+		name:  "does not treat a literal prefix with no digits as a literal",
+		input: "TestFoo/0x_prefix",
+		want:  "Foo 0x prefix",
+	},
+	{
+		name:  "does not treat a literal prefix in the middle of a lowercase word as a literal",
+		input: "TestFoo/abc0x1f",
+		want:  "Foo abc 0x 1f",
+	},
+	{
+		name:  "handles a name starting with a number",
+		input: "Test1PlusOneEqualsTwo",
+		want:  "1 plus one equals two",
+	},
+	{
+		name:  "keeps a subtest name starting with a number together",
+		input: "TestMath/2+2_is_4",
+		want:  "Math 2+2 is 4",
+	},
+	{
+		name:  "keeps a number followed by capitals together as the first word",
+		input: "Test2FAEnrollment",
+		want:  "2FA enrollment",
+	},
+	{
+		name:  "keeps a number followed by capitals together in a subtest",
+		input: "TestConnects/over_5G_network",
+		want:  "Connects over 5G network",
+	},
+	{
+		name:  "keeps a number followed by capitals together before a camel-case word",
+		input: "TestRenders3DModels",
+		want:  "Renders 3D models",
+	},
+	{
+		name:  "keeps a number with a unit together",
+		input: "TestFoo/10ms_timeout",
+		want:  "Foo 10ms timeout",
+	},
+	{
+		name:  "does not capitalise the suffix of a number that is the first word",
+		input: "Test2xSpeedup",
+		want:  "2x speedup",
+	},
+	{
+		name:  "keeps dimensions such as '2x3' together",
+		input: "TestTransposes2x3Matrix",
+		want:  "Transposes 2x3 matrix",
+	},
+	{
+		name:  "keeps multi-digit dimensions together",
+		input: "TestScalesTo1920x1080",
+		want:  "Scales to 1920x1080",
+	},
+	{
+		name:  "keeps dimensions with more than two numbers together",
+		input: "TestFoo/solves_3x3x3_cube",
+		want:  "Foo solves 3x3x3 cube",
+	},
+	{
+		name:  "normalises an uppercase dimension separator to lowercase",
+		input: "TestDrives4X4",
+		want:  "Drives 4x4",
+	},
+	{
+		name:  "keeps dimensions that are the first word together",
+		input: "Test2x3MatrixTransposesCleanly",
+		want:  "2x3 matrix transposes cleanly",
+	},
+	{
+		name:  "keeps numbers written in Arabic-Indic digits together",
+		input: "TestParse/٣٤٥_items",
+		want:  "Parse ٣٤٥ items",
+	},
+	{
+		name:  "splits numbers in Arabic-Indic digits from camel-case words",
+		input: "TestItems٣٤٥Found",
+		want:  "Items ٣٤٥ found",
+	},
+	{
+		name:  "keeps numbers written in Devanagari digits together",
+		input: "TestParse/१२३_rows",
+		want:  "Parse १२३ rows",
+	},
+	{
+		name:  "keeps a fraction as a word of its own",
+		input: "TestPrice/½_price_discount",
+		want:  "Price ½ price discount",
+	},
+	{
+		name:  "splits a fraction from the camel-case word before it",
+		input: "TestPrice½Off",
+		want:  "Price ½ off",
+	},
+	{
+		name:  "keeps a fraction together with the digits before it",
+		input: "TestCount3½Cups",
+		want:  "Count 3½ cups",
+	},
+	{
+		name:  "keeps a fraction with Arabic-Indic digits before it",
+		input: "TestPrice٣½Off",
+		want:  "Price ٣½ off",
+	},
+	{
+		name:  "keeps dimensions including fractions together",
+		input: "TestMix/2½x3",
+		want:  "Mix 2½x3",
+	},
+	{
+		name:  "keeps a superscript 2 with the letter before it",
+		input: "TestSquare/x²_is_positive",
+		want:  "Square x² is positive",
+	},
+	{
+		name:  "keeps a superscript 2 with the camel-case word before it",
+		input: "TestLevel²Works",
+		want:  "Level² works",
+	},
+	{
+		name:  "keeps a superscript 2 with the digits before it",
+		input: "TestSquares/10²_is_100",
+		want:  "Squares 10² is 100",
+	},
+	{
+		name:  "keeps a subscript digit inside the word it belongs to",
+		input: "TestH₂OBoils",
+		want:  "H₂O boils",
+	},
+	{
+		name:  "does not treat words containing x as dimensions",
+		input: "TestExtraBox",
+		want:  "Extra box",
+	},
+	{
+		name:  "does not treat a multiplier as a dimension",
+		input: "TestRetries3xFaster",
+		want:  "Retries 3x faster",
+	},
+	{
+		name:  "keeps a single letter followed by digits together in lowercase",
+		input: "TestLatencyP99UnderLimit",
+		want:  "Latency p99 under limit",
+	},
+	{
+		name:  "keeps a lowercase letter followed by digits together",
+		input: "TestLatency/p50_is_reported",
+		want:  "Latency p50 is reported",
+	},
+	{
+		name:  "keeps a single letter followed by digits together at the end of the name",
+		input: "TestBuildsForX86",
+		want:  "Builds for x86",
+	},
+	{
+		name:  "still separates digits from a preceding full word",
+		input: "TestPart2Works",
+		want:  "Part 2 works",
+	},
+	{
+		name:  "keeps an initialism followed by a number together",
+		input: "TestFormatsPerRFC3339",
+		want:  "Formats per RFC3339",
+	},
+	{
+		name:  "keeps an initialism followed by a number together when followed by another word",
+		input: "TestParsesISO8601Dates",
+		want:  "Parses ISO8601 dates",
+	},
+	{
+		name:  "keeps an initialism followed by a number together when it is the first word",
+		input: "TestSHA256EncodesCorrectly",
+		want:  "SHA256 encodes correctly",
+	},
+	{
+		name:  "keeps an initialism followed by a number together in a subtest",
+		input: "TestCache/implements_RFC7231_caching",
+		want:  "Cache implements RFC7231 caching",
+	},
+	{
+		name:  "ignores a trailing underscore",
+		input: "TestFoo_",
+		want:  "Foo",
+	},
+	{
+		name:  "collapses consecutive underscores after a multiword function name",
+		input: "TestFoo__Bar",
+		want:  "Foo bar",
+	},
+	{
+		name:  "skips subtests with empty names",
+		input: "TestFoo/_/Bar",
+		want:  "Foo bar",
+	},
+	{
+		name:  "skips an empty subtest name at the end",
+		input: "TestFoo/_",
+		want:  "Foo",
+	},
+	{
+		name:  "collapses consecutive slashes",
+		input: "TestFoo//Bar",
+		want:  "Foo bar",
+	},
+	{
+		name:  "does not treat an underscore after an empty segment as a multiword function marker",
+		input: "Test_/_FooBar_Baz",
+		want:  "Foo bar baz",
+	},
+	{
+		name:  "produces an empty sentence for a name consisting only of separators",
+		input: "Test_/_/",
+		want:  "",
+	},
+	{
+		name:  "keeps constant-style names in their original form",
+		input: "TestDefaultsToMAX_RETRIES",
+		want:  "Defaults to MAX_RETRIES",
+	},
+	{
+		name:  "keeps constant-style names in subtests in their original form",
+		input: "TestProxy/uses_HTTP_PROXY_variable",
+		want:  "Proxy uses HTTP_PROXY variable",
+	},
+	{
+		name:  "ends a constant-style name at the start of a camel-case word",
+		input: "TestReadsMAX_RETRIESFromEnv",
+		want:  "Reads MAX_RETRIES from env",
+	},
+	{
+		name:  "keeps constant-style names with numeric segments together",
+		input: "TestSetsGO_111_MODULE",
+		want:  "Sets GO_111_MODULE",
+	},
+	{
+		name:  "does not treat a single all-caps word before an underscore as a constant",
+		input: "TestHTTP_GetsPage",
+		want:  "HTTP gets page",
+	},
+	{
+		name:  "does not treat an all-caps word followed by a lowercase subtest word as a constant",
+		input: "TestFoo/uses_HTTP_proxy",
+		want:  "Foo uses HTTP proxy",
+	},
+	{
+		name:  "does not treat an underscore after a constant-style name as marking the end of a multiword function name",
+		input: "TestMAX_RETRIES_is_respected_",
+		want:  "MAX_RETRIES is respected",
+	},
+	{
+		name:  "does not end a constant-style name where splitting off a camel-case word would leave a single capital",
+		input: "TestA_AAaa_Bb",
+		want:  "A a aaa bb",
+	},
+	{
+		name:  "keeps a word containing a parenthesised group in its original form",
+		input: "TestAdd/add(2,3)=5",
+		want:  "Add add(2,3)=5",
+	},
+	{
+		name:  "keeps a word containing a bracketed group in its original form",
+		input: "TestMatch/[a-z]+_matches_abc",
+		want:  "Match [a-z]+ matches abc",
+	},
+	{
+		name:  "does not change the case of letters inside a group",
+		input: "TestParser/Call(X)_works",
+		want:  "Parser Call(X) works",
+	},
+	{
+		name:  "keeps underscores inside a group",
+		input: "TestFoo/f(a_b)_works",
+		want:  "Foo f(a_b) works",
+	},
+	{
+		name:  "keeps a map type in a subtest name as it is",
+		input: "TestDecode/map[string]int_values",
+		want:  "Decode map[string]int values",
+	},
+	{
+		name:  "keeps a slice type in a subtest name as it is",
+		input: "TestDecode/[]byte",
+		want:  "Decode []byte",
+	},
+	{
+		name:  "keeps type arguments after a test name as they are",
+		input: "TestCache_EvictsOldest[string]",
+		want:  "Cache evicts oldest[string]",
+	},
+	{
+		name:  "does not change the case of words inside type arguments",
+		input: "TestSet[MyType]",
+		want:  "Set[MyType]",
+	},
+	{
+		name:  "does not treat an underscore inside type arguments as marking the end of a multiword function name",
+		input: "TestCacheEvicts[My_Type]",
+		want:  "Cache evicts[My_Type]",
+	},
+	{
+		name:  "keeps a type expression following a test name as it is",
+		input: "TestParse[]byte",
+		want:  "Parse[]byte",
+	},
+	{
+		name:  "splits camel-case words following type arguments as usual",
+		input: "TestSet[T]AddsItems",
+		want:  "Set[T] adds items",
+	},
+	{
+		name:  "treats an unclosed bracket at the end of a name as an ordinary character",
+		input: "TestParse[",
+		want:  "Parse[",
+	},
+	{
+		name:  "treats an unclosed bracket in a subtest name as an ordinary character",
+		input: "TestFoo/map[string",
+		want:  "Foo map[string",
+	},
+	{
+		name:  "treats an unclosed parenthesis as an ordinary character",
+		input: "TestFoo/f(a_works",
+		want:  "Foo f(a works",
+	},
+	{
+		name:  "treats an unopened parenthesis as an ordinary character",
+		input: "TestFoo/a)b_c",
+		want:  "Foo a)b c",
+	},
+	{
+		name:  "treats mismatched brackets as ordinary characters",
+		input: "TestFoo/f([)]_x",
+		want:  "Foo f([)] x",
+	},
+	{
+		name:  "keeps a qualified identifier in a subtest name in its original form",
+		input: "TestDispatch/calls_http.HandlerFunc_correctly",
+		want:  "Dispatch calls http.HandlerFunc correctly",
+	},
+	{
+		name:  "keeps a qualified identifier at the end of a subtest name in its original form",
+		input: "TestWrite/uses_strings.Builder",
+		want:  "Write uses strings.Builder",
+	},
+	{
+		name:  "keeps a qualified identifier with more than one dot in its original form",
+		input: "TestServe/calls_http.Handler.ServeHTTP",
+		want:  "Serve calls http.Handler.ServeHTTP",
+	},
+	{
+		name:  "keeps an all-lowercase qualified identifier in its original form",
+		input: "TestWrite/uses_bytes.buffer_here",
+		want:  "Write uses bytes.buffer here",
+	},
+	{
+		name:  "keeps a full stop after a qualified identifier with the identifier",
+		input: "TestWrap/uses_fmt.Errorf.",
+		want:  "Wrap uses fmt.Errorf.",
+	},
+	{
+		name:  "does not treat a full stop at the end of a subtest name as qualifying a name",
+		input: "TestFoo/ends_with_a_dot.",
+		want:  "Foo ends with a dot.",
+	},
+	{
+		name:  "keeps the full stops in an abbreviation",
+		input: "TestFoo/e.g._this",
+		want:  "Foo e.g. this",
+	},
+	{
+		name:  "does not treat a capitalised name followed by a lowercase one as a qualified identifier",
+		input: "TestFoo/Foo.bar_works",
+		want:  "Foo foo.bar works",
+	},
+	{
+		name:  "treats an underscore after too many words to be a function name as an ordinary separator",
+		input: "TestParsesTheConfigurationFileAndValidatesIt_Properly",
+		want:  "Parses the configuration file and validates it properly",
+	},
+	{
+		name:  "treats an underscore after five words as marking the end of a multiword function name",
+		input: "TestNewClientWithRetryPolicy_RetriesOnTimeout",
+		want:  "NewClientWithRetryPolicy retries on timeout",
+	},
+	{
+		name:  "replaces a newline in a subtest name with a space",
+		input: "TestParse/line1\nline2",
+		want:  "Parse line 1 line 2",
+	},
+	{
+		name:  "replaces tabs and carriage returns in a subtest name with single spaces",
+		input: "TestParse/reads\t\tcolumns\r\nand_rows",
+		want:  "Parse reads columns and rows",
+	},
+	{
+		name:  "drops other control characters from a subtest name",
+		input: "TestParse/nul\x00_and_bell\a_bytes",
+		want:  "Parse nul and bell bytes",
+	},
 }
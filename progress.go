@@ -0,0 +1,131 @@
+package gotestdox
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/mattn/go-isatty"
+)
+
+// progressInterval is the shortest time between updates of the progress
+// line shown by [WithProgress], except when a test fails, or a package
+// finishes, which is shown at once. If no results arrive, the line is still
+// updated at this interval, so that its elapsed time keeps going.
+const progressInterval = 100 * time.Millisecond
+
+// progressLine shows the progress of a run so far on a single line of a
+// terminal, rewritten in place as results arrive (see [WithProgress]).
+type progressLine struct {
+	w                       io.Writer
+	total                   int
+	packages, tests, failed int
+	start, drawn            time.Time
+	shown                   bool
+	clock                   Clock
+}
+
+// newProgressLine returns a progress line written to w, for a run of the
+// given number of packages, or of an unknown number, if total is zero,
+// timed by clock.
+func newProgressLine(w io.Writer, total int, clock Clock) *progressLine {
+	return &progressLine{
+		w:     w,
+		total: total,
+		start: clock.Now(),
+		clock: clock,
+	}
+}
+
+// result counts the completed test or package r, and redraws the line, at
+// once if r is a failed test, or otherwise if it hasn't been redrawn for a
+// while.
+func (p *progressLine) result(r Result) {
+	if r.Test == "" {
+		p.packages++
+		p.draw()
+		return
+	}
+	p.tests++
+	if r.Status == "fail" {
+		p.failed++
+		p.draw()
+		return
+	}
+	if p.clock.Now().Sub(p.drawn) >= progressInterval {
+		p.draw()
+	}
+}
+
+// resultHook returns the function to be called with each result delivered
+// to the sink: the function set by [WithOnResult], for the result of each
+// test, and then, if progress is not nil, [progressLine.result], for every
+// result.
+func (td *TestDoxer) resultHook(progress *progressLine) func(Result) {
+	onResult := td.onResult
+	return func(r Result) {
+		if onResult != nil && r.Test != "" {
+			onResult(r)
+		}
+		if progress != nil {
+			progress.result(r)
+		}
+	}
+}
+
+// draw rewrites the line, such as "pkg 7/23 · 312 tests · 2 failed · 41s",
+// with the failure count in red, if there are any failures.
+func (p *progressLine) draw() {
+	pkgs := fmt.Sprintf("pkg %d", p.packages)
+	if p.total > 0 {
+		pkgs += fmt.Sprintf("/%d", p.total)
+	}
+	failed := fmt.Sprintf("%d failed", p.failed)
+	if p.failed > 0 {
+		failed = color.RedString(failed)
+	}
+	tests := fmt.Sprintf("%d tests", p.tests)
+	if p.tests == 1 {
+		tests = "1 test"
+	}
+	now := p.clock.Now()
+	elapsed := now.Sub(p.start).Round(time.Second)
+	fmt.Fprintf(p.w, "\r\033[K%s · %s · %s · %s", pkgs, tests, failed, elapsed)
+	p.drawn = now
+	p.shown = true
+}
+
+// clear removes the line, if it's shown, so that something else can be
+// printed in its place.
+func (p *progressLine) clear() {
+	if p.shown {
+		fmt.Fprint(p.w, "\r\033[K")
+		p.shown = false
+	}
+}
+
+// isTerminal reports whether w is a terminal, even if it's wrapped by the
+// locking that [TestDoxer.ExecGoTest] adds to the output streams.
+func isTerminal(w io.Writer) bool {
+	if l, ok := w.(lockedWriter); ok {
+		w = l.w
+	}
+	f, ok := w.(*os.File)
+	return ok && (isatty.IsTerminal(f.Fd()) || isatty.IsCygwinTerminal(f.Fd()))
+}
+
+// countPackages returns the number of packages matched by the package
+// patterns in the 'go test' args userArgs, as listed by 'go list', or zero
+// if they can't be listed, or ctx is cancelled first.
+func countPackages(ctx context.Context, userArgs []string) int {
+	out, err := exec.CommandContext(ctx, "go", goListArgs(userArgs)...).Output()
+	if err != nil {
+		return 0
+	}
+	return len(strings.Fields(string(out)))
+}
@@ -0,0 +1,30 @@
+package gotestdox_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/bitfield/gotestdox"
+	"github.com/fatih/color"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFilter_WithProgressShowsNothingWhenStderrIsNotATerminal(t *testing.T) {
+	t.Parallel()
+	color.NoColor = true
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	td := gotestdox.NewTestDoxer(gotestdox.WithProgress())
+	td.Stdin = strings.NewReader(`{"Action":"pass","Package":"p","Test":"TestA"}
+{"Action":"fail","Package":"p","Test":"TestB"}
+{"Action":"fail","Package":"p"}`)
+	td.Stdout, td.Stderr = stdout, stderr
+	td.Filter()
+	if stderr.Len() > 0 {
+		t.Errorf("want nothing on stderr, got %q", stderr)
+	}
+	want := "p:\n ✔ A (0.00s)\n x B (0.00s)\n\n"
+	if got := stdout.String(); !strings.HasPrefix(got, want) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
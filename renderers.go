@@ -0,0 +1,100 @@
+package gotestdox
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+)
+
+// Renderer is a [ResultSink] that formats the results of a run for output,
+// such as a [TextSink]. Renderers can be registered by name, using
+// [RegisterRenderer], so that a program can offer a choice of output
+// formats, including ones provided by third parties.
+type Renderer = ResultSink
+
+// RendererOptions are the settings given to the factory for a [Renderer],
+// so that all renderers behave consistently. Writer is where the output
+// should go, and Options are the options in effect for the run, such as
+// [WithSentenceSuffix], which renderers should respect where they apply.
+// Colour is controlled globally, by [github.com/fatih/color.NoColor].
+type RendererOptions struct {
+	Writer  io.Writer
+	Options []Option
+}
+
+// RendererFactory creates a [Renderer] with the given options.
+type RendererFactory func(opts RendererOptions) Renderer
+
+var (
+	renderersMu sync.RWMutex
+	renderers   = map[string]RendererFactory{
+		"plain": func(opts RendererOptions) Renderer {
+			return NewTextSink(opts.Writer, opts.Options...)
+		},
+		"csv": func(opts RendererOptions) Renderer {
+			return NewCSVSink(opts.Writer, opts.Options...)
+		},
+		"metrics": func(opts RendererOptions) Renderer {
+			return NewMetricsSink(opts.Writer, opts.Options...)
+		},
+		"tree": func(opts RendererOptions) Renderer {
+			return NewTreeSink(opts.Writer, opts.Options...)
+		},
+		"markdown": func(opts RendererOptions) Renderer {
+			return NewMarkdownSink(opts.Writer, opts.Options...)
+		},
+		"json": func(opts RendererOptions) Renderer {
+			return NewJSONSink(opts.Writer, opts.Options...)
+		},
+		"junit": func(opts RendererOptions) Renderer {
+			return NewJUnitSink(opts.Writer, opts.Options...)
+		},
+	}
+)
+
+// RegisterRenderer makes the renderer created by factory available under
+// the given name, for [LookupRenderer]. It's intended to be called from an
+// init function. The built-in renderers are registered as "plain" (a
+// [TextSink]), "csv" (a [CSVSink]), "metrics" (a [MetricsSink]), "tree" (a
+// [TreeSink]), "markdown" (a [MarkdownSink]), "json" (a [JSONSink]), and
+// "junit" (a [JUnitSink]).
+//
+// RegisterRenderer panics if the name is empty, or already registered, or
+// factory is nil.
+func RegisterRenderer(name string, factory RendererFactory) {
+	if name == "" {
+		panic("gotestdox: RegisterRenderer called with empty name")
+	}
+	if factory == nil {
+		panic("gotestdox: RegisterRenderer called with nil factory for " + name)
+	}
+	renderersMu.Lock()
+	defer renderersMu.Unlock()
+	if _, dup := renderers[name]; dup {
+		panic(fmt.Sprintf("gotestdox: RegisterRenderer called twice for renderer %q", name))
+	}
+	renderers[name] = factory
+}
+
+// LookupRenderer returns the factory for the renderer registered under the
+// given name, and reports whether there is one.
+func LookupRenderer(name string) (RendererFactory, bool) {
+	renderersMu.RLock()
+	defer renderersMu.RUnlock()
+	factory, ok := renderers[name]
+	return factory, ok
+}
+
+// Renderers returns the names of all the registered renderers, sorted
+// alphabetically.
+func Renderers() []string {
+	renderersMu.RLock()
+	defer renderersMu.RUnlock()
+	names := make([]string, 0, len(renderers))
+	for name := range renderers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
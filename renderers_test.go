@@ -0,0 +1,136 @@
+package gotestdox_test
+
+import (
+	"encoding/csv"
+	"strings"
+	"testing"
+
+	"github.com/bitfield/gotestdox"
+	"github.com/fatih/color"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLookupRenderer_FindsBuiltInRenderers(t *testing.T) {
+	t.Parallel()
+	for _, name := range []string{"plain", "csv", "metrics", "tree", "markdown", "json", "junit"} {
+		if _, ok := gotestdox.LookupRenderer(name); !ok {
+			t.Errorf("want renderer %q registered", name)
+		}
+	}
+	if _, ok := gotestdox.LookupRenderer("bogus"); ok {
+		t.Error("want no renderer \"bogus\"")
+	}
+}
+
+func TestLookupRenderer_ReturnsFactoryThatAppliesOptions(t *testing.T) {
+	t.Parallel()
+	color.NoColor = true
+	factory, ok := gotestdox.LookupRenderer("plain")
+	if !ok {
+		t.Fatal("no plain renderer")
+	}
+	buf := new(strings.Builder)
+	sink := factory(gotestdox.RendererOptions{
+		Writer:  buf,
+		Options: []gotestdox.Option{gotestdox.WithSentenceSuffix(".")},
+	})
+	td := gotestdox.NewTestDoxer(gotestdox.WithSink(sink))
+	td.Stdin = strings.NewReader(sinkInput)
+	td.Filter()
+	want := `p:
+ ✔ A works fine. (0.00s)
+ x B. (0.50s)
+
+q:
+ ✔ D. (0.00s)
+
+`
+	if want != buf.String() {
+		t.Error(cmp.Diff(want, buf.String()))
+	}
+}
+
+func TestRegisterRenderer_PanicsOnDuplicateName(t *testing.T) {
+	t.Parallel()
+	defer func() {
+		if recover() == nil {
+			t.Error("want panic registering \"plain\" twice")
+		}
+	}()
+	gotestdox.RegisterRenderer("plain", func(opts gotestdox.RendererOptions) gotestdox.Renderer {
+		return gotestdox.NewTextSink(opts.Writer)
+	})
+}
+
+func TestRenderers_ListsRegisteredNamesInOrder(t *testing.T) {
+	t.Parallel()
+	want := []string{"count", "csv", "json", "junit", "markdown", "metrics", "plain", "tee-test-colour", "tee-test-echo", "tee-test-failing", "tree"}
+	got := gotestdox.Renderers()
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+// controlCharsInput has a subtest whose name contains a newline and a tab,
+// as Go's testing package would never produce, but other tools might.
+var controlCharsInput = `{"Action":"pass","Package":"p","Test":"TestParse/line1\nline2\tend"}
+{"Action":"pass","Package":"p","Test":"TestParse"}
+{"Action":"pass","Package":"p","Elapsed":0.1}`
+
+func TestBuiltInRenderers_KeepControlCharactersInTestNamesFromBreakingLines(t *testing.T) {
+	t.Parallel()
+	color.NoColor = true
+	for _, name := range []string{"plain", "metrics", "tree", "markdown"} {
+		factory, _ := gotestdox.LookupRenderer(name)
+		buf := new(strings.Builder)
+		sink := factory(gotestdox.RendererOptions{
+			Writer:  buf,
+			Options: []gotestdox.Option{gotestdox.WithTestNames()},
+		})
+		td := gotestdox.NewTestDoxer(gotestdox.WithSink(sink))
+		td.Stdin = strings.NewReader(controlCharsInput)
+		td.Filter()
+		for _, line := range strings.Split(buf.String(), "\n") {
+			if strings.HasPrefix(line, "line2") {
+				t.Errorf("%s: test name broken across lines:\n%s", name, buf)
+				break
+			}
+		}
+	}
+}
+
+func TestPlainRenderer_PrintsSanitisedSentenceAndTestName(t *testing.T) {
+	t.Parallel()
+	color.NoColor = true
+	buf := new(strings.Builder)
+	td := gotestdox.NewTestDoxer(gotestdox.WithTestNames())
+	td.Stdin = strings.NewReader(controlCharsInput)
+	td.Stdout = buf
+	td.Filter()
+	want := "p:\n ✔ Parse line 1 line 2 end  [TestParse/line1 line2 end] (0.00s)\n\n"
+	if want != buf.String() {
+		t.Error(cmp.Diff(want, buf.String()))
+	}
+}
+
+func TestCSVRenderer_KeepsRawTestNameInQuotedFieldAndSanitisedSentence(t *testing.T) {
+	t.Parallel()
+	buf := new(strings.Builder)
+	td := gotestdox.NewTestDoxer(gotestdox.WithSink(gotestdox.NewCSVSink(buf)))
+	td.Stdin = strings.NewReader(controlCharsInput)
+	td.Filter()
+	records, err := csv.NewReader(strings.NewReader(buf.String())).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("want header and 2 rows, got %q", records)
+	}
+	row := records[2]
+	if row[1] != "TestParse/line1\nline2\tend" {
+		t.Errorf("want raw test name, got %q", row[1])
+	}
+	if row[2] != "Parse line 1 line 2 end" {
+		t.Errorf("want sanitised sentence, got %q", row[2])
+	}
+}
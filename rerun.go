@@ -0,0 +1,120 @@
+package gotestdox
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// valueFlags lists the 'go test' and 'go build' flags that take a value,
+// which may be given as a separate argument.
+var valueFlags = map[string]bool{
+	"C": true, "bench": true, "benchtime": true, "blockprofile": true, "count": true,
+	"coverpkg": true, "covermode": true, "coverprofile": true, "cpu": true,
+	"cpuprofile": true, "exec": true, "fuzz": true, "fuzztime": true,
+	"gcflags": true, "ldflags": true, "list": true, "memprofile": true,
+	"mod": true, "modfile": true, "o": true, "outputdir": true, "p": true,
+	"parallel": true, "run": true, "shuffle": true, "skip": true,
+	"tags": true, "timeout": true, "trace": true, "vet": true,
+}
+
+// splitGoTestArgs separates the arguments args to 'go test' into flags, with
+// their values, and package patterns.
+func splitGoTestArgs(args []string) (flags, patterns []string) {
+	flags, patterns = []string{}, []string{}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if !strings.HasPrefix(arg, "-") {
+			patterns = append(patterns, arg)
+			continue
+		}
+		flags = append(flags, arg)
+		name, _, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if !hasValue && valueFlags[name] && i+1 < len(args) {
+			i++
+			flags = append(flags, args[i])
+		}
+	}
+	return flags, patterns
+}
+
+// goTestFlags returns the flags among the arguments args to 'go test', with
+// their values, leaving out package patterns and any -run or -skip flags,
+// which would conflict with a rerun command.
+func goTestFlags(args []string) []string {
+	all, _ := splitGoTestArgs(args)
+	flags := []string{}
+	for i := 0; i < len(all); i++ {
+		name, _, hasValue := strings.Cut(strings.TrimLeft(all[i], "-"), "=")
+		drop := name == "run" || name == "skip" || name == "json"
+		if !hasValue && valueFlags[name] && i+1 < len(all) {
+			if !drop {
+				flags = append(flags, all[i], all[i+1])
+			}
+			i++
+			continue
+		}
+		if !drop {
+			flags = append(flags, all[i])
+		}
+	}
+	return flags
+}
+
+// runPattern returns a -run pattern matching exactly the test with the given
+// name, and no others. Each level of the name is anchored separately, so
+// that a subtest is matched only under its own parent, and any regular
+// expression metacharacters in the name are quoted.
+func runPattern(test string) string {
+	parent, parts := SplitSubtests(test)
+	levels := []string{"^" + regexp.QuoteMeta(parent) + "$"}
+	for _, p := range parts {
+		levels = append(levels, "^"+regexp.QuoteMeta(p)+"$")
+	}
+	return strings.Join(levels, "/")
+}
+
+// rerunCommand returns a shell command line that runs only the given test in
+// package pkg, with any extra flags.
+func rerunCommand(pkg, test string, flags []string) string {
+	args := []string{"go", "test"}
+	for _, f := range flags {
+		args = append(args, shellQuote(f))
+	}
+	args = append(args, "-run", shellQuote(runPattern(test)), shellQuote(pkg))
+	return strings.Join(args, " ")
+}
+
+// shellQuote quotes s for a POSIX shell, if it contains anything other than
+// characters that are always safe.
+func shellQuote(s string) string {
+	safe := s != "" && strings.IndexFunc(s, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./=:,@", r))
+	}) < 0
+	if safe {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// failedLeaves returns the names of the tests among results that failed,
+// other than those with failing subtests of their own, sorted by name.
+func failedLeaves(results []Result) []string {
+	failed := map[string]bool{}
+	for _, r := range results {
+		if r.Status == "fail" {
+			failed[r.Test] = true
+		}
+	}
+	for name := range failed {
+		for i := strings.LastIndex(name, "/"); i > 0; i = strings.LastIndex(name[:i], "/") {
+			delete(failed, name[:i])
+		}
+	}
+	leaves := make([]string, 0, len(failed))
+	for name := range failed {
+		leaves = append(leaves, name)
+	}
+	sort.Strings(leaves)
+	return leaves
+}
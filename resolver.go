@@ -0,0 +1,136 @@
+package gotestdox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os/exec"
+	"path/filepath"
+)
+
+// packageDirs gives the source directory of a package, and the root
+// directory of the module it belongs to, either of which may be empty if
+// not known.
+type packageDirs struct {
+	dir, module string
+}
+
+// packageResolver finds the source directories of packages, given their
+// import paths. Rather than running 'go list' for each package, it lists
+// all the packages in a module at once, the first time it's asked about
+// one of them, and caches the results. Directories are reported with any
+// symlinks resolved, so that they can be compared reliably with paths
+// printed by the tests.
+//
+// Only packages in the main module are resolved: standard library packages,
+// dependencies, and vendored packages have no source that gotestdox should
+// show, so they resolve to empty directories.
+type packageResolver struct {
+	dirs   map[string]packageDirs
+	listed map[string]bool
+	// known is set if the directories were supplied using
+	// [WithPackageDirs], in which case 'go list' is never run.
+	known bool
+	// ctx stops 'go list' if it's cancelled, and tags gives the -tags flag
+	// of the run, if any, so that packages built only with those tags are
+	// listed.
+	ctx  context.Context
+	tags []string
+}
+
+// newPackageResolver returns a resolver that uses the given directories,
+// keyed by import path, if there are any, or 'go list' otherwise, run with
+// ctx and the -tags flag, if any, among goTestArgs, the args given to 'go
+// test'. Other build flags, such as -modfile, are left out, since they may
+// not apply to the modules of other packages.
+func newPackageResolver(ctx context.Context, known map[string]string, goTestArgs []string) *packageResolver {
+	flags, _ := splitGoTestArgs(goTestArgs)
+	r := &packageResolver{
+		dirs:   map[string]packageDirs{},
+		listed: map[string]bool{},
+		known:  len(known) > 0,
+		ctx:    ctx,
+		tags:   selectFlags(flags, map[string]bool{"tags": true}),
+	}
+	for pkg, dir := range known {
+		dir = evalSymlinks(dir)
+		r.dirs[pkg] = packageDirs{dir: dir, module: moduleRoot(dir)}
+	}
+	return r
+}
+
+// resolve returns the directories of the package pkg. The module dir, if
+// not empty, is the directory of the module the package was tested in (see
+// [TestDoxer.ExecGoTestModules]); otherwise, it's the module containing the
+// current directory.
+func (r *packageResolver) resolve(pkg, module string) packageDirs {
+	if dirs, ok := r.dirs[pkg]; ok || r.known {
+		return dirs
+	}
+	if module == "" {
+		module = "."
+	}
+	root := moduleRoot(evalSymlinks(module))
+	if root != "" && !r.listed[root] {
+		r.listed[root] = true
+		r.list(root)
+	}
+	return r.dirs[pkg]
+}
+
+// listedPackage is the subset of the 'go list -json' output for a package
+// used by [packageResolver].
+type listedPackage struct {
+	ImportPath string
+	Dir        string
+	Standard   bool
+	Module     *struct {
+		Dir string
+	}
+}
+
+// list adds the packages of the module in dir to r. Any error is ignored,
+// leaving the packages unresolved.
+func (r *packageResolver) list(dir string) {
+	args := append(append([]string{"list", "-e", "-json"}, r.tags...), "./...")
+	cmd := exec.CommandContext(r.ctx, "go", args...)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return
+	}
+	r.add(out)
+}
+
+// add adds the packages described by the 'go list -json' output data to r.
+func (r *packageResolver) add(data []byte) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	for {
+		var p listedPackage
+		if err := dec.Decode(&p); err != nil {
+			return
+		}
+		if p.Standard || p.Dir == "" {
+			continue
+		}
+		dirs := packageDirs{dir: evalSymlinks(p.Dir)}
+		if p.Module != nil && p.Module.Dir != "" {
+			dirs.module = evalSymlinks(p.Module.Dir)
+		}
+		r.dirs[p.ImportPath] = dirs
+	}
+}
+
+// evalSymlinks returns the absolute path of dir with any symlinks resolved,
+// or dir as it is, if that isn't possible.
+func evalSymlinks(dir string) string {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return dir
+	}
+	resolved, err := filepath.EvalSymlinks(abs)
+	if err != nil {
+		return abs
+	}
+	return resolved
+}
@@ -0,0 +1,122 @@
+package gotestdox_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bitfield/gotestdox"
+	"github.com/fatih/color"
+	"github.com/google/go-cmp/cmp"
+)
+
+// failureIn returns 'go test -json' events for a failing test in pkg, tested
+// in the module directory module, if any, whose output gives the location
+// file:line.
+func failureIn(pkg, module, test, file string, line int) string {
+	out, _ := json.Marshal(fmt.Sprintf("    %s:%d: got 3, want 4\n", file, line))
+	return fmt.Sprintf(`{"Action":"output","Package":%[1]q,"Module":%[2]q,"Test":%[3]q,"Output":%[4]s}
+{"Action":"fail","Package":%[1]q,"Module":%[2]q,"Test":%[3]q}
+{"Action":"fail","Package":%[1]q,"Module":%[2]q}
+`, pkg, module, test, out)
+}
+
+const failingTestSource = "package p\n\nimport \"testing\"\n\nfunc TestSum(t *testing.T) {\n\tt.Error(\"got 3, want 4\")\n}\n"
+
+func TestTextSink_WithSourceSnippetsResolvesPackagesInSymlinkedModule(t *testing.T) {
+	t.Parallel()
+	color.NoColor = true
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"real/main/go.mod":      "module example.com/main\n\ngo 1.18\n\nrequire example.com/dep v0.0.0\n\nreplace example.com/dep => ../dep\n",
+		"real/main/p/p_test.go": failingTestSource,
+		"real/dep/go.mod":       "module example.com/dep\n\ngo 1.18\n",
+		"real/dep/dep_test.go":  failingTestSource,
+	})
+	link := filepath.Join(dir, "link")
+	if err := os.Symlink(filepath.Join(dir, "real"), link); err != nil {
+		t.Skip("symlinks not supported:", err)
+	}
+	module := filepath.Join(link, "main")
+	input := failureIn("example.com/main/p", module, "TestSum", "p_test.go", 6) +
+		failureIn("example.com/dep", module, "TestSum", "dep_test.go", 6)
+	want := `[` + module + `] example.com/main/p:
+ x Sum (0.00s)
+    p_test.go:
+      4 |
+      5 | func TestSum(t *testing.T) {
+    > 6 |   t.Error("got 3, want 4")
+      7 | }
+
+[` + module + `] example.com/dep:
+ x Sum (0.00s)
+
+`
+	buf := new(strings.Builder)
+	td := gotestdox.NewTestDoxer(gotestdox.WithSourceSnippets())
+	td.Stdin = strings.NewReader(input)
+	td.Stdout = buf
+	td.Filter()
+	if want != buf.String() {
+		t.Error(cmp.Diff(want, buf.String()))
+	}
+}
+
+func TestTextSink_WithPackageDirsResolvesOnlyGivenPackages(t *testing.T) {
+	t.Parallel()
+	color.NoColor = true
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"go.mod":      "module example.com/p\n\ngo 1.18\n",
+		"p_test.go":   failingTestSource,
+		"q/q_test.go": failingTestSource,
+	})
+	input := failureIn("example.com/p", "", "TestSum", "p_test.go", 6) +
+		failureIn("example.com/p/q", "", "TestSum", "q_test.go", 6)
+	want := `example.com/p:
+ x Sum (0.00s)
+    p_test.go:
+      4 |
+      5 | func TestSum(t *testing.T) {
+    > 6 |   t.Error("got 3, want 4")
+      7 | }
+
+example.com/p/q:
+ x Sum (0.00s)
+
+`
+	buf := new(strings.Builder)
+	td := gotestdox.NewTestDoxer(
+		gotestdox.WithSourceSnippets(),
+		gotestdox.WithPackageDirs(map[string]string{"example.com/p": dir}),
+	)
+	td.Stdin = strings.NewReader(input)
+	td.Stdout = buf
+	td.Filter()
+	if want != buf.String() {
+		t.Error(cmp.Diff(want, buf.String()))
+	}
+}
+
+func TestTextSink_WithSourceSnippetsResolvesPackagesBuiltOnlyWithGivenTags(t *testing.T) {
+	t.Parallel()
+	color.NoColor = true
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"go.mod":      "module example.com/m\n\ngo 1.18\n",
+		"p/p_test.go": "//go:build integration\n\n" + failingTestSource,
+	})
+	buf := new(strings.Builder)
+	td := gotestdox.NewTestDoxer(gotestdox.WithSourceSnippets())
+	td.Stdout = buf
+	stderr := new(strings.Builder)
+	td.Stderr = stderr
+	td.ExecGoTestModules([]string{dir}, []string{"-tags", "integration", "-count=1", "./..."})
+	want := `    > 8 |   t.Error("got 3, want 4")`
+	if !strings.Contains(buf.String(), want) {
+		t.Errorf("want snippet of tagged package, got:\n%s\nstderr:\n%s", buf, stderr)
+	}
+}
@@ -0,0 +1,167 @@
+package gotestdox
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/fatih/color"
+)
+
+// SchemaVersion is the version of the JSON representation of [Result] and
+// [Summary]. It is incremented whenever a change is made to that
+// representation that would prevent an older reader from interpreting it
+// correctly, so that artifacts written by one version of gotestdox can be
+// read by the next. Adding new fields does not change the version.
+const SchemaVersion = 1
+
+// Result represents the outcome of a single completed test, or, if Test is
+// empty, of a whole package. Status is the action that completed the test:
+// "pass", "fail", or "skip". Test is the name exactly as reported, even if
+// it contains control characters, such as newlines, which are never
+// included in the Sentence.
+//
+// Module is the directory of the module containing the package, for results
+// from [TestDoxer.ExecGoTestModules], or empty otherwise. Similarly,
+// Configuration is the name of the [Configuration] the test was run under,
+// for results from [TestDoxer.ExecGoTestConfigurations].
+//
+// Active is the time in seconds the test spent running, excluding any time
+// it was paused, if [WithActiveDurations] was supplied.
+//
+// Output contains the output produced by the test, as reported by 'go test
+// -json', one entry per output event. Entries usually, but not always,
+// consist of a single line ending with a newline. Flaky is true if the test
+// is known to have failed before eventually passing. Cached is true for the
+// result of a package whose results were replayed from the 'go test' cache,
+// rather than run again. TeardownFailed is true for the result of a package
+// that failed although none of its tests did, as when TestMain, or a
+// goroutine leak check run after the tests, fails the package.
+//
+// File and Line give the location in the source of the test function, or,
+// for a subtest, of the t.Run call that started it, if the name is a string
+// literal, or of its parent test, otherwise. They are set only if
+// [WithTestLocations] was supplied, and the source can be found. File is relative to the root of the module, if the test is in
+// one.
+//
+// Vet gives the diagnostics reported by vet, one per line, for the result of
+// a package that failed vet, which 'go test' runs before the tests.
+//
+// Doc is the first sentence of the doc comment of the package, for the
+// result of a package, if [WithPackageDocs] was supplied.
+//
+// TimedOut is true for the result of a package that was killed for taking
+// too long (see [WithPackageTimeout]), and for each of its tests that was
+// still running at the time, which is reported as failed.
+//
+// Excluded is true for the result of a package that wasn't tested because
+// build constraints exclude all its Go files. Its Status is "skip", even
+// though 'go test' reports it as failed.
+//
+// OutputBytes is the number of bytes of output written by the test, or, for
+// the result of a package, by the package and all its tests, if
+// [WithNoisiestTests] or [WithLargeOutputMarker] was supplied. It counts all
+// the output, even if some was dropped from Output (see [WithOutputBudget]).
+type Result struct {
+	Module         string   `json:"module,omitempty"`
+	Configuration  string   `json:"configuration,omitempty"`
+	Package        string   `json:"package"`
+	Test           string   `json:"test,omitempty"`
+	Sentence       string   `json:"sentence,omitempty"`
+	Status         string   `json:"status"`
+	Elapsed        float64  `json:"elapsed"`
+	Active         float64  `json:"active,omitempty"`
+	Output         []string `json:"output,omitempty"`
+	Flaky          bool     `json:"flaky,omitempty"`
+	Cached         bool     `json:"cached,omitempty"`
+	TeardownFailed bool     `json:"teardownFailed,omitempty"`
+	File           string   `json:"file,omitempty"`
+	Line           int      `json:"line,omitempty"`
+	Doc            string   `json:"doc,omitempty"`
+	Vet            []string `json:"vet,omitempty"`
+	TimedOut       bool     `json:"timedOut,omitempty"`
+	Excluded       bool     `json:"excluded,omitempty"`
+	OutputBytes    int      `json:"outputBytes,omitempty"`
+}
+
+// Result returns the [Result] represented by the test event e.
+func (e Event) Result() Result {
+	return Result{
+		Module:        e.Module,
+		Configuration: e.Configuration,
+		Package:       e.Package,
+		Test:          e.Test,
+		Sentence:      e.Sentence,
+		Status:        e.Action,
+		Elapsed:       e.Elapsed,
+	}
+}
+
+// String formats a Result for display, in the same way as [Event.String].
+func (r Result) String() string {
+	return r.line("")
+}
+
+// line formats r for display, adding note, if not empty, after the elapsed
+// time. A result with no status, as listed by [TestDoxer.FilterList], is
+// shown as its sentence alone.
+func (r Result) line(note string) string {
+	if r.Status == "" {
+		return " " + r.Sentence
+	}
+	status := colouredStatusSymbol(r.Status)
+	if note != "" {
+		return fmt.Sprintf(" %s %s (%.2fs, %s)", status, r.Sentence, r.Elapsed, note)
+	}
+	return fmt.Sprintf(" %s %s (%.2fs)", status, r.Sentence, r.Elapsed)
+}
+
+// UnmarshalResults reads results from r in NDJSON form: that is, one JSON
+// object representing a [Result] per line. Blank lines are ignored. If any
+// line can't be parsed, UnmarshalResults returns an error giving its line
+// number.
+func UnmarshalResults(r io.Reader) ([]Result, error) {
+	results := []Result{}
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var res Result
+		if err := json.Unmarshal(scanner.Bytes(), &res); err != nil {
+			return nil, fmt.Errorf("line %d: parsing JSON: %w\ninput: %s", line, err, scanner.Text())
+		}
+		results = append(results, res)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// statusSymbol returns the symbol marking a test with the given status: a
+// tick if it passed, a circle if it was skipped, or a cross if it failed.
+func statusSymbol(status string) string {
+	switch status {
+	case "pass":
+		return "✔"
+	case "skip":
+		return "○"
+	}
+	return "x"
+}
+
+// colouredStatusSymbol returns the [statusSymbol] for status, in green for
+// a pass, yellow for a skip, or red for a failure.
+func colouredStatusSymbol(status string) string {
+	switch status {
+	case "pass":
+		return color.GreenString(statusSymbol(status))
+	case "skip":
+		return color.YellowString(statusSymbol(status))
+	}
+	return color.RedString(statusSymbol(status))
+}
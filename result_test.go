@@ -0,0 +1,154 @@
+package gotestdox_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/bitfield/gotestdox"
+	"github.com/fatih/color"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestResult_MarshalsToStableJSON(t *testing.T) {
+	t.Parallel()
+	r := gotestdox.Result{
+		Package:  "p",
+		Test:     "TestA",
+		Sentence: "A",
+		Status:   "fail",
+		Elapsed:  0.5,
+		Output:   []string{"--- FAIL: TestA (0.50s)\n"},
+		Flaky:    true,
+	}
+	data, err := json.Marshal(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"package":"p","test":"TestA","sentence":"A","status":"fail","elapsed":0.5,"output":["--- FAIL: TestA (0.50s)\n"],"flaky":true}`
+	if want != string(data) {
+		t.Error(cmp.Diff(want, string(data)))
+	}
+}
+
+func TestResult_OmitsEmptyOptionalFieldsFromJSON(t *testing.T) {
+	t.Parallel()
+	data, err := json.Marshal(gotestdox.Result{Package: "p", Status: "pass"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"package":"p","status":"pass","elapsed":0}`
+	if want != string(data) {
+		t.Error(cmp.Diff(want, string(data)))
+	}
+}
+
+func TestResult_StringMarksSkippedTestsWithCircle(t *testing.T) {
+	t.Parallel()
+	color.NoColor = true
+	got := gotestdox.Result{
+		Status:   "skip",
+		Sentence: "Foo does x",
+	}.String()
+	want := " ○ Foo does x (0.00s)"
+	if want != got {
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
+
+func TestSummary_MarshalsToStableJSONIncludingSchemaVersion(t *testing.T) {
+	t.Parallel()
+	s := gotestdox.Summary{
+		Packages: 2,
+		Passed:   3,
+		Failed:   1,
+		Skipped:  1,
+		VagueNames: []gotestdox.VagueName{
+			{Package: "p", Test: "TestParse", File: "parse_test.go", Line: 12},
+		},
+	}
+	data, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"schemaVersion":1,"packages":2,"passed":3,"failed":1,"skipped":1,"vagueNames":[{"package":"p","test":"TestParse","file":"parse_test.go","line":12}]}`
+	if want != string(data) {
+		t.Error(cmp.Diff(want, string(data)))
+	}
+	var got gotestdox.Summary
+	err = json.Unmarshal(data, &got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cmp.Equal(s, got) {
+		t.Error(cmp.Diff(s, got))
+	}
+}
+
+func TestUnmarshalResults_ReadsResultsInNDJSONForm(t *testing.T) {
+	t.Parallel()
+	input := `{"package":"p","test":"TestA","sentence":"A","status":"pass","elapsed":0.1}
+
+{"package":"p","status":"pass","elapsed":0.2,"futureField":true}
+`
+	got, err := gotestdox.UnmarshalResults(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []gotestdox.Result{
+		{Package: "p", Test: "TestA", Sentence: "A", Status: "pass", Elapsed: 0.1},
+		{Package: "p", Status: "pass", Elapsed: 0.2},
+	}
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestUnmarshalResults_ReturnsErrorGivingLineNumberOfInvalidJSON(t *testing.T) {
+	t.Parallel()
+	input := `{"package":"p","status":"pass"}
+bogus`
+	_, err := gotestdox.UnmarshalResults(strings.NewReader(input))
+	if err == nil {
+		t.Fatal("want error")
+	}
+	if !strings.HasPrefix(err.Error(), "line 2:") {
+		t.Errorf("want error giving line 2, got %q", err)
+	}
+}
+
+func TestFilter_IncludesEachTestsOutputInItsResult(t *testing.T) {
+	t.Parallel()
+	input := `{"Action":"run","Package":"p","Test":"TestA"}
+{"Action":"output","Package":"p","Test":"TestA","Output":"=== RUN   TestA\n"}
+{"Action":"output","Package":"p","Test":"TestA","Output":"    a_test.go:9: oops\n"}
+{"Action":"output","Package":"p","Test":"TestA","Output":"--- FAIL: TestA (0.00s)\n"}
+{"Action":"fail","Package":"p","Test":"TestA"}
+{"Action":"output","Package":"p","Output":"FAIL\n"}
+{"Action":"fail","Package":"p"}`
+	sink := &recordingSink{}
+	td := gotestdox.NewTestDoxer(gotestdox.WithSink(sink))
+	td.Stdin = strings.NewReader(input)
+	td.Filter()
+	want := []gotestdox.Result{
+		{
+			Package:  "p",
+			Test:     "TestA",
+			Sentence: "A",
+			Status:   "fail",
+			Output: []string{
+				"=== RUN   TestA\n",
+				"    a_test.go:9: oops\n",
+				"--- FAIL: TestA (0.00s)\n",
+			},
+		},
+		{
+			Package: "p",
+			Status:  "fail",
+			Output:  []string{"FAIL\n"},
+		},
+	}
+	if !cmp.Equal(want, sink.results) {
+		t.Error(cmp.Diff(want, sink.results))
+	}
+}
@@ -0,0 +1,234 @@
+package gotestdox
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// resultLogFlushInterval is how often the results buffered by a result log
+// (see [WithResultLog]) are written to the file.
+const resultLogFlushInterval = time.Second
+
+// resultLog appends results to a file as NDJSON, as requested by
+// [WithResultLog]. Lines are buffered, and written out periodically, and
+// whenever the buffer fills up. Each write to the file consists only of
+// whole lines, so that the file can be read at any time, and the lines from
+// concurrent writers never interleave.
+type resultLog struct {
+	mu   sync.Mutex
+	f    *os.File
+	w    *bufio.Writer
+	err  error
+	stop chan struct{}
+	done chan struct{}
+}
+
+func openResultLog(path string) (*resultLog, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	l := &resultLog{
+		f:    f,
+		w:    bufio.NewWriter(f),
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	go l.flushPeriodically()
+	return l, nil
+}
+
+func (l *resultLog) flushPeriodically() {
+	defer close(l.done)
+	ticker := time.NewTicker(resultLogFlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+			l.flush()
+		}
+	}
+}
+
+// loggedResult is a line of a result log: a [Result], together with the
+// WallTime and NoTestFiles of the run so far (see [Summary]), so that
+// [ResumeSummary] can recover them, since neither can be worked out from
+// the results alone.
+type loggedResult struct {
+	Result
+	WallTime    float64 `json:"wallTime,omitempty"`
+	NoTestFiles int     `json:"noTestFiles,omitempty"`
+}
+
+// write appends lr to the log as a single line of JSON.
+func (l *resultLog) write(lr loggedResult) error {
+	data, err := json.Marshal(lr)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.err != nil {
+		return l.err
+	}
+	if l.w.Buffered() > 0 && l.w.Available() < len(data) {
+		// flush first, so as not to split the line between writes
+		if l.err = l.w.Flush(); l.err != nil {
+			return l.err
+		}
+	}
+	_, l.err = l.w.Write(data)
+	return l.err
+}
+
+// flush writes any buffered lines to the file.
+func (l *resultLog) flush() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.err == nil {
+		l.err = l.w.Flush()
+	}
+	return l.err
+}
+
+// close flushes the log and closes the file.
+func (l *resultLog) close() error {
+	close(l.stop)
+	<-l.done
+	err := l.flush()
+	if cerr := l.f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// resultLogSink is a [ResultSink] that appends each result to a result log
+// before delivering it to the next sink, along with the parts of summary,
+// the summary of the run so far, that are logged with each result.
+type resultLogSink struct {
+	log     *resultLog
+	next    ResultSink
+	summary *Summary
+}
+
+func (s resultLogSink) Result(r Result) error {
+	lr := loggedResult{Result: r, WallTime: s.summary.WallTime, NoTestFiles: s.summary.NoTestFiles}
+	if err := s.log.write(lr); err != nil {
+		return fmt.Errorf("writing result log: %w", err)
+	}
+	return s.next.Result(r)
+}
+
+func (s resultLogSink) Summary(sum Summary) error {
+	if err := s.log.flush(); err != nil {
+		return fmt.Errorf("writing result log: %w", err)
+	}
+	return s.next.Summary(sum)
+}
+
+// ResumeSummary reconstructs the [Summary] of a run from the result log
+// written by [WithResultLog], which may be incomplete, if the run didn't
+// finish. The counts of packages and tests, flaky tests, and modules and
+// configurations, packages that failed in TestMain or teardown, and
+// packages that were excluded by build constraints, are recovered, as are
+// the wall time of the run, and the number of packages with no test files,
+// up to its last result, and BuildFailed is set if any package failed to
+// build; information that's only available at the end of a run, such as
+// vague names, is not.
+//
+// A truncated last line, as left by a run that was killed while writing it,
+// is ignored. If any other line can't be parsed, ResumeSummary returns an
+// error giving its line number.
+func ResumeSummary(path string) (Summary, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Summary{}, err
+	}
+	summary := Summary{}
+	failures, passed := map[string]int{}, map[string]int{}
+	lines := bytes.Split(data, []byte("\n"))
+	for i, line := range lines {
+		if len(line) == 0 {
+			continue
+		}
+		var r loggedResult
+		if err := json.Unmarshal(line, &r); err != nil {
+			if i == len(lines)-1 {
+				// truncated by a crash
+				break
+			}
+			return Summary{}, fmt.Errorf("%s: line %d: parsing JSON: %w", path, i+1, err)
+		}
+		summary.resume(r.Result, failures, passed)
+		if r.WallTime > summary.WallTime {
+			summary.WallTime = r.WallTime
+		}
+		if r.NoTestFiles > summary.NoTestFiles {
+			summary.NoTestFiles = r.NoTestFiles
+		}
+	}
+	return summary, nil
+}
+
+// resume counts the result r, read from a result log, in s, tracking in
+// failures the number of times each test has failed so far, and in passed
+// the number of tests in each package that have passed so far.
+func (s *Summary) resume(r Result, failures, passed map[string]int) {
+	if r.Module != "" && !s.hasModule(r.Module) {
+		s.Modules = append(s.Modules, ModuleSummary{Dir: r.Module})
+	}
+	if r.Configuration != "" && !s.hasConfiguration(r.Configuration) {
+		s.Configurations = append(s.Configurations, ConfigurationSummary{Name: r.Configuration})
+	}
+	if r.Test == "" && r.Excluded {
+		s.ExcludedPackages++
+		return
+	}
+	s.addModule(r)
+	s.addConfiguration(r)
+	pkg := Event{Package: r.Package, Configuration: r.Configuration}
+	if r.Test == "" {
+		s.Packages++
+		s.Elapsed += r.Elapsed
+		s.addOutputBytes(r)
+		switch {
+		case len(r.Vet) > 0:
+			s.VetFailures++
+		case isBuildFailure(r):
+			s.BuildFailed = true
+		}
+		if r.TeardownFailed {
+			s.TeardownFailures++
+		}
+		if r.TimedOut {
+			s.TimedOutPackages++
+		}
+		if r.Cached {
+			s.CachedPackages++
+			s.CachedPassed += passed[pkg.key("")]
+		}
+		delete(passed, pkg.key(""))
+		return
+	}
+	if r.Status == "pass" {
+		passed[pkg.key("")]++
+	}
+	key := pkg.key(r.Test)
+	switch {
+	case r.Status == "fail":
+		failures[key]++
+	case r.Flaky:
+		s.Flaky++
+		s.FlakyFailures += failures[key]
+		delete(failures, key)
+	}
+	s.add(r)
+}
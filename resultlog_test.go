@@ -0,0 +1,146 @@
+package gotestdox_test
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/bitfield/gotestdox"
+	"github.com/google/go-cmp/cmp"
+)
+
+var resultLogInput = sinkInput + `
+{"Action":"fail","Package":"r","Test":"TestFlaky"}
+{"Action":"pass","Package":"r","Test":"TestFlaky"}
+{"Action":"output","Package":"s","Output":"FAIL\ts [build failed]\n"}
+{"Action":"fail","Package":"s","Elapsed":0}
+{"Action":"pass","Package":"r","Elapsed":0.1}
+{"Action":"pass","Package":"c","Test":"TestCached"}
+{"Action":"output","Package":"c","Output":"ok  \tc\t(cached)\n"}
+{"Action":"pass","Package":"c","Elapsed":0}`
+
+func TestFilter_WithResultLogAppendsEachResultToFile(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "results.ndjson")
+	sink := &recordingSink{}
+	td := gotestdox.NewTestDoxer(gotestdox.WithSink(sink), gotestdox.WithResultLog(path))
+	td.Stdin = strings.NewReader(resultLogInput)
+	td.Filter()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	got, err := gotestdox.UnmarshalResults(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cmp.Equal(sink.results, got) {
+		t.Error(cmp.Diff(sink.results, got))
+	}
+}
+
+func TestResumeSummary_ReconstructsSummaryOfRunFromResultLog(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "results.ndjson")
+	sink := &recordingSink{}
+	td := gotestdox.NewTestDoxer(gotestdox.WithSink(sink), gotestdox.WithResultLog(path))
+	td.Stdin = strings.NewReader(resultLogInput)
+	td.Filter()
+	got, err := gotestdox.ResumeSummary(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cmp.Equal(sink.summary, got) {
+		t.Error(cmp.Diff(sink.summary, got))
+	}
+}
+
+func TestResumeSummary_RecoversWallTimeOfRunUpToLastResult(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "results.ndjson")
+	td := gotestdox.NewTestDoxer(gotestdox.WithSink(&recordingSink{}), gotestdox.WithResultLog(path))
+	td.Stdin = strings.NewReader(`{"Time":"2024-01-01T12:00:00Z","Action":"run","Package":"p","Test":"TestA"}
+{"Time":"2024-01-01T12:00:42.25Z","Action":"pass","Package":"p","Test":"TestA"}`)
+	td.Filter()
+	got, err := gotestdox.ResumeSummary(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.WallTime != 42.25 {
+		t.Errorf("want wall time 42.25s, got %vs", got.WallTime)
+	}
+}
+
+func TestResumeSummary_IgnoresTruncatedLastLine(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "results.ndjson")
+	data := `{"package":"p","test":"TestA","status":"pass","elapsed":0}
+{"package":"p","test":"TestB","status":"fail","elapsed":0}
+{"package":"p","test":"TestC","sta`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	want := gotestdox.Summary{Passed: 1, Failed: 1}
+	got, err := gotestdox.ResumeSummary(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestResumeSummary_ErrorsOnInvalidLineBeforeLast(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "results.ndjson")
+	data := `{"package":"p","test":"TestA","status":"pass","elapsed":0}
+bogus
+{"package":"p","test":"TestB","status":"pass","elapsed":0}
+`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := gotestdox.ResumeSummary(path)
+	if err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("want error for line 2, got %v", err)
+	}
+}
+
+func TestFilter_WithResultLogDoesNotInterleaveLinesFromConcurrentRuns(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "results.ndjson")
+	input := new(strings.Builder)
+	for i := 0; i < 1000; i++ {
+		input.WriteString(`{"Action":"output","Package":"p","Test":"TestLong","Output":"` + strings.Repeat("x", 100) + `\n"}` + "\n")
+		input.WriteString(`{"Action":"pass","Package":"p","Test":"TestLong"}` + "\n")
+	}
+	const runs = 4
+	var wg sync.WaitGroup
+	for i := 0; i < runs; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			td := gotestdox.NewTestDoxer(gotestdox.WithResultLog(path))
+			td.Stdin = strings.NewReader(input.String())
+			td.Stdout = io.Discard
+			td.Filter()
+		}()
+	}
+	wg.Wait()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	results, err := gotestdox.UnmarshalResults(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != runs*1000 {
+		t.Errorf("want %d results, got %d", runs*1000, len(results))
+	}
+}
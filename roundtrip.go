@@ -0,0 +1,180 @@
+package gotestdox
+
+import (
+	"bufio"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Unprettify attempts to reverse [Prettify], turning a sentence back into
+// the name of a Go test that would produce it. Each word is capitalised and
+// the words are joined together, after the prefix "Test". If the first word
+// of the sentence is itself a multiword function name, such as
+// "HandleInput", it is followed by an underscore, to mark the end of the
+// function name. Constant-style words such as "MAX_SIZE" are also followed
+// by an underscore. For example:
+//
+//	HandleInput closes input after reading
+//
+// becomes:
+//
+//	TestHandleInput_ClosesInputAfterReading
+//
+// Since Prettify discards some information, such as where subtest names
+// begin, the result isn't necessarily the original name, but a canonical
+// name that prettifies to the same sentence, if there is one.
+func Unprettify(sentence string) string {
+	words := strings.Fields(sentence)
+	if len(words) == 0 {
+		return "Test"
+	}
+	var b strings.Builder
+	b.WriteString("Test")
+	for i, w := range words {
+		r, size := utf8.DecodeRuneInString(w)
+		b.WriteRune(unicode.ToUpper(r))
+		b.WriteString(w[size:])
+		if i == len(words)-1 {
+			break
+		}
+		if i == 0 && isMultiwordName(w) || isConstantStyle(w) {
+			b.WriteByte('_')
+		}
+	}
+	return b.String()
+}
+
+// isConstantStyle reports whether w is a constant-style identifier, such as
+// "MAX_SIZE", which must be separated from the following word by an
+// underscore.
+func isConstantStyle(w string) bool {
+	return strings.Contains(w, "_") && strings.ToUpper(w) == w
+}
+
+// isMultiwordName reports whether w looks like a camel-case identifier made
+// up of more than one word, such as "HandleInput" or "ParseJSON".
+func isMultiwordName(w string) bool {
+	runes := []rune(w)
+	for i := 1; i < len(runes); i++ {
+		if unicode.IsUpper(runes[i]) && unicode.IsLower(runes[i-1]) {
+			return true
+		}
+	}
+	return false
+}
+
+// Divergence describes a test whose name doesn't survive a round trip
+// through [Prettify] and [Unprettify], as reported by [CheckRoundTrip].
+// Sentence is the prettified name, and RoundTrip is the sentence produced
+// by prettifying the unprettified sentence again, which differs from it.
+type Divergence struct {
+	Test      string
+	Sentence  string
+	RoundTrip string
+	File      string
+	Line      int
+}
+
+// String formats a Divergence for display.
+func (d Divergence) String() string {
+	return fmt.Sprintf("%s:%d: %s: %q becomes %q", d.File, d.Line, d.Test, d.Sentence, d.RoundTrip)
+}
+
+// CheckRoundTrip finds every test function declared in the _test.go files
+// under dir, and returns those whose names are ambiguous, in the sense that
+// their sentence, when unprettified and prettified again, comes out
+// differently. Tests named in allowed are not reported, so that existing
+// names can be grandfathered in while new ones are checked. Directories
+// named testdata or vendor, or whose names begin with a dot or underscore,
+// are skipped, as by the go tool. The results are sorted by file and line.
+//
+// Only top-level test functions are checked, since the names of subtests
+// aren't known until the tests are run.
+func CheckRoundTrip(dir string, allowed []string, opts ...Option) ([]Divergence, error) {
+	cfg := newConfig(opts)
+	allow := map[string]bool{}
+	for _, name := range allowed {
+		allow[name] = true
+	}
+	divergences := []Divergence{}
+	fset := token.NewFileSet()
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			name := d.Name()
+			if path != dir && (name == "testdata" || name == "vendor" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(path, "_test.go") {
+			return nil
+		}
+		f, err := parser.ParseFile(fset, path, nil, parser.SkipObjectResolution)
+		if err != nil {
+			return err
+		}
+		for _, decl := range f.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Recv != nil || Classify(fn.Name.Name) != Test || allow[fn.Name.Name] {
+				continue
+			}
+			sentence := prettify(fn.Name.Name, cfg)
+			again := prettify(Unprettify(sentence), cfg)
+			if again == sentence {
+				continue
+			}
+			pos := fset.Position(fn.Pos())
+			divergences = append(divergences, Divergence{
+				Test:      fn.Name.Name,
+				Sentence:  sentence,
+				RoundTrip: again,
+				File:      pos.Filename,
+				Line:      pos.Line,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(divergences, func(i, j int) bool {
+		if divergences[i].File != divergences[j].File {
+			return divergences[i].File < divergences[j].File
+		}
+		return divergences[i].Line < divergences[j].Line
+	})
+	return divergences, nil
+}
+
+// ReadAllowlist reads a list of test names from the file at path, for use
+// with [CheckRoundTrip]. The file contains one name per line; blank lines,
+// and lines beginning with #, are ignored.
+func ReadAllowlist(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	names := []string{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		names = append(names, line)
+	}
+	return names, scanner.Err()
+}
@@ -0,0 +1,134 @@
+package gotestdox_test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bitfield/gotestdox"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestUnprettify_ProducesNameThatPrettifiesToSameSentence(t *testing.T) {
+	t.Parallel()
+	tcs := []struct {
+		sentence, want string
+	}{
+		{
+			sentence: "Handle input closes input after reading",
+			want:     "TestHandleInputClosesInputAfterReading",
+		},
+		{
+			sentence: "HandleInput closes input after reading",
+			want:     "TestHandleInput_ClosesInputAfterReading",
+		},
+		{
+			sentence: "ParseJSON works",
+			want:     "TestParseJSON_Works",
+		},
+		{
+			sentence: "JSON round trip",
+			want:     "TestJSONRoundTrip",
+		},
+		{
+			sentence: "MAX_SIZE is respected",
+			want:     "TestMAX_SIZE_IsRespected",
+		},
+		{
+			sentence: "",
+			want:     "Test",
+		},
+	}
+	for _, tc := range tcs {
+		got := gotestdox.Unprettify(tc.sentence)
+		if tc.want != got {
+			t.Errorf("%q: %s", tc.sentence, cmp.Diff(tc.want, got))
+		}
+	}
+}
+
+func TestCheckRoundTrip_ReportsAmbiguousNamesNotInAllowlist(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"a_test.go": `package a
+
+import "testing"
+
+func TestParsesInput(t *testing.T) {}
+
+func TestParse_Handles_Input(t *testing.T) {}
+
+func TestXOk_ID(t *testing.T) {}
+
+func helper() {}
+`,
+		"sub/b_test.go":      "package sub\n\nimport \"testing\"\n\nfunc TestOld_Name(t *testing.T) {}\n",
+		"testdata/c_test.go": "package c\n\nimport \"testing\"\n\nfunc TestIgnored_Name(t *testing.T) {}\n",
+	})
+	allow, err := gotestdox.ReadAllowlist(writeAllowlist(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := gotestdox.CheckRoundTrip(dir, allow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []gotestdox.Divergence{
+		{
+			Test:      "TestXOk_ID",
+			Sentence:  "XOk ID",
+			RoundTrip: "X ok ID",
+			File:      filepath.Join(dir, "a_test.go"),
+			Line:      9,
+		},
+	}
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func writeAllowlist(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "allow.txt")
+	writeFiles(t, filepath.Dir(path), map[string]string{
+		"allow.txt": "# grandfathered\nTestOld_Name\n\n",
+	})
+	return path
+}
+
+func TestRun_WithRoundTripFlagReportsAmbiguousNamesAndFails(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"a_test.go": "package a\n\nimport \"testing\"\n\nfunc TestXOk_ID(t *testing.T) {}\n\nfunc TestOld_Name(t *testing.T) {}\n",
+	})
+	stdout, stderr := new(strings.Builder), new(strings.Builder)
+	code := gotestdox.Run([]string{"-roundtrip", "-allow", writeAllowlist(t), dir + "/..."}, strings.NewReader(""), stdout, stderr)
+	if code != gotestdox.ExitTestsFailed {
+		t.Errorf("want exit code %d, got %d", gotestdox.ExitTestsFailed, code)
+	}
+	want := filepath.Join(dir, "a_test.go") + `:5: TestXOk_ID: "XOk ID" becomes "X ok ID"` + "\n"
+	if want != stdout.String() {
+		t.Error(cmp.Diff(want, stdout.String()))
+	}
+	if !strings.Contains(stderr.String(), "round trip: 1") {
+		t.Errorf("want count of names on stderr, got %q", stderr)
+	}
+}
+
+func TestRun_WithRoundTripFlagSucceedsIfAllNamesSurviveRoundTrip(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"a_test.go": "package a\n\nimport \"testing\"\n\nfunc TestParsesInput(t *testing.T) {}\n",
+	})
+	stdout, stderr := new(strings.Builder), new(strings.Builder)
+	code := gotestdox.Run([]string{"-roundtrip", dir}, strings.NewReader(""), stdout, stderr)
+	if code != gotestdox.ExitOK {
+		t.Errorf("want exit code %d, got %d: %s", gotestdox.ExitOK, code, stderr)
+	}
+	if stdout.Len() > 0 {
+		t.Errorf("want no output, got %q", stdout)
+	}
+}
@@ -0,0 +1,182 @@
+package gotestdox
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+
+	"github.com/mattn/go-isatty"
+)
+
+// Run runs gotestdox as a command-line program would, with the given
+// arguments (not including the program name), and standard streams,
+// configured by any GOTESTDOX_* environment variables (see [FromEnv]), and
+// by the patterns in the [IgnoreFile] in the current directory, if there is
+// one (see [WithIgnore]), and by the thresholds in the file named by the
+// GOTESTDOX_THRESHOLDS environment variable, if it's set (see
+// [ReadThresholdsFile] and [WithThresholds]). It returns the exit status for
+// the program, as described for [ExitCode].
+//
+// Run decides for itself what to do. If stdin is a terminal, there's no
+// input to read, so it runs 'go test -json' with the given args, as for
+// [TestDoxer.ExecGoTest]. Otherwise, it waits for the first line of input,
+// and formats the input as 'go test -json' output, as for
+// [TestDoxer.Filter], ignoring the args.
+//
+// Two common mistakes are detected, too. If the input is the plain-text
+// output of 'go test', without '-json', which gotestdox can't interpret, Run
+// says so, and runs the tests itself instead. And if there's no input at
+// all, as when stdin is /dev/null, or a pipe that's closed without any data,
+// Run runs the tests if any args were given, since those are presumably
+// meant for 'go test', or does nothing if not.
+//
+// When formatting its input, Run catches SIGINT and SIGTERM, as
+// [TestDoxer.ExecGoTest] does, so that ctrl-C, which stops the 'go test'
+// command writing the input as well, still prints the results so far.
+//
+// If the first arg is -roundtrip, Run checks the names of the tests under the
+// directory given by the last arg, or the current directory, using
+// [CheckRoundTrip], instead of running or formatting any tests. It prints
+// each name that doesn't survive the round trip, and returns
+// [ExitTestsFailed] if there were any. Names listed in the file given by an
+// -allow flag, read by [ReadAllowlist], are not reported:
+//
+//	gotestdox -roundtrip -allow names-allowed.txt ./...
+func Run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	opts := FromEnv()
+	if len(args) > 0 && args[0] == "-roundtrip" {
+		return runRoundTripCheck(args[1:], opts, stdout, stderr)
+	}
+	patterns, err := ReadIgnoreFile(IgnoreFile)
+	switch {
+	case err == nil:
+		opts = append(opts, WithIgnore(patterns...))
+	case !errors.Is(err, fs.ErrNotExist):
+		fmt.Fprintln(stderr, "gotestdox:", err)
+		return ExitInternalError
+	}
+	if path := os.Getenv("GOTESTDOX_THRESHOLDS"); path != "" {
+		t, err := ReadThresholdsFile(path)
+		if err != nil {
+			fmt.Fprintln(stderr, "gotestdox:", err)
+			return ExitInternalError
+		}
+		opts = append(opts, WithThresholds(t))
+	}
+	td := NewTestDoxer(opts...)
+	td.Stdout = stdout
+	td.Stderr = stderr
+	td.exitOnSecondSignal = true
+	switch input, kind := peekInput(stdin); kind {
+	case jsonInput:
+		td.Stdin = input
+		state := td.startRun(nil)
+		stop := td.catchSignals(state, nil)
+		td.filterRun(state)
+		stop()
+	case plainInput:
+		fmt.Fprintln(stderr, "gotestdox: input is not 'go test -json' output, so running 'go test' instead")
+		td.ExecGoTest(args)
+	case terminalInput:
+		td.ExecGoTest(args)
+	case noInput:
+		if len(args) == 0 {
+			return ExitOK
+		}
+		td.ExecGoTest(args)
+	}
+	return td.exitCode()
+}
+
+// runRoundTripCheck runs the round-trip check requested by the -roundtrip
+// flag to [Run], with the remaining args, returning the exit status.
+func runRoundTripCheck(args []string, opts []Option, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("gotestdox -roundtrip", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	allowPath := flags.String("allow", "", "file listing test names not to report")
+	if err := flags.Parse(args); err != nil {
+		return ExitInternalError
+	}
+	dir := "."
+	if flags.NArg() > 0 {
+		// accept a package pattern such as ./..., since the check is
+		// recursive anyway
+		dir = strings.TrimSuffix(flags.Arg(flags.NArg()-1), "/...")
+		if dir == "" {
+			dir = "."
+		}
+	}
+	var allowed []string
+	if *allowPath != "" {
+		var err error
+		allowed, err = ReadAllowlist(*allowPath)
+		if err != nil {
+			fmt.Fprintln(stderr, "gotestdox:", err)
+			return ExitInternalError
+		}
+	}
+	divergences, err := CheckRoundTrip(dir, allowed, opts...)
+	if err != nil {
+		fmt.Fprintln(stderr, "gotestdox:", err)
+		return ExitInternalError
+	}
+	for _, d := range divergences {
+		fmt.Fprintln(stdout, d)
+	}
+	if len(divergences) > 0 {
+		fmt.Fprintf(stderr, "gotestdox: test names that don't survive a round trip: %d\n", len(divergences))
+		return ExitTestsFailed
+	}
+	return ExitOK
+}
+
+// inputKind describes the input available to [Run].
+type inputKind int
+
+const (
+	noInput inputKind = iota
+	terminalInput
+	jsonInput
+	plainInput
+)
+
+// plainOutputPrefixes are the beginnings of lines that identify the
+// plain-text output of 'go test'.
+var plainOutputPrefixes = []string{
+	"=== RUN", "=== PAUSE", "=== CONT", "--- PASS", "--- FAIL", "--- SKIP",
+	"PASS", "FAIL", "ok  ", "?   ", "testing: warning: no tests to run",
+}
+
+// peekInput determines what kind of input is available from r, without
+// consuming it, returning a reader that reads all the input from the start.
+// If r is a terminal, it's not read at all. Otherwise, peekInput waits
+// until the first non-blank line arrives, or r is exhausted. Input that
+// doesn't look like the plain-text output of 'go test' is taken to be JSON,
+// so that any other invalid input is reported as such by [TestDoxer.Filter].
+func peekInput(r io.Reader) (io.Reader, inputKind) {
+	if f, ok := r.(*os.File); ok && (isatty.IsTerminal(f.Fd()) || isatty.IsCygwinTerminal(f.Fd())) {
+		return r, terminalInput
+	}
+	br := bufio.NewReader(r)
+	for {
+		line, err := br.ReadString('\n')
+		if strings.TrimSpace(line) == "" {
+			if err != nil {
+				return br, noInput
+			}
+			continue
+		}
+		input := io.MultiReader(strings.NewReader(line), br)
+		for _, prefix := range plainOutputPrefixes {
+			if strings.HasPrefix(line, prefix) {
+				return input, plainInput
+			}
+		}
+		return input, jsonInput
+	}
+}
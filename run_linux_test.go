@@ -0,0 +1,49 @@
+package gotestdox_test
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/bitfield/gotestdox"
+	"github.com/fatih/color"
+	"golang.org/x/sys/unix"
+)
+
+func TestRun_RunsGoTestGivenTerminalAndNoArgs(t *testing.T) {
+	useFakeGo(t, "#!/bin/sh\necho '{\"Action\":\"pass\",\"Package\":\"p\",\"Test\":\"TestA\",\"Elapsed\":0}'\necho '{\"Action\":\"pass\",\"Package\":\"p\",\"Elapsed\":0}'\n")
+	color.NoColor = true
+	stdout, stderr := new(strings.Builder), new(strings.Builder)
+	code := gotestdox.Run(nil, openTerminal(t), stdout, stderr)
+	if code != gotestdox.ExitOK {
+		t.Errorf("want exit code %d, got %d, with stderr %q", gotestdox.ExitOK, code, stderr)
+	}
+	if !strings.Contains(stdout.String(), " ✔ A (0.00s)") {
+		t.Errorf("want results of 'go test', got %q", stdout)
+	}
+}
+
+// openTerminal returns the terminal end of a new pseudo-terminal, which is
+// closed at the end of the test.
+func openTerminal(t *testing.T) *os.File {
+	t.Helper()
+	ptmx, err := os.OpenFile("/dev/ptmx", os.O_RDWR|unix.O_NOCTTY, 0)
+	if err != nil {
+		t.Skipf("no pseudo-terminals: %v", err)
+	}
+	t.Cleanup(func() { ptmx.Close() })
+	if err := unix.IoctlSetPointerInt(int(ptmx.Fd()), unix.TIOCSPTLCK, 0); err != nil {
+		t.Fatal(err)
+	}
+	n, err := unix.IoctlGetInt(int(ptmx.Fd()), unix.TIOCGPTN)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tty, err := os.OpenFile(fmt.Sprintf("/dev/pts/%d", n), os.O_RDWR|unix.O_NOCTTY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { tty.Close() })
+	return tty
+}
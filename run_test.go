@@ -0,0 +1,80 @@
+package gotestdox_test
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bitfield/gotestdox"
+	"github.com/fatih/color"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRun_WaitsForFirstInputFromPipeAndFiltersIt(t *testing.T) {
+	t.Parallel()
+	color.NoColor = true
+	r, w := io.Pipe()
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		io.WriteString(w, "\n"+sinkInput)
+		w.Close()
+	}()
+	stdout, stderr := new(strings.Builder), new(strings.Builder)
+	code := gotestdox.Run([]string{"bogus"}, r, stdout, stderr)
+	if code != gotestdox.ExitTestsFailed {
+		t.Errorf("want exit code %d, got %d", gotestdox.ExitTestsFailed, code)
+	}
+	want := `p:
+ ✔ A works fine (0.00s)
+ x B (0.50s)
+
+q:
+ ✔ D (0.00s)
+
+`
+	if want != stdout.String() {
+		t.Error(cmp.Diff(want, stdout.String()))
+	}
+	if stderr.Len() > 0 {
+		t.Errorf("unexpected stderr: %q", stderr)
+	}
+}
+
+func TestRun_DoesNothingGivenEmptyPipeAndNoArgs(t *testing.T) {
+	t.Parallel()
+	r, w := io.Pipe()
+	w.Close()
+	stdout, stderr := new(strings.Builder), new(strings.Builder)
+	code := gotestdox.Run(nil, r, stdout, stderr)
+	if code != gotestdox.ExitOK {
+		t.Errorf("want exit code %d, got %d", gotestdox.ExitOK, code)
+	}
+	if stdout.Len()+stderr.Len() > 0 {
+		t.Errorf("want no output, got %q and %q", stdout, stderr)
+	}
+}
+
+func TestRun_ReportsInvalidInputThatIsNotPlainTestOutput(t *testing.T) {
+	t.Parallel()
+	stdout, stderr := new(strings.Builder), new(strings.Builder)
+	code := gotestdox.Run(nil, strings.NewReader("bogus\n"), stdout, stderr)
+	if code != gotestdox.ExitInternalError {
+		t.Errorf("want exit code %d, got %d", gotestdox.ExitInternalError, code)
+	}
+	if strings.Contains(stderr.String(), "running 'go test'") {
+		t.Errorf("want no fallback to 'go test', got %q", stderr)
+	}
+}
+
+func TestRun_ReturnsInternalErrorIfGoCannotBeStarted(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+	stdout, stderr := new(strings.Builder), new(strings.Builder)
+	code := gotestdox.Run([]string{"./..."}, strings.NewReader(""), stdout, stderr)
+	if code != gotestdox.ExitInternalError {
+		t.Errorf("want exit code %d, got %d", gotestdox.ExitInternalError, code)
+	}
+	if !strings.Contains(stderr.String(), "executable file not found") {
+		t.Errorf("want error starting go reported, got %q", stderr)
+	}
+}
@@ -0,0 +1,193 @@
+package gotestdox
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// ScoreWeights control how [ScoreWeights.Score] rates the readability of a
+// sentence. A sentence starts with a score of 100, and loses points for each
+// problem found:
+//
+//   - WordCount points if it has fewer than MinWords words, or more than
+//     MaxWords
+//   - Verb points if none of its words is one of Verbs
+//   - CamelCase points for each word, or part of a word between punctuation
+//     marks, with capital letters inside it, such as "parseJSON", unless
+//     it's an initialism, such as "URL" or "IDs"
+//   - Numeric points for each word made up only of digits, such as "2"
+//
+// The score never goes below zero.
+type ScoreWeights struct {
+	MinWords  int
+	MaxWords  int
+	WordCount int
+	Verbs     []string
+	Verb      int
+	CamelCase int
+	Numeric   int
+}
+
+// scoreVerbs are the verbs recognised by [DefaultScoreWeights], in addition
+// to those recognised by [WithImperativeMood].
+var scoreVerbs = []string{
+	"are", "can", "does", "errors", "has", "have", "is", "panics",
+	"should", "succeeds", "works",
+}
+
+// DefaultScoreWeights returns the weights used by [Score], which can be
+// adjusted and supplied to [WithSentenceScores].
+func DefaultScoreWeights() ScoreWeights {
+	verbs := append(append([]string{}, imperativeVerbs...), scoreVerbs...)
+	sort.Strings(verbs)
+	return ScoreWeights{
+		MinWords:  3,
+		MaxWords:  12,
+		WordCount: 30,
+		Verbs:     verbs,
+		Verb:      30,
+		CamelCase: 20,
+		Numeric:   10,
+	}
+}
+
+// Score rates the readability of sentence, as produced by [Prettify], from 0
+// to 100, using the [DefaultScoreWeights]. For example, "Parse returns error
+// on empty input" scores 100, while "Parse" scores 40.
+func Score(sentence string) int {
+	return DefaultScoreWeights().Score(sentence)
+}
+
+// Score rates the readability of sentence from 0 to 100, using the weights
+// w, as described for [ScoreWeights].
+func (w ScoreWeights) Score(sentence string) int {
+	return w.score(sentence, nil)
+}
+
+// score is like [ScoreWeights.Score], but also treats any of the given
+// initialisms as a proper word, whatever its capitalisation.
+func (w ScoreWeights) score(sentence string, initialisms map[string]bool) int {
+	words := strings.Fields(sentence)
+	score := 100
+	if len(words) < w.MinWords || len(words) > w.MaxWords {
+		score -= w.WordCount
+	}
+	verb := false
+	for _, word := range words {
+		for _, v := range w.Verbs {
+			if strings.EqualFold(word, v) {
+				verb = true
+			}
+		}
+		for _, part := range strings.FieldsFunc(word, isPunct) {
+			if isCamelCase(part) && !initialisms[part] {
+				score -= w.CamelCase
+			}
+		}
+		if isNumeric(word) {
+			score -= w.Numeric
+		}
+	}
+	if !verb {
+		score -= w.Verb
+	}
+	if score < 0 {
+		return 0
+	}
+	return score
+}
+
+// isPunct reports whether r separates the parts of a word checked by
+// isCamelCase, as in "key=parseJSON".
+func isPunct(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
+
+// isCamelCase reports whether word contains a capital letter after its first
+// letter, other than an all-caps word, optionally followed by a plural 's',
+// as in "IDs".
+func isCamelCase(word string) bool {
+	rs := []rune(strings.TrimSuffix(word, "s"))
+	if len(rs) < 2 {
+		return false
+	}
+	upper, lower := false, false
+	for _, r := range rs[1:] {
+		upper = upper || unicode.IsUpper(r)
+		lower = lower || unicode.IsLower(r)
+	}
+	return upper && (lower || unicode.IsLower(rs[0]))
+}
+
+// ScoredName identifies a test reported by [WithSentenceScores], with the
+// [Score] of its sentence. File and Line give the location of the test
+// function, if it could be found, as for [VagueName].
+type ScoredName struct {
+	Package  string `json:"package"`
+	Test     string `json:"test"`
+	Sentence string `json:"sentence"`
+	Score    int    `json:"score"`
+	File     string `json:"file,omitempty"`
+	Line     int    `json:"line,omitempty"`
+}
+
+// String formats a ScoredName for display, including its location if known.
+func (s ScoredName) String() string {
+	if s.File == "" {
+		return fmt.Sprintf(" %3d %s: %s", s.Score, s.Package, s.Sentence)
+	}
+	return fmt.Sprintf(" %3d %s: %s (%s:%d)", s.Score, s.Package, s.Sentence, s.File, s.Line)
+}
+
+// scoreNames scores the sentence of each of the tests in results, ignoring
+// any test that has subtests of its own, and returns the average score,
+// together with the lowest-scoring tests, lowest first, up to the given
+// limit, or all of them, if limit is negative. Tests with the same score are
+// sorted by package and then by test name.
+func scoreNames(results []Result, w ScoreWeights, initialisms map[string]bool, limit int) (float64, []ScoredName) {
+	scored := []ScoredName{}
+	total := 0
+	for _, r := range leafTests(results) {
+		s := ScoredName{
+			Package:  r.Package,
+			Test:     r.Test,
+			Sentence: r.Sentence,
+			Score:    w.score(r.Sentence, initialisms),
+		}
+		total += s.Score
+		scored = append(scored, s)
+	}
+	if len(scored) == 0 {
+		return 0, scored
+	}
+	average := float64(total) / float64(len(scored))
+	sort.Slice(scored, func(i, j int) bool {
+		a, b := scored[i], scored[j]
+		if a.Score != b.Score {
+			return a.Score < b.Score
+		}
+		if a.Package != b.Package {
+			return a.Package < b.Package
+		}
+		return a.Test < b.Test
+	})
+	if limit >= 0 && len(scored) > limit {
+		scored = scored[:limit]
+	}
+	return average, scored
+}
+
+// locateScoredNames fills in the File and Line fields of each entry in
+// scored, where the source of the test function can be found.
+func locateScoredNames(scored []ScoredName, packages *packageResolver) {
+	for i, s := range scored {
+		dir := packages.resolve(s.Package, "").dir
+		if dir == "" {
+			continue
+		}
+		parent, _ := SplitSubtests(s.Test)
+		scored[i].File, scored[i].Line = locateTestFunc(dir, parent)
+	}
+}
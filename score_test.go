@@ -0,0 +1,148 @@
+package gotestdox_test
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/bitfield/gotestdox"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestScore_RatesReadabilityOfSentence(t *testing.T) {
+	t.Parallel()
+	tcs := []struct {
+		sentence string
+		want     int
+	}{
+		{sentence: "Parse returns error on empty input", want: 100},
+		{sentence: "Parse", want: 40},
+		{sentence: "Parse handles empty input", want: 100},
+		{sentence: "Parse empty input", want: 70},
+		{sentence: "Load is ok", want: 100},
+		{sentence: "Client sends IDs to the API", want: 100},
+		{sentence: "Client sends parseJSON output", want: 80},
+		{sentence: "Client sends ParseJSON and oAuth tokens", want: 60},
+		{sentence: "Server returns 404 for 2 missing pages", want: 80},
+		{sentence: "Decoder reads RFC3339 timestamps", want: 100},
+		{sentence: "Client sends key=parseJSON(fooBar)", want: 60},
+		{sentence: "One two three four five six seven eight nine ten eleven twelve thirteen", want: 40},
+		{sentence: "fooBar 1 2 3 4 5 6", want: 0},
+		{sentence: "", want: 40},
+	}
+	for _, tc := range tcs {
+		got := gotestdox.Score(tc.sentence)
+		if tc.want != got {
+			t.Errorf("%q: want %d, got %d", tc.sentence, tc.want, got)
+		}
+	}
+}
+
+func TestScoreWeights_CanBeAdjusted(t *testing.T) {
+	t.Parallel()
+	w := gotestdox.DefaultScoreWeights()
+	w.Verbs = []string{"frobs"}
+	w.Verb = 50
+	w.MinWords = 1
+	if got := w.Score("Parse frobs input"); got != 100 {
+		t.Errorf("want 100 with custom verb, got %d", got)
+	}
+	if got := w.Score("Parse returns input"); got != 50 {
+		t.Errorf("want 50 without custom verb, got %d", got)
+	}
+}
+
+var scoreInput = `{"Action":"pass","Package":"p","Test":"TestParse"}
+{"Action":"pass","Package":"p","Test":"TestParseReturnsErrorOnEmptyInput"}
+{"Action":"pass","Package":"p","Test":"TestLoad/handles_empty_input"}
+{"Action":"pass","Package":"p","Test":"TestLoad/empty_input"}
+{"Action":"pass","Package":"p","Test":"TestLoad"}
+{"Action":"pass","Package":"p"}`
+
+func TestFilter_WithSentenceScoresReportsAverageAndLowestScores(t *testing.T) {
+	t.Parallel()
+	sink := &recordingSink{}
+	td := gotestdox.NewTestDoxer(gotestdox.WithSink(sink), gotestdox.WithSentenceScores(2))
+	td.Stdin = strings.NewReader(scoreInput)
+	td.Filter()
+	if sink.summary.AverageScore != 77.5 {
+		t.Errorf("want average score 77.5, got %v", sink.summary.AverageScore)
+	}
+	want := []gotestdox.ScoredName{
+		{Package: "p", Test: "TestParse", Sentence: "Parse", Score: 40},
+		{Package: "p", Test: "TestLoad/empty_input", Sentence: "Load empty input", Score: 70},
+	}
+	if !cmp.Equal(want, sink.summary.LowestScores) {
+		t.Error(cmp.Diff(want, sink.summary.LowestScores))
+	}
+}
+
+func TestFilter_WithNegativeSentenceScoresListsAllScores(t *testing.T) {
+	t.Parallel()
+	sink := &recordingSink{}
+	td := gotestdox.NewTestDoxer(gotestdox.WithSink(sink), gotestdox.WithSentenceScores(-1))
+	td.Stdin = strings.NewReader(scoreInput)
+	td.Filter()
+	if len(sink.summary.LowestScores) != 4 {
+		t.Errorf("want all 4 scores listed, got %+v", sink.summary.LowestScores)
+	}
+}
+
+func TestFilter_WithSentenceScoresPrintsScoresAfterReport(t *testing.T) {
+	t.Parallel()
+	buf := new(strings.Builder)
+	td := gotestdox.NewTestDoxer(gotestdox.WithSentenceScores(1))
+	td.Stdin = strings.NewReader(scoreInput)
+	td.Stdout = buf
+	td.Filter()
+	want := "Sentence score: 77.5 average, lowest 1:\n  40 p: Parse\n"
+	if !strings.HasSuffix(buf.String(), want) {
+		t.Errorf("want output ending with:\n%s\ngot:\n%s", want, buf)
+	}
+}
+
+func TestFilter_WithScoreWeightsUsesGivenWeightsAndInitialisms(t *testing.T) {
+	t.Parallel()
+	input := `{"Action":"pass","Package":"p","Test":"TestClient/sends_token=OAuth"}
+{"Action":"pass","Package":"p"}`
+	weights := gotestdox.DefaultScoreWeights()
+	weights.CamelCase = 50
+	for _, tc := range []struct {
+		opts []gotestdox.Option
+		want int
+	}{
+		{want: 80},
+		{opts: []gotestdox.Option{gotestdox.WithScoreWeights(weights)}, want: 50},
+		{opts: []gotestdox.Option{gotestdox.WithScoreWeights(weights), gotestdox.WithInitialisms("OAuth")}, want: 100},
+	} {
+		sink := &recordingSink{}
+		opts := append([]gotestdox.Option{gotestdox.WithSink(sink), gotestdox.WithSentenceScores(1)}, tc.opts...)
+		td := gotestdox.NewTestDoxer(opts...)
+		td.Stdin = strings.NewReader(input)
+		td.Stdout = io.Discard
+		td.Filter()
+		if len(sink.summary.LowestScores) != 1 {
+			t.Fatalf("want 1 scored name, got %#v", sink.summary.LowestScores)
+		}
+		if got := sink.summary.LowestScores[0].Score; got != tc.want {
+			t.Errorf("%q: want score %d, got %d", sink.summary.LowestScores[0].Sentence, tc.want, got)
+		}
+	}
+}
+
+func TestFilter_WithSentenceScoresReportsFileAndLineWhenResolvable(t *testing.T) {
+	t.Parallel()
+	input := `{"Action":"pass","Package":"github.com/bitfield/gotestdox","Test":"TestScore_RatesReadabilityOfSentence"}
+{"Action":"pass","Package":"github.com/bitfield/gotestdox"}`
+	sink := &recordingSink{}
+	td := gotestdox.NewTestDoxer(gotestdox.WithSink(sink), gotestdox.WithSentenceScores(1))
+	td.Stdin = strings.NewReader(input)
+	td.Filter()
+	if len(sink.summary.LowestScores) != 1 {
+		t.Fatalf("want 1 scored name, got %#v", sink.summary.LowestScores)
+	}
+	got := sink.summary.LowestScores[0]
+	if got.File != "score_test.go" || got.Line == 0 {
+		t.Errorf("want location in score_test.go, got %s:%d", got.File, got.Line)
+	}
+}
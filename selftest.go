@@ -0,0 +1,82 @@
+package gotestdox
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"runtime"
+	"strings"
+)
+
+// selfTestInput is the captured 'go test -json' output formatted by
+// [SelfTest], and selfTestGolden is the report it should produce.
+var (
+	//go:embed selftest/input.json
+	selfTestInput string
+	//go:embed selftest/golden.txt
+	selfTestGolden string
+)
+
+// ansiEscape matches the escape sequences used to colour the report.
+var ansiEscape = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+// SelfTest checks that this build of gotestdox formats test output
+// correctly, by running some captured 'go test -json' output, embedded in
+// the program, through [TestDoxer.Filter] with the default configuration,
+// and comparing the report with the expected one, also embedded. Neither
+// the environment, the network, a Go toolchain, nor a writable disk is
+// needed, and colour is ignored, so the result is the same on any platform.
+//
+// If the report is as expected, SelfTest prints a line saying so to w. If
+// not, it prints a line-by-line diff of the expected and actual reports to
+// w, and returns an error.
+func SelfTest(w io.Writer) error {
+	out := new(strings.Builder)
+	td := NewTestDoxer()
+	td.Stdin = strings.NewReader(selfTestInput)
+	td.Stdout = out
+	td.Stderr = out
+	td.Filter()
+	got := ansiEscape.ReplaceAllString(out.String(), "")
+	if got == selfTestGolden {
+		fmt.Fprintf(w, "gotestdox self-test passed (%s/%s)\n", runtime.GOOS, runtime.GOARCH)
+		return nil
+	}
+	fmt.Fprintf(w, "gotestdox self-test failed (%s/%s): -want +got\n", runtime.GOOS, runtime.GOARCH)
+	fmt.Fprint(w, lineDiff(selfTestGolden, got))
+	return errors.New("self-test output does not match the expected report")
+}
+
+// lineDiff returns a simple diff of the lines of want and got, showing each
+// line that differs at the same position, prefixed with its line number and
+// "-" for want or "+" for got.
+func lineDiff(want, got string) string {
+	wantLines := strings.Split(want, "\n")
+	gotLines := strings.Split(got, "\n")
+	n := len(wantLines)
+	if len(gotLines) > n {
+		n = len(gotLines)
+	}
+	var b strings.Builder
+	for i := 0; i < n; i++ {
+		var wl, gl string
+		if i < len(wantLines) {
+			wl = wantLines[i]
+		}
+		if i < len(gotLines) {
+			gl = gotLines[i]
+		}
+		if wl == gl {
+			continue
+		}
+		if i < len(wantLines) {
+			fmt.Fprintf(&b, "%4d - %q\n", i+1, wl)
+		}
+		if i < len(gotLines) {
+			fmt.Fprintf(&b, "%4d + %q\n", i+1, gl)
+		}
+	}
+	return b.String()
+}
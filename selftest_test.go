@@ -0,0 +1,22 @@
+package gotestdox_test
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/bitfield/gotestdox"
+)
+
+func TestSelfTest_PassesForThisBuild(t *testing.T) {
+	t.Parallel()
+	out := new(strings.Builder)
+	err := gotestdox.SelfTest(out)
+	if err != nil {
+		t.Fatalf("%v:\n%s", err, out)
+	}
+	want := "gotestdox self-test passed (" + runtime.GOOS + "/" + runtime.GOARCH + ")\n"
+	if want != out.String() {
+		t.Errorf("want %q, got %q", want, out)
+	}
+}
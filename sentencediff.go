@@ -0,0 +1,108 @@
+package gotestdox
+
+import "strings"
+
+// SentenceEqual reports whether the test names a and b are rendered as the
+// same sentence by [Prettify], with the given options, so that a change
+// from one to the other, such as renaming TestFooReturnsErr to
+// TestFoo_ReturnsErr, is only cosmetic.
+func SentenceEqual(a, b string, opts ...Option) bool {
+	cfg := newConfig(opts)
+	return prettify(a, cfg) == prettify(b, cfg)
+}
+
+// SentenceDiff returns a word-level diff of the sentences for the test
+// names a and b, as rendered by [Prettify] with the given options, for
+// display when reviewing a change of name. Words only in a's sentence are
+// shown as "[-removed words-]", and words only in b's as "{+added words+}",
+// in the style of 'git diff --word-diff'. For example, the diff for
+// TestParseReturnsErr and TestParseReturnsNilError is:
+//
+//	Parse returns [-err-] {+nil error+}
+//
+// A function name marked with an underscore, as described for [Prettify],
+// counts as a single word, so a change to the name of the function under
+// test is shown separately from changes to the behaviour described. If the
+// sentences are the same, the diff is just the sentence.
+func SentenceDiff(a, b string, opts ...Option) string {
+	cfg := newConfig(opts)
+	before, after := parse(a, cfg), parse(b, cfg)
+	var out []string
+	var removed, added []string
+	flush := func() {
+		if len(removed) > 0 {
+			out = append(out, "[-"+strings.Join(removed, " ")+"-]")
+		}
+		if len(added) > 0 {
+			out = append(out, "{+"+strings.Join(added, " ")+"+}")
+		}
+		removed, added = nil, nil
+	}
+	for _, e := range diffWords(before, after) {
+		switch e.op {
+		case '-':
+			removed = append(removed, e.word)
+		case '+':
+			added = append(added, e.word)
+		default:
+			flush()
+			out = append(out, e.word)
+		}
+	}
+	flush()
+	return strings.Join(out, " ")
+}
+
+// wordEdit is a single step in a word-level diff: a word that's kept ('='),
+// removed ('-'), or added ('+').
+type wordEdit struct {
+	op   byte
+	word string
+}
+
+// diffWords returns the edits that turn a into b, keeping a longest common
+// subsequence of their words. Where there's a choice, removals come before
+// additions, so the result is deterministic.
+func diffWords(a, b []string) []wordEdit {
+	// lcs[i][j] is the length of the longest common subsequence of
+	// a[i:] and b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			switch {
+			case a[i] == b[j]:
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			case lcs[i+1][j] >= lcs[i][j+1]:
+				lcs[i][j] = lcs[i+1][j]
+			default:
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	edits := []wordEdit{}
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			edits = append(edits, wordEdit{'=', a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			edits = append(edits, wordEdit{'-', a[i]})
+			i++
+		default:
+			edits = append(edits, wordEdit{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		edits = append(edits, wordEdit{'-', a[i]})
+	}
+	for ; j < len(b); j++ {
+		edits = append(edits, wordEdit{'+', b[j]})
+	}
+	return edits
+}
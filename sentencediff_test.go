@@ -0,0 +1,77 @@
+package gotestdox_test
+
+import (
+	"testing"
+
+	"github.com/bitfield/gotestdox"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSentenceEqual_ReportsWhetherNamesRenderAsSameSentence(t *testing.T) {
+	t.Parallel()
+	tcs := []struct {
+		a, b string
+		opts []gotestdox.Option
+		want bool
+	}{
+		{a: "TestFooReturnsErr", b: "TestFoo_ReturnsErr", want: true},
+		{a: "TestParse/handles_empty_input", b: "TestParseHandlesEmptyInput", want: true},
+		{a: "TestFooReturnsErr", b: "TestFooReturnsError", want: false},
+		{
+			a:    "TestFooReturnsErr",
+			b:    "TestFoo_ReturnsErr",
+			opts: []gotestdox.Option{gotestdox.WithSubjectSeparator(" — ")},
+			want: false,
+		},
+	}
+	for _, tc := range tcs {
+		got := gotestdox.SentenceEqual(tc.a, tc.b, tc.opts...)
+		if tc.want != got {
+			t.Errorf("%q and %q: want %t, got %t", tc.a, tc.b, tc.want, got)
+		}
+	}
+}
+
+func TestSentenceDiff_ShowsWordsRemovedAndAdded(t *testing.T) {
+	t.Parallel()
+	tcs := []struct {
+		a, b, want string
+	}{
+		{
+			a:    "TestParseReturnsErr",
+			b:    "TestParseReturnsNilError",
+			want: "Parse returns [-err-] {+nil error+}",
+		},
+		{
+			a:    "TestFooReturnsErr",
+			b:    "TestFoo_ReturnsErr",
+			want: "Foo returns err",
+		},
+		{
+			a:    "TestParse/rejects_empty_input",
+			b:    "TestParse/rejects_input",
+			want: "Parse rejects [-empty-] input",
+		},
+		{
+			a:    "TestParse/rejects_input",
+			b:    "TestParse/quietly_rejects_bad_input",
+			want: "Parse {+quietly+} rejects {+bad+} input",
+		},
+		{
+			a:    "TestHandleInput_ClosesFile",
+			b:    "TestHandleOutput_ClosesFile",
+			want: "[-HandleInput-] {+HandleOutput+} closes file",
+		},
+		{
+			a:    "TestA",
+			b:    "TestB",
+			want: "[-A-] {+B+}",
+		},
+	}
+	for _, tc := range tcs {
+		got := gotestdox.SentenceDiff(tc.a, tc.b)
+		if tc.want != got {
+			t.Errorf("%q to %q: %s", tc.a, tc.b, cmp.Diff(tc.want, got))
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package gotestdox
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+// Serve accepts connections on l until ctx is cancelled, treating each one as
+// an independent stream of 'go test -json' events, so that a long-lived
+// process can report on many test runs. The results for each connection are
+// delivered to a new [ResultSink] obtained by calling newSink, as if by
+// [TestDoxer.Filter] with the given options. Connections are handled
+// concurrently, and don't block each other. If the stream on one connection
+// is malformed, or can't be reported, that connection is closed, and the
+// error is written to standard error, but the others are unaffected.
+//
+// When ctx is cancelled, Serve stops accepting connections, and waits for
+// those in progress to reach the end of their streams, for up to the timeout
+// set by [WithShutdownTimeout], or 5 seconds by default. Any still open after
+// that are closed. Serve then returns ctx.Err(). If accepting a connection
+// fails temporarily, as when the process runs out of file descriptors, Serve
+// tries again after a short delay, which doubles with each successive
+// failure, up to a second, as [net/http.Server] does. If it fails for some
+// other reason, Serve returns that error, after closing the connections in
+// progress in the same way.
+func Serve(ctx context.Context, l net.Listener, newSink func() ResultSink, opts ...Option) error {
+	cfg := newConfig(opts)
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-ctx.Done():
+			l.Close()
+		case <-stop:
+		}
+	}()
+	var mu sync.Mutex
+	conns := map[net.Conn]bool{}
+	var wg sync.WaitGroup
+	var err error
+	var delay time.Duration
+	for {
+		conn, acceptErr := l.Accept()
+		if acceptErr != nil {
+			err = ctx.Err()
+			if err != nil {
+				break
+			}
+			if ne, ok := acceptErr.(net.Error); ok && ne.Temporary() {
+				delay = acceptRetryDelay(delay)
+				select {
+				case <-time.After(delay):
+					continue
+				case <-ctx.Done():
+					err = ctx.Err()
+				}
+			} else {
+				err = acceptErr
+			}
+			break
+		}
+		delay = 0
+		mu.Lock()
+		conns[conn] = true
+		mu.Unlock()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			serveConn(conn, newSink(), opts)
+			mu.Lock()
+			delete(conns, conn)
+			mu.Unlock()
+		}()
+	}
+	drained := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+	case <-time.After(cfg.shutdownTimeout):
+		mu.Lock()
+		for conn := range conns {
+			conn.Close()
+		}
+		mu.Unlock()
+		<-drained
+	}
+	return err
+}
+
+// acceptRetryDelay returns how long to wait before accepting a connection
+// again, after a temporary failure, given the delay after the previous
+// consecutive failure, if any.
+func acceptRetryDelay(prev time.Duration) time.Duration {
+	const first, max = 5 * time.Millisecond, time.Second
+	if prev == 0 {
+		return first
+	}
+	if prev*2 > max {
+		return max
+	}
+	return prev * 2
+}
+
+// serveConn filters the events read from conn, delivering the results to
+// sink, and closes conn at the end of the stream. Any error is reported to
+// standard error, identifying the connection by its remote address.
+func serveConn(conn net.Conn, sink ResultSink, opts []Option) {
+	defer conn.Close()
+	// copy opts, which is shared by all the connections, before appending
+	td := NewTestDoxer(append(append([]Option{}, opts...), WithSink(sink))...)
+	td.Stdin = conn
+	if err := td.filter(context.Background(), &runState{}); err != nil {
+		fmt.Fprintf(td.Stderr, "gotestdox: connection from %s: %v\n", conn.RemoteAddr(), err)
+	}
+}
@@ -0,0 +1,176 @@
+package gotestdox_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/bitfield/gotestdox"
+)
+
+type summarySink chan gotestdox.Summary
+
+func (s summarySink) Result(gotestdox.Result) error {
+	return nil
+}
+
+func (s summarySink) Summary(sum gotestdox.Summary) error {
+	s <- sum
+	return nil
+}
+
+func TestServe_FiltersEachConnectionIndependently(t *testing.T) {
+	t.Parallel()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	summaries := make(summarySink, 10)
+	newSink := func() gotestdox.ResultSink {
+		return summaries
+	}
+	errs := make(chan error)
+	go func() {
+		errs <- gotestdox.Serve(ctx, l, newSink, gotestdox.WithShutdownTimeout(time.Second))
+	}()
+	// An open connection doesn't hold up the others
+	idle, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer idle.Close()
+	valid := `{"Action":"pass","Package":"p","Test":"TestA"}` + "\n"
+	for _, stream := range []string{"bogus\n", valid, valid} {
+		conn, err := net.Dial("tcp", l.Addr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		fmt.Fprint(conn, stream)
+		conn.Close()
+		if stream == valid {
+			select {
+			case sum := <-summaries:
+				if sum.Passed != 1 {
+					t.Errorf("want 1 passed, got %d", sum.Passed)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("want summary for each valid stream")
+			}
+		}
+	}
+	cancel()
+	select {
+	case err := <-errs:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("want context.Canceled, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Serve did not return after shutdown timeout")
+	}
+}
+
+// temporaryError is an error accepting a connection that may succeed if
+// tried again, such as running out of file descriptors.
+type temporaryError struct{}
+
+func (temporaryError) Error() string   { return "too many open files" }
+func (temporaryError) Timeout() bool   { return false }
+func (temporaryError) Temporary() bool { return true }
+
+// flakyListener is a [net.Listener] whose first few calls to Accept fail
+// with a temporaryError.
+type flakyListener struct {
+	net.Listener
+	failures int
+}
+
+func (l *flakyListener) Accept() (net.Conn, error) {
+	if l.failures > 0 {
+		l.failures--
+		return nil, temporaryError{}
+	}
+	return l.Listener.Accept()
+}
+
+func TestServe_KeepsAcceptingConnectionsAfterTemporaryError(t *testing.T) {
+	t.Parallel()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	summaries := make(summarySink, 1)
+	newSink := func() gotestdox.ResultSink {
+		return summaries
+	}
+	errs := make(chan error, 1)
+	go func() {
+		errs <- gotestdox.Serve(ctx, &flakyListener{Listener: l, failures: 3}, newSink)
+	}()
+	conn, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Fprintln(conn, `{"Action":"pass","Package":"p","Test":"TestA"}`)
+	conn.Close()
+	select {
+	case sum := <-summaries:
+		if sum.Passed != 1 {
+			t.Errorf("want 1 passed, got %d", sum.Passed)
+		}
+	case err := <-errs:
+		t.Fatalf("want Serve to carry on after temporary error, but it returned %v", err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("want summary for connection accepted after temporary error")
+	}
+}
+
+func TestServe_DeliversResultsOfEachConnectionToItsOwnSink(t *testing.T) {
+	t.Parallel()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sinks := make(chan summarySink, 20)
+	newSink := func() gotestdox.ResultSink {
+		s := make(summarySink, 2)
+		sinks <- s
+		return s
+	}
+	// spare capacity in opts, which connections mustn't share
+	opts := make([]gotestdox.Option, 1, 10)
+	opts[0] = gotestdox.WithShutdownTimeout(time.Second)
+	go gotestdox.Serve(ctx, l, newSink, opts...)
+	for i := 0; i < cap(sinks); i++ {
+		go func() {
+			conn, err := net.Dial("tcp", l.Addr().String())
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			fmt.Fprintln(conn, `{"Action":"pass","Package":"p","Test":"TestA"}`)
+			conn.Close()
+		}()
+	}
+	for i := 0; i < cap(sinks); i++ {
+		s := <-sinks
+		select {
+		case sum := <-s:
+			if sum.Passed != 1 {
+				t.Errorf("want 1 passed, got %d", sum.Passed)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("want a summary delivered to each sink")
+		}
+		if len(s) > 0 {
+			t.Error("want only one summary delivered to each sink")
+		}
+	}
+}
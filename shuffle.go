@@ -0,0 +1,41 @@
+package gotestdox
+
+import (
+	"strconv"
+	"strings"
+)
+
+// shuffleSeed returns the seed used to shuffle the order of the tests in a
+// package, if it was run with -shuffle, as printed by the test binary at the
+// start of its output, or false if there is no such seed in output.
+func shuffleSeed(output []string) (int64, bool) {
+	for _, line := range output {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "-test.shuffle ") {
+			continue
+		}
+		seed, err := strconv.ParseInt(strings.TrimPrefix(line, "-test.shuffle "), 10, 64)
+		if err != nil {
+			continue
+		}
+		return seed, true
+	}
+	return 0, false
+}
+
+// withShuffleSeed returns flags, with any -shuffle flag replaced by one
+// giving the specified seed, so that tests are run in the same order again.
+func withShuffleSeed(flags []string, seed int64) []string {
+	result := []string{}
+	for i := 0; i < len(flags); i++ {
+		name, _, hasValue := strings.Cut(strings.TrimLeft(flags[i], "-"), "=")
+		if name != "shuffle" {
+			result = append(result, flags[i])
+			continue
+		}
+		if !hasValue && i+1 < len(flags) {
+			i++
+		}
+	}
+	return append(result, "-shuffle="+strconv.FormatInt(seed, 10))
+}
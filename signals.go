@@ -0,0 +1,171 @@
+package gotestdox
+
+import (
+	"os"
+	"os/exec"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// interruptWait is how long [TestDoxer.ExecGoTest] waits for the output of
+// 'go test' to end, after passing on a signal, before killing it.
+const interruptWait = 3 * time.Second
+
+// interruption records the signal, if any, that interrupted a run.
+type interruption struct {
+	mu  sync.Mutex
+	sig os.Signal
+}
+
+func (i *interruption) set(sig os.Signal) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.sig = sig
+}
+
+// signal returns the name of the signal that interrupted the run, such as
+// "interrupt", or the empty string if it wasn't interrupted.
+func (i *interruption) signal() string {
+	if i == nil {
+		return ""
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if i.sig == nil {
+		return ""
+	}
+	return i.sig.String()
+}
+
+// processGroups is the set of 'go' commands running for a run, each in a
+// process group of its own (see [setProcessGroup]), to which
+// [TestDoxer.catchSignals] passes on any signal. Once a signal has been
+// passed on, it's passed on to any command added later, too. A nil
+// *processGroups holds no commands.
+type processGroups struct {
+	mu   sync.Mutex
+	cmds map[*exec.Cmd]bool
+	sig  os.Signal
+}
+
+// newProcessGroups returns a [*processGroups] holding the running cmds.
+func newProcessGroups(cmds ...*exec.Cmd) *processGroups {
+	g := &processGroups{cmds: map[*exec.Cmd]bool{}}
+	for _, cmd := range cmds {
+		g.cmds[cmd] = true
+	}
+	return g
+}
+
+// add adds the running cmd, passing on to it any signal already passed on
+// to the others.
+func (g *processGroups) add(cmd *exec.Cmd) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.cmds[cmd] = true
+	if g.sig != nil {
+		signalProcessGroup(cmd, g.sig)
+	}
+}
+
+// remove removes cmd, once it has finished.
+func (g *processGroups) remove(cmd *exec.Cmd) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.cmds, cmd)
+}
+
+// interrupted reports whether a signal has been passed on, in which case no
+// more commands should be started.
+func (g *processGroups) interrupted() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.sig != nil
+}
+
+// signal passes on sig to the process group of each command.
+func (g *processGroups) signal(sig os.Signal) {
+	if g == nil {
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.sig = sig
+	for cmd := range g.cmds {
+		signalProcessGroup(cmd, sig)
+	}
+}
+
+// kill kills all the processes in the process group of each command.
+func (g *processGroups) kill() {
+	if g == nil {
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for cmd := range g.cmds {
+		killProcessGroup(cmd)
+	}
+}
+
+// catchSignals arranges for SIGINT or SIGTERM to interrupt the run, rather
+// than kill gotestdox straight away, unless [WithSignalHandlingDisabled] was
+// supplied. The first signal is passed on to the commands in groups, if
+// it's not nil, and to the processes they started, so that the input ends,
+// and the results so far can be reported. If the input doesn't end within a
+// few seconds, the commands are killed. A second signal kills them at once.
+// When gotestdox is run as a command, by [Run], it also makes gotestdox exit
+// at once, with the status given by [ExitCode] for the first signal; a
+// program embedding gotestdox is left to decide for itself whether to exit.
+//
+// The signal is recorded in state, so that the run is reported as
+// interrupted. The caller must call the returned function once the run is
+// over.
+func (td *TestDoxer) catchSignals(state *runState, groups *processGroups) (stop func()) {
+	if td.signalsDisabled {
+		return func() {}
+	}
+	state.interruption = &interruption{}
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	done := make(chan struct{})
+	go func() {
+		var sig os.Signal
+		select {
+		case sig = <-signals:
+		case <-done:
+			return
+		}
+		state.interruption.set(sig)
+		exit := func() {
+			groups.kill()
+			if td.exitOnSecondSignal {
+				os.Exit(ExitCode(Summary{Interrupted: sig.String()}, nil))
+			}
+		}
+		if groups != nil {
+			groups.signal(sig)
+			wait, stop := after(td.clockOrSystem(), interruptWait)
+			defer stop()
+			select {
+			case <-signals:
+				exit()
+			case <-wait:
+				groups.kill()
+			case <-done:
+				return
+			}
+		}
+		select {
+		case <-signals:
+			exit()
+		case <-done:
+		}
+	}()
+	return func() {
+		signal.Stop(signals)
+		close(done)
+	}
+}
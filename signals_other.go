@@ -0,0 +1,30 @@
+//go:build !windows && !js && !wasip1
+
+package gotestdox
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup makes cmd start a new process group, so that a signal
+// can be passed on to it, and to any processes it starts, such as test
+// binaries, without also being delivered to gotestdox.
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// signalProcessGroup sends sig to the process group of the running cmd.
+func signalProcessGroup(cmd *exec.Cmd, sig os.Signal) {
+	if s, ok := sig.(syscall.Signal); ok && cmd.Process != nil {
+		syscall.Kill(-cmd.Process.Pid, s)
+	}
+}
+
+// killProcessGroup kills all the processes in the process group of cmd.
+func killProcessGroup(cmd *exec.Cmd) {
+	if cmd.Process != nil {
+		syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
@@ -0,0 +1,74 @@
+//go:build !windows && !js && !wasip1
+
+package gotestdox_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/bitfield/gotestdox"
+)
+
+// Not parallel, since the signal is sent to the whole test process.
+func TestExecGoTestPackages_PassesOnSignalToPackagesWithTimeout(t *testing.T) {
+	dir := t.TempDir()
+	log := filepath.Join(t.TempDir(), "child.log")
+	writeFiles(t, dir, map[string]string{
+		"go.mod":      "module example.com/m\n\ngo 1.18\n",
+		"a/a_test.go": fmt.Sprintf(childTest, log),
+		"b/b_test.go": "package b\n\nimport (\n\t\"testing\"\n\t\"time\"\n)\n\nfunc TestBHangs(t *testing.T) { time.Sleep(time.Hour) }\n",
+	})
+	// build the tests first, so that the child is running when the signal
+	// is sent
+	build := exec.Command("go", "test", "-count=1", "-run=^$", "./...")
+	build.Dir = dir
+	if out, err := build.CombinedOutput(); err != nil {
+		t.Fatalf("%v: %s", err, out)
+	}
+	td := gotestdox.NewTestDoxer(gotestdox.WithPackageTimeout(time.Minute))
+	td.Stdout = io.Discard
+	td.Stderr = io.Discard
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		td.ExecGoTestPackages(context.Background(), []string{"-C", dir, "-count=1", "./..."})
+	}()
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		if _, err := os.Stat(log); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("child never started")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	syscall.Kill(os.Getpid(), syscall.SIGINT)
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("want run interrupted, but it's still going")
+	}
+	if td.Summary.Interrupted != "interrupt" {
+		t.Errorf("want run marked as interrupted, got %q", td.Summary.Interrupted)
+	}
+	before, err := os.Stat(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(200 * time.Millisecond)
+	after, err := os.Stat(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after.Size() != before.Size() {
+		t.Error("want child of interrupted test stopped, but it's still running")
+	}
+}
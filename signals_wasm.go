@@ -0,0 +1,27 @@
+//go:build js || wasip1
+
+package gotestdox
+
+import (
+	"os"
+	"os/exec"
+)
+
+// setProcessGroup does nothing on WebAssembly, which has no process groups.
+// Starting processes isn't supported there in any case, but the package
+// still builds, so that [Prettify] can be used in a browser.
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// signalProcessGroup sends sig to the running cmd.
+func signalProcessGroup(cmd *exec.Cmd, sig os.Signal) {
+	if cmd.Process != nil {
+		cmd.Process.Signal(sig)
+	}
+}
+
+// killProcessGroup kills the running cmd.
+func killProcessGroup(cmd *exec.Cmd) {
+	if cmd.Process != nil {
+		cmd.Process.Kill()
+	}
+}
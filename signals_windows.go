@@ -0,0 +1,37 @@
+//go:build windows
+
+package gotestdox
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+
+	"golang.org/x/sys/windows"
+)
+
+// setProcessGroup makes cmd start a new process group, so that a console
+// control event can be sent to it, and to any processes it starts, without
+// also being delivered to gotestdox.
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: windows.CREATE_NEW_PROCESS_GROUP}
+}
+
+// signalProcessGroup sends a Ctrl-Break event, the nearest equivalent of a
+// signal, to the process group of the running cmd. This only works if cmd
+// shares gotestdox's console; otherwise, cmd is killed when the wait for
+// it to finish runs out.
+func signalProcessGroup(cmd *exec.Cmd, _ os.Signal) {
+	if cmd.Process != nil {
+		windows.GenerateConsoleCtrlEvent(windows.CTRL_BREAK_EVENT, uint32(cmd.Process.Pid))
+	}
+}
+
+// killProcessGroup kills cmd. Unlike on other platforms, any processes it
+// started are left running, but they exit once their output can no longer
+// be written.
+func killProcessGroup(cmd *exec.Cmd) {
+	if cmd.Process != nil {
+		cmd.Process.Kill()
+	}
+}
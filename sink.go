@@ -0,0 +1,584 @@
+package gotestdox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/fatih/color"
+)
+
+// ResultSink receives the results of a test run, as they are produced by
+// [TestDoxer.Filter]. Result is called once for each completed test, as soon
+// as it completes, and once for each completed package (with an empty Test
+// field), after all the results for its tests. Summary is called once, at the
+// end of the run.
+//
+// If either method returns an error, filtering stops, and the error is
+// reported.
+type ResultSink interface {
+	Result(Result) error
+	Summary(Summary) error
+}
+
+// Summary gives the overall results of a test run. Packages is the number of
+// packages with a final pass or fail status, and Passed, Failed, and Skipped
+// count the individual tests (including subtests) with each status.
+// VagueNames lists any tests reported by the vague name check (see
+// [WithVagueNameCheck]), and Modules gives the results for each module, in a
+// run started by [TestDoxer.ExecGoTestModules]. Aborted is true if the run was
+// stopped at the first failure (see [WithFailFast]), and Interrupted is the
+// name of the signal, such as "interrupt", that stopped the run, if any (see
+// [TestDoxer.ExecGoTest]). ShuffleSeeds gives the
+// seed used to shuffle the tests in each package run with -shuffle, so that
+// the same order can be replayed. Configurations gives the results for each
+// configuration, in a run started by [TestDoxer.ExecGoTestConfigurations].
+// Environment describes the toolchain used, if [WithEnvironment] was
+// supplied. NoCases lists any tests reported by the check enabled by
+// [WithNoCasesCheck], and Durations gives the distribution of test
+// durations, if [WithDurationHistogram] was supplied. SkipCategories gives
+// the number of skipped tests in each category, if [WithSkipCategories] was
+// supplied.
+//
+// Flaky is the number of tests that failed, but then passed when run again
+// later in the same stream, as when failed tests are retried, and
+// FlakyFailures is the number of times they failed, which are included in
+// Failed. TeardownFailures is the number of packages that failed although
+// none of their tests did (see [Result]), and Truncated is the number of
+// results whose output was cut short (see [WithOutputBudget]). Ignored is
+// the number of tests left out of the report by [WithIgnore]. BuildFailed is
+// true if any package failed to build, VetFailures is the number of packages
+// that failed vet (see [Result]), which don't count as build failures,
+// TimedOutPackages is the number of packages killed for taking too long (see
+// [WithPackageTimeout]), ExcludedPackages is the number of packages whose Go
+// files were all excluded by build constraints, which don't count towards
+// Packages, or as failures, NoTestFiles is the number of packages that had
+// no test files, and InternalError is true if the run was stopped by an
+// error in gotestdox itself, such as invalid input. See [ExitCode] for a way
+// to turn these into an exit status.
+//
+// CachedPackages is the number of packages whose results were replayed from
+// the 'go test' cache, and CachedPassed is the number of passing tests in
+// those packages, which are included in Passed. Stderr is what 'go test'
+// wrote to its standard error, a line at a time, if [WithCapturedStderr] was
+// supplied. AverageScore is the average [Score] of the sentences for the
+// tests, and LowestScores lists the lowest-scoring tests, if
+// [WithSentenceScores] was supplied. Coverage gives the coverage of each
+// function under test, if there was a cover profile (see
+// [WithCoverProfile]). UnknownActions is the number of events whose action
+// gotestdox didn't recognise (see [ActionUnknown]), which were ignored.
+// Elapsed is the total time taken by the packages, in seconds, and
+// ThresholdViolations describes each threshold set by [WithThresholds]
+// that the run exceeded. FailureGroups lists the sets of tests that failed
+// with identical output, if [WithFailureGroups] was supplied. OutputBytes is
+// the number of bytes of output written by all the packages in the run,
+// PackageOutputBytes gives the number for each package, and NoisiestTests
+// lists the tests that wrote the most, if [WithNoisiestTests] or
+// [WithLargeOutputMarker] was supplied, as appropriate. WallTime is how
+// long the run took, in seconds, from its first event to its last, going by
+// the time recorded in each event, or, for an event with none, the time by
+// the [Clock] when it arrived, whereas Elapsed adds up the time taken by
+// each package, even those that ran at the same time. Like Elapsed, it's
+// rounded to hundredths of a second.
+//
+// When marshalled to JSON, a Summary always includes the current
+// [SchemaVersion].
+type Summary struct {
+	Packages            int                    `json:"packages"`
+	Passed              int                    `json:"passed"`
+	Failed              int                    `json:"failed"`
+	Skipped             int                    `json:"skipped"`
+	VagueNames          []VagueName            `json:"vagueNames,omitempty"`
+	Modules             []ModuleSummary        `json:"modules,omitempty"`
+	Configurations      []ConfigurationSummary `json:"configurations,omitempty"`
+	Aborted             bool                   `json:"aborted,omitempty"`
+	Interrupted         string                 `json:"interrupted,omitempty"`
+	ShuffleSeeds        map[string]int64       `json:"shuffleSeeds,omitempty"`
+	Environment         *Environment           `json:"environment,omitempty"`
+	NoCases             []Result               `json:"noCases,omitempty"`
+	Durations           *DurationHistogram     `json:"durations,omitempty"`
+	SkipCategories      map[string]int         `json:"skipCategories,omitempty"`
+	Flaky               int                    `json:"flaky,omitempty"`
+	FlakyFailures       int                    `json:"flakyFailures,omitempty"`
+	BuildFailed         bool                   `json:"buildFailed,omitempty"`
+	VetFailures         int                    `json:"vetFailures,omitempty"`
+	TimedOutPackages    int                    `json:"timedOutPackages,omitempty"`
+	ExcludedPackages    int                    `json:"excludedPackages,omitempty"`
+	NoTestFiles         int                    `json:"noTestFiles,omitempty"`
+	InternalError       bool                   `json:"internalError,omitempty"`
+	CachedPackages      int                    `json:"cachedPackages,omitempty"`
+	CachedPassed        int                    `json:"cachedPassed,omitempty"`
+	TeardownFailures    int                    `json:"teardownFailures,omitempty"`
+	Truncated           int                    `json:"truncated,omitempty"`
+	Ignored             int                    `json:"ignored,omitempty"`
+	Stderr              []string               `json:"stderr,omitempty"`
+	AverageScore        float64                `json:"averageScore,omitempty"`
+	LowestScores        []ScoredName           `json:"lowestScores,omitempty"`
+	Coverage            []FunctionCoverage     `json:"coverage,omitempty"`
+	UnknownActions      int                    `json:"unknownActions,omitempty"`
+	Elapsed             float64                `json:"elapsed,omitempty"`
+	ThresholdViolations []string               `json:"thresholdViolations,omitempty"`
+	FailureGroups       []FailureGroup         `json:"failureGroups,omitempty"`
+	OutputBytes         int                    `json:"outputBytes,omitempty"`
+	PackageOutputBytes  map[string]int         `json:"packageOutputBytes,omitempty"`
+	NoisiestTests       []OutputSize           `json:"noisiestTests,omitempty"`
+	WallTime            float64                `json:"wallTime,omitempty"`
+}
+
+// MarshalJSON implements [json.Marshaler], adding the schema version to the
+// JSON representation of s.
+func (s Summary) MarshalJSON() ([]byte, error) {
+	type summary Summary
+	return json.Marshal(struct {
+		SchemaVersion int `json:"schemaVersion"`
+		summary
+	}{
+		SchemaVersion: SchemaVersion,
+		summary:       summary(s),
+	})
+}
+
+// add counts the completed test result r in s.
+func (s *Summary) add(r Result) {
+	switch r.Status {
+	case "pass":
+		s.Passed++
+	case "fail":
+		s.Failed++
+	case "skip":
+		s.Skipped++
+	}
+}
+
+// TextSink is a [ResultSink] that prints results as text, as described in
+// the documentation for [TestDoxer.Filter]. Results are buffered until the
+// end of each package, and then printed under the name of the package,
+// sorted alphabetically by sentence.
+type TextSink struct {
+	w        io.Writer
+	results  map[string][]Result
+	seeds    map[string]int64
+	noisy    []noisyTest
+	snippets *sourceSnippets
+	// fastHidden is the number of tests left out by WithMinDuration.
+	fastHidden int
+	// state is that of the run being reported, if any.
+	state *runState
+	config
+}
+
+// noisyTest is a passing test that produced unexpected output, as reported
+// by [WithNoisyTests].
+type noisyTest struct {
+	Result
+	lines []string
+}
+
+// NewTextSink returns a [*TextSink] that prints to w, configured by opts.
+func NewTextSink(w io.Writer, opts ...Option) *TextSink {
+	cfg := newConfig(opts)
+	return newTextSink(w, cfg, &runState{}, newPackageResolver(context.Background(), cfg.packageDirs, nil))
+}
+
+// newTextSink returns a [*TextSink] that prints to w, configured by cfg,
+// reporting the run with the given state, and finding the source of any
+// snippets it shows using packages.
+func newTextSink(w io.Writer, cfg config, state *runState, packages *packageResolver) *TextSink {
+	return &TextSink{
+		w:        w,
+		results:  map[string][]Result{},
+		seeds:    map[string]int64{},
+		snippets: newSourceSnippets(packages),
+		state:    state,
+		config:   cfg,
+	}
+}
+
+// Result buffers the result of a test, or, if r is a package result, prints
+// the package and the results of all its tests.
+func (s *TextSink) Result(r Result) error {
+	key := r.Configuration + " " + r.Package
+	if r.Test == "" {
+		if seed, ok := shuffleSeed(r.Output); ok {
+			s.seeds[r.Package] = seed
+		}
+		h, tests := heading(r), s.results[key]
+		if r.Excluded {
+			fmt.Fprintf(s.w, "%s: %s\n\n", h, color.New(color.Faint).Sprint("skipped ("+excludedMessage+")"))
+			delete(s.results, key)
+			return nil
+		}
+		if r.Cached {
+			h += " " + color.New(color.Faint).Sprint("(cached)")
+			if s.hideCachedTests {
+				tests = nil
+			}
+		}
+		s.printPackage(h, r, tests)
+		delete(s.results, key)
+		return nil
+	}
+	if r.Status == "skip" && s.fanOutThreshold == 0 {
+		return nil
+	}
+	s.results[key] = append(s.results[key], r)
+	if s.noisySymbol != "" && r.Status == "pass" {
+		if lines := noisyLines(r.Output, s.noisyLogs); len(lines) > 0 {
+			s.noisy = append(s.noisy, noisyTest{Result: r, lines: lines})
+		}
+	}
+	return nil
+}
+
+// Summary prints whatever is reported at the end of the run, as configured:
+// the results for any packages that hadn't finished, if the run was aborted
+// or interrupted; anything 'go test' wrote to standard error, if captured;
+// any tests that ran no cases (see [WithNoCasesCheck]); any noisy tests (see
+// [WithNoisyTests]); the number of fast tests hidden (see [WithMinDuration]);
+// the number of passing tests that were cached, if any; the reasons for any
+// skipped tests (see [WithSkipCategories]); the environment; the shuffle
+// seed for each package run with -shuffle; the results for each module, in
+// a multi-module run, and for each configuration, in a multi-configuration
+// run; the list of tests found by the vague name check; the sentence
+// scores; the coverage of the functions under test; the histogram of test
+// durations; the number of events with unknown actions, if any; and any
+// thresholds exceeded (see [WithThresholds]).
+func (s *TextSink) Summary(sum Summary) error {
+	if sum.Aborted || sum.Interrupted != "" {
+		keys := make([]string, 0, len(s.results))
+		for key := range s.results {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			tests := s.results[key]
+			s.printPackage(heading(tests[0]), Result{Package: tests[0].Package}, tests)
+			delete(s.results, key)
+		}
+		if sum.Aborted {
+			fmt.Fprintln(s.w, "(run aborted after first failure)")
+		}
+		if sum.Interrupted != "" {
+			fmt.Fprintf(s.w, "%d passed, %d failed, %d skipped (interrupted)\n", sum.Passed, sum.Failed, sum.Skipped)
+		}
+	}
+	if len(sum.Stderr) > 0 {
+		fmt.Fprintln(s.w, "go test stderr:")
+		for _, line := range sum.Stderr {
+			fmt.Fprintln(s.w, s.indent(line))
+		}
+	}
+	for _, r := range sum.NoCases {
+		fmt.Fprintf(s.w, "⚠ %s ran no cases (%s)\n", r.Sentence, r.Package)
+	}
+	if len(sum.NoisiestTests) > 0 {
+		fmt.Fprintf(s.w, "Noisiest tests (%s of output in total):\n", formatBytes(sum.OutputBytes))
+		for _, o := range sum.NoisiestTests {
+			fmt.Fprintln(s.w, o.String())
+		}
+	}
+	if len(s.noisy) > 0 {
+		fmt.Fprintln(s.w, "Noisy tests:")
+		for _, n := range s.noisy {
+			fmt.Fprintf(s.w, " %s: %s\n", n.Package, n.Sentence)
+			for _, line := range n.lines {
+				fmt.Fprintln(s.w, s.indent(line))
+			}
+		}
+	}
+	if s.fastHidden > 0 {
+		fmt.Fprintf(s.w, "… %s fast tests hidden (under %s)\n", formatCount(s.fastHidden), s.minDuration)
+	}
+	if sum.CachedPackages > 0 {
+		fmt.Fprintf(s.w, "%d passed (%d cached)\n", sum.Passed, sum.CachedPassed)
+	}
+	if len(sum.SkipCategories) > 0 {
+		fmt.Fprintln(s.w, skipBreakdown(sum.SkipCategories))
+	}
+	if sum.Environment != nil {
+		fmt.Fprintf(s.w, "environment: %s\n", sum.Environment)
+	}
+	pkgs := make([]string, 0, len(sum.ShuffleSeeds))
+	for pkg := range sum.ShuffleSeeds {
+		pkgs = append(pkgs, pkg)
+	}
+	sort.Strings(pkgs)
+	for _, pkg := range pkgs {
+		fmt.Fprintf(s.w, "shuffle seed: %d (%s)\n", sum.ShuffleSeeds[pkg], pkg)
+	}
+	for _, m := range sum.Modules {
+		if m.NoTests {
+			fmt.Fprintf(s.w, "%s: no tests\n", m.Dir)
+			continue
+		}
+		fmt.Fprintf(s.w, "%s: %d packages, %d passed, %d failed, %d skipped\n", m.Dir, m.Packages, m.Passed, m.Failed, m.Skipped)
+	}
+	for _, c := range sum.Configurations {
+		fmt.Fprintf(s.w, "(%s): %d packages, %d passed, %d failed, %d skipped\n", c.Name, c.Packages, c.Passed, c.Failed, c.Skipped)
+	}
+	if s.vagueCheck {
+		fmt.Fprintf(s.w, "Vague test names (fewer than %d behaviour words): %d\n", s.vagueMinWords, len(sum.VagueNames))
+		for _, v := range sum.VagueNames {
+			fmt.Fprintln(s.w, v.String())
+		}
+	}
+	if s.scoreCheck {
+		fmt.Fprintf(s.w, "Sentence score: %.1f average, lowest %d:\n", sum.AverageScore, len(sum.LowestScores))
+		for _, n := range sum.LowestScores {
+			fmt.Fprintln(s.w, n.String())
+		}
+	}
+	if len(sum.Coverage) > 0 {
+		renderCoverage(s.w, sum.Coverage)
+	}
+	if sum.Durations != nil {
+		sum.Durations.render(s.w)
+	}
+	if sum.UnknownActions > 0 {
+		fmt.Fprintf(s.w, "⚠ %d events with unknown actions ignored\n", sum.UnknownActions)
+	}
+	if len(sum.ThresholdViolations) > 0 {
+		fmt.Fprintln(s.w, "Thresholds exceeded:")
+		for _, v := range sum.ThresholdViolations {
+			fmt.Fprintf(s.w, " %s %s\n", color.RedString("x"), v)
+		}
+	}
+	return nil
+}
+
+// heading returns the heading for the package of r: its name, preceded by
+// the directory of its module in brackets, and followed by the name of its
+// configuration in parentheses, if any.
+func heading(r Result) string {
+	h := r.Package
+	if r.Module != "" {
+		h = "[" + r.Module + "] " + h
+	}
+	if r.Configuration != "" {
+		h += " (" + r.Configuration + ")"
+	}
+	return h
+}
+
+// printPackage prints the heading for the package with the result pkg,
+// followed by the results of its tests, sorted alphabetically by sentence,
+// and, if the package failed vet, the diagnostics, or, if it failed in
+// TestMain or teardown, the last lines of its output.
+func (s *TextSink) printPackage(heading string, pkg Result, tests []Result) {
+	fmt.Fprintf(s.w, "%s:\n", heading)
+	if pkg.Doc != "" {
+		fmt.Fprintln(s.w, " "+color.New(color.Italic).Sprint(pkg.Doc))
+	}
+	failed := failedLeaves(tests)
+	details := map[string][]Result{}
+	if s.fanOutThreshold > 0 {
+		tests, details = summariseFanOut(tests, s.fanOutThreshold, s.fanOutListSkips, s.config)
+	}
+	if s.collapseNumeric {
+		tests = collapseNumericSubtests(tests, s.config)
+	}
+	if !s.parentLines {
+		tests = dropHostParents(tests)
+	}
+	if s.minDuration > 0 {
+		tests = s.hideFast(tests)
+	}
+	sortBySentence(tests)
+	identical := map[string][]Result{}
+	grouped := map[string]bool{}
+	if s.failureGroups {
+		identical = failedIdentically(tests)
+		for _, same := range identical {
+			for _, r := range same {
+				grouped[r.Test] = true
+			}
+		}
+	}
+	for _, r := range tests {
+		if grouped[r.Test] {
+			continue
+		}
+		fmt.Fprintln(s.w, s.format(r))
+		switch {
+		case s.failureGroups && r.Status == "fail":
+			for _, line := range failureOutput(r) {
+				fmt.Fprintln(s.w, s.indent(line))
+			}
+		case s.examples && r.Status == "fail" && Classify(r.Test) == Example:
+			for _, line := range exampleMismatch(r.Output) {
+				fmt.Fprintln(s.w, s.indent(line))
+			}
+		}
+		if s.sourceSnippets && r.Status == "fail" {
+			for _, line := range s.snippets.snippet(r) {
+				fmt.Fprintln(s.w, s.indent(line))
+			}
+		}
+		if same := identical[r.Test]; len(same) > 0 {
+			fmt.Fprintln(s.w, s.indent(identicalMessage(len(same))))
+			for _, m := range same {
+				fmt.Fprintln(s.w, s.indent(s.format(m)))
+			}
+		}
+		listed := details[r.Test]
+		sortBySentence(listed)
+		for _, l := range listed {
+			fmt.Fprintln(s.w, "  "+s.format(l))
+		}
+	}
+	if len(pkg.Vet) > 0 {
+		fmt.Fprintf(s.w, " %s %s\n", color.RedString("x"), vetMessage)
+		for _, line := range pkg.Vet {
+			fmt.Fprintln(s.w, s.indent(line))
+		}
+	}
+	if pkg.TimedOut {
+		fmt.Fprintf(s.w, " %s timed out after %s\n", color.RedString("x"), roundDuration(time.Duration(pkg.Elapsed*float64(time.Second))))
+	}
+	if pkg.TeardownFailed {
+		fmt.Fprintf(s.w, " %s %s\n", color.RedString("x"), teardownMessage)
+		for _, line := range teardownOutput(pkg.Output) {
+			fmt.Fprintln(s.w, s.indent(line))
+		}
+	}
+	if s.rerunCommands && len(failed) > 0 {
+		flags := []string{}
+		if s.rerunFlags {
+			flags = goTestFlags(s.state.goTestArgs)
+		}
+		if seed, ok := s.seeds[pkg.Package]; ok {
+			flags = withShuffleSeed(flags, seed)
+		}
+		env := ""
+		if c, ok := s.state.findConfiguration(tests[0].Configuration); ok {
+			flags = append(append([]string{}, c.Args...), flags...)
+			env = envPrefix(c.Env)
+		}
+		fmt.Fprintln(s.w, "Rerun failed tests with:")
+		for _, name := range failed {
+			fmt.Fprintln(s.w, "  "+env+rerunCommand(pkg.Package, name, flags))
+		}
+	}
+	fmt.Fprintln(s.w)
+}
+
+// format returns the line to be printed for r, with its sentence in the
+// imperative mood, any punctuation or test name added to it, and any
+// slowdown since the previous run noted, as configured.
+func (s *TextSink) format(r Result) string {
+	if s.imperativeVerbs != nil {
+		r.Sentence = imperative(r.Sentence, s.subjectSep, s.imperativeVerbs)
+	}
+	r.Sentence = punctuate(r.Sentence, s.sentenceSuffix)
+	if s.activeDurations {
+		r.Elapsed = r.Active
+	}
+	if s.noisySymbol != "" && r.Status == "pass" && len(noisyLines(r.Output, s.noisyLogs)) > 0 {
+		r.Sentence += " " + s.noisySymbol
+	}
+	if s.largeOutput > 0 && r.OutputBytes > s.largeOutput {
+		r.Sentence += " " + s.largeOutputSymbol
+	}
+	if s.showNames || (s.showNamesFailed && r.Status == "fail") {
+		r.Sentence += "  " + color.New(color.Faint).Sprint("["+printable(r.Test)+"]")
+	}
+	if s.testLocations && r.File != "" {
+		r.Sentence += "  " + color.New(color.Faint).Sprintf("%s:%d", r.File, r.Line)
+	}
+	note := s.state.previous.regression(r, s.config)
+	if r.TimedOut {
+		note = strings.TrimSuffix(timedOutNote+", "+note, ", ")
+	}
+	return r.line(note)
+}
+
+// indent returns a line of captured test output, indented to be printed
+// under a test's sentence, with any tabs expanded to the width set by
+// [WithTabWidth]. Otherwise, since the line is indented by spaces, a tab
+// following some other indentation, as in the output of cmp.Diff, might not
+// reach the same column as one that doesn't, and the lines would no longer
+// line up.
+func (s *TextSink) indent(line string) string {
+	return "    " + expandTabs(line, s.tabWidth)
+}
+
+// expandTabs returns line with each tab replaced by enough spaces to reach
+// the next multiple of width runes, or unchanged if width is less than 1.
+func expandTabs(line string, width int) string {
+	if width < 1 || !strings.Contains(line, "\t") {
+		return line
+	}
+	var b strings.Builder
+	col := 0
+	for _, r := range line {
+		if r == '\t' {
+			n := width - col%width
+			b.WriteString(strings.Repeat(" ", n))
+			col += n
+			continue
+		}
+		b.WriteRune(r)
+		col++
+	}
+	return b.String()
+}
+
+func sortBySentence(tests []Result) {
+	sort.Slice(tests, func(i, j int) bool {
+		return tests[i].Sentence < tests[j].Sentence
+	})
+}
+
+// punctuate returns sentence with suffix appended, unless suffix is empty, or
+// sentence is a question, or already ends with punctuation or with a
+// code-like word (see [WithSentenceSuffix]).
+func punctuate(sentence, suffix string) string {
+	words := strings.Fields(sentence)
+	if suffix == "" || len(words) == 0 {
+		return sentence
+	}
+	if isQuestion(words) {
+		return sentence
+	}
+	if strings.ContainsAny(sentence[len(sentence)-1:], ".!?:;,") {
+		return sentence
+	}
+	if isCodeLike(words[len(words)-1]) {
+		return sentence
+	}
+	return sentence + suffix
+}
+
+// isQuestion reports whether the sentence with the given words asks whether
+// something is the case: that is, if it starts with "whether", either as its
+// first word or just after the function name, or contains "or not".
+func isQuestion(words []string) bool {
+	for i := 0; i < len(words) && i < 2; i++ {
+		if strings.EqualFold(words[i], "whether") {
+			return true
+		}
+	}
+	for i := 1; i < len(words); i++ {
+		if strings.EqualFold(words[i-1], "or") && strings.EqualFold(words[i], "not") {
+			return true
+		}
+	}
+	return false
+}
+
+// isCodeLike reports whether word looks like code rather than English: that
+// is, whether it contains anything other than letters, hyphens, and
+// apostrophes.
+func isCodeLike(word string) bool {
+	for _, r := range word {
+		if !unicode.IsLetter(r) && r != '-' && r != '\'' {
+			return true
+		}
+	}
+	return false
+}
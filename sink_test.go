@@ -0,0 +1,543 @@
+package gotestdox_test
+
+import (
+	"errors"
+	"regexp"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/bitfield/gotestdox"
+	"github.com/fatih/color"
+	"github.com/google/go-cmp/cmp"
+)
+
+type recordingSink struct {
+	results []gotestdox.Result
+	summary gotestdox.Summary
+	err     error
+}
+
+func (s *recordingSink) Result(r gotestdox.Result) error {
+	s.results = append(s.results, r)
+	return s.err
+}
+
+func (s *recordingSink) Summary(sum gotestdox.Summary) error {
+	s.summary = sum
+	return nil
+}
+
+var sinkInput = `{"Action":"run","Package":"p","Test":"TestB"}
+{"Action":"fail","Package":"p","Test":"TestB","Elapsed":0.5}
+{"Action":"pass","Package":"p","Test":"TestA/works_fine"}
+{"Action":"pass","Package":"p","Test":"TestA"}
+{"Action":"skip","Package":"p","Test":"TestC"}
+{"Action":"pass","Package":"p","Test":"ExampleIgnored"}
+{"Action":"fail","Package":"p","Elapsed":1.2}
+{"Action":"pass","Package":"q","Test":"TestD"}
+{"Action":"pass","Package":"q","Elapsed":0.1}`
+
+func TestFilter_WithSinkDeliversResultsAndSummaryToSink(t *testing.T) {
+	t.Parallel()
+	sink := &recordingSink{}
+	buf := new(strings.Builder)
+	td := gotestdox.NewTestDoxer(gotestdox.WithSink(sink))
+	td.Stdin = strings.NewReader(sinkInput)
+	td.Stdout = buf
+	td.Filter()
+	if buf.Len() > 0 {
+		t.Errorf("want no text output, got:\n%s", buf)
+	}
+	want := []gotestdox.Result{
+		{Package: "p", Test: "TestB", Sentence: "B", Status: "fail", Elapsed: 0.5},
+		{Package: "p", Test: "TestA/works_fine", Sentence: "A works fine", Status: "pass"},
+		{Package: "p", Test: "TestA", Sentence: "A", Status: "pass"},
+		{Package: "p", Test: "TestC", Sentence: "C", Status: "skip"},
+		{Package: "p", Status: "fail", Elapsed: 1.2},
+		{Package: "q", Test: "TestD", Sentence: "D", Status: "pass"},
+		{Package: "q", Status: "pass", Elapsed: 0.1},
+	}
+	if !cmp.Equal(want, sink.results) {
+		t.Error(cmp.Diff(want, sink.results))
+	}
+	wantSummary := gotestdox.Summary{
+		Packages: 2,
+		Passed:   3,
+		Failed:   1,
+		Skipped:  1,
+		Elapsed:  1.3,
+	}
+	if !cmp.Equal(wantSummary, sink.summary) {
+		t.Error(cmp.Diff(wantSummary, sink.summary))
+	}
+	if td.OK {
+		t.Error("want not ok")
+	}
+}
+
+func TestFilter_ProducesIdenticalOutputWithDefaultAndExplicitTextSink(t *testing.T) {
+	t.Parallel()
+	color.NoColor = true
+	want := `p:
+ ✔ A works fine (0.00s)
+ x B (0.50s)
+
+q:
+ ✔ D (0.00s)
+
+`
+	implicit := new(strings.Builder)
+	td := gotestdox.NewTestDoxer()
+	td.Stdin = strings.NewReader(sinkInput)
+	td.Stdout = implicit
+	td.Filter()
+	if want != implicit.String() {
+		t.Error(cmp.Diff(want, implicit.String()))
+	}
+	explicit := new(strings.Builder)
+	td = gotestdox.NewTestDoxer(gotestdox.WithSink(gotestdox.NewTextSink(explicit)))
+	td.Stdin = strings.NewReader(sinkInput)
+	td.Filter()
+	if want != explicit.String() {
+		t.Error(cmp.Diff(want, explicit.String()))
+	}
+}
+
+func TestFilter_StopsWithErrorWhenSinkReturnsError(t *testing.T) {
+	t.Parallel()
+	sink := &recordingSink{err: errors.New("oh no")}
+	stderr := new(strings.Builder)
+	td := gotestdox.NewTestDoxer(gotestdox.WithSink(sink))
+	td.Stdin = strings.NewReader(sinkInput)
+	td.Stderr = stderr
+	td.Filter()
+	if len(sink.results) != 1 {
+		t.Errorf("want filtering to stop after first result, got %d results", len(sink.results))
+	}
+	if !strings.Contains(stderr.String(), "oh no") {
+		t.Errorf("want error reported, got %q", stderr)
+	}
+	if td.OK {
+		t.Error("want not ok")
+	}
+}
+
+func TestTextSink_WithSentenceSuffixPunctuatesPlainSentencesOnly(t *testing.T) {
+	t.Parallel()
+	color.NoColor = true
+	input := `{"Action":"pass","Package":"p","Test":"TestParser/handles_empty_input"}
+{"Action":"pass","Package":"p","Test":"TestParser/returns_MAX_SIZE"}
+{"Action":"pass","Package":"p","Test":"TestParser/reports_whether_input_is_valid"}
+{"Action":"pass","Package":"p","Test":"TestParser/handles_input_or_not"}
+{"Action":"pass","Package":"p","Test":"TestParser/computes_add(2,3)=5"}
+{"Action":"pass","Package":"p","Test":"TestParser/is_done."}
+{"Action":"pass","Package":"p","Test":"TestWhetherItWorks"}
+{"Action":"pass","Package":"p","Elapsed":0.1}`
+	want := `p:
+ ✔ Parser computes add(2,3)=5 (0.00s)
+ ✔ Parser handles empty input. (0.00s)
+ ✔ Parser handles input or not (0.00s)
+ ✔ Parser is done. (0.00s)
+ ✔ Parser reports whether input is valid. (0.00s)
+ ✔ Parser returns MAX_SIZE (0.00s)
+ ✔ Whether it works (0.00s)
+
+`
+	buf := new(strings.Builder)
+	td := gotestdox.NewTestDoxer(gotestdox.WithSentenceSuffix("."))
+	td.Stdin = strings.NewReader(input)
+	td.Stdout = buf
+	td.Filter()
+	if want != buf.String() {
+		t.Error(cmp.Diff(want, buf.String()))
+	}
+}
+
+func TestTextSink_WithTestNamesShowsOriginalNameAfterEachSentence(t *testing.T) {
+	t.Parallel()
+	color.NoColor = true
+	want := `p:
+ ✔ A works fine  [TestA/works_fine] (0.00s)
+ x B  [TestB] (0.50s)
+
+q:
+ ✔ D  [TestD] (0.00s)
+
+`
+	buf := new(strings.Builder)
+	td := gotestdox.NewTestDoxer(gotestdox.WithTestNames())
+	td.Stdin = strings.NewReader(sinkInput)
+	td.Stdout = buf
+	td.Filter()
+	if want != buf.String() {
+		t.Error(cmp.Diff(want, buf.String()))
+	}
+}
+
+func TestTextSink_WithTestNamesOnFailureShowsOriginalNameOfFailuresOnly(t *testing.T) {
+	t.Parallel()
+	color.NoColor = true
+	want := `p:
+ ✔ A works fine (0.00s)
+ x B  [TestB] (0.50s)
+
+q:
+ ✔ D (0.00s)
+
+`
+	buf := new(strings.Builder)
+	td := gotestdox.NewTestDoxer(gotestdox.WithTestNamesOnFailure())
+	td.Stdin = strings.NewReader(sinkInput)
+	td.Stdout = buf
+	td.Filter()
+	if want != buf.String() {
+		t.Error(cmp.Diff(want, buf.String()))
+	}
+}
+
+func TestTextSink_WithRerunCommandsPrintsAnchoredCommandForEachFailingLeaf(t *testing.T) {
+	t.Parallel()
+	color.NoColor = true
+	input := `{"Action":"fail","Package":"example.com/parser","Test":"TestParser/rejects_BOM"}
+{"Action":"fail","Package":"example.com/parser","Test":"TestParser/it's_(a+b)"}
+{"Action":"pass","Package":"example.com/parser","Test":"TestParser/accepts_ASCII"}
+{"Action":"fail","Package":"example.com/parser","Test":"TestParser"}
+{"Action":"fail","Package":"example.com/parser","Test":"TestLexer"}
+{"Action":"fail","Package":"example.com/parser"}
+{"Action":"pass","Package":"example.com/ok","Test":"TestOK"}
+{"Action":"pass","Package":"example.com/ok"}`
+	want := `example.com/parser:
+ x Lexer (0.00s)
+ ✔ Parser accepts ASCII (0.00s)
+ x Parser it's (a+b) (0.00s)
+ x Parser rejects BOM (0.00s)
+Rerun failed tests with:
+  go test -run '^TestLexer$' example.com/parser
+  go test -run '^TestParser$/^it'\''s_\(a\+b\)$' example.com/parser
+  go test -run '^TestParser$/^rejects_BOM$' example.com/parser
+
+example.com/ok:
+ ✔ OK (0.00s)
+
+`
+	buf := new(strings.Builder)
+	td := gotestdox.NewTestDoxer(gotestdox.WithRerunCommands())
+	td.Stdin = strings.NewReader(input)
+	td.Stdout = buf
+	td.Filter()
+	if want != buf.String() {
+		t.Error(cmp.Diff(want, buf.String()))
+	}
+}
+
+func TestFilter_WithFailFastStopsAtFirstFailureAndPrintsBufferedResults(t *testing.T) {
+	t.Parallel()
+	color.NoColor = true
+	input := `{"Action":"pass","Package":"p","Test":"TestA"}
+{"Action":"fail","Package":"p","Test":"TestB","Elapsed":0.5}
+{"Action":"pass","Package":"p","Test":"TestC"}
+{"Action":"fail","Package":"p","Elapsed":1.2}
+{"Action":"pass","Package":"q","Test":"TestD"}
+{"Action":"pass","Package":"q","Elapsed":0.1}`
+	want := `p:
+ ✔ A (0.00s)
+ x B (0.50s)
+
+(run aborted after first failure)
+`
+	sink := &recordingSink{}
+	buf := new(strings.Builder)
+	td := gotestdox.NewTestDoxer(gotestdox.WithFailFast())
+	td.Stdin = strings.NewReader(input)
+	td.Stdout = buf
+	td.Filter()
+	if want != buf.String() {
+		t.Error(cmp.Diff(want, buf.String()))
+	}
+	if td.OK {
+		t.Error("want not ok")
+	}
+	td = gotestdox.NewTestDoxer(gotestdox.WithFailFast(), gotestdox.WithSink(sink))
+	td.Stdin = strings.NewReader(input)
+	td.Filter()
+	if !sink.summary.Aborted {
+		t.Error("want summary to record that the run was aborted")
+	}
+	if len(sink.results) != 2 {
+		t.Errorf("want 2 results before aborting, got %d", len(sink.results))
+	}
+}
+
+func TestTextSink_ReportsShuffleSeedAndAddsItToRerunCommands(t *testing.T) {
+	t.Parallel()
+	color.NoColor = true
+	input := `{"Action":"output","Package":"p","Output":"-test.shuffle 1699553123\n"}
+{"Action":"fail","Package":"p","Test":"TestA","Elapsed":0.1}
+{"Action":"output","Package":"p","Output":"FAIL\n"}
+{"Action":"fail","Package":"p","Elapsed":0.2}`
+	want := `p:
+ x A (0.10s)
+Rerun failed tests with:
+  go test -shuffle=1699553123 -run '^TestA$' p
+
+shuffle seed: 1699553123 (p)
+`
+	buf := new(strings.Builder)
+	td := gotestdox.NewTestDoxer(gotestdox.WithRerunCommands())
+	td.Stdin = strings.NewReader(input)
+	td.Stdout = buf
+	td.Filter()
+	if want != buf.String() {
+		t.Error(cmp.Diff(want, buf.String()))
+	}
+	sink := &recordingSink{}
+	td = gotestdox.NewTestDoxer(gotestdox.WithSink(sink))
+	td.Stdin = strings.NewReader(input)
+	td.Filter()
+	wantSeeds := map[string]int64{"p": 1699553123}
+	if !cmp.Equal(wantSeeds, sink.summary.ShuffleSeeds) {
+		t.Error(cmp.Diff(wantSeeds, sink.summary.ShuffleSeeds))
+	}
+}
+
+func TestFilter_WithEnvironmentRecordsToolchainInSummary(t *testing.T) {
+	t.Parallel()
+	sink := &recordingSink{}
+	td := gotestdox.NewTestDoxer(gotestdox.WithEnvironment(), gotestdox.WithSink(sink))
+	td.Stdin = strings.NewReader(sinkInput)
+	td.Filter()
+	env := sink.summary.Environment
+	if env == nil {
+		t.Fatal("want environment in summary")
+	}
+	if !strings.HasPrefix(env.GoVersion, "go") {
+		t.Errorf("want Go version, got %q", env.GoVersion)
+	}
+	if env.GOOS != runtime.GOOS || env.GOARCH != runtime.GOARCH {
+		t.Errorf("want %s/%s, got %s/%s", runtime.GOOS, runtime.GOARCH, env.GOOS, env.GOARCH)
+	}
+}
+
+func TestEnvironmentString_IncludesOnlyKnownSettings(t *testing.T) {
+	t.Parallel()
+	env := gotestdox.Environment{GoVersion: "go1.21.0", GOOS: "linux", GOARCH: "amd64"}
+	want := "go1.21.0, linux/amd64"
+	if got := env.String(); want != got {
+		t.Errorf("want %q, got %q", want, got)
+	}
+	env.Race = true
+	env.Tags = "integration"
+	env.Commit = "abc1234"
+	want = "go1.21.0, linux/amd64, race, tags integration, commit abc1234"
+	if got := env.String(); want != got {
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
+
+func TestTextSink_WithNoisyTestsMarksAndListsPassingTestsWithStrayOutput(t *testing.T) {
+	t.Parallel()
+	color.NoColor = true
+	input := `{"Action":"output","Package":"p","Test":"TestA","Output":"=== RUN   TestA\n"}
+{"Action":"output","Package":"p","Test":"TestA","Output":"debug: x = 3\n"}
+{"Action":"output","Package":"p","Test":"TestA","Output":"--- PASS: TestA (0.00s)\n"}
+{"Action":"pass","Package":"p","Test":"TestA"}
+{"Action":"output","Package":"p","Test":"TestB","Output":"=== RUN   TestB\n"}
+{"Action":"output","Package":"p","Test":"TestB","Output":"    b_test.go:12: checking\n"}
+{"Action":"output","Package":"p","Test":"TestB","Output":"        second line\n"}
+{"Action":"output","Package":"p","Test":"TestB","Output":"--- PASS: TestB (0.00s)\n"}
+{"Action":"pass","Package":"p","Test":"TestB"}
+{"Action":"output","Package":"p","Test":"TestC","Output":"stray\n"}
+{"Action":"fail","Package":"p","Test":"TestC"}
+{"Action":"fail","Package":"p"}`
+	tcs := []struct {
+		opts []gotestdox.Option
+		want string
+	}{
+		{
+			opts: []gotestdox.Option{gotestdox.WithNoisyTests("⚠")},
+			want: `p:
+ ✔ A ⚠ (0.00s)
+ ✔ B (0.00s)
+ x C (0.00s)
+
+Noisy tests:
+ p: A
+    debug: x = 3
+`,
+		},
+		{
+			opts: []gotestdox.Option{gotestdox.WithNoisyTests("⚠"), gotestdox.WithNoisyTestLogs()},
+			want: `p:
+ ✔ A ⚠ (0.00s)
+ ✔ B ⚠ (0.00s)
+ x C (0.00s)
+
+Noisy tests:
+ p: A
+    debug: x = 3
+ p: B
+        b_test.go:12: checking
+            second line
+`,
+		},
+	}
+	for _, tc := range tcs {
+		buf := new(strings.Builder)
+		td := gotestdox.NewTestDoxer(tc.opts...)
+		td.Stdin = strings.NewReader(input)
+		td.Stdout = buf
+		td.Filter()
+		if tc.want != buf.String() {
+			t.Error(cmp.Diff(tc.want, buf.String()))
+		}
+	}
+}
+
+func TestTextSink_OmitsParentLinesUnlessParentStatusDiffersFromSubtests(t *testing.T) {
+	t.Parallel()
+	color.NoColor = true
+	input := `{"Action":"pass","Package":"p","Test":"TestA/works"}
+{"Action":"pass","Package":"p","Test":"TestA"}
+{"Action":"pass","Package":"p","Test":"TestB/works"}
+{"Action":"fail","Package":"p","Test":"TestB"}
+{"Action":"fail","Package":"p","Test":"TestC/breaks"}
+{"Action":"pass","Package":"p","Test":"TestC/works"}
+{"Action":"fail","Package":"p","Test":"TestC"}
+{"Action":"fail","Package":"p"}`
+	tcs := []struct {
+		opts []gotestdox.Option
+		want string
+	}{
+		{
+			want: `p:
+ ✔ A works (0.00s)
+ x B (0.00s)
+ ✔ B works (0.00s)
+ x C breaks (0.00s)
+ ✔ C works (0.00s)
+
+`,
+		},
+		{
+			opts: []gotestdox.Option{gotestdox.WithParentLines()},
+			want: `p:
+ ✔ A (0.00s)
+ ✔ A works (0.00s)
+ x B (0.00s)
+ ✔ B works (0.00s)
+ x C (0.00s)
+ x C breaks (0.00s)
+ ✔ C works (0.00s)
+
+`,
+		},
+	}
+	for _, tc := range tcs {
+		buf := new(strings.Builder)
+		td := gotestdox.NewTestDoxer(tc.opts...)
+		td.Stdin = strings.NewReader(input)
+		td.Stdout = buf
+		td.Filter()
+		if tc.want != buf.String() {
+			t.Error(cmp.Diff(tc.want, buf.String()))
+		}
+	}
+}
+
+func TestTextSink_WithImperativeMoodDropsThirdPersonSFromKnownVerbs(t *testing.T) {
+	t.Parallel()
+	color.NoColor = true
+	input := `{"Action":"pass","Package":"p","Test":"TestParse_ReturnsErrorOnEmptyInput"}
+{"Action":"pass","Package":"p","Test":"TestParse_MatchesBraces"}
+{"Action":"pass","Package":"p","Test":"TestParse_TriesAgain"}
+{"Action":"pass","Package":"p","Test":"TestParse_PossessesState"}
+{"Action":"pass","Package":"p","Test":"TestParse_StatusStaysClean"}
+{"Action":"pass","Package":"p","Test":"TestParse_FrobnicatesInput"}
+{"Action":"pass","Package":"p","Test":"TestReturns"}
+{"Action":"pass","Package":"p"}`
+	want := `p:
+ ✔ Parse — frobnicate input (0.00s)
+ ✔ Parse — match braces (0.00s)
+ ✔ Parse — possesses state (0.00s)
+ ✔ Parse — return error on empty input (0.00s)
+ ✔ Parse — status stays clean (0.00s)
+ ✔ Parse — try again (0.00s)
+ ✔ Returns (0.00s)
+
+`
+	buf := new(strings.Builder)
+	td := gotestdox.NewTestDoxer(
+		gotestdox.WithImperativeMood("frobnicates"),
+		gotestdox.WithSubjectSeparator(" — "),
+		gotestdox.WithOnResult(func(r gotestdox.Result) {
+			want := "Parse — returns error on empty input"
+			if r.Test == "TestParse_ReturnsErrorOnEmptyInput" && r.Sentence != want {
+				t.Errorf("want result sentence %q unchanged, got %q", want, r.Sentence)
+			}
+		}),
+	)
+	td.Stdin = strings.NewReader(input)
+	td.Stdout = buf
+	td.Filter()
+	if want != buf.String() {
+		t.Error(cmp.Diff(want, buf.String()))
+	}
+}
+
+func TestFilter_WithSkipCategoriesBreaksDownSkippedTestsByReason(t *testing.T) {
+	t.Parallel()
+	color.NoColor = true
+	input := `{"Action":"output","Package":"p","Test":"TestA","Output":"    a_test.go:5: skipping in short mode\n"}
+{"Action":"skip","Package":"p","Test":"TestA"}
+{"Action":"output","Package":"p","Test":"TestB","Output":"    b_test.go:5: skipping test in short mode.\n"}
+{"Action":"skip","Package":"p","Test":"TestB"}
+{"Action":"output","Package":"p","Test":"TestC","Output":"    c_test.go:5: requires network access\n"}
+{"Action":"skip","Package":"p","Test":"TestC"}
+{"Action":"output","Package":"p","Test":"TestD","Output":"    d_test.go:5: needs Docker\n"}
+{"Action":"skip","Package":"p","Test":"TestD"}
+{"Action":"skip","Package":"p","Test":"TestE"}
+{"Action":"pass","Package":"p","Test":"TestF"}
+{"Action":"pass","Package":"p"}`
+	want := `p:
+ ✔ F (0.00s)
+
+5 skipped: 2 short-mode, 1 docker, 1 network, 1 other
+`
+	sink := &recordingSink{}
+	buf := new(strings.Builder)
+	opts := []gotestdox.Option{gotestdox.WithSkipCategory("docker", regexp.MustCompile(`(?i)docker`))}
+	td := gotestdox.NewTestDoxer(opts...)
+	td.Stdin = strings.NewReader(input)
+	td.Stdout = buf
+	td.Filter()
+	if want != buf.String() {
+		t.Error(cmp.Diff(want, buf.String()))
+	}
+	td = gotestdox.NewTestDoxer(append(opts, gotestdox.WithSink(sink))...)
+	td.Stdin = strings.NewReader(input)
+	td.Filter()
+	wantCategories := map[string]int{"short-mode": 2, "network": 1, "docker": 1, "other": 1}
+	if !cmp.Equal(wantCategories, sink.summary.SkipCategories) {
+		t.Error(cmp.Diff(wantCategories, sink.summary.SkipCategories))
+	}
+}
+
+func TestFilter_WithSkipCategoriesIgnoresTestNameInGoTestOutput(t *testing.T) {
+	t.Parallel()
+	input := `{"Action":"output","Package":"p","Test":"TestNetworkRetry","Output":"=== RUN   TestNetworkRetry\n"}
+{"Action":"output","Package":"p","Test":"TestNetworkRetry","Output":"    n_test.go:5: skipping in short mode\n"}
+{"Action":"output","Package":"p","Test":"TestNetworkRetry","Output":"--- SKIP: TestNetworkRetry (0.00s)\n"}
+{"Action":"skip","Package":"p","Test":"TestNetworkRetry"}
+{"Action":"output","Package":"p","Test":"TestOfflineCache","Output":"--- SKIP: TestOfflineCache (0.00s)\n"}
+{"Action":"skip","Package":"p","Test":"TestOfflineCache"}
+{"Action":"pass","Package":"p"}`
+	sink := &recordingSink{}
+	td := gotestdox.NewTestDoxer(gotestdox.WithSink(sink), gotestdox.WithSkipCategories())
+	td.Stdin = strings.NewReader(input)
+	td.Filter()
+	want := map[string]int{"short-mode": 1, "other": 1}
+	if !cmp.Equal(want, sink.summary.SkipCategories) {
+		t.Error(cmp.Diff(want, sink.summary.SkipCategories))
+	}
+}
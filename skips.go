@@ -0,0 +1,76 @@
+package gotestdox
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// skipRule assigns skipped tests whose output matches pattern to the
+// category name (see [WithSkipCategories]).
+type skipRule struct {
+	name    string
+	pattern *regexp.Regexp
+}
+
+// defaultSkipRules are the rules used by [WithSkipCategories] to categorise
+// skipped tests, after any supplied using [WithSkipCategory].
+var defaultSkipRules = []skipRule{
+	{"short-mode", regexp.MustCompile(`(?i)short mode|testing\.Short|-short`)},
+	{"network", regexp.MustCompile(`(?i)network|internet|connectivity|offline`)},
+	{"build-tag", regexp.MustCompile(`(?i)build tag|-tags|requires tag`)},
+}
+
+// otherSkips is the category of skipped tests that match no rule.
+const otherSkips = "other"
+
+// skipCategory returns the name of the category of the skipped test r,
+// according to the first of rules whose pattern matches its output, or
+// "other" if none does. The lines printed by 'go test' itself, such as
+// "--- SKIP: TestNetworkRetry", are left out, so that only the reason for
+// the skip is matched, and not the name of the test.
+func skipCategory(r Result, rules []skipRule) string {
+	lines := []string{}
+	for _, out := range r.Output {
+		for _, line := range strings.Split(strings.TrimSuffix(out, "\n"), "\n") {
+			if !isGoTestBoilerplate(line) {
+				lines = append(lines, line)
+			}
+		}
+	}
+	output := strings.Join(lines, "\n")
+	for _, rule := range rules {
+		if rule.pattern.MatchString(output) {
+			return rule.name
+		}
+	}
+	return otherSkips
+}
+
+// skipBreakdown returns a line summarising the number of skipped tests in
+// each of the given categories, largest first, such as "14 skipped: 9
+// short-mode, 3 network, 2 other". Uncategorised tests always come last.
+func skipBreakdown(categories map[string]int) string {
+	names := make([]string, 0, len(categories))
+	total := 0
+	for name, n := range categories {
+		names = append(names, name)
+		total += n
+	}
+	sort.Slice(names, func(i, j int) bool {
+		a, b := names[i], names[j]
+		if (a == otherSkips) != (b == otherSkips) {
+			return b == otherSkips
+		}
+		if categories[a] != categories[b] {
+			return categories[a] > categories[b]
+		}
+		return a < b
+	})
+	counts := make([]string, len(names))
+	for i, name := range names {
+		counts[i] = fmt.Sprintf("%d %s", categories[name], name)
+	}
+	return fmt.Sprintf("%d skipped: %s", total, strings.Join(counts, ", "))
+}
@@ -0,0 +1,134 @@
+package gotestdox
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// failureLocation matches a line of test output giving the file name and
+// line number of a failure, such as "    parse_test.go:42: got 3, want 4",
+// capturing the file name and line number.
+var failureLocation = regexp.MustCompile(`^\s+([^\s:]+\.go):(\d+): `)
+
+// generatedFile matches the comment marking a generated Go source file.
+var generatedFile = regexp.MustCompile(`(?m)^// Code generated .* DO NOT EDIT\.$`)
+
+// snippetContext is the number of lines shown before and after the failing
+// line in a source snippet (see [WithSourceSnippets]).
+const snippetContext = 2
+
+// sourceSnippets finds and caches the test source shown for failures by
+// [WithSourceSnippets]. Each file is read at most once, and each package
+// looked up at most once.
+type sourceSnippets struct {
+	files    map[string][]string
+	packages *packageResolver
+}
+
+func newSourceSnippets(packages *packageResolver) *sourceSnippets {
+	return &sourceSnippets{
+		files:    map[string][]string{},
+		packages: packages,
+	}
+}
+
+// snippet returns the lines of a short excerpt from the source of the test
+// r, around the first location given in its output, with the failing line
+// marked. If there's no location, or the file can't be found in the
+// package's module, or isn't a hand-written Go file, or has no such line,
+// snippet returns nil.
+func (s *sourceSnippets) snippet(r Result) []string {
+	file, line := "", 0
+	for _, out := range r.Output {
+		if m := failureLocation.FindStringSubmatch(out); m != nil {
+			file = m[1]
+			line, _ = strconv.Atoi(m[2])
+			break
+		}
+	}
+	if file == "" {
+		return nil
+	}
+	path := s.resolve(r.Package, r.Module, file)
+	if path == "" {
+		return nil
+	}
+	lines := s.read(path)
+	if line < 1 || line > len(lines) {
+		return nil
+	}
+	first, last := line-snippetContext, line+snippetContext
+	if first < 1 {
+		first = 1
+	}
+	if last > len(lines) {
+		last = len(lines)
+	}
+	width := len(strconv.Itoa(last))
+	snippet := []string{filepath.Base(path) + ":"}
+	for n := first; n <= last; n++ {
+		marker := " "
+		if n == line {
+			marker = ">"
+		}
+		text := fmt.Sprintf("%s %*d | %s", marker, width, n, lines[n-1])
+		snippet = append(snippet, strings.TrimRight(text, " "))
+	}
+	return snippet
+}
+
+// resolve returns the path of the source file named in the output of a
+// test in pkg, tested in the given module directory, if any, or the empty
+// string if it's not within the package's module. An absolute path, as
+// printed by 'go test -fullpath', is used as it is, with any symlinks
+// resolved, as they are in the module directory; any other path is taken to
+// be relative to the package directory.
+func (s *sourceSnippets) resolve(pkg, module, file string) string {
+	dirs := s.packages.resolve(pkg, module)
+	if dirs.dir == "" || dirs.module == "" {
+		return ""
+	}
+	path := filepath.Join(dirs.dir, file)
+	if filepath.IsAbs(file) {
+		path = evalSymlinks(file)
+	}
+	rel, err := filepath.Rel(dirs.module, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return ""
+	}
+	return path
+}
+
+// read returns the lines of the file at path, or nil if it can't be read,
+// or is a generated file.
+func (s *sourceSnippets) read(path string) []string {
+	if lines, ok := s.files[path]; ok {
+		return lines
+	}
+	var lines []string
+	data, err := os.ReadFile(path)
+	if err == nil && !generatedFile.Match(data) {
+		lines = strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	}
+	s.files[path] = lines
+	return lines
+}
+
+// moduleRoot returns the closest directory to dir, including dir itself,
+// that contains a go.mod file, or the empty string if there is none.
+func moduleRoot(dir string) string {
+	for {
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
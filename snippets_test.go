@@ -0,0 +1,68 @@
+package gotestdox_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bitfield/gotestdox"
+	"github.com/fatih/color"
+	"github.com/google/go-cmp/cmp"
+)
+
+// failureAt returns 'go test -json' events for a failing test whose output
+// gives the location path:line.
+func failureAt(test, path string, line int) string {
+	out, _ := json.Marshal(fmt.Sprintf("    %s:%d: got 3, want 4\n", path, line))
+	return fmt.Sprintf(`{"Action":"output","Package":"p","Test":%q,"Output":%s}
+{"Action":"fail","Package":"p","Test":%q}
+`, test, out, test)
+}
+
+func TestTextSink_WithSourceSnippetsShowsSourceAroundFailingLine(t *testing.T) {
+	t.Parallel()
+	color.NoColor = true
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"mod/go.mod":          "module example.com/p\n\ngo 1.18\n",
+		"mod/p_test.go":       "package p\n\nimport \"testing\"\n\nfunc TestSum(t *testing.T) {\n\tt.Error(\"got 3, want 4\")\n}\n",
+		"mod/gen_test.go":     "// Code generated by gen. DO NOT EDIT.\n\npackage p\n\nfunc TestGen(t *testing.T) {\n\tt.Fail()\n}\n",
+		"outside/notes_go.go": "line 1\nline 2\nline 3\n",
+		"other/go.mod":        "module example.com/other\n\ngo 1.18\n",
+		"other/secret.go":     "line 1\nline 2\nline 3\n",
+	})
+	input := failureAt("TestSum", filepath.Join(dir, "mod", "p_test.go"), 6) +
+		failureAt("TestGen", filepath.Join(dir, "mod", "gen_test.go"), 6) +
+		failureAt("TestOutside", filepath.Join(dir, "outside", "notes_go.go"), 2) +
+		failureAt("TestInOtherModule", filepath.Join(dir, "other", "secret.go"), 2) +
+		failureAt("TestOutOfRange", filepath.Join(dir, "mod", "p_test.go"), 99) +
+		failureAt("TestMissing", filepath.Join(dir, "mod", "missing_test.go"), 1) +
+		`{"Action":"fail","Package":"p"}`
+	want := `p:
+ x Gen (0.00s)
+ x In other module (0.00s)
+ x Missing (0.00s)
+ x Out of range (0.00s)
+ x Outside (0.00s)
+ x Sum (0.00s)
+    p_test.go:
+      4 |
+      5 | func TestSum(t *testing.T) {
+    > 6 |   t.Error("got 3, want 4")
+      7 | }
+
+`
+	buf := new(strings.Builder)
+	td := gotestdox.NewTestDoxer(
+		gotestdox.WithSourceSnippets(),
+		gotestdox.WithPackageDirs(map[string]string{"p": filepath.Join(dir, "mod")}),
+	)
+	td.Stdin = strings.NewReader(input)
+	td.Stdout = buf
+	td.Filter()
+	if want != buf.String() {
+		t.Error(cmp.Diff(want, buf.String()))
+	}
+}
@@ -0,0 +1,113 @@
+package gotestdox
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-isatty"
+)
+
+// stallReport keeps track of the tests that have started but not yet
+// completed, so that they can be listed when no events have arrived for a
+// while (see [WithStallReport]).
+type stallReport struct {
+	w       io.Writer
+	tty     bool
+	shown   bool
+	last    time.Time
+	started map[string]startedTest
+	clock   Clock
+}
+
+type startedTest struct {
+	pkg, test string
+	at        time.Time
+}
+
+func newStallReport(w io.Writer, clock Clock) *stallReport {
+	f, ok := w.(*os.File)
+	return &stallReport{
+		w:       w,
+		tty:     ok && isatty.IsTerminal(f.Fd()),
+		last:    clock.Now(),
+		started: map[string]startedTest{},
+		clock:   clock,
+	}
+}
+
+// record updates the set of started tests from event, which has the
+// timestamp t. If t is zero, the time the event arrived is used instead. Any
+// status line currently shown on a terminal is cleared first.
+func (s *stallReport) record(event Event, t time.Time) {
+	s.clear()
+	s.last = s.clock.Now()
+	if t.IsZero() {
+		t = s.last
+	}
+	key := event.Package + " " + event.Test
+	switch {
+	case event.Kind() == ActionRun:
+		s.started[key] = startedTest{pkg: event.Package, test: event.Test, at: t}
+	case event.IsPackageResult():
+		for k, st := range s.started {
+			if st.pkg == event.Package {
+				delete(s.started, k)
+			}
+		}
+	case event.completes():
+		delete(s.started, key)
+	}
+}
+
+// clear removes the status line, if one is currently shown on a terminal.
+func (s *stallReport) clear() {
+	if s.shown {
+		fmt.Fprint(s.w, "\r\033[K")
+		s.shown = false
+	}
+}
+
+// report prints a status line, as of now, saying how long it has been since
+// the last event and listing the tests still running, longest running
+// first. On a terminal, the line is left in place to be cleared by the next
+// event; otherwise, it ends with a newline.
+func (s *stallReport) report(now time.Time) {
+	quiet := roundDuration(now.Sub(s.last))
+	tests := make([]startedTest, 0, len(s.started))
+	for _, st := range s.started {
+		tests = append(tests, st)
+	}
+	sort.Slice(tests, func(i, j int) bool {
+		if !tests[i].at.Equal(tests[j].at) {
+			return tests[i].at.Before(tests[j].at)
+		}
+		return tests[i].pkg+" "+tests[i].test < tests[j].pkg+" "+tests[j].test
+	})
+	running := make([]string, len(tests))
+	for i, st := range tests {
+		running[i] = fmt.Sprintf("%s %s (%s)", st.pkg, st.test, roundDuration(now.Sub(st.at)))
+	}
+	line := fmt.Sprintf("no events for %s; running: %s", quiet, strings.Join(running, ", "))
+	if len(running) == 0 {
+		line = fmt.Sprintf("no events for %s; no tests running", quiet)
+	}
+	if s.tty {
+		fmt.Fprint(s.w, "\r\033[K"+line)
+		s.shown = true
+		return
+	}
+	fmt.Fprintln(s.w, line)
+}
+
+// roundDuration rounds d to the nearest second, or to the nearest 10ms if it's
+// shorter than a second, for display.
+func roundDuration(d time.Duration) time.Duration {
+	if d < time.Second {
+		return d.Round(10 * time.Millisecond)
+	}
+	return d.Round(time.Second)
+}
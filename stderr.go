@@ -0,0 +1,64 @@
+package gotestdox
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// stderrPrefix is the prefix added to each line forwarded from the standard
+// error of 'go test', if [WithPrefixedStderr] is supplied.
+const stderrPrefix = "stderr│ "
+
+// maxStderrLine is the longest line of standard error from 'go test' that
+// can be forwarded or captured intact.
+const maxStderrLine = 1024 * 1024
+
+// lockedWriter is a writer that holds mu for the duration of each write, so
+// that writes from different goroutines sharing mu are never interleaved.
+type lockedWriter struct {
+	mu *sync.Mutex
+	w  io.Writer
+}
+
+func (l lockedWriter) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.w.Write(p)
+}
+
+// childStderr reads the standard error of 'go test', a line at a time,
+// either forwarding each complete line, or capturing it for the report (see
+// [WithCapturedStderr]). Done is closed once all the output has been read.
+type childStderr struct {
+	lines []string
+	done  chan struct{}
+}
+
+// readStderr starts reading r, forwarding each line to w while holding mu,
+// or capturing it, if [WithCapturedStderr] was supplied.
+func (td *TestDoxer) readStderr(r io.Reader, mu *sync.Mutex, w io.Writer) *childStderr {
+	c := &childStderr{done: make(chan struct{})}
+	prefix := ""
+	if td.prefixStderr {
+		prefix = stderrPrefix
+	}
+	go func() {
+		defer close(c.done)
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(nil, maxStderrLine)
+		for scanner.Scan() {
+			if td.captureStderr {
+				c.lines = append(c.lines, scanner.Text())
+				continue
+			}
+			mu.Lock()
+			fmt.Fprintln(w, prefix+scanner.Text())
+			mu.Unlock()
+		}
+		// don't leave 'go test' blocked writing a line that's too long
+		io.Copy(io.Discard, r)
+	}()
+	return c
+}
@@ -0,0 +1,106 @@
+package gotestdox_test
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/bitfield/gotestdox"
+	"github.com/fatih/color"
+	"github.com/google/go-cmp/cmp"
+)
+
+// fakeGoTest is a stand-in for the go command which writes its standard
+// error in pieces, interleaved with its JSON output.
+const fakeGoTest = `#!/bin/sh
+printf 'go: downloading exa' >&2
+echo '{"Action":"run","Package":"p","Test":"TestA"}'
+sleep 0.1
+echo '{"Action":"pass","Package":"p","Test":"TestA","Elapsed":0}'
+printf 'mple.com/dep v1.0.0\n# p\nvet: p_test.go:1: bogus' >&2
+sleep 0.1
+printf '\n' >&2
+echo '{"Action":"pass","Package":"p","Elapsed":0}'
+`
+
+// useFakeGoTest puts a fake go command first in the PATH for the rest of
+// the test.
+func useFakeGoTest(t *testing.T) {
+	t.Helper()
+	useFakeGo(t, fakeGoTest)
+}
+
+// useFakeGo puts a go command running the given shell script first in the
+// PATH for the rest of the test.
+func useFakeGo(t *testing.T, script string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake go command is a shell script")
+	}
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, "go"), []byte(script), 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+func TestExecGoTest_ForwardsStderrOnlyBetweenLinesOfReport(t *testing.T) {
+	useFakeGoTest(t)
+	color.NoColor = true
+	out := new(strings.Builder)
+	td := gotestdox.NewTestDoxer(gotestdox.WithPrefixedStderr())
+	td.Stdout, td.Stderr = out, out
+	td.ExecGoTest(nil)
+	if !td.OK {
+		t.Fatalf("want OK, got output:\n%s", out)
+	}
+	lines := map[string]bool{}
+	for _, line := range strings.Split(out.String(), "\n") {
+		lines[line] = true
+	}
+	for _, want := range []string{
+		"p:",
+		" ✔ A (0.00s)",
+		"stderr│ go: downloading example.com/dep v1.0.0",
+		"stderr│ # p",
+		"stderr│ vet: p_test.go:1: bogus",
+	} {
+		if !lines[want] {
+			t.Errorf("want line %q, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestExecGoTest_PrintsCapturedStderrAfterReport(t *testing.T) {
+	useFakeGoTest(t)
+	color.NoColor = true
+	stdout, stderr := new(strings.Builder), new(strings.Builder)
+	td := gotestdox.NewTestDoxer(gotestdox.WithCapturedStderr())
+	td.Stdout, td.Stderr = stdout, stderr
+	td.ExecGoTest(nil)
+	want := `p:
+ ✔ A (0.00s)
+
+go test stderr:
+    go: downloading example.com/dep v1.0.0
+    # p
+    vet: p_test.go:1: bogus
+`
+	if want != stdout.String() {
+		t.Error(cmp.Diff(want, stdout.String()))
+	}
+	if stderr.Len() > 0 {
+		t.Errorf("unexpected stderr: %q", stderr)
+	}
+	wantStderr := []string{
+		"go: downloading example.com/dep v1.0.0",
+		"# p",
+		"vet: p_test.go:1: bogus",
+	}
+	if !cmp.Equal(wantStderr, td.Summary.Stderr) {
+		t.Error(cmp.Diff(wantStderr, td.Summary.Stderr))
+	}
+}
@@ -0,0 +1,66 @@
+package gotestdox
+
+import "strings"
+
+// testStatusPrefixes begin the lines that 'go test' prints, indented for a
+// subtest, as each test starts, pauses, continues, or finishes.
+var testStatusPrefixes = []string{
+	"=== RUN ", "=== PAUSE ", "=== CONT ", "=== NAME ",
+	"--- PASS: ", "--- FAIL: ", "--- SKIP: ",
+}
+
+// teardownMessage is printed by a [TextSink] for a package that failed
+// although none of its tests did.
+const teardownMessage = "package failed in TestMain / teardown"
+
+// teardownLines is the maximum number of lines of package output shown by a
+// [TextSink] for a package that failed in TestMain or teardown.
+const teardownLines = 10
+
+// isTeardownFailure reports whether the package result r shows that the
+// package failed even though none of its tests did, given the number of its
+// tests that failed, as when TestMain, or a goroutine leak check such as
+// goleak's, fails the package after all its tests have passed. A package
+// that failed to build, or failed vet, doesn't count.
+func isTeardownFailure(r Result, failedTests int) bool {
+	return r.Status == "fail" && failedTests == 0 && !isBuildFailure(r) && len(r.Vet) == 0 && !r.TimedOut
+}
+
+// teardownOutput returns the last few lines of the package output, leaving
+// out the lines printed by 'go test' itself, such as "FAIL" and "exit status
+// 1", so that what's left is whatever TestMain or the teardown printed.
+func teardownOutput(output []string) []string {
+	lines := []string{}
+	for _, out := range output {
+		for _, line := range strings.Split(strings.TrimSuffix(out, "\n"), "\n") {
+			if !isGoTestBoilerplate(line) {
+				lines = append(lines, line)
+			}
+		}
+	}
+	if len(lines) > teardownLines {
+		lines = lines[len(lines)-teardownLines:]
+	}
+	return lines
+}
+
+// isGoTestBoilerplate reports whether line is one of the lines that 'go test'
+// prints at the end of a package's output, or as a test starts or finishes,
+// such as "=== RUN   TestA" or "--- SKIP: TestA (0.00s)", or a blank line.
+func isGoTestBoilerplate(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	for _, prefix := range testStatusPrefixes {
+		if strings.HasPrefix(trimmed, prefix) {
+			return true
+		}
+	}
+	switch {
+	case trimmed == "",
+		line == "PASS", line == "FAIL",
+		strings.HasPrefix(line, "FAIL\t"),
+		strings.HasPrefix(line, "ok  \t"),
+		strings.HasPrefix(line, "exit status "):
+		return true
+	}
+	return false
+}
@@ -0,0 +1,106 @@
+package gotestdox_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bitfield/gotestdox"
+	"github.com/fatih/color"
+	"github.com/google/go-cmp/cmp"
+)
+
+var teardownInput = `{"Action":"run","Package":"p","Test":"TestA"}
+{"Action":"output","Package":"p","Test":"TestA","Output":"=== RUN   TestA\n"}
+{"Action":"output","Package":"p","Test":"TestA","Output":"--- PASS: TestA (0.00s)\n"}
+{"Action":"pass","Package":"p","Test":"TestA","Elapsed":0}
+{"Action":"output","Package":"p","Output":"PASS\n"}
+{"Action":"output","Package":"p","Output":"goleak: Errors on successful test run: found unexpected goroutines:\n"}
+{"Action":"output","Package":"p","Output":"[Goroutine 7 in state select, with p.poll on top of the stack:\n"}
+{"Action":"output","Package":"p","Output":"]\n"}
+{"Action":"output","Package":"p","Output":"FAIL\tp\t0.412s\n"}
+{"Action":"fail","Package":"p","Elapsed":0.412}
+{"Action":"fail","Package":"q","Test":"TestB"}
+{"Action":"output","Package":"q","Output":"FAIL\n"}
+{"Action":"output","Package":"q","Output":"FAIL\tq\t0.010s\n"}
+{"Action":"fail","Package":"q","Elapsed":0.01}
+{"Action":"output","Package":"r","Output":"FAIL\tr [build failed]\n"}
+{"Action":"fail","Package":"r","Elapsed":0}`
+
+func TestFilter_MarksPackagesThatFailWithoutAnyTestFailing(t *testing.T) {
+	t.Parallel()
+	sink := &recordingSink{}
+	td := gotestdox.NewTestDoxer(gotestdox.WithSink(sink))
+	td.Stdin = strings.NewReader(teardownInput)
+	td.Filter()
+	if td.OK {
+		t.Error("want not OK")
+	}
+	if sink.summary.TeardownFailures != 1 {
+		t.Errorf("want 1 teardown failure, got %d", sink.summary.TeardownFailures)
+	}
+	teardown := map[string]bool{}
+	for _, r := range sink.results {
+		if r.Test == "" {
+			teardown[r.Package] = r.TeardownFailed
+		}
+	}
+	want := map[string]bool{"p": true, "q": false, "r": false}
+	if !cmp.Equal(want, teardown) {
+		t.Error(cmp.Diff(want, teardown))
+	}
+}
+
+func TestTextSink_ShowsTeardownOutputOfPackageThatFailedAfterItsTests(t *testing.T) {
+	t.Parallel()
+	color.NoColor = true
+	buf := new(strings.Builder)
+	td := gotestdox.NewTestDoxer()
+	td.Stdin = strings.NewReader(teardownInput)
+	td.Stdout = buf
+	td.Filter()
+	want := `p:
+ ✔ A (0.00s)
+ x package failed in TestMain / teardown
+    goleak: Errors on successful test run: found unexpected goroutines:
+    [Goroutine 7 in state select, with p.poll on top of the stack:
+    ]
+
+q:
+ x B (0.00s)
+
+r:
+
+`
+	if want != buf.String() {
+		t.Error(cmp.Diff(want, buf.String()))
+	}
+}
+
+func TestTextSink_WithTabWidthExpandsTabsInTeardownOutputToTabStops(t *testing.T) {
+	t.Parallel()
+	color.NoColor = true
+	input := `{"Action":"pass","Package":"p","Test":"TestA"}
+{"Action":"output","Package":"p","Output":"  \tname:\t\"x\",\n"}
+{"Action":"output","Package":"p","Output":"- \tsize:\t1,\n"}
+{"Action":"output","Package":"p","Output":"+ \tsize:\t2,\n"}
+{"Action":"fail","Package":"p","Elapsed":0.1}`
+	tcs := map[int]string{
+		8: `            name:   "x",
+    -       size:   1,
+    +       size:   2,
+`,
+		0: "      \tname:\t\"x\",\n    - \tsize:\t1,\n    + \tsize:\t2,\n",
+	}
+	for width, want := range tcs {
+		buf := new(strings.Builder)
+		td := gotestdox.NewTestDoxer(gotestdox.WithTabWidth(width))
+		td.Stdin = strings.NewReader(input)
+		td.Stdout = buf
+		td.Filter()
+		_, got, _ := strings.Cut(buf.String(), "teardown\n")
+		got = strings.TrimSuffix(got, "\n")
+		if want != got {
+			t.Errorf("width %d: %s", width, cmp.Diff(want, got))
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package gotestdox
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// additionalRenderer is a renderer requested by [WithAdditionalRenderer].
+type additionalRenderer struct {
+	name string
+	w    io.Writer
+	opts []Option
+}
+
+// teeSink is a [ResultSink] that delivers each result to a primary sink, and
+// to any number of secondary ones. An error from the primary sink is
+// returned at once, but an error from a secondary sink only stops further
+// delivery to that sink, and is reported by err at the end of the run.
+type teeSink struct {
+	primary     ResultSink
+	secondaries []*secondarySink
+}
+
+// secondarySink is one of the secondary sinks of a [teeSink], together with
+// the first error it returned, if any.
+type secondarySink struct {
+	name string
+	sink ResultSink
+	err  error
+}
+
+// newTeeSink returns a [teeSink] delivering to primary, and to a new instance
+// of each of the given renderers, or an error if any of them isn't
+// registered.
+func newTeeSink(primary ResultSink, extra []additionalRenderer) (*teeSink, error) {
+	t := &teeSink{primary: primary}
+	for _, r := range extra {
+		factory, ok := LookupRenderer(r.name)
+		if !ok {
+			return nil, fmt.Errorf("unknown renderer %q (registered renderers: %s)", r.name, strings.Join(Renderers(), ", "))
+		}
+		sink := factory(RendererOptions{
+			Writer:  uncolouredWriter{r.w},
+			Options: r.opts,
+		})
+		t.secondaries = append(t.secondaries, &secondarySink{name: r.name, sink: sink})
+	}
+	return t, nil
+}
+
+func (t *teeSink) Result(r Result) error {
+	if err := t.primary.Result(r); err != nil {
+		return err
+	}
+	for _, s := range t.secondaries {
+		if s.err == nil {
+			s.err = s.sink.Result(r)
+		}
+	}
+	return nil
+}
+
+// Summary delivers the summary to the primary sink first, and then to each
+// secondary sink, in the order they were added.
+func (t *teeSink) Summary(sum Summary) error {
+	if err := t.primary.Summary(sum); err != nil {
+		return err
+	}
+	for _, s := range t.secondaries {
+		if s.err == nil {
+			s.err = s.sink.Summary(sum)
+		}
+	}
+	return nil
+}
+
+// err returns an error describing the errors from any secondary sinks, or
+// nil if there were none.
+func (t *teeSink) err() error {
+	msgs := []string{}
+	for _, s := range t.secondaries {
+		if s.err != nil {
+			msgs = append(msgs, fmt.Sprintf("renderer %q: %v", s.name, s.err))
+		}
+	}
+	if len(msgs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%s", strings.Join(msgs, "; "))
+}
+
+// uncolouredWriter is a writer that removes any colour escape sequences from
+// what's written to it, before writing it to w.
+type uncolouredWriter struct {
+	w io.Writer
+}
+
+func (u uncolouredWriter) Write(p []byte) (int, error) {
+	if _, err := u.w.Write(ansiEscape.ReplaceAll(p, nil)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -0,0 +1,208 @@
+package gotestdox_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/bitfield/gotestdox"
+	"github.com/google/go-cmp/cmp"
+)
+
+func init() {
+	gotestdox.RegisterRenderer("tee-test-echo", func(opts gotestdox.RendererOptions) gotestdox.Renderer {
+		return echoSink{w: opts.Writer}
+	})
+	gotestdox.RegisterRenderer("tee-test-failing", func(opts gotestdox.RendererOptions) gotestdox.Renderer {
+		return &failingSink{w: opts.Writer}
+	})
+	gotestdox.RegisterRenderer("tee-test-colour", func(opts gotestdox.RendererOptions) gotestdox.Renderer {
+		return colourSink{w: opts.Writer}
+	})
+}
+
+// echoSink writes a line to w for each result, and for the summary.
+type echoSink struct {
+	w io.Writer
+}
+
+func (s echoSink) Result(r gotestdox.Result) error {
+	_, err := fmt.Fprintf(s.w, "%s %s %s\n", r.Status, r.Package, r.Test)
+	return err
+}
+
+func (s echoSink) Summary(sum gotestdox.Summary) error {
+	_, err := fmt.Fprintf(s.w, "summary %d passed\n", sum.Passed)
+	return err
+}
+
+// failingSink is like echoSink, but fails on the second result.
+type failingSink struct {
+	w    io.Writer
+	seen int
+}
+
+func (s *failingSink) Result(r gotestdox.Result) error {
+	s.seen++
+	if s.seen == 2 {
+		return errors.New("disk full")
+	}
+	return echoSink{s.w}.Result(r)
+}
+
+func (s *failingSink) Summary(sum gotestdox.Summary) error {
+	return echoSink{s.w}.Summary(sum)
+}
+
+// colourSink writes a coloured line for each result.
+type colourSink struct {
+	w io.Writer
+}
+
+func (s colourSink) Result(r gotestdox.Result) error {
+	_, err := fmt.Fprintf(s.w, "\x1b[32m✔\x1b[0m \x1b[1;31m%s\x1b[0m\n", r.Sentence)
+	return err
+}
+
+func (s colourSink) Summary(gotestdox.Summary) error {
+	return nil
+}
+
+// eventLog is shared between several writers, to record the order in which
+// their output arrives.
+type eventLog struct {
+	lines []string
+}
+
+func (l *eventLog) writer(name string) io.Writer {
+	return writerFunc(func(p []byte) (int, error) {
+		for _, line := range strings.Split(strings.TrimSuffix(string(p), "\n"), "\n") {
+			l.lines = append(l.lines, name+": "+line)
+		}
+		return len(p), nil
+	})
+}
+
+type writerFunc func([]byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) {
+	return f(p)
+}
+
+const teeInput = `{"Action":"pass","Package":"p","Test":"TestA"}
+{"Action":"pass","Package":"p","Test":"TestB"}
+{"Action":"pass","Package":"p","Elapsed":0.1}`
+
+func TestFilter_WithAdditionalRendererDeliversEachResultToAllSinksInOrder(t *testing.T) {
+	t.Parallel()
+	log := &eventLog{}
+	td := gotestdox.NewTestDoxer(
+		gotestdox.WithSink(echoSink{w: log.writer("primary")}),
+		gotestdox.WithAdditionalRenderer("tee-test-echo", log.writer("first")),
+		gotestdox.WithAdditionalRenderer("tee-test-echo", log.writer("second")),
+	)
+	td.Stdin = strings.NewReader(teeInput)
+	td.Filter()
+	if !td.OK {
+		t.Error("want OK, got not OK")
+	}
+	want := []string{
+		"primary: pass p TestA",
+		"first: pass p TestA",
+		"second: pass p TestA",
+		"primary: pass p TestB",
+		"first: pass p TestB",
+		"second: pass p TestB",
+		"primary: pass p ",
+		"first: pass p ",
+		"second: pass p ",
+		"primary: summary 2 passed",
+		"first: summary 2 passed",
+		"second: summary 2 passed",
+	}
+	if !cmp.Equal(want, log.lines) {
+		t.Error(cmp.Diff(want, log.lines))
+	}
+}
+
+func TestFilterContext_WithFailingAdditionalRendererFinishesPrimaryAndReportsErrorAtEnd(t *testing.T) {
+	t.Parallel()
+	log := &eventLog{}
+	err := gotestdox.FilterContext(context.Background(), strings.NewReader(teeInput), io.Discard,
+		gotestdox.WithSink(echoSink{w: log.writer("primary")}),
+		gotestdox.WithAdditionalRenderer("tee-test-failing", log.writer("failing")),
+		gotestdox.WithAdditionalRenderer("tee-test-echo", log.writer("echo")),
+	)
+	want := []string{
+		"primary: pass p TestA",
+		"failing: pass p TestA",
+		"echo: pass p TestA",
+		"primary: pass p TestB",
+		"echo: pass p TestB",
+		"primary: pass p ",
+		"echo: pass p ",
+		"primary: summary 2 passed",
+		"echo: summary 2 passed",
+	}
+	if !cmp.Equal(want, log.lines) {
+		t.Error(cmp.Diff(want, log.lines))
+	}
+	if err == nil {
+		t.Fatal("want error from failing renderer, got nil")
+	}
+	wantErr := `renderer "tee-test-failing": disk full`
+	if err.Error() != wantErr {
+		t.Errorf("want error %q, got %q", wantErr, err)
+	}
+}
+
+func TestFilterContext_WithUnknownAdditionalRendererReturnsErrorWithoutOutput(t *testing.T) {
+	t.Parallel()
+	sink := &recordingSink{}
+	err := gotestdox.FilterContext(context.Background(), strings.NewReader(teeInput), io.Discard,
+		gotestdox.WithSink(sink),
+		gotestdox.WithAdditionalRenderer("bogus", io.Discard),
+	)
+	if err == nil || !strings.Contains(err.Error(), `unknown renderer "bogus"`) {
+		t.Errorf("want unknown renderer error, got %v", err)
+	}
+	if len(sink.results) > 0 {
+		t.Errorf("want no results delivered, got %v", sink.results)
+	}
+}
+
+func TestFilter_WithAdditionalRendererRemovesColourFromItsOutput(t *testing.T) {
+	t.Parallel()
+	buf := new(strings.Builder)
+	td := gotestdox.NewTestDoxer(
+		gotestdox.WithSink(&recordingSink{}),
+		gotestdox.WithAdditionalRenderer("tee-test-colour", buf),
+	)
+	td.Stdin = strings.NewReader(teeInput)
+	td.Filter()
+	want := "✔ A\n✔ B\n✔ \n"
+	if want != buf.String() {
+		t.Error(cmp.Diff(want, buf.String()))
+	}
+}
+
+func TestFilter_WithAdditionalRendererAppliesOnlyItsOwnOptions(t *testing.T) {
+	t.Parallel()
+	primary := &recordingSink{}
+	buf := new(strings.Builder)
+	td := gotestdox.NewTestDoxer(
+		gotestdox.WithSink(primary),
+		gotestdox.WithAdditionalRenderer("plain", buf, gotestdox.WithSentenceSuffix(".")),
+	)
+	td.Stdin = strings.NewReader(teeInput)
+	td.Filter()
+	if !strings.Contains(buf.String(), "✔ A. (0.00s)\n") {
+		t.Errorf("want sentence suffix in additional output, got:\n%s", buf)
+	}
+	if primary.results[0].Sentence != "A" {
+		t.Errorf("want primary sentence %q, got %q", "A", primary.results[0].Sentence)
+	}
+}
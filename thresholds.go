@@ -0,0 +1,140 @@
+package gotestdox
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Thresholds sets limits on the outcome of a run, beyond whether its tests
+// passed, as checked by [WithThresholds]. A zero limit means no limit.
+//
+// MaxDuration is the longest the packages may take, in total, as reported by
+// 'go test'. Packages whose results were replayed from the cache take no
+// time. MinTests is the fewest tests that may complete, which catches tests
+// that were deleted, or skipped, by accident. A test that was retried counts
+// once, skipped tests don't count towards it, and nor do those replayed from
+// the cache, if CountCachedSeparately is true. MaxFailed and MaxFlaky are
+// the most tests that may fail, or be flaky (see [Summary]). Since any
+// failure fails the run anyway, MaxFailed only matters to the report: to
+// fail the run if any test is flaky, use [WithFlakyAsFailure].
+type Thresholds struct {
+	MaxDuration           time.Duration
+	MinTests              int
+	MaxFailed             int
+	MaxFlaky              int
+	CountCachedSeparately bool
+}
+
+// check returns a message describing each way in which the run with the
+// summary sum falls outside t, or nil if it's within them all.
+func (t Thresholds) check(sum Summary) []string {
+	violations := []string(nil)
+	if elapsed := time.Duration(sum.Elapsed * float64(time.Second)); t.MaxDuration > 0 && elapsed > t.MaxDuration {
+		violations = append(violations, fmt.Sprintf("tests took %s, longer than the maximum of %s", elapsed.Round(time.Millisecond), t.MaxDuration))
+	}
+	if t.MinTests > 0 {
+		// a flaky test that failed before it passed is one test
+		tests := sum.Passed + sum.Failed - sum.FlakyFailures
+		switch {
+		case t.CountCachedSeparately && tests-sum.CachedPassed < t.MinTests:
+			violations = append(violations, fmt.Sprintf("%d tests ran (%d more cached), fewer than the minimum of %d", tests-sum.CachedPassed, sum.CachedPassed, t.MinTests))
+		case !t.CountCachedSeparately && tests < t.MinTests:
+			violations = append(violations, fmt.Sprintf("%d tests ran, fewer than the minimum of %d", tests, t.MinTests))
+		}
+	}
+	if failed := sum.Failed - sum.FlakyFailures; t.MaxFailed > 0 && failed > t.MaxFailed {
+		violations = append(violations, fmt.Sprintf("%d tests failed, more than the maximum of %d", failed, t.MaxFailed))
+	}
+	if t.MaxFlaky > 0 && sum.Flaky > t.MaxFlaky {
+		violations = append(violations, fmt.Sprintf("%d tests were flaky, more than the maximum of %d", sum.Flaky, t.MaxFlaky))
+	}
+	return violations
+}
+
+// ReadThresholdsFile reads [Thresholds] from the file at filePath. A file
+// whose name ends in ".json" is a JSON object, such as:
+//
+//	{"maxDuration": "10m", "minTests": 500, "maxFlaky": 3}
+//
+// Any other file has one setting per line, as in a simple YAML mapping,
+// with blank lines, and comments starting with '#', ignored:
+//
+//	maxDuration: 10m
+//	minTests: 500
+//	countCachedSeparately: true
+//
+// The settings are maxDuration, as for [time.ParseDuration], minTests,
+// maxFailed, maxFlaky, and countCachedSeparately. Any other setting, or an
+// invalid value, is an error.
+func ReadThresholdsFile(filePath string) (Thresholds, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return Thresholds{}, err
+	}
+	t := Thresholds{}
+	if strings.EqualFold(filepath.Ext(filePath), ".json") {
+		settings := map[string]json.RawMessage{}
+		if err := json.Unmarshal(data, &settings); err != nil {
+			return Thresholds{}, fmt.Errorf("%s: %w", filePath, err)
+		}
+		for key, raw := range settings {
+			value := string(raw)
+			if unquoted, err := strconv.Unquote(value); err == nil {
+				value = unquoted
+			}
+			if err := t.set(key, value); err != nil {
+				return Thresholds{}, fmt.Errorf("%s: %w", filePath, err)
+			}
+		}
+		return t, nil
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	line := 0
+	for scanner.Scan() {
+		line++
+		text, _, _ := strings.Cut(scanner.Text(), "#")
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(text, ":")
+		if !ok {
+			return Thresholds{}, fmt.Errorf("%s:%d: want 'setting: value', got %q", filePath, line, strings.TrimSpace(text))
+		}
+		value = strings.Trim(strings.TrimSpace(value), `"'`)
+		if err := t.set(strings.TrimSpace(key), value); err != nil {
+			return Thresholds{}, fmt.Errorf("%s:%d: %w", filePath, line, err)
+		}
+	}
+	return t, scanner.Err()
+}
+
+// set sets the threshold named key, as in a file read by
+// [ReadThresholdsFile], to value.
+func (t *Thresholds) set(key, value string) error {
+	var err error
+	switch key {
+	case "maxDuration":
+		t.MaxDuration, err = time.ParseDuration(value)
+	case "minTests":
+		t.MinTests, err = strconv.Atoi(value)
+	case "maxFailed":
+		t.MaxFailed, err = strconv.Atoi(value)
+	case "maxFlaky":
+		t.MaxFlaky, err = strconv.Atoi(value)
+	case "countCachedSeparately":
+		t.CountCachedSeparately, err = strconv.ParseBool(value)
+	default:
+		return fmt.Errorf("unknown threshold %q", key)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid %s %q", key, value)
+	}
+	return nil
+}
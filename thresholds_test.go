@@ -0,0 +1,175 @@
+package gotestdox_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bitfield/gotestdox"
+	"github.com/fatih/color"
+	"github.com/google/go-cmp/cmp"
+)
+
+var thresholdsInput = `{"Action":"pass","Package":"p","Test":"TestA","Elapsed":1.2}
+{"Action":"fail","Package":"p","Test":"TestB","Elapsed":0.1}
+{"Action":"pass","Package":"p","Test":"TestB","Elapsed":0.1}
+{"Action":"fail","Package":"p","Test":"TestC","Elapsed":0.1}
+{"Action":"pass","Package":"p","Test":"TestC","Elapsed":0.1}
+{"Action":"skip","Package":"p","Test":"TestD"}
+{"Action":"pass","Package":"p","Elapsed":1.5}
+{"Action":"pass","Package":"q","Test":"TestE","Elapsed":0.4}
+{"Action":"pass","Package":"q","Test":"TestF","Elapsed":0.5}
+{"Action":"output","Package":"q","Output":"ok  \tq\t(cached)\n"}
+{"Action":"pass","Package":"q","Elapsed":0}
+{"Action":"pass","Package":"r","Test":"TestG","Elapsed":2}
+{"Action":"pass","Package":"r","Elapsed":2.25}`
+
+func thresholdViolations(t *testing.T, thresholds gotestdox.Thresholds) gotestdox.Summary {
+	t.Helper()
+	sink := &recordingSink{}
+	td := gotestdox.NewTestDoxer(gotestdox.WithSink(sink), gotestdox.WithThresholds(thresholds))
+	td.Stdin = strings.NewReader(thresholdsInput)
+	td.Filter()
+	return sink.summary
+}
+
+func TestFilter_WithThresholdsReportsEachThresholdExceeded(t *testing.T) {
+	t.Parallel()
+	sum := thresholdViolations(t, gotestdox.Thresholds{
+		MaxDuration: 3 * time.Second,
+		MinTests:    10,
+		MaxFlaky:    1,
+	})
+	want := []string{
+		"tests took 3.75s, longer than the maximum of 3s",
+		"6 tests ran, fewer than the minimum of 10",
+		"2 tests were flaky, more than the maximum of 1",
+	}
+	if !cmp.Equal(want, sum.ThresholdViolations) {
+		t.Error(cmp.Diff(want, sum.ThresholdViolations))
+	}
+	if got := gotestdox.ExitCode(sum, nil); got != gotestdox.ExitThresholdsExceeded {
+		t.Errorf("want exit code %d, got %d", gotestdox.ExitThresholdsExceeded, got)
+	}
+}
+
+func TestFilter_WithThresholdsReportsNothingForRunWithinThresholds(t *testing.T) {
+	t.Parallel()
+	sum := thresholdViolations(t, gotestdox.Thresholds{
+		MaxDuration: 4 * time.Second,
+		MinTests:    6,
+		MaxFailed:   1,
+		MaxFlaky:    2,
+	})
+	if sum.ThresholdViolations != nil {
+		t.Errorf("want no violations, got %q", sum.ThresholdViolations)
+	}
+	if sum.Elapsed != 3.75 {
+		t.Errorf("want 3.75s elapsed, got %g", sum.Elapsed)
+	}
+	if got := gotestdox.ExitCode(sum, nil); got != gotestdox.ExitOK {
+		t.Errorf("want exit code %d, got %d", gotestdox.ExitOK, got)
+	}
+}
+
+func TestFilter_WithThresholdsCountsCachedTestsSeparatelyIfRequested(t *testing.T) {
+	t.Parallel()
+	sum := thresholdViolations(t, gotestdox.Thresholds{
+		MinTests:              8,
+		CountCachedSeparately: true,
+	})
+	want := []string{"4 tests ran (2 more cached), fewer than the minimum of 8"}
+	if !cmp.Equal(want, sum.ThresholdViolations) {
+		t.Error(cmp.Diff(want, sum.ThresholdViolations))
+	}
+}
+
+func TestExitCode_ReportsTestFailureRatherThanThresholdsExceeded(t *testing.T) {
+	t.Parallel()
+	sum := gotestdox.Summary{Failed: 1, ThresholdViolations: []string{"too slow"}}
+	if got := gotestdox.ExitCode(sum, nil); got != gotestdox.ExitTestsFailed {
+		t.Errorf("want exit code %d, got %d", gotestdox.ExitTestsFailed, got)
+	}
+}
+
+func TestTextSink_ListsThresholdsExceededAtEndOfReport(t *testing.T) {
+	t.Parallel()
+	color.NoColor = true
+	buf := new(strings.Builder)
+	td := gotestdox.NewTestDoxer(gotestdox.WithThresholds(gotestdox.Thresholds{MaxDuration: time.Second, MinTests: 20}))
+	td.Stdin = strings.NewReader(thresholdsInput)
+	td.Stdout = buf
+	td.Filter()
+	want := `Thresholds exceeded:
+ x tests took 3.75s, longer than the maximum of 1s
+ x 6 tests ran, fewer than the minimum of 20
+`
+	if !strings.HasSuffix(buf.String(), want) {
+		t.Errorf("want output ending:\n%s\ngot:\n%s", want, buf.String())
+	}
+}
+
+func writeThresholdsFile(t *testing.T, name, contents string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReadThresholdsFile_ReadsJSONOrYAMLSettings(t *testing.T) {
+	t.Parallel()
+	want := gotestdox.Thresholds{
+		MaxDuration:           10 * time.Minute,
+		MinTests:              500,
+		MaxFailed:             5,
+		MaxFlaky:              3,
+		CountCachedSeparately: true,
+	}
+	files := map[string]string{
+		"thresholds.json": `{"maxDuration": "10m", "minTests": 500, "maxFailed": 5, "maxFlaky": 3, "countCachedSeparately": true}`,
+		"thresholds.yaml": `# CI gates
+maxDuration: 10m
+minTests: 500   # about the size of the suite
+
+maxFailed: "5"
+maxFlaky: 3
+countCachedSeparately: true
+`,
+	}
+	for name, contents := range files {
+		got, err := gotestdox.ReadThresholdsFile(writeThresholdsFile(t, name, contents))
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !cmp.Equal(want, got) {
+			t.Errorf("%s: %s", name, cmp.Diff(want, got))
+		}
+	}
+}
+
+func TestReadThresholdsFile_ReturnsErrorForInvalidSettings(t *testing.T) {
+	t.Parallel()
+	tcs := []struct {
+		name, contents, want string
+	}{
+		{"bad.yaml", "minTests: 5\nmaxDuration: soon\n", `:2: invalid maxDuration "soon"`},
+		{"bad.yaml", "minTests: 5\nmaxSkipped: 3\n", `:2: unknown threshold "maxSkipped"`},
+		{"bad.yaml", "minTests 5\n", `:1: want 'setting: value', got "minTests 5"`},
+		{"bad.json", `{"minTests": "many"}`, `: invalid minTests "many"`},
+		{"bad.json", `{"minTests": 5`, `: unexpected end of JSON input`},
+	}
+	for _, tc := range tcs {
+		_, err := gotestdox.ReadThresholdsFile(writeThresholdsFile(t, tc.name, tc.contents))
+		if err == nil {
+			t.Errorf("%q: want error", tc.contents)
+			continue
+		}
+		if !strings.HasSuffix(err.Error(), tc.want) {
+			t.Errorf("%q: want error ending %q, got %q", tc.contents, tc.want, err)
+		}
+	}
+}
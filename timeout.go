@@ -0,0 +1,133 @@
+package gotestdox
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// timedOutNote is added to the line for a test that was still running when
+// its package timed out (see [WithPackageTimeout]).
+const timedOutNote = "timed out"
+
+// packageTimeoutFor returns the limit set by [WithPackageTimeout] or
+// [WithPackageTimeouts] for the package with the import path pkg, or zero if
+// there is none. The longest matching pattern wins, and of patterns of the
+// same length, the one that sorts first.
+func (c config) packageTimeoutFor(pkg string) time.Duration {
+	d, best := c.packageTimeout, ""
+	matched := false
+	for pattern, limit := range c.packageTimeouts {
+		if !matchGlob(pattern, pkg) {
+			continue
+		}
+		if matched && (len(pattern) < len(best) || len(pattern) == len(best) && pattern > best) {
+			continue
+		}
+		d, best, matched = limit, pattern, true
+	}
+	return d
+}
+
+// timeoutEvent is an event added to the output of a package that timed out.
+// Unlike [Event], empty fields are left out, so that they don't override
+// those added by writeEvents.
+type timeoutEvent struct {
+	Action  string
+	Package string
+	Test    string  `json:",omitempty"`
+	Elapsed float64 `json:",omitempty"`
+	Output  string  `json:",omitempty"`
+}
+
+// timedOutTests records the tests that were still running when their
+// package was killed for taking too long, and the packages themselves,
+// keyed as by [Event.key], so that the filter can mark their results as
+// timed out. Since the events for them are made up by gotestdox, rather
+// than coming from 'go test', this is kept out of the event stream: it's
+// filled in by [TestDoxer.execParallel] before passing on the output of each
+// run that timed out. A nil *timedOutTests records nothing.
+type timedOutTests struct {
+	mu   sync.Mutex
+	keys map[string]bool
+}
+
+// add records the tests, and the package, of run, which timed out.
+func (t *timedOutTests) add(run *goTestRun) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.keys == nil {
+		t.keys = map[string]bool{}
+	}
+	e := Event{Package: run.pkg, Configuration: run.configuration}
+	t.keys[e.key("")] = true
+	for _, test := range run.timedOutTests {
+		t.keys[e.key(test)] = true
+	}
+}
+
+// has reports whether the test or package with key timed out.
+func (t *timedOutTests) has(key string) bool {
+	if t == nil {
+		return false
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.keys[key]
+}
+
+// timeoutEvents returns the events completing the output out of the 'go
+// test -json' process for pkg, which was killed at the time killed, having
+// run for longer than timeout: a failure for each test still running,
+// innermost first, and then one for the package, along with the names of
+// those tests. Any line cut short when the process was killed is dropped. If
+// the package had already finished, timeoutEvents returns nothing.
+func timeoutEvents(out []byte, pkg string, timeout time.Duration, killed time.Time) ([]byte, []string) {
+	type running struct {
+		test  string
+		start time.Time
+	}
+	tests := []running{}
+	for _, line := range bytes.Split(out, []byte("\n")) {
+		var e Event
+		if json.Unmarshal(line, &e) != nil || e.Package != pkg {
+			continue
+		}
+		switch {
+		case e.IsPackageResult():
+			return nil, nil
+		case e.Test == "":
+		case e.Kind() == ActionRun:
+			tests = append(tests, running{e.Test, e.Time})
+		case e.completes():
+			for i, t := range tests {
+				if t.test == e.Test {
+					tests = append(tests[:i], tests[i+1:]...)
+					break
+				}
+			}
+		}
+	}
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	names := make([]string, 0, len(tests))
+	for i := len(tests) - 1; i >= 0; i-- {
+		elapsed := 0.0
+		if !tests[i].start.IsZero() {
+			elapsed = math.Round(killed.Sub(tests[i].start).Seconds()*100) / 100
+		}
+		enc.Encode(timeoutEvent{Action: "fail", Package: pkg, Test: tests[i].test, Elapsed: elapsed})
+		names = append(names, tests[i].test)
+	}
+	enc.Encode(timeoutEvent{Action: "output", Package: pkg, Output: fmt.Sprintf("FAIL\t%s\t(timed out after %s)\n", pkg, timeout)})
+	enc.Encode(timeoutEvent{Action: "fail", Package: pkg, Elapsed: timeout.Seconds()})
+	return b.Bytes(), names
+}
+
+// completeLines returns out up to the end of its last complete line.
+func completeLines(out []byte) []byte {
+	return out[:bytes.LastIndexByte(out, '\n')+1]
+}
@@ -0,0 +1,63 @@
+package gotestdox
+
+import (
+	"strings"
+	"unicode"
+)
+
+// defaultStopWords are the words that [TitleCaseSentence] keeps in lower case,
+// unless they start the sentence, if no others are given using
+// [WithTitleStopWords].
+var defaultStopWords = []string{"a", "an", "the", "of", "to", "in", "on", "for"}
+
+// TitleCaseSentence returns s in title case, for use as a heading in a
+// generated document: the first letter of each word is capitalised, except
+// for stop words such as "the" or "of" anywhere but at the start. For
+// example, "parsing of the configuration file" becomes "Parsing of the
+// Configuration File".
+//
+// Words that are already all in capitals, such as initialisms, are left
+// as they are, and so are words containing any non-ASCII letters, whose
+// capitalisation may depend on the language. The stop words can be changed
+// using [WithTitleStopWords].
+//
+// TitleCaseSentence is intended for headings only, such as those a
+// [MarkdownSink] writes for tests with subtests: the sentences for
+// individual tests, as produced by [Prettify], are not title cased.
+func TitleCaseSentence(s string, opts ...Option) string {
+	return newConfig(opts).titleCase(s)
+}
+
+// titleCase returns s in title case, as described for [TitleCaseSentence],
+// using the stop words in c.
+func (c config) titleCase(s string) string {
+	stop := c.stopWords
+	if stop == nil {
+		stop = map[string]bool{}
+		for _, w := range defaultStopWords {
+			stop[w] = true
+		}
+	}
+	words := strings.Fields(s)
+	for i, w := range words {
+		switch {
+		case !isASCII(w) || strings.ToUpper(w) == w:
+			// leave as is
+		case i > 0 && stop[strings.ToLower(w)]:
+			words[i] = strings.ToLower(w)
+		default:
+			words[i] = strings.ToUpper(w[:1]) + w[1:]
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// isASCII reports whether s consists only of ASCII characters.
+func isASCII(s string) bool {
+	for _, r := range s {
+		if r > unicode.MaxASCII {
+			return false
+		}
+	}
+	return true
+}
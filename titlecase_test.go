@@ -0,0 +1,52 @@
+package gotestdox_test
+
+import (
+	"testing"
+
+	"github.com/bitfield/gotestdox"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestTitleCaseSentence_CapitalisesWordsExceptStopWords(t *testing.T) {
+	t.Parallel()
+	tcs := []struct {
+		input, want string
+	}{
+		{
+			input: "parsing of the configuration file",
+			want:  "Parsing of the Configuration File",
+		},
+		{
+			input: "the Parser Of JSON input",
+			want:  "The Parser of JSON Input",
+		},
+		{
+			input: "handleInput closes input on EOF",
+			want:  "HandleInput Closes Input on EOF",
+		},
+		{
+			input: "résumé parsing for écoles",
+			want:  "résumé Parsing for écoles",
+		},
+		{
+			input: "",
+			want:  "",
+		},
+	}
+	for _, tc := range tcs {
+		got := gotestdox.TitleCaseSentence(tc.input)
+		if tc.want != got {
+			t.Errorf("%q: %s", tc.input, cmp.Diff(tc.want, got))
+		}
+	}
+}
+
+func TestTitleCaseSentence_WithTitleStopWordsReplacesDefaultStopWords(t *testing.T) {
+	t.Parallel()
+	input := "parsing of the file and its contents"
+	want := "Parsing Of The File and its Contents"
+	got := gotestdox.TitleCaseSentence(input, gotestdox.WithTitleStopWords("and", "Its"))
+	if want != got {
+		t.Error(cmp.Diff(want, got))
+	}
+}
@@ -0,0 +1,151 @@
+package gotestdox
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// TreeSink is a [ResultSink] that prints the tests of each package as a
+// tree, with each subtest under its parent, and only the words that the
+// subtest adds to its parent's sentence:
+//
+//	p:
+//	├── ✔ Parse (0.01s)
+//	│   ├── ✔ handles empty input (0.00s)
+//	│   └── ✔ rejects invalid input (0.00s)
+//	└── ○ Format (0.00s)
+//
+// The tests of each package are printed, sorted by name, once its result
+// arrives, or at the end of the run, for any package that didn't finish.
+// Skipped tests are included, marked by a circle.
+type TreeSink struct {
+	w     io.Writer
+	tests map[string][]Result
+	order []string
+	config
+}
+
+// NewTreeSink returns a [*TreeSink] that writes to w, configured by opts.
+func NewTreeSink(w io.Writer, opts ...Option) *TreeSink {
+	return &TreeSink{
+		w:      w,
+		tests:  map[string][]Result{},
+		config: newConfig(opts),
+	}
+}
+
+// Result buffers the result of a test, or prints the tree of tests for a
+// package, given its result.
+func (s *TreeSink) Result(r Result) error {
+	key := Event{Package: r.Package, Configuration: r.Configuration}.key("")
+	if r.Test == "" {
+		return s.printPackage(key, r)
+	}
+	if _, ok := s.tests[key]; !ok {
+		s.order = append(s.order, key)
+	}
+	s.tests[key] = append(s.tests[key], r)
+	return nil
+}
+
+// Summary prints the trees of any packages whose results never arrived.
+func (s *TreeSink) Summary(Summary) error {
+	for _, key := range s.order {
+		tests, ok := s.tests[key]
+		if !ok {
+			continue
+		}
+		if err := s.printPackage(key, tests[0]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// printPackage prints the heading for the package whose result, or any of
+// whose test results, is pkg, followed by the tree of its tests buffered
+// under key, and forgets them.
+func (s *TreeSink) printPackage(key string, pkg Result) error {
+	tests := s.tests[key]
+	delete(s.tests, key)
+	if len(tests) == 0 {
+		return nil
+	}
+	heading := pkg.Package
+	if pkg.Configuration != "" {
+		heading += " (" + pkg.Configuration + ")"
+	}
+	b := new(strings.Builder)
+	fmt.Fprintf(b, "%s:\n", heading)
+	nodes := newTestTree(tests)
+	nodes.print(b, "", nodes.children[""], "")
+	b.WriteString("\n")
+	_, err := io.WriteString(s.w, b.String())
+	return err
+}
+
+// testTree is the tests of a package arranged by their subtest hierarchy.
+// Each test's children are listed under its name, sorted by name, and the
+// top-level tests, or those whose parents have no result, under "".
+type testTree struct {
+	results  map[string]Result
+	children map[string][]string
+}
+
+// newTestTree arranges tests into a tree. A test with more than one result,
+// as when run with 'go test -count', appears once, with its last result.
+func newTestTree(tests []Result) testTree {
+	t := testTree{
+		results:  map[string]Result{},
+		children: map[string][]string{},
+	}
+	for _, r := range tests {
+		t.results[r.Test] = r
+	}
+	for name := range t.results {
+		parent := name
+		for {
+			i := strings.LastIndex(parent, "/")
+			if i < 0 {
+				parent = ""
+				break
+			}
+			parent = parent[:i]
+			if _, ok := t.results[parent]; ok {
+				break
+			}
+		}
+		t.children[parent] = append(t.children[parent], name)
+	}
+	for _, names := range t.children {
+		sort.Strings(names)
+	}
+	return t
+}
+
+// print writes the lines for the tests names, whose parent's sentence is
+// parentSentence, to b, each indented by indent, and followed by its own
+// subtests.
+func (t testTree) print(b *strings.Builder, indent string, names []string, parentSentence string) {
+	for i, name := range names {
+		r := t.results[name]
+		branch, next := "├── ", "│   "
+		if i == len(names)-1 {
+			branch, next = "└── ", "    "
+		}
+		fmt.Fprintf(b, "%s%s%s %s (%.2fs)\n", indent, branch, colouredStatusSymbol(r.Status), subtestWords(r.Sentence, parentSentence), r.Elapsed)
+		t.print(b, indent+next, t.children[name], r.Sentence)
+	}
+}
+
+// subtestWords returns the words that the sentence of a subtest adds to
+// parentSentence, the sentence of its parent, or the whole sentence if it
+// doesn't start with its parent's.
+func subtestWords(sentence, parentSentence string) string {
+	if parentSentence != "" && strings.HasPrefix(sentence, parentSentence+" ") {
+		return sentence[len(parentSentence)+1:]
+	}
+	return sentence
+}
@@ -0,0 +1,60 @@
+package gotestdox_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bitfield/gotestdox"
+	"github.com/fatih/color"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestTreeSink_PrintsSubtestsUnderTheirParents(t *testing.T) {
+	t.Parallel()
+	color.NoColor = true
+	buf := new(strings.Builder)
+	td := gotestdox.NewTestDoxer(gotestdox.WithSink(gotestdox.NewTreeSink(buf)))
+	td.Stdin = strings.NewReader(`{"Action":"pass","Package":"p","Test":"TestParse/handles_empty_input"}
+{"Action":"fail","Package":"p","Test":"TestParse/rejects_invalid_input/with_nested_brackets"}
+{"Action":"fail","Package":"p","Test":"TestParse/rejects_invalid_input","Elapsed":0.25}
+{"Action":"fail","Package":"p","Test":"TestParse","Elapsed":0.5}
+{"Action":"skip","Package":"p","Test":"TestFormat"}
+{"Action":"fail","Package":"p","Elapsed":1}`)
+	td.Filter()
+	want := `p:
+├── ○ Format (0.00s)
+└── x Parse (0.50s)
+    ├── ✔ handles empty input (0.00s)
+    └── x rejects invalid input (0.25s)
+        └── x with nested brackets (0.00s)
+
+`
+	if want != buf.String() {
+		t.Error(cmp.Diff(want, buf.String()))
+	}
+}
+
+func TestTreeSink_PrintsPackagesThatDidNotFinishAtEndOfRun(t *testing.T) {
+	t.Parallel()
+	color.NoColor = true
+	buf := new(strings.Builder)
+	td := gotestdox.NewTestDoxer(gotestdox.WithSink(gotestdox.NewTreeSink(buf)))
+	td.Stdin = strings.NewReader(sinkInput + "\n" + `{"Action":"pass","Package":"r","Test":"TestE"}`)
+	td.Filter()
+	want := `p:
+├── ✔ A (0.00s)
+│   └── ✔ works fine (0.00s)
+├── x B (0.50s)
+└── ○ C (0.00s)
+
+q:
+└── ✔ D (0.00s)
+
+r:
+└── ✔ E (0.00s)
+
+`
+	if want != buf.String() {
+		t.Error(cmp.Diff(want, buf.String()))
+	}
+}
@@ -0,0 +1,123 @@
+package gotestdox
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// VagueName identifies a test whose name was reported by the vague name check
+// (see [WithVagueNameCheck]). File and Line give the location of the test
+// function, if it could be found, or are empty otherwise. For a subtest, this
+// is the location of its parent test function.
+type VagueName struct {
+	Package string `json:"package"`
+	Test    string `json:"test"`
+	File    string `json:"file,omitempty"`
+	Line    int    `json:"line,omitempty"`
+}
+
+// String formats a VagueName for display, including its location if known.
+func (v VagueName) String() string {
+	if v.File == "" {
+		return fmt.Sprintf(" %s: %s", v.Package, printable(v.Test))
+	}
+	return fmt.Sprintf(" %s: %s (%s:%d)", v.Package, printable(v.Test), v.File, v.Line)
+}
+
+// behaviourWords returns the number of words in sentence that follow the
+// function name (that is, the first word).
+func behaviourWords(sentence string) int {
+	words := strings.Fields(sentence)
+	if len(words) == 0 {
+		return 0
+	}
+	return len(words) - 1
+}
+
+// findVagueNames returns the tests in results whose behaviour clause is shorter
+// than minWords, ignoring any test that has subtests of its own. The results
+// are sorted by package and then by test name.
+func findVagueNames(results []Result, minWords int) []VagueName {
+	vague := []VagueName{}
+	for _, e := range leafTests(results) {
+		if behaviourWords(e.Sentence) < minWords {
+			vague = append(vague, VagueName{
+				Package: e.Package,
+				Test:    e.Test,
+			})
+		}
+	}
+	sort.Slice(vague, func(i, j int) bool {
+		if vague[i].Package != vague[j].Package {
+			return vague[i].Package < vague[j].Package
+		}
+		return vague[i].Test < vague[j].Test
+	})
+	return vague
+}
+
+// leafTests returns the tests in results that have no subtests of their
+// own, leaving out any repeated results for the same test.
+func leafTests(results []Result) []Result {
+	hasSubtests := map[string]bool{}
+	for _, e := range results {
+		if i := strings.LastIndex(e.Test, "/"); i > 0 {
+			hasSubtests[e.Package+" "+e.Test[:i]] = true
+		}
+	}
+	leaves := []Result{}
+	seen := map[string]bool{}
+	for _, e := range results {
+		key := e.Package + " " + e.Test
+		if seen[key] || hasSubtests[key] {
+			continue
+		}
+		seen[key] = true
+		leaves = append(leaves, e)
+	}
+	return leaves
+}
+
+// locateVagueNames fills in the File and Line fields of each entry in vague,
+// where the source of the test function can be found.
+func locateVagueNames(vague []VagueName, packages *packageResolver) {
+	for i, v := range vague {
+		dir := packages.resolve(v.Package, "").dir
+		if dir == "" {
+			continue
+		}
+		parent, _ := SplitSubtests(v.Test)
+		vague[i].File, vague[i].Line = locateTestFunc(dir, parent)
+	}
+}
+
+// locateTestFunc searches the test files in dir for a function declaration
+// with the given name, returning the base name of the file and the line
+// number of the declaration. If there is no such function, it returns the
+// empty string and zero.
+func locateTestFunc(dir, name string) (string, int) {
+	files, err := filepath.Glob(filepath.Join(dir, "*_test.go"))
+	if err != nil {
+		return "", 0
+	}
+	fset := token.NewFileSet()
+	for _, path := range files {
+		f, err := parser.ParseFile(fset, path, nil, parser.SkipObjectResolution)
+		if err != nil {
+			continue
+		}
+		for _, decl := range f.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Recv != nil || fn.Name.Name != name {
+				continue
+			}
+			return filepath.Base(path), fset.Position(fn.Pos()).Line
+		}
+	}
+	return "", 0
+}
@@ -0,0 +1,292 @@
+package gotestdox
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ProblemKind identifies a kind of structural problem found in the output of
+// 'go test -json' by [Validate].
+type ProblemKind int
+
+const (
+	// InvalidJSON is the kind of a line, other than the last, that isn't
+	// valid JSON.
+	InvalidJSON ProblemKind = iota
+	// TruncatedJSON is the kind of a last line that isn't valid JSON, as
+	// when the stream was cut off while it was being written.
+	TruncatedJSON
+	// UnfinishedTest is the kind of a test that started running, but has
+	// no pass, fail, or skip event.
+	UnfinishedTest
+	// UnstartedTest is the kind of a pass, fail, or skip event for a test
+	// that never started running.
+	UnstartedTest
+	// DuplicateResult is the kind of a second pass, fail, or skip event for
+	// a test, or a package, without the test having run again since.
+	DuplicateResult
+	// MissingPackageResult is the kind of a package with events, but no
+	// final pass, fail, or skip event.
+	MissingPackageResult
+	// TimeWentBackwards is the kind of an event stamped earlier than the
+	// previous event for its package.
+	TimeWentBackwards
+)
+
+var problemKindNames = map[ProblemKind]string{
+	InvalidJSON:          "invalid JSON",
+	TruncatedJSON:        "truncated JSON at end of input",
+	UnfinishedTest:       "test started but never finished",
+	UnstartedTest:        "test finished but never started",
+	DuplicateResult:      "duplicate result",
+	MissingPackageResult: "package with no final status",
+	TimeWentBackwards:    "timestamp earlier than previous event",
+}
+
+// String returns a description of the kind, such as "invalid JSON".
+func (k ProblemKind) String() string {
+	if s, ok := problemKindNames[k]; ok {
+		return s
+	}
+	return "ProblemKind(?)"
+}
+
+// corrupting reports whether problems of kind k mean that the input can't
+// be reported on accurately. Unfinished tests are normal when a test binary
+// panics or times out, and timestamps can go backwards when the clock is
+// adjusted, so these are not.
+func (k ProblemKind) corrupting() bool {
+	return k != UnfinishedTest && k != TimeWentBackwards
+}
+
+// validationExamples is the number of examples of each kind of problem
+// given in a [ValidationReport].
+const validationExamples = 3
+
+// ValidationReport describes the structural problems found by [Validate] in
+// the output of 'go test -json'. Lines is the number of lines read, and
+// Events the number of them that were valid events. Problems gives the
+// number of each kind of problem found, in the order of the [ProblemKind]
+// constants, leaving out those not found.
+type ValidationReport struct {
+	Lines    int                 `json:"lines"`
+	Events   int                 `json:"events"`
+	Problems []ValidationProblem `json:"problems,omitempty"`
+}
+
+// ValidationProblem counts the problems of one kind found by [Validate],
+// giving the first few as examples, in the order they were found.
+type ValidationProblem struct {
+	Kind     ProblemKind      `json:"kind"`
+	Count    int              `json:"count"`
+	Examples []ProblemExample `json:"examples"`
+}
+
+// ProblemExample identifies the line of input where a problem was found,
+// and the package and test it concerns, if known.
+type ProblemExample struct {
+	Line    int    `json:"line"`
+	Package string `json:"package,omitempty"`
+	Test    string `json:"test,omitempty"`
+}
+
+// String formats the example as, for example, "line 12 (example.com/p
+// TestFoo)".
+func (e ProblemExample) String() string {
+	where := strings.TrimSpace(e.Package + " " + e.Test)
+	if where == "" {
+		return fmt.Sprintf("line %d", e.Line)
+	}
+	return fmt.Sprintf("line %d (%s)", e.Line, where)
+}
+
+// OK reports whether no problems were found.
+func (r ValidationReport) OK() bool {
+	return len(r.Problems) == 0
+}
+
+// Corrupt reports whether any of the problems found mean that a report on
+// the input would be wrong: that is, any problem other than an unfinished
+// test, or a timestamp going backwards.
+func (r ValidationReport) Corrupt() bool {
+	for _, p := range r.Problems {
+		if p.Kind.corrupting() {
+			return true
+		}
+	}
+	return false
+}
+
+// String describes the problems found, one kind per line, with examples,
+// such as:
+//
+//	test started but never finished: 2 (line 5 (p TestA), line 9 (p TestB))
+func (r ValidationReport) String() string {
+	if r.OK() {
+		return fmt.Sprintf("no problems in %d lines", r.Lines)
+	}
+	lines := make([]string, len(r.Problems))
+	for i, p := range r.Problems {
+		examples := make([]string, len(p.Examples))
+		for j, e := range p.Examples {
+			examples[j] = e.String()
+		}
+		more := ""
+		if p.Count > len(p.Examples) {
+			more = ", …"
+		}
+		lines[i] = fmt.Sprintf("%s: %d (%s%s)", p.Kind, p.Count, strings.Join(examples, ", "), more)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// add records a problem of kind k at the given line.
+func (r *ValidationReport) add(k ProblemKind, e ProblemExample) {
+	for i := range r.Problems {
+		if r.Problems[i].Kind == k {
+			r.Problems[i].Count++
+			if len(r.Problems[i].Examples) < validationExamples {
+				r.Problems[i].Examples = append(r.Problems[i].Examples, e)
+			}
+			return
+		}
+	}
+	r.Problems = append(r.Problems, ValidationProblem{Kind: k, Count: 1, Examples: []ProblemExample{e}})
+}
+
+// Validate reads the output of 'go test -json' from r, and reports any
+// structural problems with it, such as tests that never finished, or a last
+// line cut off part way through, as described for [ProblemKind]. This is
+// useful for checking an archived artifact before reporting on it, since
+// problems like these can otherwise make a report subtly wrong. See
+// [WithValidation] to check the input to [TestDoxer.Filter] or [Aggregate]
+// automatically.
+//
+// Validate returns an error only if r can't be read. Extra fields, and
+// unknown actions, are not problems, since newer releases of Go may add
+// them.
+func Validate(r io.Reader) (ValidationReport, error) {
+	report := ValidationReport{}
+	type pkgState struct {
+		line int
+		done bool
+		last time.Time
+	}
+	packages := map[string]*pkgState{}
+	order := []string{}
+	running := map[string]ProblemExample{}
+	runOrder := []string{}
+	finished := map[string]bool{}
+	pendingLine := 0
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 16<<20)
+	for scanner.Scan() {
+		report.Lines++
+		if pendingLine > 0 {
+			report.add(InvalidJSON, ProblemExample{Line: pendingLine})
+			pendingLine = 0
+		}
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var e Event
+		if err := json.Unmarshal(line, &e); err != nil {
+			pendingLine = report.Lines
+			continue
+		}
+		report.Events++
+		if e.Package == "" {
+			// build output, which belongs to no package
+			continue
+		}
+		pkg := packages[e.Package]
+		if pkg == nil {
+			pkg = &pkgState{line: report.Lines}
+			packages[e.Package] = pkg
+			order = append(order, e.Package)
+		}
+		at := ProblemExample{Line: report.Lines, Package: e.Package, Test: e.Test}
+		if !e.Time.IsZero() {
+			if e.Time.Before(pkg.last) {
+				report.add(TimeWentBackwards, at)
+			} else {
+				pkg.last = e.Time
+			}
+		}
+		key := e.key(e.Test)
+		switch {
+		case e.Test == "" && e.completes():
+			if pkg.done {
+				report.add(DuplicateResult, at)
+			}
+			pkg.done = true
+		case e.Test == "":
+		case e.Kind() == ActionRun:
+			// the package's tests are being run again, as when a flaky
+			// test is retried, so another result for it is expected
+			pkg.done = false
+			if _, ok := running[key]; !ok {
+				runOrder = append(runOrder, key)
+			}
+			running[key] = at
+			delete(finished, key)
+		case e.completes():
+			_, wasRunning := running[key]
+			switch {
+			case wasRunning:
+				delete(running, key)
+				finished[key] = true
+			case finished[key]:
+				report.add(DuplicateResult, at)
+			default:
+				report.add(UnstartedTest, at)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return report, err
+	}
+	if pendingLine > 0 {
+		report.add(TruncatedJSON, ProblemExample{Line: pendingLine})
+	}
+	for _, key := range runOrder {
+		if at, ok := running[key]; ok {
+			report.add(UnfinishedTest, at)
+			delete(running, key)
+		}
+	}
+	for _, name := range order {
+		if pkg := packages[name]; !pkg.done {
+			report.add(MissingPackageResult, ProblemExample{Line: pkg.line, Package: name})
+		}
+	}
+	sort.Slice(report.Problems, func(i, j int) bool {
+		return report.Problems[i].Kind < report.Problems[j].Kind
+	})
+	return report, nil
+}
+
+// validated returns a reader giving the same data as r, having read it all
+// and checked it with [Validate], or an error if the data is corrupt, for
+// the consumers that accept [WithValidation].
+func validated(r io.Reader) (io.Reader, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	report, err := Validate(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if report.Corrupt() {
+		return nil, &ValidationError{Report: report}
+	}
+	return bytes.NewReader(data), nil
+}
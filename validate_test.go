@@ -0,0 +1,155 @@
+package gotestdox_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/bitfield/gotestdox"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestValidate_FindsNoProblemsInWellFormedStream(t *testing.T) {
+	t.Parallel()
+	input := `{"Time":"2022-03-01T10:00:00Z","Action":"start","Package":"p"}
+{"Time":"2022-03-01T10:00:00Z","Action":"run","Package":"p","Test":"TestA"}
+{"Time":"2022-03-01T10:00:00Z","Action":"output","Package":"p","Test":"TestA","Output":"=== RUN   TestA\n"}
+{"Time":"2022-03-01T10:00:01Z","Action":"pass","Package":"p","Test":"TestA","Elapsed":1}
+{"Time":"2022-03-01T10:00:01Z","Action":"run","Package":"p","Test":"TestA"}
+{"Time":"2022-03-01T10:00:02Z","Action":"pass","Package":"p","Test":"TestA","Elapsed":1}
+{"Time":"2022-03-01T10:00:02Z","Action":"pass","Package":"p","Elapsed":2}
+`
+	report, err := gotestdox.Validate(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := gotestdox.ValidationReport{Lines: 7, Events: 7}
+	if !cmp.Equal(want, report) {
+		t.Error(cmp.Diff(want, report))
+	}
+	if report.Corrupt() {
+		t.Error("want not corrupt")
+	}
+}
+
+func TestFilterContext_WithValidationAcceptsRetriedPackage(t *testing.T) {
+	t.Parallel()
+	input := `{"Action":"run","Package":"p","Test":"TestFlaky"}
+{"Action":"fail","Package":"p","Test":"TestFlaky"}
+{"Action":"fail","Package":"p"}
+{"Action":"run","Package":"p","Test":"TestFlaky"}
+{"Action":"pass","Package":"p","Test":"TestFlaky"}
+{"Action":"pass","Package":"p"}
+`
+	report, err := gotestdox.Validate(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Problems) > 0 {
+		t.Errorf("want no problems, got %+v", report.Problems)
+	}
+	err = gotestdox.FilterContext(context.Background(), strings.NewReader(input), io.Discard, gotestdox.WithValidation())
+	if err != nil {
+		t.Errorf("want retried package accepted, got %v", err)
+	}
+}
+
+func TestValidate_ReportsEachKindOfProblemWithExampleLines(t *testing.T) {
+	t.Parallel()
+	input := `{"Time":"2022-03-01T10:00:00Z","Action":"run","Package":"p","Test":"TestUnfinished"}
+{"Time":"2022-03-01T10:00:01Z","Action":"pass","Package":"p","Test":"TestUnstarted"}
+{"Time":"2022-03-01T10:00:01Z","Action":"run","Package":"p","Test":"TestTwice"}
+{"Time":"2022-03-01T10:00:02Z","Action":"fail","Package":"p","Test":"TestTwice"}
+{"Time":"2022-03-01T10:00:00Z","Action":"fail","Package":"p","Test":"TestTwice"}
+not JSON
+{"Time":"2022-03-01T10:00:02Z","Action":"fail","Package":"p"}
+{"Time":"2022-03-01T10:00:02Z","Action":"run","Package":"q","Test":"TestB"}
+{"Time":"2022-03-01T10:00:02Z","Action":"pass","Package":"q","Test":"TestB"}
+{"Time":"2022-03-01T10:00:03Z","Action":"pa`
+	report, err := gotestdox.Validate(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	example := func(line int, pkg, test string) []gotestdox.ProblemExample {
+		return []gotestdox.ProblemExample{{Line: line, Package: pkg, Test: test}}
+	}
+	want := gotestdox.ValidationReport{
+		Lines:  10,
+		Events: 8,
+		Problems: []gotestdox.ValidationProblem{
+			{Kind: gotestdox.InvalidJSON, Count: 1, Examples: example(6, "", "")},
+			{Kind: gotestdox.TruncatedJSON, Count: 1, Examples: example(10, "", "")},
+			{Kind: gotestdox.UnfinishedTest, Count: 1, Examples: example(1, "p", "TestUnfinished")},
+			{Kind: gotestdox.UnstartedTest, Count: 1, Examples: example(2, "p", "TestUnstarted")},
+			{Kind: gotestdox.DuplicateResult, Count: 1, Examples: example(5, "p", "TestTwice")},
+			{Kind: gotestdox.MissingPackageResult, Count: 1, Examples: example(8, "q", "")},
+			{Kind: gotestdox.TimeWentBackwards, Count: 1, Examples: example(5, "p", "TestTwice")},
+		},
+	}
+	if !cmp.Equal(want, report) {
+		t.Error(cmp.Diff(want, report))
+	}
+	if !report.Corrupt() {
+		t.Error("want corrupt")
+	}
+}
+
+func TestValidate_LimitsExamplesButCountsEveryProblem(t *testing.T) {
+	t.Parallel()
+	input := &bytes.Buffer{}
+	for i := 0; i < 5; i++ {
+		input.WriteString(`{"Action":"run","Package":"p","Test":"TestHang` + string(rune('A'+i)) + `"}` + "\n")
+	}
+	input.WriteString(`{"Action":"fail","Package":"p"}` + "\n")
+	report, err := gotestdox.Validate(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Corrupt() {
+		t.Error("unfinished tests alone should not make the input corrupt")
+	}
+	want := "test started but never finished: 5 (line 1 (p TestHangA), line 2 (p TestHangB), line 3 (p TestHangC), …)"
+	if got := report.String(); want != got {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+const truncatedRun = `{"Action":"run","Package":"p","Test":"TestA"}
+{"Action":"pass","Package":"p","Test":"TestA"}
+{"Action":"pass","Package":"p"}
+{"Action":"pa`
+
+func TestFilterContext_WithValidationRefusesCorruptInput(t *testing.T) {
+	t.Parallel()
+	out := &bytes.Buffer{}
+	err := gotestdox.FilterContext(context.Background(), strings.NewReader(truncatedRun), out, gotestdox.WithValidation())
+	if err == nil {
+		t.Fatal("want error for corrupt input")
+	}
+	if !strings.Contains(err.Error(), "truncated JSON at end of input: 1 (line 4)") {
+		t.Errorf("error should describe the problem, got %q", err)
+	}
+	if out.Len() > 0 {
+		t.Errorf("want nothing printed for corrupt input, got %q", out)
+	}
+}
+
+func TestAggregate_WithValidationIdentifiesCorruptRun(t *testing.T) {
+	t.Parallel()
+	runs := []io.Reader{
+		strings.NewReader(`{"Action":"run","Package":"p","Test":"TestA"}
+{"Action":"pass","Package":"p","Test":"TestA"}
+{"Action":"pass","Package":"p"}
+`),
+		strings.NewReader(truncatedRun),
+	}
+	_, err := gotestdox.Aggregate(runs, gotestdox.WithValidation())
+	if err == nil {
+		t.Fatal("want error for corrupt run")
+	}
+	if !strings.HasPrefix(err.Error(), "run 2: input is corrupt") {
+		t.Errorf("error should identify the run, got %q", err)
+	}
+}
@@ -0,0 +1,69 @@
+package gotestdox
+
+import (
+	"regexp"
+	"strings"
+)
+
+// vetMessage heads the section of diagnostics printed by a [TextSink] for a
+// package that failed vet.
+const vetMessage = "vet:"
+
+// vetHeader matches the line that 'go test' prints before the diagnostics
+// from vet for a package, such as "# [example.com/pkg]", as opposed to the
+// line before compiler errors, such as "# example.com/pkg".
+var vetHeader = regexp.MustCompile(`^# \[[^\]]+\]$`)
+
+// diagnosticLine matches a line of output giving the location of a problem
+// in a Go source file, at the start of the line, such as
+// "./x_test.go:9:14: fmt.Printf format %d has arg of wrong type".
+var diagnosticLine = regexp.MustCompile(`^\S+\.go:\d+(:\d+)?: `)
+
+// vetDiagnostics returns the diagnostics from vet in the build output of a
+// package: those following a vet header, and any line explicitly marked
+// "vet:", without their trailing newlines. If there are none, the build
+// failed for some other reason, such as a compiler error, and
+// vetDiagnostics returns nil.
+func vetDiagnostics(output []string) []string {
+	var diagnostics []string
+	inVet := false
+	for _, line := range outputLines(output) {
+		switch {
+		case strings.HasPrefix(line, "vet: "):
+			diagnostics = append(diagnostics, line)
+		case vetHeader.MatchString(line):
+			inVet = true
+		case strings.HasPrefix(line, "# "):
+			inVet = false
+		case inVet && line != "":
+			diagnostics = append(diagnostics, line)
+		}
+	}
+	return diagnostics
+}
+
+// untestedDiagnostics returns the diagnostics in the output of a package
+// that failed before any of its tests ran, as printed by versions of 'go
+// test' that report vet failures as package output, rather than build
+// output: any line marked "vet:", or giving a source location at the
+// start of the line, without indentation, which would mean it came from a
+// test.
+func untestedDiagnostics(output []string) []string {
+	var diagnostics []string
+	for _, line := range outputLines(output) {
+		if strings.HasPrefix(line, "vet: ") || diagnosticLine.MatchString(line) {
+			diagnostics = append(diagnostics, line)
+		}
+	}
+	return diagnostics
+}
+
+// outputLines splits output events, which usually, but not always, hold a
+// single line each, into lines, without their trailing newlines.
+func outputLines(output []string) []string {
+	lines := []string{}
+	for _, out := range output {
+		lines = append(lines, strings.Split(strings.TrimSuffix(out, "\n"), "\n")...)
+	}
+	return lines
+}
@@ -0,0 +1,91 @@
+package gotestdox_test
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/bitfield/gotestdox"
+	"github.com/fatih/color"
+	"github.com/google/go-cmp/cmp"
+)
+
+// The stream in testdata/vet/go1.27.json was captured from a real run of 'go
+// test -json' on a module with one package that fails vet, one that fails
+// to compile, and one that passes, with the timestamps and elapsed times
+// removed. go1.20.json is derived from it, giving the vet diagnostics as
+// package output instead, as releases before Go 1.24 did.
+
+func TestFilter_ClassifiesVetFailuresSeparatelyFromBuildFailures(t *testing.T) {
+	t.Parallel()
+	for _, release := range []string{"go1.20", "go1.27"} {
+		f, err := os.Open("testdata/vet/" + release + ".json")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		sink := &recordingSink{}
+		td := gotestdox.NewTestDoxer(gotestdox.WithSink(sink))
+		td.Stdin = f
+		td.Filter()
+		got := map[string][]string{}
+		for _, r := range sink.results {
+			if r.Test == "" {
+				got[r.Package] = r.Vet
+			}
+			if r.TeardownFailed {
+				t.Errorf("%s: %s should not count as a teardown failure", release, r.Package)
+			}
+		}
+		want := map[string][]string{
+			"example.com/vetm":        {`./x_test.go:9:14: fmt.Printf format %d has arg "s" of wrong type string`},
+			"example.com/vetm/broken": nil,
+			"example.com/vetm/good":   nil,
+		}
+		if !cmp.Equal(want, got) {
+			t.Errorf("%s: %s", release, cmp.Diff(want, got))
+		}
+		if sink.summary.VetFailures != 1 || !sink.summary.BuildFailed {
+			t.Errorf("%s: want 1 vet failure and a build failure, got %d, %t", release, sink.summary.VetFailures, sink.summary.BuildFailed)
+		}
+	}
+}
+
+func TestFilter_DoesNotTreatOutputOfTestMainAsVetDiagnostics(t *testing.T) {
+	t.Parallel()
+	sink := &recordingSink{}
+	td := gotestdox.NewTestDoxer(gotestdox.WithSink(sink))
+	td.Stdin = strings.NewReader(`{"Action":"output","Package":"p","Output":"main_test.go:12: can't connect to database\n"}
+{"Action":"output","Package":"p","Output":"FAIL\tp\t0.01s\n"}
+{"Action":"fail","Package":"p","Elapsed":0.01}`)
+	td.Filter()
+	if sink.summary.VetFailures != 0 || sink.summary.TeardownFailures != 1 {
+		t.Errorf("want a teardown failure, not a vet failure, got %+v", sink.summary)
+	}
+}
+
+func TestTextSink_ShowsVetDiagnosticsUnderPackageHeading(t *testing.T) {
+	t.Parallel()
+	color.NoColor = true
+	buf := &bytes.Buffer{}
+	td := gotestdox.NewTestDoxer()
+	td.Stdin = strings.NewReader(`{"ImportPath":"p [p.test]","Action":"build-output","Output":"# p\n"}
+{"ImportPath":"p [p.test]","Action":"build-output","Output":"# [p]\n"}
+{"ImportPath":"p [p.test]","Action":"build-output","Output":"./p_test.go:9:14:\tfmt.Printf format %d has arg \"s\" of wrong type string\n"}
+{"ImportPath":"p [p.test]","Action":"build-output","Output":"./p_test.go:12:2: unreachable code\n"}
+{"ImportPath":"p [p.test]","Action":"build-fail"}
+{"Action":"fail","Package":"p","Elapsed":0,"FailedBuild":"p [p.test]"}`)
+	td.Stdout = buf
+	td.Filter()
+	want := `p:
+ x vet:
+    ./p_test.go:9:14:   fmt.Printf format %d has arg "s" of wrong type string
+    ./p_test.go:12:2: unreachable code
+
+`
+	got := buf.String()
+	if !strings.HasPrefix(got, want) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
@@ -0,0 +1,40 @@
+//go:build js && wasm
+
+// Command wasm exposes [gotestdox.PrettifyFunc] to JavaScript, when compiled to
+// WebAssembly, so that a web page, such as a test dashboard, can show the
+// sentences for test names without asking a server for them. Build it with:
+//
+//	GOOS=js GOARCH=wasm go build -o gotestdox.wasm ./wasm
+//
+// Once the module is running, using wasm_exec.js from the Go distribution,
+// it defines a global function prettify, which takes a test name and returns
+// its sentence, using the default options:
+//
+//	prettify("TestHandleInput_ClosesInputAfterReading")
+//	// "HandleInput closes input after reading"
+//
+// Anything other than a string gives an empty string.
+//
+// Building with the gotestdox_ascii tag leaves out the Unicode case mapping
+// tables from golang.org/x/text, making the module smaller, at the cost of
+// casing only ASCII letters (see the README for the sizes):
+//
+//	GOOS=js GOARCH=wasm go build -tags gotestdox_ascii -o gotestdox.wasm ./wasm
+package main
+
+import (
+	"syscall/js"
+
+	"github.com/bitfield/gotestdox"
+)
+
+func main() {
+	prettify := gotestdox.PrettifyFunc()
+	js.Global().Set("prettify", js.FuncOf(func(_ js.Value, args []js.Value) any {
+		if len(args) == 0 || args[0].Type() != js.TypeString {
+			return ""
+		}
+		return prettify(args[0].String())
+	}))
+	select {}
+}